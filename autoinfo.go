@@ -0,0 +1,159 @@
+package cat
+
+import (
+	"context"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+)
+
+// Command names of auto-information mode, e.g. "AI2;", "AI0;" and "AI;" on Kenwood and Elecraft rigs.
+const (
+	CmdAutoInfoOn   cmds.CatCmdName = "AUTOINFOON"
+	CmdAutoInfoOff  cmds.CatCmdName = "AUTOINFOOFF"
+	CmdAutoInfoRead cmds.CatCmdName = "READAUTOINFO"
+)
+
+// TagAutoInfo reports the auto-information mode of the rig, e.g. "2" from an "AI2;" frame; "0" is off.
+const TagAutoInfo tags.CatStateTag = "AUTOINFO"
+
+const (
+	// defaultAutoInfoBurstSize is used when Options.AutoInfo.BurstSize is zero.
+	defaultAutoInfoBurstSize = 32
+	// defaultAutoInfoBurstGapMS is used when Options.AutoInfo.BurstGapMS is zero.
+	defaultAutoInfoBurstGapMS = 5
+	// defaultAutoInfoConfirmTimeoutMS is used when Options.AutoInfo.ConfirmTimeoutMS is zero.
+	defaultAutoInfoConfirmTimeoutMS = 1000
+)
+
+// AutoInfoActive reports whether the rig has confirmed auto-information mode and reports its changes by itself.
+func (s *Service) AutoInfoActive() bool {
+	return s.autoInfo.Load()
+}
+
+// enableAutoInfo switches the rig to auto-information mode in the background of the run whose shutdown channel is
+// shutdown. It is called on Start and after a reconnect, since rigs fall back to answering only when asked when
+// they lose power or the link. Polling continues unchanged until the rig confirms the mode.
+func (s *Service) enableAutoInfo(shutdown <-chan struct{}) {
+	if !s.Options.AutoInfo.Enabled {
+		return
+	}
+	s.autoInfo.Store(false)
+	if !s.launchTask(shutdown, func() { s.confirmAutoInfo(shutdown) }) {
+		s.logger().WarnWith().Msg("auto-information mode not enabled: the service is stopping")
+	}
+}
+
+// confirmAutoInfo runs for enableAutoInfo: it writes the command that enables the mode, then reads the mode back
+// with CmdAutoInfoRead and marks it active only if the rig reports it on. A rig that ignores the command, or a
+// definition without CmdAutoInfoRead, leaves the mode inactive.
+func (s *Service) confirmAutoInfo(shutdown <-chan struct{}) {
+	name := s.Options.AutoInfo.Command
+	if name == "" {
+		name = CmdAutoInfoOn
+	}
+	handle, err := s.EnqueueTracked(name, nil, WithOrigin(OriginInternal))
+	if err != nil {
+		s.logger().WarnWith().Err(err).Msg("auto-information mode not enabled")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), durationOrDefault(s.Options.AutoInfo.ConfirmTimeoutMS, defaultAutoInfoConfirmTimeoutMS))
+	defer cancel()
+	select {
+	case <-shutdown:
+		return
+	case <-ctx.Done():
+		s.logger().WarnWith().Msg("auto-information mode not enabled: the command was not written in time")
+		return
+	case <-handle.Done():
+	}
+	if outcome := handle.Outcome(); outcome.State == OutcomeFailed {
+		s.logger().WarnWith().Str("err", outcome.Err).Msg("auto-information mode not enabled")
+		return
+	}
+
+	mode, err := s.readBackWith(ctx, shutdown, CmdAutoInfoRead, TagAutoInfo)
+	switch {
+	case err != nil:
+		s.logger().WarnWith().Err(err).Msg("auto-information mode not confirmed by the rig; polling continues")
+	case mode == "" || mode == "0":
+		s.logger().WarnWith().Str("mode", mode).Msg("rig reports auto-information mode off; polling continues")
+	default:
+		s.autoInfo.Store(true)
+		s.logger().DebugWith().Str("mode", mode).Msg("auto-information mode confirmed by the rig")
+	}
+}
+
+// disableAutoInfo writes the command that ends auto-information mode, if the rig definition provides one, so that
+// the rig does not keep pushing updates to a closed port. It is called by Stop once the workers have exited.
+func (s *Service) disableAutoInfo() {
+	if !s.autoInfo.Swap(false) {
+		return
+	}
+	name := s.Options.AutoInfo.DisableCommand
+	if name == "" {
+		name = CmdAutoInfoOff
+	}
+	if _, err := s.commandLookup(name); err != nil {
+		return
+	}
+	prepared, err := s.prepare(newCommandRequest(name, nil, WithOrigin(OriginInternal)))
+	if err != nil {
+		s.logger().WarnWith().Err(err).Msg("auto-information mode not disabled")
+		return
+	}
+	for _, cmd := range prepared {
+		_ = s.writeCommand(cmd)
+	}
+}
+
+// readAutoInfoBurst keeps reading while auto-information mode is active and frames arrive back-to-back, so that a
+// burst of unsolicited lines (e.g. while turning the VFO knob) is handled within one listener tick instead of
+// backing up in the port. It returns false if shutdown was signaled.
+func (s *Service) readAutoInfoBurst(shutdown <-chan struct{}) bool {
+	if !s.autoInfo.Load() {
+		return true
+	}
+	burst := s.Options.AutoInfo.BurstSize
+	if burst <= 0 {
+		burst = defaultAutoInfoBurstSize
+	}
+	gap := durationOrDefault(s.Options.AutoInfo.BurstGapMS, defaultAutoInfoBurstGapMS)
+
+	for i := 1; i < burst; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), gap)
+		lineBytes, err := s.readLink(ctx)
+		cancel()
+		if err != nil || len(lineBytes) == 0 {
+			// End of the burst; read errors are handled on the next tick.
+			return true
+		}
+		if !s.handleFrame(shutdown, lineBytes, time.Now()) {
+			return false
+		}
+	}
+	return true
+}
+
+// pollsSuspended reports whether all polling is suspended, as it is in auto-information mode with
+// QuirkNoAIWhilePolling.
+func (s *Service) pollsSuspended() bool {
+	return s.autoInfo.Load() && s.hasQuirk(QuirkNoAIWhilePolling)
+}
+
+// pollInterval returns the interval of a poll entry, scaled while auto-information mode is active. ok is false if
+// polling is suspended, by PollIntervalScale or pollsSuspended.
+func (s *Service) pollInterval(interval time.Duration) (scaled time.Duration, ok bool) {
+	scale := s.Options.AutoInfo.PollIntervalScale
+	if s.pollsSuspended() {
+		return interval, false
+	}
+	if !s.autoInfo.Load() || scale == 0 {
+		return interval, true
+	}
+	if scale < 0 {
+		return interval, false
+	}
+	return interval * time.Duration(scale), true
+}
@@ -0,0 +1,105 @@
+package cat
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newAutoInfoTestService(t *testing.T) *Service {
+	cfg := &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: CmdAutoInfoOn.String(), Cmd: "AI2;"},
+			{Name: CmdAutoInfoOff.String(), Cmd: "AI0;"},
+			{Name: CmdAutoInfoRead.String(), Cmd: "AI;"},
+		},
+		CatStates: []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 2, Length: 11}}}},
+	}
+	service := newStartedTestService(t, cfg)
+	service.Options.AutoInfo = AutoInfoOptions{Enabled: true, BurstGapMS: 20}
+	return service
+}
+
+// startAutoInfoRig starts a sender talking to a fake rig that reports its auto-information mode on "AI;" and
+// switches to it on "AI2;" unless it ignores the command.
+func startAutoInfoRig(t *testing.T, service *Service, ignoresCommand bool) *answeringTransport {
+	var mu sync.Mutex
+	mode := "0"
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case cmd == "AI2;" && !ignoresCommand:
+			mode = "2"
+		case cmd == "AI;":
+			service.cache.update(types.CatStatus{TagAutoInfo.String(): mode}, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	return rig
+}
+
+func TestAutoInfoEnabledAndDisabled(t *testing.T) {
+	service := newAutoInfoTestService(t)
+	rig := startAutoInfoRig(t, service, false)
+
+	service.enableAutoInfo(service.currentRun.shutdownChannel)
+	require.Eventually(t, service.AutoInfoActive, time.Second, time.Millisecond)
+	require.Equal(t, []string{"AI2;", "AI;"}, rig.writes())
+
+	service.disableAutoInfo()
+	require.False(t, service.AutoInfoActive())
+	require.Equal(t, []string{"AI2;", "AI;", "AI0;"}, rig.writes())
+}
+
+func TestAutoInfoNotActiveUntilConfirmed(t *testing.T) {
+	service := newAutoInfoTestService(t)
+	service.Options.AutoInfo.ConfirmTimeoutMS = 100
+	rig := startAutoInfoRig(t, service, true)
+
+	service.enableAutoInfo(service.currentRun.shutdownChannel)
+	require.Eventually(t, func() bool { return len(rig.writes()) == 2 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, service.AutoInfoActive(), "the rig reports the mode off")
+	_, ok := service.pollInterval(time.Second)
+	require.True(t, ok, "polling continues")
+
+	service.disableAutoInfo()
+	require.Equal(t, []string{"AI2;", "AI;"}, rig.writes(), "nothing to disable")
+}
+
+func TestAutoInfoBurstIsReadInOneTick(t *testing.T) {
+	service := newAutoInfoTestService(t)
+	service.config.CatConfig.ListenerRateLimiterIntervalMS = 300
+	service.processingChannel = make(chan receivedState, 8)
+	service.autoInfo.Store(true)
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortListener": service.serialPortListener})
+
+	for _, f := range []string{"FA00014074000;", "FA00014074010;", "FA00014074020;", "FA00014074030;"} {
+		fake.push(f)
+	}
+	// Without burst reading, one frame would be handled per 300ms tick.
+	require.Eventually(t, func() bool { return len(service.processingChannel) == 4 }, 500*time.Millisecond, 5*time.Millisecond)
+}
+
+func TestPollIntervalScaledWhileAutoInfoActive(t *testing.T) {
+	service := newAutoInfoTestService(t)
+	service.Options.AutoInfo.PollIntervalScale = 10
+
+	interval, ok := service.pollInterval(time.Second)
+	require.True(t, ok)
+	require.Equal(t, time.Second, interval)
+
+	service.autoInfo.Store(true)
+	interval, ok = service.pollInterval(time.Second)
+	require.True(t, ok)
+	require.Equal(t, 10*time.Second, interval)
+
+	service.Options.AutoInfo.PollIntervalScale = -1
+	_, ok = service.pollInterval(time.Second)
+	require.False(t, ok)
+}
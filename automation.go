@@ -0,0 +1,230 @@
+package cat
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+// ScheduledAction is a set of commands run at a time of day, e.g. reducing power at 22:00 or selecting another
+// antenna on weekends.
+type ScheduledAction struct {
+	// Name identifies the action for EnableAction, DisableAction and ScheduledActions.
+	Name string
+	// At is the time of day, "HH:MM" in 24-hour notation.
+	At string
+	// Days restricts the action to the given weekdays. Empty means every day.
+	Days []time.Weekday
+	// Commands are queued as one batch, in order, when the action runs.
+	Commands []CatCommandRequest
+	// Disabled leaves the action off until EnableAction is called.
+	Disabled bool
+}
+
+// ScheduledActionInfo describes a scheduled action and its runs.
+type ScheduledActionInfo struct {
+	Name    string
+	Enabled bool
+	// NextRun is zero while the action is disabled.
+	NextRun time.Time
+	// LastRun is zero if the action has not run yet; LastErr is why the last run failed, if it did.
+	LastRun time.Time
+	LastErr string
+}
+
+// scheduledAction is a parsed ScheduledAction with its run state.
+type scheduledAction struct {
+	spec         ScheduledAction
+	hour, minute int
+
+	enabled bool
+	lastRun time.Time
+	lastErr string
+}
+
+// automation holds the scheduled actions of Options.Automation.
+type automation struct {
+	mu      sync.Mutex
+	actions []*scheduledAction
+	// wake tells the scheduler to recompute its timer after an action was enabled or disabled.
+	wake chan struct{}
+}
+
+// newAutomation parses the scheduled actions.
+func newAutomation(opts AutomationOptions) (*automation, error) {
+	const op errors.Op = "cat.newAutomation"
+	a := &automation{wake: make(chan struct{}, 1)}
+	for _, spec := range opts.Actions {
+		if spec.Name == "" {
+			return nil, errors.New(op).Msg("scheduled action without a name")
+		}
+		if a.find(spec.Name) != nil {
+			return nil, errors.New(op).Msgf("duplicate scheduled action %q", spec.Name)
+		}
+		at, err := time.Parse("15:04", spec.At)
+		if err != nil {
+			return nil, errors.New(op).Msgf("scheduled action %q: invalid time of day %q", spec.Name, spec.At)
+		}
+		a.actions = append(a.actions, &scheduledAction{spec: spec, hour: at.Hour(), minute: at.Minute(), enabled: !spec.Disabled})
+	}
+	return a, nil
+}
+
+// find returns the action called name, or nil. The caller holds mu or has exclusive access.
+func (a *automation) find(name string) *scheduledAction {
+	for _, action := range a.actions {
+		if action.spec.Name == name {
+			return action
+		}
+	}
+	return nil
+}
+
+// nextRun returns the first time after now the action is due, in loc.
+func (sa *scheduledAction) nextRun(now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	for day := 0; day <= 7; day++ {
+		d := now.AddDate(0, 0, day)
+		candidate := time.Date(d.Year(), d.Month(), d.Day(), sa.hour, sa.minute, 0, 0, loc)
+		if !candidate.After(now) {
+			continue
+		}
+		if len(sa.spec.Days) == 0 || slices.Contains(sa.spec.Days, candidate.Weekday()) {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
+
+// automationLocation returns the time zone of the scheduled actions.
+func (s *Service) automationLocation() *time.Location {
+	if s.Options.Automation.Location != nil {
+		return s.Options.Automation.Location
+	}
+	return time.Local
+}
+
+// EnableAction turns the scheduled action called name on.
+func (s *Service) EnableAction(name string) error {
+	return s.setActionEnabled("cat.Service.EnableAction", name, true)
+}
+
+// DisableAction turns the scheduled action called name off; it does not run until enabled again.
+func (s *Service) DisableAction(name string) error {
+	return s.setActionEnabled("cat.Service.DisableAction", name, false)
+}
+
+func (s *Service) setActionEnabled(op errors.Op, name string, enabled bool) error {
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	a := s.automation
+	if a == nil {
+		return errors.New(op).Msgf("no scheduled action %q", name)
+	}
+	a.mu.Lock()
+	action := a.find(name)
+	if action != nil {
+		action.enabled = enabled
+	}
+	a.mu.Unlock()
+	if action == nil {
+		return errors.New(op).Msgf("no scheduled action %q", name)
+	}
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ScheduledActions describes the scheduled actions, including when each runs next.
+func (s *Service) ScheduledActions() ([]ScheduledActionInfo, error) {
+	const op errors.Op = "cat.Service.ScheduledActions"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	a := s.automation
+	if a == nil {
+		return nil, nil
+	}
+	now, loc := time.Now(), s.automationLocation()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	infos := make([]ScheduledActionInfo, 0, len(a.actions))
+	for _, action := range a.actions {
+		info := ScheduledActionInfo{Name: action.spec.Name, Enabled: action.enabled, LastRun: action.lastRun, LastErr: action.lastErr}
+		if action.enabled {
+			info.NextRun = action.nextRun(now, loc)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// scheduler runs the scheduled actions when they are due.
+func (s *Service) scheduler(shutdown <-chan struct{}) {
+	a := s.automation
+	loc := s.automationLocation()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-a.wake:
+		case now := <-timer.C:
+			s.runDueActions(last, now)
+			last = now
+		}
+
+		// Wake up for the earliest enabled action.
+		next := time.Now().Add(time.Hour)
+		a.mu.Lock()
+		for _, action := range a.actions {
+			if at := action.nextRun(last, loc); action.enabled && !at.IsZero() && at.Before(next) {
+				next = at
+			}
+		}
+		a.mu.Unlock()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// runDueActions runs the enabled actions due in (from, to].
+func (s *Service) runDueActions(from, to time.Time) {
+	a := s.automation
+	loc := s.automationLocation()
+	a.mu.Lock()
+	var due []*scheduledAction
+	for _, action := range a.actions {
+		if at := action.nextRun(from, loc); action.enabled && !at.IsZero() && !at.After(to) {
+			due = append(due, action)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, action := range due {
+		_, err := s.EnqueueBatch(action.spec.Commands, WithOrigin(OriginScheduler))
+		a.mu.Lock()
+		action.lastRun, action.lastErr = to, ""
+		if err != nil {
+			action.lastErr = err.Error()
+		}
+		a.mu.Unlock()
+		if err != nil {
+			s.logger().WarnWith().Err(err).Str("action", action.spec.Name).Msg("scheduled action failed")
+			continue
+		}
+		s.logger().InfoWith().Str("action", action.spec.Name).Msg("scheduled action run")
+	}
+}
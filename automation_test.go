@@ -0,0 +1,62 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledActionNextRun(t *testing.T) {
+	a, err := newAutomation(AutomationOptions{Actions: []ScheduledAction{
+		{Name: "night", At: "22:00"},
+		{Name: "weekend", At: "08:30", Days: []time.Weekday{time.Saturday, time.Sunday}},
+	}})
+	require.NoError(t, err)
+
+	// Wednesday
+	now := time.Date(2026, 10, 14, 22, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC), a.find("night").nextRun(now, time.UTC))
+	require.Equal(t, time.Date(2026, 10, 14, 22, 0, 0, 0, time.UTC), a.find("night").nextRun(now.Add(-time.Minute), time.UTC))
+	require.Equal(t, time.Date(2026, 10, 17, 8, 30, 0, 0, time.UTC), a.find("weekend").nextRun(now, time.UTC))
+
+	for _, bad := range []ScheduledAction{{Name: "x", At: "25:00"}, {At: "10:00"}} {
+		_, err = newAutomation(AutomationOptions{Actions: []ScheduledAction{bad}})
+		require.Error(t, err)
+	}
+}
+
+func TestScheduledActionsRunAndToggle(t *testing.T) {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands, types.CatCommand{Name: CmdSetTxPower.String(), Cmd: "PC%s;"})
+	service := newStartedTestService(t, cfg)
+	service.Options.Automation = AutomationOptions{Location: time.UTC, Actions: []ScheduledAction{
+		{Name: "night", At: "22:00", Commands: []CatCommandRequest{{Name: CmdSetTxPower, Params: []string{"010"}}}},
+	}}
+	var err error
+	service.automation, err = newAutomation(service.Options.Automation)
+	require.NoError(t, err)
+
+	at := time.Date(2026, 10, 14, 22, 0, 0, 0, time.UTC)
+	service.runDueActions(at.Add(-time.Second), at.Add(-time.Millisecond))
+	require.Empty(t, drainCommands(service))
+	service.runDueActions(at.Add(-time.Second), at)
+	require.Equal(t, []string{"PC010;"}, drainCommands(service))
+
+	infos, err := service.ScheduledActions()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	require.Equal(t, at, infos[0].LastRun)
+	require.False(t, infos[0].NextRun.IsZero())
+
+	require.NoError(t, service.DisableAction("night"))
+	service.runDueActions(at.Add(-time.Second), at)
+	require.Empty(t, drainCommands(service))
+	infos, _ = service.ScheduledActions()
+	require.False(t, infos[0].Enabled)
+	require.True(t, infos[0].NextRun.IsZero())
+
+	require.NoError(t, service.EnableAction("night"))
+	require.Error(t, service.EnableAction("day"))
+}
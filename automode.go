@@ -0,0 +1,59 @@
+package cat
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+)
+
+// ModeSegment is a frequency range (inclusive) with the mode that should be used inside it, e.g. the FT8 sub-band
+// with DATA-U. Commands are extra commands (such as a filter preset) sent after the mode.
+type ModeSegment struct {
+	Label    string
+	MinHz    int64
+	MaxHz    int64
+	Mode     string
+	Commands []cmds.CatCmdName
+}
+
+// withoutAutoMode marks a frequency change whose mode is set explicitly by the caller, e.g. by Tune.
+func withoutAutoMode() CommandOption {
+	return func(req *commandRequest) {
+		req.skipAutoMode = true
+	}
+}
+
+// autoModeFilter queues the mode (and any extra commands) of the segment a VFO A frequency change lands in. Because
+// it runs in the command pipeline every tuning source benefits, not only the typed API.
+func (s *Service) autoModeFilter(req *commandRequest) error {
+	if len(s.Options.AutoModeSegments) == 0 || req.skipAutoMode || req.name != CmdSetVfoAFreq || len(req.params) != 1 {
+		return nil
+	}
+	hz, err := strconv.ParseInt(strings.TrimSpace(req.params[0]), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	for _, seg := range s.Options.AutoModeSegments {
+		if hz < seg.MinHz || hz > seg.MaxHz {
+			continue
+		}
+		if cached, ok := s.cache.get(tags.MainMode.String()); ok && strings.EqualFold(cached.Value, seg.Mode) {
+			return nil
+		}
+		value, err := s.encodeMappedValue(tags.MainMode, seg.Mode)
+		if err != nil {
+			s.logger().WarnWith().Err(err).Str("segment", seg.Label).Msg("auto-mode: mode is not mapped for this rig")
+			return nil
+		}
+		req.then = append(req.then, &commandRequest{name: CmdSetMainMode, params: []string{value}})
+		for _, name := range seg.Commands {
+			req.then = append(req.then, &commandRequest{name: name})
+		}
+		s.logger().DebugWith().Str("segment", seg.Label).Str("mode", seg.Mode).Msg("auto-mode selected")
+		return nil
+	}
+	return nil
+}
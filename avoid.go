@@ -0,0 +1,111 @@
+package cat
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+// AvoidAction decides what happens to a command that tunes or transmits inside an avoid range.
+type AvoidAction string
+
+const (
+	// AvoidReject refuses the command unless it carries ConfirmAvoidRange. This is the default.
+	AvoidReject AvoidAction = "reject"
+	// AvoidWarn lets the command through and notifies the operator.
+	AvoidWarn AvoidAction = "warn"
+)
+
+// AvoidRange is a frequency range (inclusive) that should not be tuned to or transmitted on, such as a beacon or
+// emergency frequency.
+type AvoidRange struct {
+	Label  string
+	MinHz  int64
+	MaxHz  int64
+	Action AvoidAction
+}
+
+// contains reports whether hz falls inside the range.
+func (r AvoidRange) contains(hz int64) bool {
+	return hz >= r.MinHz && hz <= r.MaxHz
+}
+
+// ConfirmAvoidRange acknowledges that the command intentionally tunes or transmits inside an avoid range.
+func ConfirmAvoidRange() CommandOption {
+	return func(req *commandRequest) {
+		req.confirmAvoid = true
+	}
+}
+
+// avoidRangeFilter rejects or warns about frequency changes into an avoid range, and transmit commands issued while
+// VFO A is inside one.
+func (s *Service) avoidRangeFilter(req *commandRequest) error {
+	const op errors.Op = "cat.Service.avoidRangeFilter"
+	if len(s.Options.AvoidRanges) == 0 {
+		return nil
+	}
+
+	var hz int64
+	switch {
+	case req.name == CmdSetVfoAFreq || req.name == CmdSetVfoBFreq:
+		if len(req.params) != 1 {
+			return nil
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(req.params[0]), 10, 64)
+		if err != nil {
+			return nil
+		}
+		hz = v
+	case s.isTxCommand(req.name):
+		v, ok := s.cachedFrequency()
+		if !ok {
+			return nil
+		}
+		hz = v
+	default:
+		return nil
+	}
+
+	for _, r := range s.Options.AvoidRanges {
+		if !r.contains(hz) {
+			continue
+		}
+		switch {
+		case req.confirmAvoid:
+			s.logger().WarnWith().Str("range", r.Label).Int64("hz", hz).Msg("CAT avoid range overridden by caller")
+		case r.Action == AvoidWarn:
+			s.notify(SeverityWarning, "Avoided frequency",
+				fmt.Sprintf("%d Hz is inside the avoid range %q.", hz, r.Label),
+				"Move to another frequency unless this is intentional.")
+		default:
+			return errors.New(op).Msgf("%d Hz is inside the avoid range %q; confirmation required", hz, r.Label)
+		}
+	}
+	return nil
+}
+
+// isTxCommand reports whether name keys the transmitter. PTTON always does.
+func (s *Service) isTxCommand(name cmds.CatCmdName) bool {
+	return name == CmdPTTOn || slices.Contains(s.Options.TxCommands, name)
+}
+
+// cachedFrequency returns the last reported VFO A frequency in Hz.
+func (s *Service) cachedFrequency() (int64, bool) {
+	if s.cache == nil {
+		return 0, false
+	}
+	cached, ok := s.cache.get(tags.VfoAFreq.String())
+	if !ok {
+		return 0, false
+	}
+	hz, err := strconv.ParseInt(strings.TrimSpace(cached.Value), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return hz, true
+}
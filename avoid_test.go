@@ -0,0 +1,30 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvoidRangeRejectsUnlessConfirmed(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.AvoidRanges = []AvoidRange{{Label: "20m beacons", MinHz: 14099000, MaxHz: 14101000}}
+
+	err := service.Tune(14100000, "USB")
+	require.Error(t, err)
+	require.ErrorContains(t, errors.Root(err), "20m beacons")
+	require.Empty(t, drainCommands(service))
+
+	require.NoError(t, service.Tune(14100000, "USB", ConfirmAvoidRange()))
+	require.Equal(t, []string{"FA014100000;", "MD02;"}, drainCommands(service))
+}
+
+func TestAvoidRangeWarnOnly(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.AvoidRanges = []AvoidRange{{Label: "emergency", MinHz: 14300000, MaxHz: 14300000, Action: AvoidWarn}}
+
+	require.NoError(t, service.Tune(14300000, "USB"))
+	require.Len(t, drainCommands(service), 2)
+	require.Len(t, service.notificationChannel, 1)
+}
@@ -0,0 +1,187 @@
+package cat
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/types"
+)
+
+// EventBandMap is the kind of BandMapEvent.
+const EventBandMap EventKind = "BAND_MAP"
+
+const (
+	// defaultBandMapSize is used when Options.BandMap.Size is zero.
+	defaultBandMapSize = 10
+	// defaultBandMapDwellMS is used when Options.BandMap.MinDwellMS is zero.
+	defaultBandMapDwellMS = 5000
+	// defaultBandMapMergeHz is used when Options.BandMap.MergeHz is zero.
+	defaultBandMapMergeHz = 500
+)
+
+// BandMapEntry is a recently visited frequency of the band map.
+type BandMapEntry struct {
+	FrequencyHz int64
+	// Mode is the mode of the last visit.
+	Mode string
+	// Dwell is the time spent on the frequency over all Visits. The last visit ended at LastVisit, or is ongoing.
+	Dwell     time.Duration
+	Visits    int
+	LastVisit time.Time
+}
+
+// BandMapEvent reports a visit recorded in the band map when VFO A left the frequency. Entry holds the totals of
+// the frequency including the visit.
+type BandMapEvent struct {
+	At    time.Time
+	Band  bands.Band
+	Entry BandMapEntry
+}
+
+func (e BandMapEvent) Kind() EventKind { return EventBandMap }
+func (e BandMapEvent) Time() time.Time { return e.At }
+
+// bandMap holds the recorded visits of each band and the ongoing visit.
+type bandMap struct {
+	mu sync.Mutex
+	// bands holds the entries of each band, most recently visited first.
+	bands    map[bands.Band][]BandMapEntry
+	current  bandVisit
+	visiting bool
+}
+
+// bandVisit is a stay of VFO A on a frequency of the band plan.
+type bandVisit struct {
+	band  bands.Band
+	hz    int64
+	mode  string
+	since time.Time
+}
+
+// BandMap returns the recently visited frequencies of each band, most recently visited first, including the
+// ongoing visit once it has lasted Options.BandMap.MinDwellMS. It is empty unless Options.BandMap is enabled.
+func (s *Service) BandMap() map[bands.Band][]BandMapEntry {
+	now := time.Now()
+	m := &s.bandMap
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[bands.Band][]BandMapEntry, len(m.bands))
+	for band, entries := range m.bands {
+		out[band] = slices.Clone(entries)
+	}
+	if m.visiting && now.Sub(m.current.since) >= s.bandMapDwell() {
+		out[m.current.band] = s.mergeVisit(out[m.current.band], m.current, now)
+	}
+	return out
+}
+
+// BandMapFor returns the recently visited frequencies of band, like BandMap.
+func (s *Service) BandMapFor(band bands.Band) []BandMapEntry {
+	return s.BandMap()[band]
+}
+
+// ClearBandMap forgets the visited frequencies.
+func (s *Service) ClearBandMap() {
+	m := &s.bandMap
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bands = nil
+	m.visiting = false
+}
+
+// trackBandMap follows VFO A in status, received at now, and records the visit it ends in the band map.
+func (s *Service) trackBandMap(status types.CatStatus, now time.Time) {
+	if !s.Options.BandMap.Enabled {
+		return
+	}
+	m := &s.bandMap
+	raw, ok := status[tags.VfoAFreq.String()]
+	if !ok {
+		if mode, ok := status[tags.MainMode.String()]; ok {
+			m.mu.Lock()
+			m.current.mode = mode
+			m.mu.Unlock()
+		}
+		return
+	}
+	hz, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	if m.visiting && abs64(hz-m.current.hz) <= s.bandMapMergeHz() {
+		if mode, ok := status[tags.MainMode.String()]; ok {
+			m.current.mode = mode
+		}
+		m.mu.Unlock()
+		return
+	}
+	ended, hadVisit := m.current, m.visiting
+	band, inPlan := s.bandForFrequency(hz)
+	mode, _ := s.cache.get(tags.MainMode.String())
+	m.current = bandVisit{band: band, hz: hz, mode: mode.Value, since: now}
+	m.visiting = inPlan
+
+	var entry BandMapEntry
+	recorded := hadVisit && now.Sub(ended.since) >= s.bandMapDwell()
+	if recorded {
+		if m.bands == nil {
+			m.bands = make(map[bands.Band][]BandMapEntry)
+		}
+		m.bands[ended.band] = s.mergeVisit(m.bands[ended.band], ended, now)
+		entry = m.bands[ended.band][0]
+	}
+	m.mu.Unlock()
+
+	if recorded {
+		s.emitEvent(BandMapEvent{At: now, Band: ended.band, Entry: entry})
+	}
+}
+
+// mergeVisit adds visit, ended at end, to the entries of its band and returns them, most recent first and
+// trimmed to Options.BandMap.Size.
+func (s *Service) mergeVisit(entries []BandMapEntry, visit bandVisit, end time.Time) []BandMapEntry {
+	entry := BandMapEntry{FrequencyHz: visit.hz}
+	if i := slices.IndexFunc(entries, func(e BandMapEntry) bool {
+		return abs64(e.FrequencyHz-visit.hz) <= s.bandMapMergeHz()
+	}); i >= 0 {
+		entry = entries[i]
+		entries = slices.Delete(entries, i, i+1)
+	}
+	entry.FrequencyHz = visit.hz
+	entry.Mode = visit.mode
+	entry.Dwell += end.Sub(visit.since)
+	entry.Visits++
+	entry.LastVisit = end
+
+	size := s.Options.BandMap.Size
+	if size <= 0 {
+		size = defaultBandMapSize
+	}
+	entries = slices.Insert(entries, 0, entry)
+	return entries[:min(len(entries), size)]
+}
+
+func (s *Service) bandMapDwell() time.Duration {
+	return durationOrDefault(s.Options.BandMap.MinDwellMS, defaultBandMapDwellMS)
+}
+
+func (s *Service) bandMapMergeHz() int64 {
+	if hz := s.Options.BandMap.MergeHz; hz > 0 {
+		return hz
+	}
+	return defaultBandMapMergeHz
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
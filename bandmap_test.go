@@ -0,0 +1,45 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandMapRecordsDwelledFrequencies(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.BandMap = BandMapOptions{Enabled: true, Size: 2}
+	start := time.Now().Add(-time.Hour)
+	visit := func(at time.Duration, status types.CatStatus) {
+		service.cache.update(status, start.Add(at))
+		service.trackBandMap(status, start.Add(at))
+	}
+
+	visit(0, types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "USB"})
+	visit(time.Minute, types.CatStatus{"VFOAFREQ": "00014074200"}) // fine tuning, same visit
+	visit(2*time.Minute, types.CatStatus{"MAINMODE": "CW"})
+	visit(3*time.Minute, types.CatStatus{"VFOAFREQ": "00014100000"})
+	visit(3*time.Minute+time.Second, types.CatStatus{"VFOAFREQ": "00014200000"}) // tuned across, not recorded
+
+	e := (<-service.eventChannel).(BandMapEvent)
+	require.Equal(t, bands.Band20, e.Band)
+	require.Equal(t, BandMapEntry{FrequencyHz: 14_074_000, Mode: "CW", Dwell: 3 * time.Minute, Visits: 1, LastVisit: start.Add(3 * time.Minute)}, e.Entry)
+	require.Empty(t, service.eventChannel)
+
+	visit(10*time.Minute, types.CatStatus{"VFOAFREQ": "00014074100"})
+	visit(15*time.Minute, types.CatStatus{"VFOAFREQ": "00007074000"})
+	entries := service.BandMapFor(bands.Band20)
+	require.Len(t, entries, 2)
+	require.Equal(t, BandMapEntry{FrequencyHz: 14_074_100, Mode: "CW", Dwell: 8 * time.Minute, Visits: 2, LastVisit: start.Add(15 * time.Minute)}, entries[0])
+	require.Equal(t, int64(14_200_000), entries[1].FrequencyHz)
+
+	forty := service.BandMap()[bands.Band40]
+	require.Len(t, forty, 1, "the ongoing visit is included once it dwelled long enough")
+	require.Equal(t, int64(7_074_000), forty[0].FrequencyHz)
+
+	service.ClearBandMap()
+	require.Empty(t, service.BandMap())
+}
@@ -0,0 +1,99 @@
+package cat
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// bandChannelSize is the buffer size of the channel returned by BandChannel.
+	bandChannelSize = 8
+)
+
+// BandRange is a contiguous frequency range belonging to an amateur band. The range is inclusive.
+type BandRange struct {
+	Band  bands.Band
+	MinHz int64
+	MaxHz int64
+}
+
+// defaultBandPlan covers the widest IARU allocation of each band, so that a frequency is attributed to a band in
+// every region. Options.BandPlan replaces it entirely when set.
+var defaultBandPlan = []BandRange{
+	{Band: bands.Band160, MinHz: 1_800_000, MaxHz: 2_000_000},
+	{Band: bands.Band80, MinHz: 3_500_000, MaxHz: 4_000_000},
+	{Band: bands.Band60, MinHz: 5_060_000, MaxHz: 5_450_000},
+	{Band: bands.Band40, MinHz: 7_000_000, MaxHz: 7_300_000},
+	{Band: bands.Band30, MinHz: 10_100_000, MaxHz: 10_150_000},
+	{Band: bands.Band20, MinHz: 14_000_000, MaxHz: 14_350_000},
+	{Band: bands.Band17, MinHz: 18_068_000, MaxHz: 18_168_000},
+	{Band: bands.Band15, MinHz: 21_000_000, MaxHz: 21_450_000},
+	{Band: bands.Band12, MinHz: 24_890_000, MaxHz: 24_990_000},
+	{Band: bands.Band10, MinHz: 28_000_000, MaxHz: 29_700_000},
+	{Band: bands.Band6, MinHz: 50_000_000, MaxHz: 54_000_000},
+}
+
+// bandForFrequency returns the band containing hz according to the configured band plan.
+func (s *Service) bandForFrequency(hz int64) (bands.Band, bool) {
+	plan := s.Options.BandPlan
+	if len(plan) == 0 {
+		plan = defaultBandPlan
+	}
+	for _, r := range plan {
+		if hz >= r.MinHz && hz <= r.MaxHz {
+			return r.Band, true
+		}
+	}
+	return "", false
+}
+
+// BandChangedEvent reports that VFO A moved to another band. Band is empty when the frequency is outside the band
+// plan, and Previous is empty for the first frequency reported after Start or after leaving the band plan.
+type BandChangedEvent struct {
+	At          time.Time
+	Previous    bands.Band
+	Band        bands.Band
+	FrequencyHz int64
+}
+
+// BandChannel returns a channel delivering a BandChangedEvent whenever the operator QSYs to another band, or an
+// error if the service is uninitialized. When the consumer falls behind, the oldest undelivered event is
+// discarded.
+func (s *Service) BandChannel() (<-chan BandChangedEvent, error) {
+	const op errors.Op = "cat.Service.BandChannel"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	return s.bandChannel, nil
+}
+
+// trackBand emits a BandChangedEvent when status moves VFO A to another band, and lets the rotator follow.
+func (s *Service) trackBand(status types.CatStatus) {
+	raw, ok := status[tags.VfoAFreq.String()]
+	if !ok {
+		return
+	}
+	hz, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return
+	}
+	band, _ := s.bandForFrequency(hz)
+	if s.bandKnown && band == s.lastBand {
+		return
+	}
+	event := BandChangedEvent{At: time.Now(), Previous: s.lastBand, Band: band, FrequencyHz: hz}
+	s.lastBand, s.bandKnown = band, true
+
+	s.publish(TopicBand, event)
+	s.route(TopicSegmentBand, event, event.At)
+	if !offerEvicting(s.bandChannel, event) {
+		s.logger().WarnWith().Str("band", band.String()).Msg("dropping band event: band channel full")
+	}
+	s.followRotator(event)
+}
@@ -0,0 +1,35 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandChangedEvents(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.bandChannel = make(chan BandChangedEvent, bandChannelSize)
+	service.Options.BandPlan = []BandRange{
+		{Band: bands.Band20, MinHz: 14_000_000, MaxHz: 14_350_000},
+		{Band: bands.Band40, MinHz: 7_000_000, MaxHz: 7_200_000}, // region 1
+	}
+
+	for _, hz := range []string{"014074000", "014250000", "007074000", "007250000", "007100000"} {
+		service.trackBand(types.CatStatus{"VFOAFREQ": hz})
+	}
+	service.trackBand(types.CatStatus{"MAINMODE": "USB"})
+
+	var got [][2]bands.Band
+	for len(service.bandChannel) > 0 {
+		e := <-service.bandChannel
+		got = append(got, [2]bands.Band{e.Previous, e.Band})
+	}
+	require.Equal(t, [][2]bands.Band{
+		{"", bands.Band20},
+		{bands.Band20, bands.Band40},
+		{bands.Band40, ""}, // outside the configured plan
+		{"", bands.Band40},
+	}, got)
+}
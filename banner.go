@@ -0,0 +1,105 @@
+package cat
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// EventRigConnected is the kind of RigConnectedEvent.
+const EventRigConnected EventKind = "RIG_CONNECTED"
+
+// defaultBannerTimeoutMS is used when Options.Banner.TimeoutMS is zero.
+const defaultBannerTimeoutMS = 2000
+
+// RigConnectedEvent is the single record of a connection to the rig, emitted once Start has opened the link and
+// the rig has answered, if Options.Banner is enabled.
+type RigConnectedEvent struct {
+	At time.Time
+	// Rig is the name of the rig configuration.
+	Rig string
+	// Model is the identity reported by the rig, or the configured model if it reports none.
+	Model string
+	// Firmware is the value of Options.FirmwareTag, if the rig reports it.
+	Firmware string
+	// Port is the serial port, or the rigctld endpoint; Serial is zero for rigctld.
+	Port   string
+	Serial types.SerialConfig
+	// Latency is the time the rig took to answer Options.Banner.Command.
+	Latency time.Duration
+}
+
+func (e RigConnectedEvent) Kind() EventKind { return EventRigConnected }
+func (e RigConnectedEvent) Time() time.Time { return e.At }
+
+// announceConnection probes the rig once after Start and emits the RigConnectedEvent. Nothing is emitted if the
+// rig does not answer in time.
+func (s *Service) announceConnection(shutdown <-chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), durationOrDefault(s.Options.Banner.TimeoutMS, defaultBannerTimeoutMS))
+	defer cancel()
+	go func() {
+		select {
+		case <-shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	event, err := s.rigConnected(ctx)
+	if err != nil {
+		s.logger().WarnWith().Err(err).Msg("rig did not answer the connection probe")
+		return
+	}
+	s.logger().InfoWith().Str("rig", event.Rig).Str("model", event.Model).Str("firmware", event.Firmware).
+		Str("port", event.Port).Int("baud", event.Serial.BaudRate).Dur("latency", event.Latency).Msg("rig connected")
+	s.emitEvent(event)
+}
+
+// rigConnected sends the banner probe and describes the connection.
+func (s *Service) rigConnected(ctx context.Context) (RigConnectedEvent, error) {
+	const op errors.Op = "cat.Service.rigConnected"
+	name := s.Options.Banner.Command
+	if name == "" {
+		name = s.readCommandFor(tags.Identity)
+	}
+	start := time.Now()
+	status, err := s.SendCommand(ctx, name)
+	if err != nil {
+		return RigConnectedEvent{}, errors.New(op).Err(err)
+	}
+
+	cfg := s.rigConfig()
+	event := RigConnectedEvent{
+		At:     time.Now(),
+		Rig:    cfg.Name,
+		Model:  s.reportedValue(status, tags.Identity.String()),
+		Port:   cfg.SerialConfig.PortName,
+		Serial: cfg.SerialConfig,
+	}
+	event.Latency = event.At.Sub(start)
+	if event.Model == "" {
+		event.Model = cfg.Model
+	}
+	if tag := s.Options.FirmwareTag; tag != "" {
+		event.Firmware = s.reportedValue(status, tag)
+	}
+	if addr, ok := s.rigctldAddress(); ok {
+		event.Port, event.Serial = addr, types.SerialConfig{}
+	}
+	return event, nil
+}
+
+// reportedValue returns the value of tag in status, or the cached value if status does not report it.
+func (s *Service) reportedValue(status types.CatStatus, tag string) string {
+	if value, ok := status[tag]; ok {
+		return strings.TrimSpace(value)
+	}
+	if cached, ok := s.cache.get(tag); ok {
+		return strings.TrimSpace(cached.Value)
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newBannerTestService(t *testing.T, answer bool) *Service {
+	t.Helper()
+	cfg := &types.RigConfig{
+		Name:  "shack",
+		Model: "TS-590SG",
+		CatCommands: []types.CatCommand{
+			{Name: "READIDENTITY", Cmd: "ID;"},
+		},
+		CatStates: []types.CatState{{Prefix: "ID", Markers: []types.Marker{{Tag: "IDENTITY", Index: 0, Length: 3}}}},
+	}
+	cfg.SerialConfig = types.SerialConfig{PortName: "/dev/ttyUSB0", BaudRate: 115200}
+	service := newStartedTestService(t, cfg)
+	service.Options.ReadCommands = map[string]cmds.CatCmdName{"IDENTITY": "READIDENTITY"}
+	service.Options.FirmwareTag = "FIRMWARE"
+	service.cache.update(types.CatStatus{"FIRMWARE": "1.08"}, time.Now())
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if answer && cmd == "ID;" {
+			state, _ := service.lookupCatState([]byte("ID023"))
+			service.deliverToWaiters(state, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	return service
+}
+
+func TestAnnounceConnectionEmitsRigConnected(t *testing.T) {
+	service := newBannerTestService(t, true)
+	service.announceConnection(make(chan struct{}))
+
+	e := (<-service.eventChannel).(RigConnectedEvent)
+	require.Equal(t, "shack", e.Rig)
+	require.Equal(t, "023", e.Model)
+	require.Equal(t, "1.08", e.Firmware)
+	require.Equal(t, "/dev/ttyUSB0", e.Port)
+	require.Equal(t, 115200, e.Serial.BaudRate)
+	require.Positive(t, e.Latency)
+}
+
+func TestAnnounceConnectionSilentWithoutAnswer(t *testing.T) {
+	service := newBannerTestService(t, false)
+	service.Options.Banner.TimeoutMS = 20
+	service.announceConnection(make(chan struct{}))
+	require.Empty(t, service.eventChannel)
+}
@@ -0,0 +1,199 @@
+package cat
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Station-Manager/errors"
+)
+
+// Batch tracks commands queued by EnqueueBatch. They are written back-to-back, in order, with no other command
+// in between.
+type Batch struct {
+	cmds []queuedCommand
+	sent atomic.Int64
+
+	done chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+	err  error
+	// release, if set, is called once the batch has finished.
+	release func()
+}
+
+func newBatch(cmds []queuedCommand) *Batch {
+	return &Batch{cmds: cmds, done: make(chan struct{})}
+}
+
+// Done returns a channel that is closed when every command has been written, when writing stopped at the first
+// failing command, or when the service stopped before the batch was written.
+func (b *Batch) Done() <-chan struct{} {
+	return b.done
+}
+
+// Err returns the error of the first command that failed, or nil.
+func (b *Batch) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Progress returns the number of commands written so far and the total.
+func (b *Batch) Progress() (sent, total int) {
+	return int(b.sent.Load()), len(b.cmds)
+}
+
+// finish ends the batch with err (nil for success). Only the first call has an effect.
+func (b *Batch) finish(err error) {
+	b.once.Do(func() {
+		b.mu.Lock()
+		b.err = err
+		b.mu.Unlock()
+		close(b.done)
+		if b.release != nil {
+			b.release()
+		}
+	})
+}
+
+// pendingBatches holds the batches that are queued or being written.
+type pendingBatches struct {
+	mu      sync.Mutex
+	batches map[*Batch]struct{}
+}
+
+// add tracks b until it finishes.
+func (p *pendingBatches) add(b *Batch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.batches == nil {
+		p.batches = make(map[*Batch]struct{})
+	}
+	p.batches[b] = struct{}{}
+	b.release = func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.batches, b)
+	}
+}
+
+// failPendingBatches fails the batches still queued once the workers have stopped, so that callers waiting on
+// them return. The sender skips them if it finds them in the queue after a restart.
+func (s *Service) failPendingBatches() {
+	const op errors.Op = "cat.Service.failPendingBatches"
+	p := &s.batches
+	p.mu.Lock()
+	pending := make([]*Batch, 0, len(p.batches))
+	for b := range p.batches {
+		pending = append(pending, b)
+	}
+	p.mu.Unlock()
+	for _, b := range pending {
+		b.fail(errors.New(op).Msg(errMsgServiceNotStarted))
+	}
+}
+
+// EnqueueBatch queues commands that must not be interrupted, e.g. a memory programming sequence. Every command is
+// validated and formatted up front, so the batch is either queued completely or not at all. It is queued as a
+// single entry, so the poller and other callers cannot interleave with it, and the sender writes its commands
+// back-to-back, stopping at the first failure. opts, such as WithPriority, apply to every command; the batch is
+// queued with the priority of its first command.
+func (s *Service) EnqueueBatch(requests []CatCommandRequest, opts ...CommandOption) (*Batch, error) {
+	const op errors.Op = "cat.Service.EnqueueBatch"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if !s.started.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+
+	var prepared []queuedCommand
+	var handles []*CommandHandle
+	failAll := func(err error) {
+		for _, h := range handles {
+			h.fail(err)
+		}
+	}
+	for _, r := range requests {
+		req := newCommandRequest(r.Name, r.Params, opts...)
+		req.outcome = s.newCommandHandle(req)
+		handles = append(handles, req.outcome)
+		cmds, err := s.prepare(req)
+		if err != nil {
+			failAll(err)
+			return nil, errors.New(op).Err(err)
+		}
+		req.outcome.expect(len(cmds))
+		prepared = append(prepared, cmds...)
+	}
+
+	batch := newBatch(prepared)
+	if len(prepared) == 0 {
+		batch.finish(nil)
+		return batch, nil
+	}
+
+	first := prepared[0]
+	s.batches.add(batch)
+	if err := s.queueCommand(queuedCommand{origin: first.origin, priority: first.priority, batch: batch}); err != nil {
+		failAll(err)
+		batch.finish(err)
+		return nil, errors.New(op).Err(err)
+	}
+	for _, h := range handles {
+		h.accepted()
+	}
+	return batch, nil
+}
+
+// runBatch queues requests as a batch and waits until it has been written, or until ctx is done. The batch is not
+// withdrawn when ctx is done first, so it may still be written.
+func (s *Service) runBatch(ctx context.Context, requests []CatCommandRequest) error {
+	const op errors.Op = "cat.Service.runBatch"
+	batch, err := s.EnqueueBatch(requests)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	select {
+	case <-batch.Done():
+		if err = batch.Err(); err != nil {
+			return errors.New(op).Err(err)
+		}
+		return nil
+	case <-ctx.Done():
+		return errors.New(op).Err(ctx.Err())
+	}
+}
+
+// writeBatch writes the commands of batch back-to-back. It returns false on shutdown.
+func (s *Service) writeBatch(shutdown <-chan struct{}, throttle *sendThrottle, batch *Batch) bool {
+	const op errors.Op = "cat.Service.writeBatch"
+	select {
+	case <-batch.done:
+		return true // failed by Stop while it was queued
+	default:
+	}
+	for _, cmd := range batch.cmds {
+		if !throttle.wait(shutdown, cmd.origin) {
+			batch.fail(errors.New(op).Msg(errMsgServiceNotStarted))
+			return false
+		}
+		if err := s.writeCommand(cmd); err != nil {
+			batch.fail(err)
+			return true
+		}
+		s.afterWrite(shutdown, cmd)
+		batch.sent.Add(1)
+	}
+	batch.finish(nil)
+	return true
+}
+
+// fail ends the batch with err, failing the outcome of every command that was not written.
+func (b *Batch) fail(err error) {
+	for _, cmd := range b.cmds[b.sent.Load():] {
+		cmd.outcome.fail(err)
+	}
+	b.finish(err)
+}
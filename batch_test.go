@@ -0,0 +1,107 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatchTestService(t *testing.T) *Service {
+	return newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "MEMWRITE", Cmd: "MW%s;"},
+			{Name: "READ", Cmd: "FA;"},
+		},
+	})
+}
+
+func TestBatchIsNotInterleaved(t *testing.T) {
+	service := newBatchTestService(t)
+	service.Options.RateLimit.InterCommandDelayMS = 20
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	batch, err := service.EnqueueBatch([]CatCommandRequest{
+		{Name: "MEMWRITE", Params: []string{"001"}},
+		{Name: "MEMWRITE", Params: []string{"002"}},
+		{Name: "MEMWRITE", Params: []string{"003"}},
+	})
+	require.NoError(t, err)
+
+	// Even a high-priority command queued mid-batch waits until the batch has been written.
+	require.Eventually(t, func() bool { return len(fake.writes()) >= 1 }, time.Second, time.Millisecond)
+	require.NoError(t, service.EnqueueCommandWith("READ", nil, WithPriority(PriorityHigh)))
+
+	select {
+	case <-batch.Done():
+	case <-time.After(time.Second):
+		t.Fatal("batch did not complete")
+	}
+	require.NoError(t, batch.Err())
+	sent, total := batch.Progress()
+	require.Equal(t, 3, sent)
+	require.Equal(t, 3, total)
+	require.Eventually(t, func() bool { return len(fake.writes()) == 4 }, time.Second, time.Millisecond)
+	require.Equal(t, []string{"MW001;", "MW002;", "MW003;", "FA;"}, fake.writes())
+}
+
+func TestBatchStopsAtFirstFailure(t *testing.T) {
+	service := newBatchTestService(t)
+	service.linkDown.Store(true)
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	batch, err := service.EnqueueBatch([]CatCommandRequest{
+		{Name: "MEMWRITE", Params: []string{"001"}},
+		{Name: "MEMWRITE", Params: []string{"002"}},
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-batch.Done():
+	case <-time.After(time.Second):
+		t.Fatal("batch did not complete")
+	}
+	require.Error(t, batch.Err())
+	require.Equal(t, errMsgLinkDown, errors.Root(batch.Err()).Error())
+	sent, _ := batch.Progress()
+	require.Zero(t, sent)
+}
+
+func TestBatchRejectedQueuesNothing(t *testing.T) {
+	service := newBatchTestService(t)
+
+	_, err := service.EnqueueBatch([]CatCommandRequest{
+		{Name: "MEMWRITE", Params: []string{"001"}},
+		{Name: "UNKNOWN"},
+	})
+	require.Error(t, err)
+	require.Empty(t, drainCommands(service))
+}
+
+func TestStopFailsQueuedBatches(t *testing.T) {
+	service := newBatchTestService(t)
+	batch, err := service.EnqueueBatch([]CatCommandRequest{
+		{Name: "MEMWRITE", Params: []string{"001"}},
+		{Name: "MEMWRITE", Params: []string{"002"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, service.Stop())
+	select {
+	case <-batch.Done():
+	case <-time.After(time.Second):
+		t.Fatal("a batch queued at Stop never completed")
+	}
+	require.ErrorContains(t, errors.Root(batch.Err()), errMsgServiceNotStarted)
+	require.Empty(t, service.batches.batches, "finished batches are no longer tracked")
+
+	// A restarted sender skips the failed batch left in the queue.
+	service.started.Store(true)
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	require.Eventually(t, func() bool { return len(service.sendChannel) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, service.EnqueueCommand("READ"))
+	require.Eventually(t, func() bool { return len(fake.writes()) == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, []string{"FA;"}, fake.writes())
+}
@@ -0,0 +1,148 @@
+package cat
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+// EventBaudRate is the kind of BaudRateEvent.
+const EventBaudRate EventKind = "BAUD_RATE"
+
+// defaultBaudProbeTimeoutMS is used when Options.BaudProbe.TimeoutMS is zero.
+const defaultBaudProbeTimeoutMS = 300
+
+// BaudRateEvent reports the baud rate negotiated on Start; see Options.BaudProbe.
+type BaudRateEvent struct {
+	At   time.Time
+	Port string
+	// Configured is the rate of the rig configuration and BaudRate the one the rig answered at.
+	Configured int
+	BaudRate   int
+}
+
+func (e BaudRateEvent) Kind() EventKind { return EventBaudRate }
+func (e BaudRateEvent) Time() time.Time { return e.At }
+
+// negotiateBaudRate opens the link at each candidate baud rate in turn until the rig answers the probe command,
+// and keeps it open with the active SerialConfig updated to that rate. A rate the port cannot be opened at is
+// skipped. It falls back to the configured rate if none is answered.
+func (s *Service) negotiateBaudRate() error {
+	const op errors.Op = "cat.Service.negotiateBaudRate"
+	if _, ok := s.rigctldAddress(); ok {
+		return s.initializeTransport() // rigctld has no baud rate
+	}
+	name := s.Options.BaudProbe.Command
+	if name == "" {
+		name = s.readCommandFor(tags.Identity)
+	}
+	cmd, err := s.commandLookup(name)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	wire, err := s.codec().encodeCommand(cmd.Cmd)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	timeout := durationOrDefault(s.Options.BaudProbe.TimeoutMS, defaultBaudProbeTimeoutMS)
+
+	port := s.rigConfig().SerialConfig.PortName
+	configured := s.configuredBaudRate()
+	for _, baud := range baudCandidates(configured, s.Options.BaudProbe.BaudRates) {
+		s.setBaudRate(baud)
+		if err = s.initializeTransport(); err != nil {
+			s.logger().WarnWith().Err(err).Str("port", port).Int("baud", baud).Msg("port not opened at candidate baud rate")
+			continue
+		}
+		if s.probeBaudRate(wire, timeout) {
+			s.logger().InfoWith().Str("port", port).Int("configured", configured).Int("baud", baud).
+				Msg("baud rate negotiated")
+			s.emitEvent(BaudRateEvent{At: time.Now(), Port: port, Configured: configured, BaudRate: baud})
+			return nil
+		}
+		_ = s.link().Close()
+		s.setLink(nil)
+	}
+
+	s.logger().WarnWith().Int("baud", configured).Msg("rig answered at no baud rate; using the configured one")
+	s.setBaudRate(configured)
+	if err = s.initializeTransport(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// baudCandidates returns the configured rate followed by the other candidates, or the defaults if there are none.
+func baudCandidates(configured int, candidates []int) []int {
+	if len(candidates) == 0 {
+		candidates = defaultDiscoveryBaudRates
+	}
+	out := make([]int, 0, len(candidates)+1)
+	if configured > 0 {
+		out = append(out, configured)
+	}
+	for _, baud := range candidates {
+		if baud > 0 && !slices.Contains(out, baud) {
+			out = append(out, baud)
+		}
+	}
+	return out
+}
+
+// probeBaudRate writes wire to the link and reports whether a frame matching a configured state comes back within
+// timeout. At a wrong rate the rig's reply is garbage that matches none.
+func (s *Service) probeBaudRate(wire string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	t := s.link()
+	s.noteWire(wire)
+	if err := t.WriteCommand(ctx, wire); err != nil {
+		return false
+	}
+	for {
+		frame, err := t.ReadResponseBytes(ctx)
+		if err != nil {
+			return false
+		}
+		if len(frame) == 0 || s.isEcho(frame) {
+			continue
+		}
+		if decoded, ok := s.codec().decodeFrame(frame); ok {
+			if _, ok = s.lookupCatState(decoded); ok {
+				return true
+			}
+		}
+	}
+}
+
+// configuredBaudRate returns the baud rate of the rig configuration, which the active one differs from once a rate
+// was negotiated.
+func (s *Service) configuredBaudRate() int {
+	s.definitionMu.RLock()
+	defer s.definitionMu.RUnlock()
+	if s.configuredBaud != 0 {
+		return s.configuredBaud
+	}
+	return s.config.SerialConfig.BaudRate
+}
+
+// setBaudRate replaces the active rig configuration by a copy with the given baud rate, remembering the configured
+// rate while they differ.
+func (s *Service) setBaudRate(baud int) {
+	s.definitionMu.Lock()
+	defer s.definitionMu.Unlock()
+	configured := s.config.SerialConfig.BaudRate
+	if s.configuredBaud != 0 {
+		configured = s.configuredBaud
+	}
+	cfg := *s.config
+	cfg.SerialConfig.BaudRate = baud
+	s.config = &cfg
+	s.configuredBaud = 0
+	if baud != configured {
+		s.configuredBaud = configured
+	}
+}
@@ -0,0 +1,76 @@
+package cat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newBaudProbeTestService returns a service whose rig answers ID; only at the given baud rate.
+func newBaudProbeTestService(t *testing.T, rigBaud int) (*Service, *[]int) {
+	t.Helper()
+	cfg := &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READIDENTITY", Cmd: "ID;"}},
+		CatStates:   []types.CatState{{Prefix: "ID", Markers: []types.Marker{{Tag: "IDENTITY", Index: 0, Length: 3}}}},
+	}
+	cfg.SerialConfig = types.SerialConfig{PortName: "/dev/ttyUSB0", BaudRate: 9600}
+	service := newStartedTestService(t, cfg)
+	service.Options.BaudProbe = BaudProbeOptions{Enabled: true, BaudRates: []int{38400, 9600, 19200}, Command: "READIDENTITY", TimeoutMS: 20}
+
+	var dialed []int
+	service.dialer = func() (Transport, error) {
+		baud := service.rigConfig().SerialConfig.BaudRate
+		dialed = append(dialed, baud)
+		port := &answeringTransport{fakeTransport: newFakeTransport()}
+		port.onWrite = func(cmd string) {
+			if baud == rigBaud {
+				port.push("ID023")
+			} else {
+				port.push("\x8f\xf3\x00")
+			}
+		}
+		return port, nil
+	}
+	return service, &dialed
+}
+
+func TestNegotiateBaudRateLocksOntoAnsweredRate(t *testing.T) {
+	service, dialed := newBaudProbeTestService(t, 19200)
+	require.NoError(t, service.negotiateBaudRate())
+
+	require.Equal(t, []int{9600, 38400, 19200}, *dialed, "the configured rate is tried first")
+	require.Equal(t, 19200, service.RigConfig().SerialConfig.BaudRate)
+	require.NotNil(t, service.link())
+	e := (<-service.eventChannel).(BaudRateEvent)
+	require.Equal(t, "/dev/ttyUSB0", e.Port)
+	require.Equal(t, 9600, e.Configured)
+	require.Equal(t, 19200, e.BaudRate)
+}
+
+func TestNegotiateBaudRateFallsBackToConfigured(t *testing.T) {
+	service, dialed := newBaudProbeTestService(t, 4800)
+	require.NoError(t, service.negotiateBaudRate())
+
+	require.Equal(t, []int{9600, 38400, 19200, 9600}, *dialed)
+	require.Equal(t, 9600, service.RigConfig().SerialConfig.BaudRate)
+	require.NotNil(t, service.link())
+	require.Empty(t, service.eventChannel)
+}
+
+func TestNegotiateBaudRateSkipsRateThePortRejects(t *testing.T) {
+	service, dialed := newBaudProbeTestService(t, 19200)
+	dial := service.dialer
+	service.dialer = func() (Transport, error) {
+		if service.rigConfig().SerialConfig.BaudRate == 38400 {
+			return nil, errors.New("invalid baud rate")
+		}
+		return dial()
+	}
+	require.NoError(t, service.negotiateBaudRate())
+
+	require.Equal(t, []int{9600, 19200}, *dialed, "38400 could not be opened")
+	require.Equal(t, 19200, service.RigConfig().SerialConfig.BaudRate)
+	require.Equal(t, 9600, service.configuredBaudRate())
+}
@@ -0,0 +1,166 @@
+package cat
+
+import (
+	"time"
+
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// defaultSelfBenchmarkDuration is how long RunSelfBenchmark runs each case when no duration is given.
+	defaultSelfBenchmarkDuration = 200 * time.Millisecond
+	// selfBenchmarkSubscribers is the number of subscribers in the fan-out cases.
+	selfBenchmarkSubscribers = 4
+)
+
+// selfBenchmarkBudgets are the regression gates of RunSelfBenchmark, in nanoseconds per operation. The published
+// baselines, measured on an x86-64 development host, are in the comments; the budgets are 20 times higher so that
+// a Raspberry Pi class host passes comfortably while a real regression does not.
+var selfBenchmarkBudgets = map[string]float64{
+	"lookupCatState": 3_000,  // baseline ~130ns
+	"parseState":     10_000, // baseline ~520ns
+	"statusFanOut":   6_000,  // baseline ~280ns
+	"frameToStatus":  32_000, // baseline ~1.6µs
+}
+
+// SelfBenchmarkResult is the outcome of one RunSelfBenchmark case.
+type SelfBenchmarkResult struct {
+	Name       string
+	Iterations int
+	NsPerOp    float64
+	// BudgetNs is the regression gate for the case; WithinBudget reports whether NsPerOp stayed below it.
+	BudgetNs     float64
+	WithinBudget bool
+}
+
+// SelfBenchmarkReport holds the results of RunSelfBenchmark.
+type SelfBenchmarkReport struct {
+	Results []SelfBenchmarkResult
+}
+
+// Passed reports whether every case stayed within its budget.
+func (r SelfBenchmarkReport) Passed() bool {
+	for _, res := range r.Results {
+		if !res.WithinBudget {
+			return false
+		}
+	}
+	return true
+}
+
+// selfBenchmarkCase is one measured pipeline stage.
+type selfBenchmarkCase struct {
+	name string
+	run  func()
+}
+
+// RunSelfBenchmark measures the frame pipeline (state lookup, marker extraction, status fan-out and the path from
+// a received frame to the emitted status) on a synthetic rig definition, running each case for about perCase
+// (200ms if not positive). It does not touch the serial port or any running Service, so it can be run in-app to
+// check that a host is fast enough.
+func RunSelfBenchmark(perCase time.Duration) SelfBenchmarkReport {
+	if perCase <= 0 {
+		perCase = defaultSelfBenchmarkDuration
+	}
+	var report SelfBenchmarkReport
+	for _, c := range newSelfBenchmarkCases() {
+		iterations, elapsed := measure(c.run, perCase)
+		nsPerOp := float64(elapsed.Nanoseconds()) / float64(iterations)
+		budget := selfBenchmarkBudgets[c.name]
+		report.Results = append(report.Results, SelfBenchmarkResult{
+			Name:         c.name,
+			Iterations:   iterations,
+			NsPerOp:      nsPerOp,
+			BudgetNs:     budget,
+			WithinBudget: nsPerOp <= budget,
+		})
+	}
+	return report
+}
+
+// measure calls run in growing batches until d has elapsed and returns the iteration count and the time taken.
+func measure(run func(), d time.Duration) (int, time.Duration) {
+	iterations, batch := 0, 1
+	start := time.Now()
+	for time.Since(start) < d {
+		for range batch {
+			run()
+		}
+		iterations += batch
+		batch = min(batch*2, 1<<16)
+	}
+	return iterations, time.Since(start)
+}
+
+// newSelfBenchmarkService returns a service with a Kenwood-style definition, ready for the pipeline stages.
+func newSelfBenchmarkService() *Service {
+	mode := []types.ValueMapping{{Key: "1", Value: "LSB"}, {Key: "2", Value: "USB"}, {Key: "3", Value: "CW"}}
+	s := &Service{
+		LoggerService: &logging.Service{},
+		config: &types.RigConfig{CatStates: []types.CatState{
+			{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+			{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: mode}}},
+			{Prefix: "IF", Markers: []types.Marker{
+				{Tag: "VFOAFREQ", Index: 0, Length: 11},
+				{Tag: "MAINMODE", Index: 27, Length: 1, ValueMappings: mode},
+				{Tag: "SPLIT", Index: 30, Length: 1},
+			}},
+		}},
+		cache:            newStateCache(),
+		broadcastChannel: make(chan types.CatStatus, broadcastQueueSize),
+	}
+	_ = s.initializeStateSet()
+	return s
+}
+
+// newSelfBenchmarkCases returns the cases shared by RunSelfBenchmark and the package benchmarks.
+func newSelfBenchmarkCases() []selfBenchmarkCase {
+	s := newSelfBenchmarkService()
+	frames := [][]byte{
+		[]byte("FA00014074000"),
+		[]byte("MD2"),
+		[]byte("IF00014074000     +00000000002000000"),
+	}
+	ifState, _ := s.lookupCatState(frames[2])
+	status := types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "USB"}
+
+	subs := make([]chan types.CatStatus, selfBenchmarkSubscribers)
+	for i := range subs {
+		subs[i] = make(chan types.CatStatus, 1)
+	}
+	fanOut := func(st types.CatStatus) {
+		display := s.translateStatus(st)
+		for _, ch := range subs {
+			offerEvicting(ch, display)
+		}
+	}
+
+	next := 0
+	return []selfBenchmarkCase{
+		{name: "lookupCatState", run: func() {
+			_, _ = s.lookupCatState(frames[next%len(frames)])
+			next++
+		}},
+		{name: "parseState", run: func() {
+			_, _ = s.parseState(ifState)
+		}},
+		{name: "statusFanOut", run: func() {
+			fanOut(status)
+		}},
+		{name: "frameToStatus", run: func() {
+			frame, _ := s.codec().decodeFrame(frames[next%len(frames)])
+			next++
+			state, ok := s.lookupCatState(frame)
+			if !ok {
+				return
+			}
+			st, err := s.parseState(state)
+			if err != nil {
+				return
+			}
+			s.cache.update(st, time.Now())
+			fanOut(st)
+		}},
+	}
+}
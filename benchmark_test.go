@@ -0,0 +1,39 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkPipeline(b *testing.B) {
+	for _, c := range newSelfBenchmarkCases() {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				c.run()
+			}
+		})
+	}
+}
+
+func TestSelfBenchmarkFramesParse(t *testing.T) {
+	s := newSelfBenchmarkService()
+	state, ok := s.lookupCatState([]byte("IF00014074000     +00000000002000000"))
+	require.True(t, ok)
+	status, err := s.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, "USB", status["MAINMODE"])
+}
+
+func TestRunSelfBenchmarkCoversEveryBudget(t *testing.T) {
+	report := RunSelfBenchmark(time.Millisecond)
+
+	require.Len(t, report.Results, len(selfBenchmarkBudgets))
+	for _, res := range report.Results {
+		require.Contains(t, selfBenchmarkBudgets, res.Name)
+		require.Positive(t, res.Iterations, res.Name)
+		require.Positive(t, res.NsPerOp, res.Name)
+	}
+}
@@ -0,0 +1,172 @@
+package cat
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// Command names of CW key-down and key-up for break-in (QSK), e.g. "TX;" and "RX;" on rigs keyed through CAT.
+const (
+	CmdKeyDown cmds.CatCmdName = "KEYDOWN"
+	CmdKeyUp   cmds.CatCmdName = "KEYUP"
+)
+
+const (
+	// defaultBreakInSpinMS is used when Options.BreakIn.SpinMS is zero.
+	defaultBreakInSpinMS = 2
+)
+
+// KeyLine keys the transmitter by a hardware signal instead of a CAT command, e.g. a serial control line.
+type KeyLine interface {
+	// SetKey asserts (down) or releases the key.
+	SetKey(down bool) error
+}
+
+// BreakInStats summarizes the timing of the key transitions made with Key and KeyAt.
+type BreakInStats struct {
+	Transitions uint64
+	// MeanLatency and MaxLatency are the time taken to signal a transition, from the call (Key) or the scheduled
+	// instant (KeyAt) until the command was written or the line was set.
+	MeanLatency time.Duration
+	MaxLatency  time.Duration
+	// Scheduled is the number of transitions made with KeyAt. MeanJitter and MaxJitter are how far their
+	// signaling started from the scheduled instant.
+	Scheduled  uint64
+	MeanJitter time.Duration
+	MaxJitter  time.Duration
+}
+
+// breakIn serializes key transitions and accumulates their timing.
+type breakIn struct {
+	// keyMu keeps transitions in order when Key and KeyAt are called from several goroutines.
+	keyMu sync.Mutex
+
+	mu         sync.Mutex
+	stats      BreakInStats
+	sumLatency time.Duration
+	sumJitter  time.Duration
+}
+
+func (b *breakIn) record(latency time.Duration, jitter time.Duration, scheduled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Transitions++
+	b.sumLatency += latency
+	b.stats.MaxLatency = max(b.stats.MaxLatency, latency)
+	b.stats.MeanLatency = b.sumLatency / time.Duration(b.stats.Transitions)
+	if !scheduled {
+		return
+	}
+	jitter = max(jitter, -jitter)
+	b.stats.Scheduled++
+	b.sumJitter += jitter
+	b.stats.MaxJitter = max(b.stats.MaxJitter, jitter)
+	b.stats.MeanJitter = b.sumJitter / time.Duration(b.stats.Scheduled)
+}
+
+// Key keys (down) or unkeys the transmitter for CW break-in right away. Unlike SetPTT it bypasses the send queue
+// and the rate limits: the transition is written by the calling goroutine, through Service.KeyLine or
+// Options.ControlLines.CW if set and otherwise with the KEYDOWN and KEYUP commands (see Options.BreakIn). Like
+// SetPTT, key-down is refused inside an avoid range unless ConfirmAvoidRange is given, and is tracked by PTTState
+// and the PTT watchdog.
+func (s *Service) Key(down bool, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.Key"
+	if err := s.key(down, time.Now(), false, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// KeyAt makes the transition at the given instant, e.g. from a CW element schedule, blocking until it was made.
+// It sleeps until shortly before the instant and spins for the rest to keep jitter low; see BreakInStats.
+func (s *Service) KeyAt(down bool, at time.Time, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.KeyAt"
+	if err := s.key(down, at, true, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// BreakInStats returns the timing of the key transitions made so far.
+func (s *Service) BreakInStats() BreakInStats {
+	s.breakIn.mu.Lock()
+	defer s.breakIn.mu.Unlock()
+	return s.breakIn.stats
+}
+
+func (s *Service) key(down bool, at time.Time, scheduled bool, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.key"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if !s.started.Load() {
+		return errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+	if down {
+		if err := s.avoidRangeFilter(newCommandRequest(CmdPTTOn, nil, opts...)); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+
+	// Prepare the commands before waiting, so that only the write is left at the instant.
+	line := s.keyLine()
+	var prepared []queuedCommand
+	if line == nil {
+		name := s.Options.BreakIn.KeyUpCommand
+		if name == "" {
+			name = CmdKeyUp
+		}
+		if down {
+			if name = s.Options.BreakIn.KeyDownCommand; name == "" {
+				name = CmdKeyDown
+			}
+		}
+		var err error
+		if prepared, err = s.prepare(newCommandRequest(name, nil, WithPriority(PriorityHigh), Force())); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+
+	s.breakIn.keyMu.Lock()
+	defer s.breakIn.keyMu.Unlock()
+	if scheduled {
+		waitUntil(at, durationOrDefault(s.Options.BreakIn.SpinMS, defaultBreakInSpinMS))
+	}
+	start := time.Now()
+	if line != nil {
+		if err := line.SetKey(down); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	for _, cmd := range prepared {
+		if err := s.writeCommand(cmd); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	done := time.Now()
+	if down {
+		s.noteKeyedBy(func() error { return s.key(false, time.Now(), false) })
+	} else {
+		s.noteReleased()
+	}
+	if !scheduled {
+		at = start
+	}
+	s.breakIn.record(done.Sub(at), start.Sub(at), scheduled)
+	return nil
+}
+
+// waitUntil returns at the given instant: it sleeps until spin before it, then spins, since timers and the
+// scheduler are too coarse for CW element timing on their own.
+func waitUntil(at time.Time, spin time.Duration) {
+	if d := time.Until(at) - spin; d > 0 {
+		time.Sleep(d)
+	}
+	for time.Now().Before(at) {
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,106 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newBreakInTestService(t *testing.T) (*Service, *fakeTransport) {
+	service := newStartedTestService(t, &types.RigConfig{CatCommands: []types.CatCommand{
+		{Name: CmdKeyDown.String(), Cmd: "TX;"},
+		{Name: CmdKeyUp.String(), Cmd: "RX;"},
+	}})
+	return service, startTestWorkers(t, service, nil)
+}
+
+func TestKeyWritesBypassingQueue(t *testing.T) {
+	service, fake := newBreakInTestService(t)
+	// A full queue does not delay break-in.
+	for len(service.sendChannel) < cap(service.sendChannel) {
+		service.sendChannel <- queuedCommand{}
+	}
+
+	require.NoError(t, service.Key(true))
+	require.NoError(t, service.Key(false))
+	require.Equal(t, []string{"TX;", "RX;"}, fake.writes())
+
+	stats := service.BreakInStats()
+	require.Equal(t, uint64(2), stats.Transitions)
+	require.Zero(t, stats.Scheduled)
+	require.LessOrEqual(t, stats.MeanLatency, stats.MaxLatency)
+}
+
+func TestKeyAtWaitsForInstant(t *testing.T) {
+	service, fake := newBreakInTestService(t)
+
+	at := time.Now().Add(20 * time.Millisecond)
+	require.NoError(t, service.KeyAt(true, at))
+	require.False(t, time.Now().Before(at))
+	require.Equal(t, []string{"TX;"}, fake.writes())
+
+	stats := service.BreakInStats()
+	require.Equal(t, uint64(1), stats.Scheduled)
+	require.Equal(t, stats.MaxJitter, stats.MeanJitter)
+}
+
+type recordingKeyLine struct{ keys []bool }
+
+func (l *recordingKeyLine) SetKey(down bool) error {
+	l.keys = append(l.keys, down)
+	return nil
+}
+
+func TestKeyThroughKeyLine(t *testing.T) {
+	service, fake := newBreakInTestService(t)
+	line := &recordingKeyLine{}
+	service.KeyLine = line
+
+	require.NoError(t, service.Key(true))
+	require.NoError(t, service.Key(false))
+	require.Equal(t, []bool{true, false}, line.keys)
+	require.Empty(t, fake.writes())
+}
+
+func TestKeyRequiresStartedService(t *testing.T) {
+	service := &Service{}
+	require.Error(t, service.Key(true))
+}
+
+func TestKeyDownIsTrackedAsPTT(t *testing.T) {
+	service, fake := newBreakInTestService(t)
+	service.Options.PTT.MaxTxMS = 20
+
+	require.NoError(t, service.Key(true))
+	require.True(t, service.PTTState().On)
+	select {
+	case e := <-service.eventChannel:
+		require.IsType(t, PTTWatchdogEvent{}, e)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	require.False(t, service.PTTState().On)
+	require.Equal(t, []string{"TX;", "RX;"}, fake.writes(), "the watchdog keyed up")
+}
+
+func TestKeyDownChecksAvoidRanges(t *testing.T) {
+	service, fake := newBreakInTestService(t)
+	service.Options.AvoidRanges = []AvoidRange{{Label: "20m beacons", MinHz: 14099000, MaxHz: 14101000}}
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014100000"}, time.Now())
+
+	require.Error(t, service.Key(true))
+	require.Empty(t, fake.writes())
+	require.NoError(t, service.Key(true, ConfirmAvoidRange()))
+	require.Equal(t, []string{"TX;"}, fake.writes())
+}
+
+func TestKeyUpLeavesPTTKeyed(t *testing.T) {
+	service, _ := newBreakInTestService(t)
+	service.notePTT(true) // keyed by SetPTT
+
+	require.NoError(t, service.Key(true))
+	require.NoError(t, service.Key(false))
+	require.True(t, service.PTTState().On)
+}
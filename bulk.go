@@ -0,0 +1,147 @@
+package cat
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// bulkChannelSize bounds the number of bulk commands queued ahead of the sender.
+	bulkChannelSize = 8
+)
+
+// bulkItem is a single command of a bulk transfer waiting to be written.
+type bulkItem struct {
+	cmd      queuedCommand
+	transfer *BulkTransfer
+}
+
+// BulkTransfer tracks a large operation (memory dump, menu export, ...) whose commands are time-sliced against
+// the regular traffic by the sender.
+type BulkTransfer struct {
+	Label string
+
+	total int
+	sent  atomic.Int64
+
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	err    error
+	cancel chan struct{}
+}
+
+func newBulkTransfer(label string, total int) *BulkTransfer {
+	return &BulkTransfer{
+		Label:  label,
+		total:  total,
+		done:   make(chan struct{}),
+		cancel: make(chan struct{}),
+	}
+}
+
+// Done returns a channel that is closed when every command has been written, or the transfer failed or was
+// cancelled.
+func (b *BulkTransfer) Done() <-chan struct{} {
+	return b.done
+}
+
+// Err returns the reason the transfer ended early, or nil.
+func (b *BulkTransfer) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Progress returns the number of commands written so far and the total.
+func (b *BulkTransfer) Progress() (sent, total int) {
+	return int(b.sent.Load()), b.total
+}
+
+// Cancel stops the transfer; commands not yet written are discarded.
+func (b *BulkTransfer) Cancel() {
+	const op errors.Op = "cat.BulkTransfer.Cancel"
+	b.finish(errors.New(op).Msg("bulk transfer cancelled"))
+}
+
+// advance records a written command and completes the transfer after the last one.
+func (b *BulkTransfer) advance() {
+	if int(b.sent.Add(1)) >= b.total {
+		b.finish(nil)
+	}
+}
+
+// finish ends the transfer with err (nil for success). Only the first call has an effect.
+func (b *BulkTransfer) finish(err error) {
+	b.once.Do(func() {
+		b.mu.Lock()
+		b.err = err
+		b.mu.Unlock()
+		close(b.cancel)
+		close(b.done)
+	})
+}
+
+// finished reports whether the transfer has ended.
+func (b *BulkTransfer) finished() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// EnqueueBulk starts a bulk transfer of the given commands. Every command is validated and formatted up front, so
+// the transfer either starts completely or not at all; the commands are then written in slices between the regular
+// traffic. opts, such as WithOrigin, apply to every command.
+func (s *Service) EnqueueBulk(label string, requests []CatCommandRequest, opts ...CommandOption) (*BulkTransfer, error) {
+	const op errors.Op = "cat.Service.EnqueueBulk"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if !s.started.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+
+	var prepared []queuedCommand
+	for _, r := range requests {
+		cmds, err := s.prepare(newCommandRequest(r.Name, r.Params, opts...))
+		if err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		prepared = append(prepared, cmds...)
+	}
+
+	transfer := newBulkTransfer(label, len(prepared))
+	if len(prepared) == 0 {
+		transfer.finish(nil)
+		return transfer, nil
+	}
+
+	s.mu.Lock()
+	run := s.currentRun
+	s.mu.Unlock()
+	if run == nil {
+		return nil, errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+
+	// Feed the bounded bulk channel from a separate goroutine so that arbitrarily large transfers do not block
+	// the caller.
+	go func() {
+		for _, cmd := range prepared {
+			select {
+			case <-run.shutdownChannel:
+				transfer.finish(errors.New(op).Msg(errMsgServiceNotStarted))
+				return
+			case <-transfer.cancel:
+				return
+			case s.bulkChannel <- bulkItem{cmd: cmd, transfer: transfer}:
+			}
+		}
+	}()
+
+	return transfer, nil
+}
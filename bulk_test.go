@@ -0,0 +1,62 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestWorkers attaches a fake transport to a test service and launches the given workers. The workers are
+// stopped when the test ends.
+func startTestWorkers(t *testing.T, service *Service, workers map[string]func(<-chan struct{})) *fakeTransport {
+	t.Helper()
+	fake := newFakeTransport()
+	service.transport = fake
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.mu.Lock()
+	service.setRun(run)
+	service.mu.Unlock()
+	for name, worker := range workers {
+		service.launchWorkerThread(run, worker, name)
+	}
+	t.Cleanup(func() {
+		close(run.shutdownChannel)
+		run.wg.Wait()
+	})
+	return fake
+}
+
+func TestBulkTransferIsTimeSliced(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "MEMREAD", Cmd: "MR%s;"},
+			{Name: "READ", Cmd: "FA;"},
+		},
+	})
+	service.Options.Bulk = BulkOptions{SliceSize: 2, YieldMS: 200}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	var requests []CatCommandRequest
+	for _, ch := range []string{"001", "002", "003", "004"} {
+		requests = append(requests, CatCommandRequest{Name: "MEMREAD", Params: []string{ch}})
+	}
+	transfer, err := service.EnqueueBulk("memory dump", requests)
+	require.NoError(t, err)
+
+	// A regular poll issued mid-transfer is written during the yield after the first slice.
+	require.Eventually(t, func() bool { return len(fake.writes()) >= 2 }, time.Second, time.Millisecond)
+	require.NoError(t, service.EnqueueCommand("READ"))
+
+	select {
+	case <-transfer.Done():
+	case <-time.After(time.Second):
+		t.Fatal("bulk transfer did not complete")
+	}
+	require.NoError(t, transfer.Err())
+	sent, total := transfer.Progress()
+	require.Equal(t, 4, sent)
+	require.Equal(t, 4, total)
+	require.Equal(t, []string{"MR001;", "MR002;", "FA;", "MR003;", "MR004;"}, fake.writes())
+}
@@ -0,0 +1,54 @@
+package cat
+
+import (
+	"slices"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+// Capabilities describes what a rig supports, so that frontends can offer only what works.
+type Capabilities struct {
+	Bands []bands.Band
+	// Modes are the display values of the rig's modes, e.g. "USB" or "CW-R".
+	Modes     []string
+	MaxPowerW int
+	Split     bool
+	DualWatch bool
+}
+
+// Capabilities returns what the rig supports: Options.Capabilities if set, otherwise the capabilities of the rig
+// driver. With the generic driver they are derived from the rig definition, so only the modes, from the
+// MAINMODE mappings, and split support, from the split commands, are known.
+func (s *Service) Capabilities() (Capabilities, error) {
+	const op errors.Op = "cat.Service.Capabilities"
+	if !s.initialized.Load() {
+		return Capabilities{}, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	var caps Capabilities
+	switch {
+	case s.Options.Capabilities != nil:
+		caps = *s.Options.Capabilities
+	case s.driver != nil:
+		caps = s.driver.Capabilities()
+	default:
+		caps = s.definitionCapabilities()
+	}
+	// Copy the slices so that callers cannot modify the driver's or the options'.
+	caps.Bands = slices.Clone(caps.Bands)
+	caps.Modes = slices.Clone(caps.Modes)
+	return caps, nil
+}
+
+// definitionCapabilities derives the capabilities of a rig with the generic driver from its definition.
+func (s *Service) definitionCapabilities() Capabilities {
+	var caps Capabilities
+	if marker, ok := s.markerFor(tags.MainMode); ok {
+		caps.Modes = mappedValues(marker.ValueMappings)
+	}
+	_, errOn := s.commandLookup(CmdSplitOn)
+	_, errOff := s.commandLookup(CmdSplitOff)
+	caps.Split = errOn == nil && errOff == nil
+	return caps
+}
@@ -0,0 +1,51 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesFromDriver(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, DriverKenwoodTS590)
+	_, err := service.Capabilities()
+	require.Equal(t, errMsgServiceNotInit, errors.Root(err).Error())
+	require.NoError(t, service.Initialize())
+
+	caps, err := service.Capabilities()
+	require.NoError(t, err)
+	require.Equal(t, 100, caps.MaxPowerW)
+	require.True(t, caps.Split)
+	require.Contains(t, caps.Modes, "CW-R")
+	require.Contains(t, caps.Bands, bands.Band60)
+
+	caps.Modes[0] = "changed"
+	again, _ := service.Capabilities()
+	require.Equal(t, "LSB", again.Modes[0])
+}
+
+func TestCapabilitiesFromOptions(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, DriverKenwoodTS590)
+	service.Options.Capabilities = &Capabilities{Bands: []bands.Band{bands.Band20}, MaxPowerW: 5}
+	require.NoError(t, service.Initialize())
+
+	caps, err := service.Capabilities()
+	require.NoError(t, err)
+	require.Equal(t, Capabilities{Bands: []bands.Band{bands.Band20}, MaxPowerW: 5}, caps)
+}
+
+func TestCapabilitiesFromDefinition(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: CmdSplitOn.String(), Cmd: "FT1;"}, {Name: CmdSplitOff.String(), Cmd: "FT0;"}},
+		CatStates: []types.CatState{{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1,
+			ValueMappings: []types.ValueMapping{{Key: "1", Value: "LSB"}, {Key: "2", Value: "USB"}}}}}},
+	}, "")
+	require.NoError(t, service.Initialize())
+
+	caps, err := service.Capabilities()
+	require.NoError(t, err)
+	require.Equal(t, Capabilities{Modes: []string{"LSB", "USB"}, Split: true}, caps)
+}
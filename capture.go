@@ -0,0 +1,313 @@
+package cat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/serial"
+)
+
+const (
+	// defaultCaptureMaxBytes is used when Options.TrafficCapture.MaxBytes is zero.
+	defaultCaptureMaxBytes = 10 << 20
+	// defaultCaptureMaxFiles is used when Options.TrafficCapture.MaxFiles is zero.
+	defaultCaptureMaxFiles = 5
+	// defaultCaptureQueueSize is used when Options.TrafficCapture.QueueSize is zero.
+	defaultCaptureQueueSize = 1024
+)
+
+// newCaptureChannel creates the queue of the capture file writer if a capture file is configured.
+func newCaptureChannel(opts TrafficCaptureOptions) chan TrafficFrame {
+	if opts.Path == "" {
+		return nil
+	}
+	size := opts.QueueSize
+	if size <= 0 {
+		size = defaultCaptureQueueSize
+	}
+	return make(chan TrafficFrame, size)
+}
+
+// offerCapture queues frame for the capture file without blocking, dropping it if the queue is full.
+func (s *Service) offerCapture(frame TrafficFrame) {
+	if s.captureChannel == nil {
+		return
+	}
+	select {
+	case s.captureChannel <- frame:
+	default:
+		s.metricsSink().Count("capture_dropped", 1)
+	}
+}
+
+// trafficCapture writes the queued frames to the capture file until shutdown.
+func (s *Service) trafficCapture(shutdown <-chan struct{}) {
+	opts := s.Options.TrafficCapture
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultCaptureMaxBytes
+	}
+	maxFiles := opts.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultCaptureMaxFiles
+	}
+	file, err := openCaptureFile(opts.Path, maxBytes, maxFiles)
+	if err != nil {
+		s.logger().ErrorWith().Err(err).Msg("traffic capture disabled")
+		s.reportError("capture", err)
+		return
+	}
+	defer func() {
+		if err := file.close(); err != nil {
+			s.logger().WarnWith().Err(err).Msg("closing the capture file failed")
+		}
+	}()
+
+	logs := newLogLimiter(time.Minute)
+	for {
+		select {
+		case <-shutdown:
+			// Keep what was queued before the shutdown.
+			for len(s.captureChannel) > 0 {
+				_ = file.write(<-s.captureChannel)
+			}
+			return
+		case frame := <-s.captureChannel:
+			err = file.write(frame)
+			if err == nil && len(s.captureChannel) == 0 {
+				err = file.flush()
+			}
+			if err != nil {
+				if suppressed, ok := logs.allow(time.Now()); ok {
+					s.logger().WarnWith().Err(err).Int("suppressed", suppressed).Msg("writing the capture file failed")
+				}
+			}
+		}
+	}
+}
+
+// captureFile is a capture file that rotates when it reaches maxBytes.
+type captureFile struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+	f        *os.File
+	w        *bufio.Writer
+	size     int64
+}
+
+// openCaptureFile opens path for appending.
+func openCaptureFile(path string, maxBytes int64, maxFiles int) (*captureFile, error) {
+	c := &captureFile{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *captureFile) open() error {
+	const op errors.Op = "cat.captureFile.open"
+	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.New(op).Err(err).Msgf("Cannot open capture file %s.", c.path)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return errors.New(op).Err(err)
+	}
+	c.f, c.w, c.size = f, bufio.NewWriter(f), info.Size()
+	return nil
+}
+
+// write appends frame as one JSON line, rotating the file first if the line would take it past maxBytes.
+func (c *captureFile) write(frame TrafficFrame) error {
+	const op errors.Op = "cat.captureFile.write"
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	line = append(line, '\n')
+	if c.size > 0 && c.size+int64(len(line)) > c.maxBytes {
+		if err = c.rotate(); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	n, err := c.w.Write(line)
+	c.size += int64(n)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest, renames the current file to path.1 and starts a new one.
+func (c *captureFile) rotate() error {
+	const op errors.Op = "cat.captureFile.rotate"
+	if err := c.close(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	for i := c.maxFiles - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", c.path, i), fmt.Sprintf("%s.%d", c.path, i+1))
+		if err != nil && !stderr.Is(err, fs.ErrNotExist) {
+			return errors.New(op).Err(err)
+		}
+	}
+	if err := os.Rename(c.path, c.path+".1"); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return c.open()
+}
+
+func (c *captureFile) flush() error {
+	return c.w.Flush()
+}
+
+func (c *captureFile) close() error {
+	err := c.w.Flush()
+	if cerr := c.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ReadTrafficCapture reads a capture file written with Options.TrafficCapture.
+func ReadTrafficCapture(path string) ([]TrafficFrame, error) {
+	const op errors.Op = "cat.ReadTrafficCapture"
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(op).Err(err).Msgf("Cannot read capture file %s.", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	var frames []TrafficFrame
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var frame TrafficFrame
+		if err = json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, errors.New(op).Err(err).Msgf("Capture file %s is invalid at line %d.", path, line)
+		}
+		frames = append(frames, frame)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return frames, nil
+}
+
+// ReplayTransport is a Transport that plays back the received frames of a capture, so that a session can be
+// reproduced offline through the listener and processor, e.g. with Service.Dial. Written commands are kept but
+// not answered; the capture already holds the rig's answers.
+type ReplayTransport struct {
+	speed float64
+
+	mu      sync.Mutex
+	frames  []TrafficFrame
+	next    int
+	last    time.Time
+	written []string
+
+	done      chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewReplayTransport returns a transport replaying the RX frames of capture in order. A positive speed keeps the
+// original gaps between the frames, divided by speed, so 1 is real time; zero replays as fast as they are read.
+func NewReplayTransport(capture []TrafficFrame, speed float64) *ReplayTransport {
+	r := &ReplayTransport{speed: speed, done: make(chan struct{}), closed: make(chan struct{})}
+	for _, frame := range capture {
+		if frame.Direction == TrafficRX {
+			r.frames = append(r.frames, frame)
+		}
+	}
+	if len(r.frames) == 0 {
+		close(r.done)
+	}
+	return r
+}
+
+// WriteCommand implements Transport.
+func (r *ReplayTransport) WriteCommand(_ context.Context, cmd string) error {
+	select {
+	case <-r.closed:
+		return serial.ErrClosed
+	default:
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written = append(r.written, cmd)
+	return nil
+}
+
+// ReadResponseBytes implements Transport. Once every frame was replayed it blocks until ctx is done.
+func (r *ReplayTransport) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	r.mu.Lock()
+	if r.next >= len(r.frames) {
+		r.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-r.closed:
+			return nil, serial.ErrClosed
+		}
+	}
+	frame := r.frames[r.next]
+	var wait time.Duration
+	if r.speed > 0 && r.next > 0 {
+		gap := time.Duration(float64(frame.Time.Sub(r.frames[r.next-1].Time)) / r.speed)
+		wait = gap - time.Since(r.last)
+	}
+	r.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-r.closed:
+			return nil, serial.ErrClosed
+		case <-timer.C:
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next++
+	r.last = time.Now()
+	if r.next == len(r.frames) {
+		close(r.done)
+	}
+	return append([]byte(nil), frame.Data...), nil
+}
+
+// Close implements Transport.
+func (r *ReplayTransport) Close() error {
+	r.closeOnce.Do(func() { close(r.closed) })
+	return nil
+}
+
+// Done is closed once every frame was replayed.
+func (r *ReplayTransport) Done() <-chan struct{} {
+	return r.done
+}
+
+// Written returns the commands written to the transport, in order.
+func (r *ReplayTransport) Written() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.written...)
+}
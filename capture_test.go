@@ -0,0 +1,95 @@
+package cat
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficCaptureRotatesFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cat.jsonl")
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.TrafficCapture = TrafficCaptureOptions{Path: path, MaxBytes: 200, MaxFiles: 2}
+	service.captureChannel = newCaptureChannel(service.Options.TrafficCapture)
+
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.launchWorkerThread(run, service.trafficCapture, "trafficCapture")
+	for i := range 10 {
+		service.recordTraffic(TrafficRX, fmt.Appendf(nil, "FA%011d", i))
+	}
+	close(run.shutdownChannel)
+	run.wg.Wait()
+
+	var data []string
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		frames, err := ReadTrafficCapture(name)
+		require.NoError(t, err)
+		require.Len(t, frames, 2, "two frames fit in 200 bytes")
+		for _, frame := range frames {
+			require.Equal(t, TrafficRX, frame.Direction)
+			data = append(data, string(frame.Data))
+		}
+	}
+	require.Equal(t, []string{"FA00000000004", "FA00000000005", "FA00000000006", "FA00000000007", "FA00000000008", "FA00000000009"}, data)
+	_, err := ReadTrafficCapture(path + ".3")
+	require.Error(t, err, "older files are dropped")
+}
+
+func TestReplayTransportFeedsPipeline(t *testing.T) {
+	cfg := &types.RigConfig{CatStates: []types.CatState{
+		{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+		{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1}}},
+	}}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 1
+	service := newStartedTestService(t, cfg)
+	service.processingChannel = make(chan receivedState, 4)
+	service.statusChannel = make(chan types.CatStatus, 1)
+
+	start := time.Now()
+	replay := NewReplayTransport([]TrafficFrame{
+		{Time: start, Direction: TrafficTX, Data: []byte("FA;")},
+		{Time: start, Direction: TrafficRX, Data: []byte("FA00014074000")},
+		{Time: start.Add(time.Second), Direction: TrafficRX, Data: []byte("MD2")},
+	}, 0)
+	service.Dial = func() (Transport, error) { return replay, nil }
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortListener": service.serialPortListener,
+		"lineProcessor":      service.lineProcessor,
+	})
+	require.NoError(t, service.initializeTransport())
+
+	<-replay.Done()
+	require.Eventually(t, func() bool { return service.counters.framesParsed.Load() == 2 }, 2*time.Second, 5*time.Millisecond)
+	freq, _ := service.cache.get("VFOAFREQ")
+	require.Equal(t, "00014074000", freq.Value)
+	mode, _ := service.cache.get("MAINMODE")
+	require.Equal(t, "2", mode.Value)
+}
+
+func TestReplayTransportKeepsGaps(t *testing.T) {
+	start := time.Now()
+	replay := NewReplayTransport([]TrafficFrame{
+		{Time: start, Direction: TrafficRX, Data: []byte("FA00014074000")},
+		{Time: start.Add(400 * time.Millisecond), Direction: TrafficRX, Data: []byte("MD2")},
+	}, 4)
+	ctx := context.Background()
+	_, err := replay.ReadResponseBytes(ctx)
+	require.NoError(t, err)
+	began := time.Now()
+	frame, err := replay.ReadResponseBytes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "MD2", string(frame))
+	require.GreaterOrEqual(t, time.Since(began), 90*time.Millisecond, "the 400ms gap at 4x speed")
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = replay.ReadResponseBytes(short)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "an exhausted replay blocks")
+	require.NoError(t, replay.WriteCommand(ctx, "FA;"))
+	require.Equal(t, []string{"FA;"}, replay.Written())
+}
@@ -0,0 +1,51 @@
+package cat
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Station-Manager/errors"
+)
+
+// Charset is the character set of a text value reported by the rig, see StateOptions.Charsets.
+type Charset string
+
+const (
+	// CharsetASCII keeps printable ASCII and drops every other byte, e.g. the control characters some rigs use
+	// as padding.
+	CharsetASCII Charset = "ascii"
+	// CharsetLatin1 converts ISO-8859-1 text, as used for names on many European rigs, to UTF-8.
+	CharsetLatin1 Charset = "latin1"
+	// CharsetUTF8 accepts UTF-8 text and replaces invalid sequences with U+FFFD.
+	CharsetUTF8 Charset = "utf8"
+)
+
+// decode converts raw to UTF-8 and trims the spaces and NULs rigs pad fixed-width text fields with.
+func (c Charset) decode(raw string) (string, error) {
+	const op errors.Op = "cat.Charset.decode"
+	var text string
+	switch c {
+	case CharsetASCII:
+		text = strings.Map(func(r rune) rune {
+			if r < 0x20 || r > 0x7e {
+				return -1
+			}
+			return r
+		}, raw)
+	case CharsetLatin1:
+		var b strings.Builder
+		b.Grow(len(raw))
+		for i := 0; i < len(raw); i++ {
+			b.WriteRune(rune(raw[i]))
+		}
+		text = b.String()
+	case CharsetUTF8:
+		text = raw
+		if !utf8.ValidString(raw) {
+			text = strings.ToValidUTF8(raw, string(utf8.RuneError))
+		}
+	default:
+		return "", errors.New(op).Msgf("unknown charset %q", c)
+	}
+	return strings.TrimRight(text, " \x00"), nil
+}
@@ -0,0 +1,132 @@
+package cat
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultChunkAckTimeoutMS is used when ChunkedCommand.AckTimeoutMS is zero.
+	defaultChunkAckTimeoutMS = 1000
+)
+
+// ChunkedCommand describes a payload too large for a single frame, such as a voice message upload or a memory
+// bank write. The command template named by Name takes either one %s (the encoded chunk) or two (the chunk index,
+// then the encoded chunk).
+type ChunkedCommand struct {
+	Name      cmds.CatCmdName
+	Payload   []byte
+	ChunkSize int
+	// Encode renders a chunk for the template. Nil sends the bytes as they are.
+	Encode func(chunk []byte) string
+	// AckPrefix is the prefix of the response acknowledging a chunk. Empty means chunks are not acknowledged.
+	AckPrefix string
+	// AckTimeoutMS is how long to wait for each acknowledgement. The unit is milliseconds.
+	//
+	// Default is 1000ms.
+	AckTimeoutMS time.Duration
+	// Retries is the number of times an unacknowledged chunk is resent before giving up.
+	Retries int
+	// StartChunk resumes a previously interrupted transfer from the given chunk index.
+	StartChunk int
+}
+
+// ChunkProgress reports the state of a chunked transfer after each acknowledged chunk.
+type ChunkProgress struct {
+	Chunk      int // index of the chunk just completed
+	Chunks     int
+	Bytes      int // bytes completed so far
+	TotalBytes int
+}
+
+// SendChunked sends cc chunk by chunk, waiting for each acknowledgement when configured and retrying
+// unacknowledged chunks. progress, if not nil, is called after every completed chunk. It returns the index of the
+// next chunk to send; on failure this can be stored in StartChunk to resume the transfer later.
+func (s *Service) SendChunked(ctx context.Context, cc ChunkedCommand, progress func(ChunkProgress)) (int, error) {
+	const op errors.Op = "cat.Service.SendChunked"
+	if !s.initialized.Load() {
+		return cc.StartChunk, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if cc.ChunkSize <= 0 {
+		return cc.StartChunk, errors.New(op).Msgf("invalid chunk size: %d", cc.ChunkSize)
+	}
+
+	template, err := s.commandLookup(cc.Name)
+	if err != nil {
+		return cc.StartChunk, errors.New(op).Err(err)
+	}
+	withIndex := strings.Count(template.Cmd, "%s") == 2
+
+	encode := cc.Encode
+	if encode == nil {
+		encode = func(chunk []byte) string { return string(chunk) }
+	}
+	timeout := cc.AckTimeoutMS
+	if timeout <= 0 {
+		timeout = defaultChunkAckTimeoutMS
+	}
+	timeout *= time.Millisecond
+
+	chunks := (len(cc.Payload) + cc.ChunkSize - 1) / cc.ChunkSize
+	for i := cc.StartChunk; i < chunks; i++ {
+		start := i * cc.ChunkSize
+		end := min(start+cc.ChunkSize, len(cc.Payload))
+
+		params := []string{encode(cc.Payload[start:end])}
+		if withIndex {
+			params = append([]string{strconv.Itoa(i)}, params...)
+		}
+
+		if err = s.sendChunk(ctx, cc, params, timeout); err != nil {
+			return i, errors.New(op).Err(err).Msgf("Chunk %d of %d failed.", i+1, chunks)
+		}
+		if progress != nil {
+			progress(ChunkProgress{Chunk: i, Chunks: chunks, Bytes: end, TotalBytes: len(cc.Payload)})
+		}
+	}
+	return chunks, nil
+}
+
+// sendChunk enqueues one chunk and waits for its acknowledgement, resending it up to cc.Retries times. Only an
+// acknowledgement received once the chunk began to be written counts, and the timeout runs from the write, so that
+// a late acknowledgement of the previous chunk or a long queue does not get the chunk resent or skipped.
+func (s *Service) sendChunk(ctx context.Context, cc ChunkedCommand, params []string, timeout time.Duration) error {
+	const op errors.Op = "cat.Service.sendChunk"
+
+	if cc.AckPrefix == "" {
+		if err := s.EnqueueCommand(cc.Name, params...); err != nil {
+			return errors.New(op).Err(err)
+		}
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Register before sending so that a fast acknowledgement is not missed.
+		acks, from, cancel := s.awaitResponse(cc.AckPrefix)
+		written := make(chan struct{})
+		handle, err := s.EnqueueTracked(cc.Name, params, respondedFrom(from, written))
+		if err != nil {
+			cancel()
+			return errors.New(op).Err(err)
+		}
+
+		_, acked, err := s.awaitWrittenResponse(ctx, handle, written, acks, timeout)
+		cancel()
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		if acked {
+			return nil
+		}
+
+		if attempt >= cc.Retries {
+			return errors.New(op).Msgf("no acknowledgement after %d attempts", attempt+1)
+		}
+		s.logger().WarnWith().Int("attempt", attempt+1).Msg("chunk not acknowledged; resending")
+	}
+}
@@ -0,0 +1,97 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendChunkedWaitsForAcks(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "UPLOAD", Cmd: "UP%s,%s;"}},
+	})
+
+	// Acknowledge every chunk except the first attempt of chunk 1, which has to be resent.
+	attempts := 0
+	runFakeRig(t, service, func(cmd string) {
+		attempts++
+		if cmd == "UP1,cd;" && attempts == 2 {
+			return
+		}
+		service.deliverToWaiters(types.CatState{Prefix: "UP"}, time.Now())
+	})
+
+	var progress []ChunkProgress
+	next, err := service.SendChunked(context.Background(), ChunkedCommand{
+		Name:         "UPLOAD",
+		Payload:      []byte("abcde"),
+		ChunkSize:    2,
+		AckPrefix:    "up",
+		AckTimeoutMS: 50,
+		Retries:      1,
+	}, func(p ChunkProgress) { progress = append(progress, p) })
+
+	require.NoError(t, err)
+	require.Equal(t, 3, next)
+	require.Len(t, progress, 3)
+	require.Equal(t, ChunkProgress{Chunk: 2, Chunks: 3, Bytes: 5, TotalBytes: 5}, progress[2])
+}
+
+func TestSendChunkedReportsResumePoint(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "UPLOAD", Cmd: "UP%s;"}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	next, err := service.SendChunked(ctx, ChunkedCommand{
+		Name:         "UPLOAD",
+		Payload:      []byte("abcd"),
+		ChunkSize:    2,
+		AckPrefix:    "UP",
+		AckTimeoutMS: 10,
+		StartChunk:   1,
+	}, nil)
+
+	require.Error(t, err)
+	require.Equal(t, 1, next)
+}
+
+func TestSendChunkedIgnoresAcksFromBeforeTheWrite(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "UPLOAD", Cmd: "UP%s;"}},
+	})
+	errs := make(chan error, 1)
+	go func() {
+		_, err := service.SendChunked(context.Background(), ChunkedCommand{
+			Name:         "UPLOAD",
+			Payload:      []byte("ab"),
+			ChunkSize:    2,
+			AckPrefix:    "UP",
+			AckTimeoutMS: 50,
+		}, nil)
+		errs <- err
+	}()
+	require.Eventually(t, func() bool { return len(service.sendChannel) == 1 }, time.Second, time.Millisecond)
+
+	// A late acknowledgement of an earlier chunk, received while this one is still queued.
+	service.deliverToWaiters(types.CatState{Prefix: "UP"}, time.Now())
+	time.Sleep(100 * time.Millisecond) // longer than the ack timeout: it runs from the write
+
+	rig := newFakeTransport()
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+
+	require.ErrorContains(t, errors.Root(<-errs), "no acknowledgement after 1 attempts")
+	require.Equal(t, []string{"UPab;"}, rig.writes())
+}
+
+func TestSendChunkedBeforeInitialize(t *testing.T) {
+	next, err := (&Service{}).SendChunked(context.Background(), ChunkedCommand{Name: "UPLOAD", Payload: []byte("ab"), ChunkSize: 1}, nil)
+	require.ErrorContains(t, err, errMsgServiceNotInit)
+	require.Zero(t, next)
+}
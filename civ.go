@@ -0,0 +1,135 @@
+package cat
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	civPreamble = 0xFE
+	civEnd      = 0xFD
+	// defaultCIVControllerAddress is used when Options.CIV.ControllerAddress is zero.
+	defaultCIVControllerAddress = 0xE0
+	// defaultBCDFrequencyBytes is the size of a BCD frequency when no marker gives its length.
+	defaultBCDFrequencyBytes = 5
+)
+
+// CIVOptions configures the CI-V protocol.
+type CIVOptions struct {
+	// RigAddress is the rig's CI-V address, e.g. 0x94 for an IC-7300.
+	RigAddress byte
+	// ControllerAddress is our CI-V address.
+	//
+	// Default is 0xE0.
+	ControllerAddress byte
+}
+
+// civCodec frames CI-V commands and strips the framing from responses. Commands are configured as hex text
+// without the framing, e.g. "03" (read frequency) or "05%s" (set frequency). Received frames are turned into
+// upper-case hex text starting at the command byte, so that states are configured with hex prefixes such as "03"
+// and markers address hex digits.
+type civCodec struct {
+	rig        byte
+	controller byte
+}
+
+func newCIVCodec(opts CIVOptions) civCodec {
+	controller := opts.ControllerAddress
+	if controller == 0 {
+		controller = defaultCIVControllerAddress
+	}
+	return civCodec{rig: opts.RigAddress, controller: controller}
+}
+
+func (c civCodec) encodeCommand(cmd string) (string, error) {
+	const op errors.Op = "cat.civCodec.encodeCommand"
+	body, err := hex.DecodeString(strings.ReplaceAll(cmd, " ", ""))
+	if err != nil {
+		return "", errors.New(op).Msgf("invalid CI-V command %q: %v", cmd, err)
+	}
+	frame := make([]byte, 0, len(body)+5)
+	frame = append(frame, civPreamble, civPreamble, c.rig, c.controller)
+	frame = append(frame, body...)
+	frame = append(frame, civEnd)
+	return string(frame), nil
+}
+
+func (c civCodec) decodeFrame(frame []byte) ([]byte, bool) {
+	frame = bytes.TrimSuffix(frame, []byte{civEnd})
+	// Line noise or a collision can leave extra preamble bytes; skip them all.
+	start := 0
+	for start < len(frame) && frame[start] == civPreamble {
+		start++
+	}
+	if start < 2 || len(frame)-start < 3 {
+		return nil, false
+	}
+	to, body := frame[start], frame[start+2:]
+	if to != c.controller {
+		return nil, false // the echo of our own command, or traffic between other devices on the bus
+	}
+	return []byte(strings.ToUpper(hex.EncodeToString(body))), true
+}
+
+func (c civCodec) lineDelimiter() byte { return civEnd }
+
+// ValueEncoding describes how a marker's raw value is encoded on the wire.
+type ValueEncoding string
+
+const (
+	// EncodingBCDLittleEndian is packed BCD with the least significant byte first, as used by CI-V for
+	// frequencies. The marker addresses hex digits, two per byte.
+	EncodingBCDLittleEndian ValueEncoding = "bcd-le"
+	// EncodingBCDBigEndian is packed BCD with the most significant byte first.
+	EncodingBCDBigEndian ValueEncoding = "bcd-be"
+)
+
+// decodeBCD converts hex text holding packed BCD into a decimal string without leading zeros.
+func decodeBCD(hexDigits string, enc ValueEncoding) (string, error) {
+	const op errors.Op = "cat.decodeBCD"
+	if len(hexDigits)%2 != 0 {
+		return "", errors.New(op).Msgf("odd number of BCD digits in %q", hexDigits)
+	}
+	pairs := make([]string, 0, len(hexDigits)/2)
+	for i := 0; i < len(hexDigits); i += 2 {
+		pairs = append(pairs, hexDigits[i:i+2])
+	}
+	if enc == EncodingBCDLittleEndian {
+		for i, j := 0, len(pairs)-1; i < j; i, j = i+1, j-1 {
+			pairs[i], pairs[j] = pairs[j], pairs[i]
+		}
+	}
+	digits := strings.Join(pairs, "")
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", errors.New(op).Msgf("invalid BCD value %q", hexDigits)
+		}
+	}
+	trimmed := strings.TrimLeft(digits, "0")
+	if trimmed == "" {
+		trimmed = "0"
+	}
+	return trimmed, nil
+}
+
+// encodeBCD renders a non-negative decimal value as packed BCD hex text of the given number of bytes.
+func encodeBCD(value int64, size int, enc ValueEncoding) (string, error) {
+	const op errors.Op = "cat.encodeBCD"
+	digits := strconv.FormatInt(value, 10)
+	if value < 0 || len(digits) > size*2 {
+		return "", errors.New(op).Msgf("value %d does not fit in %d BCD bytes", value, size)
+	}
+	digits = strings.Repeat("0", size*2-len(digits)) + digits
+	if enc == EncodingBCDLittleEndian {
+		pairs := make([]string, 0, size)
+		for i := len(digits); i > 0; i -= 2 {
+			pairs = append(pairs, digits[i-2:i])
+		}
+		digits = strings.Join(pairs, "")
+	}
+	return digits, nil
+}
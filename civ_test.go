@@ -0,0 +1,59 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIVCodecFraming(t *testing.T) {
+	codec := newCIVCodec(CIVOptions{RigAddress: 0x94})
+
+	wire, err := codec.encodeCommand("03")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xFE, 0xFE, 0x94, 0xE0, 0x03, 0xFD}, []byte(wire))
+
+	// The echo of our own command is addressed to the rig and ignored.
+	_, ok := codec.decodeFrame([]byte{0xFE, 0xFE, 0x94, 0xE0, 0x03})
+	require.False(t, ok)
+
+	frame, ok := codec.decodeFrame([]byte{0xFE, 0xFE, 0xE0, 0x94, 0x03, 0x00, 0x40, 0x07, 0x14, 0x00})
+	require.True(t, ok)
+	require.Equal(t, "030040071400", string(frame))
+}
+
+func TestBCDRoundTrip(t *testing.T) {
+	value, err := decodeBCD("0040071400", EncodingBCDLittleEndian)
+	require.NoError(t, err)
+	require.Equal(t, "14074000", value)
+
+	encoded, err := encodeBCD(14074000, 5, EncodingBCDLittleEndian)
+	require.NoError(t, err)
+	require.Equal(t, "0040071400", encoded)
+
+	encoded, err = encodeBCD(1200, 2, EncodingBCDBigEndian)
+	require.NoError(t, err)
+	require.Equal(t, "1200", encoded)
+
+	_, err = decodeBCD("0A", EncodingBCDBigEndian)
+	require.Error(t, err)
+}
+
+func TestCIVFrequencyStateAndCommand(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: CmdSetVfoAFreq.String(), Cmd: "05%s"}},
+		CatStates:   []types.CatState{{Prefix: "03", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 10}}}},
+	})
+	service.Options.StateOptions = map[string]StateOptions{"03": {Encodings: map[string]ValueEncoding{"VFOAFREQ": EncodingBCDLittleEndian}}}
+	service.protocol = newCIVCodec(CIVOptions{RigAddress: 0x94})
+
+	state, ok := service.lookupCatState([]byte("030040071400"))
+	require.True(t, ok)
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "14074000"}, status)
+
+	require.NoError(t, service.setFrequencyHz(VFOA, 7074000))
+	require.Equal(t, []string{"050040070700"}, drainCommands(service))
+}
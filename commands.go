@@ -0,0 +1,165 @@
+package cat
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Command names used by the higher-level operations of this package. A rig definition provides the template for
+// each one in its CatCommands, e.g. {Name: "SETVFOAFREQ", Cmd: "FA%s;"}.
+const (
+	CmdSetVfoAFreq cmds.CatCmdName = "SETVFOAFREQ"
+	CmdSetVfoBFreq cmds.CatCmdName = "SETVFOBFREQ"
+	CmdSetMainMode cmds.CatCmdName = "SETMAINMODE"
+	CmdPTTOn       cmds.CatCmdName = "PTTON"
+	CmdPTTOff      cmds.CatCmdName = "PTTOFF"
+)
+
+// SetFrequencyHz tunes the given VFO to hz, rendered in the rig's native format.
+func (s *Service) SetFrequencyHz(vfo VFO, hz int64, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.SetFrequencyHz"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if err := s.setFrequencyHz(vfo, hz, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// SetMode sets the main mode, given as its display value, e.g. "USB".
+func (s *Service) SetMode(mode string, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.SetMode"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if err := s.setMode(mode, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// SetPTT keys (on) or unkeys the transmitter using the PTTON and PTTOFF commands, or Options.ControlLines.PTT if
+// set. Keying is subject to the avoid ranges like any other transmit command.
+func (s *Service) SetPTT(on bool, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.SetPTT"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if s.Options.ControlLines.PTT != "" {
+		if err := s.setPTTLine(on, opts...); err != nil {
+			return errors.New(op).Err(err)
+		}
+		return nil
+	}
+	name := CmdPTTOff
+	if on {
+		name = CmdPTTOn
+	}
+	if err := s.EnqueueCommandWith(name, nil, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// markerFor returns the first marker, across all configured states, that reports the given tag.
+func (s *Service) markerFor(tag tags.CatStateTag) (types.Marker, bool) {
+	for _, state := range s.rigConfig().CatStates {
+		for _, marker := range state.Markers {
+			if marker.Tag == tag.String() {
+				return marker, true
+			}
+		}
+	}
+	return types.Marker{}, false
+}
+
+// formatFrequency renders hz in the rig's native width, which is taken from the marker that reports the frequency
+// for tag. If no marker is configured the value is sent unpadded. Tags with a BCD encoding are rendered as BCD.
+func (s *Service) formatFrequency(tag tags.CatStateTag, hz int64) (string, error) {
+	const op errors.Op = "cat.Service.formatFrequency"
+	if hz <= 0 {
+		return "", errors.New(op).Msgf("invalid frequency: %d Hz", hz)
+	}
+
+	if enc, ok := s.tagEncoding(tag.String()); ok {
+		marker, _ := s.markerFor(tag)
+		size := marker.Length / 2
+		if size == 0 {
+			size = defaultBCDFrequencyBytes
+		}
+		value, err := encodeBCD(hz, size, enc)
+		if err != nil {
+			return "", errors.New(op).Err(err)
+		}
+		return value, nil
+	}
+
+	value := strconv.FormatInt(hz, 10)
+	if marker, ok := s.markerFor(tag); ok && marker.Length > 0 {
+		if len(value) > marker.Length {
+			return "", errors.New(op).Msgf("frequency %d Hz exceeds the %d digits supported by the rig", hz, marker.Length)
+		}
+		value = strings.Repeat("0", marker.Length-len(value)) + value
+	}
+	return value, nil
+}
+
+// encodeMappedValue converts a display value (e.g. "USB") back into the raw rig value (e.g. "2") by reversing the
+// value mappings of the marker that reports tag. Values without mappings are passed through unchanged.
+func (s *Service) encodeMappedValue(tag tags.CatStateTag, display string) (string, error) {
+	const op errors.Op = "cat.Service.encodeMappedValue"
+
+	marker, ok := s.markerFor(tag)
+	if !ok || len(marker.ValueMappings) == 0 {
+		return display, nil
+	}
+	for _, vm := range marker.ValueMappings {
+		if strings.EqualFold(vm.Value, display) {
+			return vm.Key, nil
+		}
+	}
+	return "", errors.New(op).Msgf("value %q is not mapped for %s", display, tag)
+}
+
+// setFrequencyHz enqueues the command that sets the frequency of vfo.
+func (s *Service) setFrequencyHz(vfo VFO, hz int64, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.setFrequencyHz"
+
+	tag, err := vfo.frequencyTag()
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	value, err := s.formatFrequency(tag, hz)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	name := CmdSetVfoAFreq
+	if vfo == VFOB {
+		name = CmdSetVfoBFreq
+	}
+	if err = s.EnqueueCommandWith(name, []string{value}, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// setMode enqueues the command that sets the main mode.
+func (s *Service) setMode(mode string, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.setMode"
+
+	value, err := s.encodeMappedValue(tags.MainMode, mode)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.EnqueueCommandWith(CmdSetMainMode, []string{value}, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedSetters(t *testing.T) {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands,
+		types.CatCommand{Name: CmdPTTOn.String(), Cmd: "TX;"},
+		types.CatCommand{Name: CmdPTTOff.String(), Cmd: "RX;"},
+	)
+	service := newStartedTestService(t, cfg)
+
+	require.NoError(t, service.SetFrequencyHz(VFOB, 7074000))
+	require.NoError(t, service.SetMode("LSB"))
+	require.NoError(t, service.SetPTT(true))
+	require.NoError(t, service.SetPTT(false))
+	// PTTOFF jumps the queue; the sender drops the keying it overtook.
+	require.Equal(t, []string{"RX;", "FB007074000;", "MD01;", "TX;"}, drainCommands(service))
+
+	require.Error(t, service.SetFrequencyHz(VFO(7), 7074000))
+	require.Error(t, service.SetMode("PKT"))
+}
+
+func TestTypedSettersRequireInitialization(t *testing.T) {
+	service := &Service{}
+
+	require.Error(t, service.SetFrequencyHz(VFOA, 14074000))
+	require.Error(t, service.SetMode("USB"))
+	require.Error(t, service.SetPTT(true))
+}
+
+func TestSetPTTChecksAvoidRanges(t *testing.T) {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands, types.CatCommand{Name: CmdPTTOn.String(), Cmd: "TX;"})
+	service := newStartedTestService(t, cfg)
+	service.Options.AvoidRanges = []AvoidRange{{Label: "20m beacons", MinHz: 14099000, MaxHz: 14101000}}
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014100000"}, time.Now())
+
+	require.Error(t, service.SetPTT(true))
+	require.NoError(t, service.SetPTT(true, ConfirmAvoidRange()))
+	require.Equal(t, []string{"TX;"}, drainCommands(service))
+}
@@ -0,0 +1,143 @@
+package cat
+
+import (
+	"sync"
+
+	"github.com/Station-Manager/errors"
+	bugst "go.bug.st/serial"
+)
+
+// ControlLine names a serial control line used to key the rig.
+type ControlLine string
+
+const (
+	LineRTS ControlLine = "RTS"
+	LineDTR ControlLine = "DTR"
+)
+
+const (
+	// controlPortBaudRate is the baud rate of a secondary control-line port. Only the lines are used, so it does
+	// not matter; it is set because opening a port requires a mode.
+	controlPortBaudRate = 9600
+)
+
+// controlLinePort is a port whose control lines can be set.
+type controlLinePort interface {
+	SetRTS(bool) error
+	SetDTR(bool) error
+}
+
+// controlLinePortCloser is a secondary control-line port.
+type controlLinePortCloser interface {
+	controlLinePort
+	Close() error
+}
+
+// controlLines holds the secondary control-line port, opened on first use and closed by Stop.
+type controlLines struct {
+	mu   sync.Mutex
+	port controlLinePortCloser
+	// open replaces openControlPort when set; used by the tests.
+	open func(name string) (controlLinePortCloser, error)
+}
+
+// openControlPort opens a secondary control-line port with both lines released, so that opening it does not key
+// the rig: low, or high for an inverting driver.
+func openControlPort(name string, invert bool) (controlLinePortCloser, error) {
+	return bugst.Open(name, &bugst.Mode{
+		BaudRate:          controlPortBaudRate,
+		InitialStatusBits: &bugst.ModemOutputBits{RTS: invert, DTR: invert},
+	})
+}
+
+// setControlLine asserts or releases line on Options.ControlLines.Port.
+func (s *Service) setControlLine(line ControlLine, asserted bool) error {
+	const op errors.Op = "cat.Service.setControlLine"
+	port, err := s.controlPort()
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	// Interfaces with an inverting driver are keyed with the line low.
+	level := asserted != s.Options.ControlLines.Invert
+	switch line {
+	case LineRTS:
+		err = port.SetRTS(level)
+	case LineDTR:
+		err = port.SetDTR(level)
+	default:
+		return errors.New(op).Msgf("unknown control line %q", line)
+	}
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// controlPort returns the port carrying the control lines, opening it on first use.
+func (s *Service) controlPort() (controlLinePort, error) {
+	const op errors.Op = "cat.Service.controlPort"
+	name := s.Options.ControlLines.Port
+	if name == "" {
+		return nil, errors.New(op).Msg("Options.ControlLines.Port is not set; the lines of the CAT port cannot be set.")
+	}
+
+	lines := &s.controlLines
+	lines.mu.Lock()
+	defer lines.mu.Unlock()
+	if lines.port == nil {
+		open := lines.open
+		if open == nil {
+			open = func(name string) (controlLinePortCloser, error) {
+				return openControlPort(name, s.Options.ControlLines.Invert)
+			}
+		}
+		port, err := open(name)
+		if err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		lines.port = port
+	}
+	return lines.port, nil
+}
+
+// releaseControlLines releases the configured lines and closes the secondary port, if open. It is called by Stop.
+func (s *Service) releaseControlLines() {
+	for _, line := range []ControlLine{s.Options.ControlLines.PTT, s.Options.ControlLines.CW} {
+		if line == "" {
+			continue
+		}
+		if err := s.setControlLine(line, false); err != nil {
+			s.logger().WarnWith().Err(err).Str("line", string(line)).Msg("control line not released")
+		}
+	}
+
+	lines := &s.controlLines
+	lines.mu.Lock()
+	defer lines.mu.Unlock()
+	if lines.port != nil {
+		if err := lines.port.Close(); err != nil {
+			s.logger().WarnWith().Err(err).Msg("failed to close the control-line port")
+		}
+		lines.port = nil
+	}
+}
+
+// controlLineKey keys CW break-in with Options.ControlLines.CW.
+type controlLineKey struct {
+	s    *Service
+	line ControlLine
+}
+
+func (k controlLineKey) SetKey(down bool) error { return k.s.setControlLine(k.line, down) }
+
+// keyLine returns the KeyLine used for break-in: Service.KeyLine, else the configured CW control line, else nil
+// for CAT commands.
+func (s *Service) keyLine() KeyLine {
+	if s.KeyLine != nil {
+		return s.KeyLine
+	}
+	if line := s.Options.ControlLines.CW; line != "" {
+		return controlLineKey{s: s, line: line}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cat
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeControlPort struct {
+	mu     sync.Mutex
+	rts    []bool
+	dtr    []bool
+	closed bool
+}
+
+func (p *fakeControlPort) SetRTS(v bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rts = append(p.rts, v)
+	return nil
+}
+
+func (p *fakeControlPort) SetDTR(v bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dtr = append(p.dtr, v)
+	return nil
+}
+
+func (p *fakeControlPort) Close() error {
+	p.closed = true
+	return nil
+}
+
+func (p *fakeControlPort) lines() (rts, dtr []bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]bool(nil), p.rts...), append([]bool(nil), p.dtr...)
+}
+
+func newControlLineTestService(t *testing.T, opts ControlLineOptions) (*Service, *fakeControlPort) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.ControlLines = opts
+	port := &fakeControlPort{}
+	service.controlLines.open = func(name string) (controlLinePortCloser, error) {
+		require.Equal(t, "/dev/ttyUSB1", name)
+		return port, nil
+	}
+	startTestWorkers(t, service, nil)
+	return service, port
+}
+
+func TestPTTByControlLine(t *testing.T) {
+	service, port := newControlLineTestService(t, ControlLineOptions{PTT: LineRTS, Port: "/dev/ttyUSB1", Invert: true})
+
+	require.NoError(t, service.PTT(true))
+	require.True(t, service.PTTState().On)
+	require.NoError(t, service.PTT(false))
+	require.False(t, service.PTTState().On)
+
+	rts, dtr := port.lines()
+	require.Equal(t, []bool{false, true}, rts, "inverted lines are keyed low")
+	require.Empty(t, dtr)
+}
+
+func TestPTTLineWatchdog(t *testing.T) {
+	service, port := newControlLineTestService(t, ControlLineOptions{PTT: LineRTS, Port: "/dev/ttyUSB1"})
+	service.Options.PTT.MaxTxMS = 20
+
+	require.NoError(t, service.PTT(true))
+	select {
+	case e := <-service.eventChannel:
+		require.IsType(t, PTTWatchdogEvent{}, e)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	require.False(t, service.PTTState().On)
+	rts, _ := port.lines()
+	require.Equal(t, []bool{true, false}, rts)
+}
+
+func TestCWByControlLine(t *testing.T) {
+	service, port := newControlLineTestService(t, ControlLineOptions{CW: LineDTR, Port: "/dev/ttyUSB1"})
+
+	require.NoError(t, service.Key(true))
+	require.NoError(t, service.KeyAt(false, time.Now().Add(5*time.Millisecond)))
+	_, dtr := port.lines()
+	require.Equal(t, []bool{true, false}, dtr)
+
+	service.releaseControlLines()
+	require.True(t, port.closed)
+}
+
+func TestCWLineWatchdog(t *testing.T) {
+	service, port := newControlLineTestService(t, ControlLineOptions{CW: LineDTR, Port: "/dev/ttyUSB1"})
+	service.Options.PTT.MaxTxMS = 20
+
+	require.NoError(t, service.Key(true))
+	require.True(t, service.PTTState().On)
+	select {
+	case e := <-service.eventChannel:
+		require.IsType(t, PTTWatchdogEvent{}, e)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	require.False(t, service.PTTState().On)
+	_, dtr := port.lines()
+	require.Equal(t, []bool{true, false}, dtr, "the watchdog released the key line")
+}
+
+func TestControlLinesRequirePort(t *testing.T) {
+	service, _ := newControlLineTestService(t, ControlLineOptions{PTT: LineRTS})
+	require.Error(t, service.PTT(true))
+	require.False(t, service.PTTState().On)
+}
@@ -0,0 +1,348 @@
+package cat
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// Command names of the rig's CW keyer, e.g. "KY %s;", "KS%s;" and "KY;" on Kenwood rigs, or "17%s" with
+// Options.CW.Hex on Icom rigs.
+const (
+	// CmdSendCW sends one chunk of text; the template takes one %s.
+	CmdSendCW cmds.CatCmdName = "SENDCW"
+	// CmdSetKeyerSpeed sets the keyer speed in WPM, rendered with three digits.
+	CmdSetKeyerSpeed cmds.CatCmdName = "SETKEYERSPEED"
+	// CmdStopCW stops the keyer and discards its buffer.
+	CmdStopCW cmds.CatCmdName = "STOPCW"
+	// CmdReadCWBuffer asks the rig whether its keyer buffer has space; see CWOptions.BufferPrefix.
+	CmdReadCWBuffer cmds.CatCmdName = "READCWBUFFER"
+)
+
+const (
+	// defaultCWChunkSize is used when Options.CW.ChunkSize is zero.
+	defaultCWChunkSize = 24
+	// defaultCWBufferPollMS is used when Options.CW.BufferPollMS is zero.
+	defaultCWBufferPollMS = 100
+	// defaultCWLeadMS is used when Options.CW.LeadMS is zero.
+	defaultCWLeadMS = 500
+	// cwUnitsPerChar is the average length of a character in dot units, from the 50 units of "PARIS ".
+	cwUnitsPerChar = 50.0 / 6
+	// defaultCWMaxLength is used when Options.CW.MaxLength is zero.
+	defaultCWMaxLength = 500
+	// cwAlphabet holds the characters that SendCW accepts besides letters and digits: those with a Morse code.
+	cwAlphabet = " .,?'!/()&:=+-\"@"
+)
+
+// CWTransmission tracks text sent with SendCW.
+type CWTransmission struct {
+	total int
+	sent  atomic.Int64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	err    error
+	// aborted is the error set by AbortCW, reported in place of the one the transmission ends with.
+	aborted error
+}
+
+// Done returns a channel that is closed when all text has been handed to the rig's keyer, or when the
+// transmission failed or was aborted.
+func (t *CWTransmission) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns why the transmission ended early, or nil.
+func (t *CWTransmission) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// Progress returns the number of characters handed to the keyer so far and the total.
+func (t *CWTransmission) Progress() (sent, total int) {
+	return int(t.sent.Load()), t.total
+}
+
+func (t *CWTransmission) finish(err error) {
+	t.once.Do(func() {
+		t.mu.Lock()
+		if t.aborted != nil {
+			err = t.aborted
+		}
+		t.err = err
+		t.mu.Unlock()
+		close(t.done)
+	})
+}
+
+// abort cancels the transmission, which then ends with err.
+func (t *CWTransmission) abort(err error) {
+	t.mu.Lock()
+	if t.aborted == nil {
+		t.aborted = err
+	}
+	t.mu.Unlock()
+	t.cancel()
+}
+
+// SendCW sends text as Morse with the rig's keyer at wpm. Zero keeps the rig's speed, which is then taken to be
+// Options.CW.DefaultWPM unless Options.CW.BufferPrefix is set. The text may only hold
+// letters, digits and the punctuation that has a Morse code, up to Options.CW.MaxLength characters, since it is
+// written into the SENDCW template unchanged. It is split into
+// chunks for the SENDCW command, which are handed to the rig as its keyer buffer has space: as reported in
+// response to READCWBUFFER when Options.CW.BufferPrefix is set, and otherwise as estimated from the speed. Only one
+// transmission runs at a time; see AbortCW.
+func (s *Service) SendCW(text string, wpm int) (*CWTransmission, error) {
+	const op errors.Op = "cat.Service.SendCW"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if !s.started.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+	if wpm < 0 || wpm > 999 {
+		return nil, errors.New(op).Msgf("invalid keyer speed: %d WPM", wpm)
+	}
+	if wpm == 0 && s.Options.CW.BufferPrefix == "" && s.Options.CW.DefaultWPM <= 0 {
+		return nil, errors.New(op).Msg("a keyer speed is required to pace the chunks; pass wpm or set Options.CW.DefaultWPM or Options.CW.BufferPrefix")
+	}
+	if _, err := s.commandLookup(CmdSendCW); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	text = strings.ToUpper(text)
+	if err := s.validateCWText(text); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	chunks := s.cwChunks(text)
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &CWTransmission{total: len([]rune(text)), cancel: cancel, done: make(chan struct{})}
+
+	s.cw.mu.Lock()
+	if s.cw.current != nil {
+		select {
+		case <-s.cw.current.done:
+		default:
+			s.cw.mu.Unlock()
+			cancel()
+			return nil, errors.New(op).Msg("a CW transmission is already in progress")
+		}
+	}
+	s.cw.current = t
+	s.cw.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		t.finish(s.transmitCW(ctx, t, chunks, wpm))
+	}()
+	return t, nil
+}
+
+// AbortCW stops the transmission in progress, if any, and the rig's keyer right away by writing STOPCW directly.
+// STOPCW is written once the transmission has ended, so that no chunk still being written follows it.
+func (s *Service) AbortCW() error {
+	const op errors.Op = "cat.Service.AbortCW"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	s.cw.mu.Lock()
+	t := s.cw.current
+	s.cw.mu.Unlock()
+	if t != nil {
+		t.abort(errors.New(op).Msg("CW transmission aborted"))
+		<-t.done
+	}
+
+	if _, err := s.commandLookup(CmdStopCW); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err := s.writeNow(CmdStopCW); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// abortCWOnStop stops a transmission in progress. It is called by Stop once the workers have exited.
+func (s *Service) abortCWOnStop() {
+	s.cw.mu.Lock()
+	t := s.cw.current
+	s.cw.mu.Unlock()
+	if t == nil {
+		return
+	}
+	select {
+	case <-t.done:
+	default:
+		if err := s.AbortCW(); err != nil {
+			s.logger().WarnWith().Err(err).Msg("CW keyer not stopped")
+		}
+	}
+}
+
+// validateCWText checks that text, in upper case, holds only characters of the CW alphabet and is no longer than
+// Options.CW.MaxLength.
+func (s *Service) validateCWText(text string) error {
+	const op errors.Op = "cat.Service.validateCWText"
+	maxLength := s.Options.CW.MaxLength
+	if maxLength <= 0 {
+		maxLength = defaultCWMaxLength
+	}
+	if n := len([]rune(text)); n > maxLength {
+		return errors.New(op).Msgf("CW text of %d characters exceeds the limit of %d", n, maxLength)
+	}
+	for _, r := range text {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && !strings.ContainsRune(cwAlphabet, r) {
+			return errors.New(op).Msgf("CW text holds %q, which has no Morse code", r)
+		}
+	}
+	return nil
+}
+
+// cwChunks splits text into chunks of Options.CW.ChunkSize characters.
+func (s *Service) cwChunks(text string) []string {
+	size := s.cwChunkSize()
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); start += size {
+		chunks = append(chunks, string(runes[start:min(start+size, len(runes))]))
+	}
+	return chunks
+}
+
+// cwChunkSize returns Options.CW.ChunkSize or its default.
+func (s *Service) cwChunkSize() int {
+	if s.Options.CW.ChunkSize > 0 {
+		return s.Options.CW.ChunkSize
+	}
+	return defaultCWChunkSize
+}
+
+// transmitCW writes the keyer speed and the chunks of t, waiting for buffer space before each chunk.
+func (s *Service) transmitCW(ctx context.Context, t *CWTransmission, chunks []string, wpm int) error {
+	const op errors.Op = "cat.Service.transmitCW"
+	if wpm > 0 {
+		if _, err := s.commandLookup(CmdSetKeyerSpeed); err == nil {
+			if err = s.writeNow(CmdSetKeyerSpeed, fmt.Sprintf("%03d", wpm)); err != nil {
+				return errors.New(op).Err(err)
+			}
+		}
+	}
+
+	pace := wpm
+	if pace == 0 {
+		pace = s.Options.CW.DefaultWPM
+	}
+	var estimated time.Duration
+	for _, chunk := range chunks {
+		if err := s.awaitCWBuffer(ctx, estimated); err != nil {
+			return errors.New(op).Err(err)
+		}
+		param := chunk
+		if s.Options.CW.Pad {
+			param = fmt.Sprintf("%-*s", s.cwChunkSize(), chunk)
+		}
+		if s.Options.CW.Hex {
+			param = strings.ToUpper(hex.EncodeToString([]byte(param)))
+		}
+		if err := s.writeNow(CmdSendCW, param); err != nil {
+			return errors.New(op).Err(err)
+		}
+		t.sent.Add(int64(len([]rune(chunk))))
+		estimated = cwDuration(len([]rune(chunk)), pace)
+	}
+	return nil
+}
+
+// awaitCWBuffer waits until the keyer can take another chunk: until the rig reports buffer space if
+// Options.CW.BufferPrefix is set, and otherwise until the previous chunk, estimated to take previous, is about to
+// run out.
+func (s *Service) awaitCWBuffer(ctx context.Context, previous time.Duration) error {
+	const op errors.Op = "cat.Service.awaitCWBuffer"
+	prefix := s.Options.CW.BufferPrefix
+	if prefix == "" {
+		wait := previous - durationOrDefault(s.Options.CW.LeadMS, defaultCWLeadMS)
+		if wait <= 0 {
+			if err := ctx.Err(); err != nil {
+				return errors.New(op).Err(err)
+			}
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return errors.New(op).Err(ctx.Err())
+		case <-timer.C:
+			return nil
+		}
+	}
+
+	full := s.Options.CW.BufferFullValue
+	if full == "" {
+		full = "1"
+	}
+	poll := durationOrDefault(s.Options.CW.BufferPollMS, defaultCWBufferPollMS)
+	for {
+		state, cancel := s.awaitState(prefix)
+		if err := s.writeNow(CmdReadCWBuffer); err != nil {
+			cancel()
+			return errors.New(op).Err(err)
+		}
+		timer := time.NewTimer(poll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			cancel()
+			return errors.New(op).Err(ctx.Err())
+		case st := <-state:
+			timer.Stop()
+			cancel()
+			if strings.TrimSpace(strings.TrimSuffix(st.Data, ";")) != full {
+				return nil
+			}
+			// Full: ask again after the poll interval.
+			select {
+			case <-ctx.Done():
+				return errors.New(op).Err(ctx.Err())
+			case <-time.After(poll):
+			}
+		case <-timer.C:
+			cancel()
+		}
+	}
+}
+
+// cwDuration estimates how long the keyer takes to send chars characters at wpm.
+func cwDuration(chars, wpm int) time.Duration {
+	if wpm <= 0 {
+		return 0
+	}
+	unit := 1200 * time.Millisecond / time.Duration(wpm)
+	return time.Duration(float64(chars) * cwUnitsPerChar * float64(unit))
+}
+
+// writeNow formats and writes a command from the calling goroutine, bypassing the send queue and the rate limits.
+// It is used where queueing would add delay that matters, such as keying.
+func (s *Service) writeNow(name cmds.CatCmdName, params ...string) error {
+	const op errors.Op = "cat.Service.writeNow"
+	prepared, err := s.prepare(newCommandRequest(name, params, WithOrigin(OriginInternal), WithPriority(PriorityHigh), Force()))
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	for _, cmd := range prepared {
+		if err = s.writeCommand(cmd); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package cat
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newCWTestService(t *testing.T) *Service {
+	service := newStartedTestService(t, &types.RigConfig{CatCommands: []types.CatCommand{
+		{Name: CmdSendCW.String(), Cmd: "KY %s;"},
+		{Name: CmdSetKeyerSpeed.String(), Cmd: "KS%s;"},
+		{Name: CmdStopCW.String(), Cmd: "KY0;"},
+		{Name: CmdReadCWBuffer.String(), Cmd: "KY;"},
+	}})
+	service.Options.CW = CWOptions{ChunkSize: 5, Pad: true, LeadMS: 10000}
+	return service
+}
+
+func awaitCW(t *testing.T, tx *CWTransmission) {
+	t.Helper()
+	select {
+	case <-tx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("CW transmission did not finish")
+	}
+}
+
+func TestSendCWChunksText(t *testing.T) {
+	service := newCWTestService(t)
+	fake := startTestWorkers(t, service, nil)
+
+	tx, err := service.SendCW("cq cq de k1abc", 20)
+	require.NoError(t, err)
+	awaitCW(t, tx)
+	require.NoError(t, tx.Err())
+	sent, total := tx.Progress()
+	require.Equal(t, 14, total)
+	require.Equal(t, total, sent)
+	require.Equal(t, []string{"KS020;", "KY CQ CQ;", "KY  DE K;", "KY 1ABC ;"}, fake.writes())
+}
+
+func TestSendCWRejectsTextOutsideTheCWAlphabet(t *testing.T) {
+	service := newCWTestService(t)
+	service.Options.CW.MaxLength = 10
+	for _, text := range []string{"CQ;TX1;", "CQ\r", "73 Ä", "CQ CQ CQ DE K1ABC"} {
+		_, err := service.SendCW(text, 20)
+		require.Error(t, err, text)
+	}
+	require.Nil(t, service.cw.current, "nothing is sent")
+}
+
+func TestSendCWNeedsASpeedToPaceChunks(t *testing.T) {
+	service := newCWTestService(t)
+	fake := startTestWorkers(t, service, nil)
+	_, err := service.SendCW("CQ CQ DE K1ABC", 0)
+	require.Error(t, err, "the chunks would overflow the keyer buffer")
+
+	service.Options.CW.LeadMS = 1
+	service.Options.CW.DefaultWPM = 5
+	tx, err := service.SendCW("CQ CQ DE K1ABC", 0)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { sent, _ := tx.Progress(); return sent == 5 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, []string{"KY CQ CQ;"}, fake.writes(), "paced at the default speed")
+	require.NoError(t, service.AbortCW())
+	awaitCW(t, tx)
+}
+
+func TestSendCWWaitsForBufferSpace(t *testing.T) {
+	service := newCWTestService(t)
+	service.Options.CW.BufferPrefix = "KY"
+	service.Options.CW.BufferPollMS = 5
+
+	var mu sync.Mutex
+	full := 2
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if cmd != "KY;" {
+			return
+		}
+		mu.Lock()
+		data := "0;"
+		if full > 0 {
+			full--
+			data = "1;"
+		}
+		mu.Unlock()
+		service.deliverToWaiters(types.CatState{Prefix: "KY", Data: data}, time.Now())
+	}}
+	startTestWorkers(t, service, nil)
+	service.setLink(rig)
+
+	tx, err := service.SendCW("TEST", 0)
+	require.NoError(t, err)
+	awaitCW(t, tx)
+	require.NoError(t, tx.Err())
+	require.Equal(t, []string{"KY;", "KY;", "KY;", "KY TEST ;"}, rig.writes())
+}
+
+func TestAbortCW(t *testing.T) {
+	service := newCWTestService(t)
+	service.Options.CW.LeadMS = 1
+	fake := startTestWorkers(t, service, nil)
+
+	// At 5 WPM every chunk takes seconds, so the second one is never sent.
+	tx, err := service.SendCW("CQ CQ DE K1ABC", 5)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { sent, _ := tx.Progress(); return sent == 5 }, time.Second, time.Millisecond)
+	_, err = service.SendCW("QRZ", 5)
+	require.Error(t, err, "one transmission at a time")
+
+	require.NoError(t, service.AbortCW())
+	awaitCW(t, tx)
+	require.Error(t, tx.Err())
+	require.Equal(t, []string{"KS005;", "KY CQ CQ;", "KY0;"}, fake.writes())
+}
+
+func TestAbortCWWaitsForChunkBeingWritten(t *testing.T) {
+	service := newCWTestService(t)
+	startTestWorkers(t, service, nil)
+	gate := make(chan struct{})
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if cmd == "KY CQ CQ;" {
+			<-gate
+		}
+	}}
+	service.setLink(rig)
+	service.Options.CW.DefaultWPM = 20
+
+	tx, err := service.SendCW("CQ CQ DE K1ABC", 0)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(rig.writes()) == 1 }, time.Second, time.Millisecond)
+
+	aborted := make(chan error, 1)
+	go func() { aborted <- service.AbortCW() }()
+	time.Sleep(20 * time.Millisecond)
+	require.Empty(t, aborted, "AbortCW waits for the chunk being written")
+	require.Equal(t, []string{"KY CQ CQ;"}, rig.writes())
+
+	close(gate)
+	select {
+	case err = <-aborted:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("AbortCW did not return")
+	}
+	awaitCW(t, tx)
+	require.ErrorContains(t, tx.Err(), "aborted")
+	require.Equal(t, []string{"KY CQ CQ;", "KY0;"}, rig.writes(), "STOPCW follows the last chunk")
+}
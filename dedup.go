@@ -0,0 +1,62 @@
+package cat
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+)
+
+// Force sends a command even if duplicate suppression would skip it, e.g. when the cached rig state is suspect.
+func Force() CommandOption {
+	return func(req *commandRequest) {
+		req.force = true
+	}
+}
+
+// setCommandTags maps the set commands of this package to the tag that reports the value they set.
+var setCommandTags = map[cmds.CatCmdName]tags.CatStateTag{
+	CmdSetVfoAFreq: tags.VfoAFreq,
+	CmdSetVfoBFreq: tags.VfoBFreq,
+	CmdSetMainMode: tags.MainMode,
+	CmdSetTxPower:  tags.TxPwr,
+}
+
+// duplicateFilter skips a set command whose value equals the fresh cached state of the rig. Follow-up commands
+// added by earlier filters are still sent.
+func (s *Service) duplicateFilter(req *commandRequest) error {
+	if !s.Options.SuppressDuplicates || req.force || len(req.params) != 1 || s.cache == nil {
+		return nil
+	}
+	tag, ok := s.commandTag(req.name)
+	if !ok {
+		return nil
+	}
+
+	cached, ok := s.cache.get(tag.String())
+	if !ok || !s.isFresh(tag, cached) {
+		return nil
+	}
+	// The cache holds mapped (display) values while the parameter is the raw rig value.
+	current, err := s.encodeMappedValue(tag, cached.Value)
+	if err != nil {
+		return nil
+	}
+	if sameValue(current, req.params[0]) {
+		s.logger().DebugWith().Str("command", req.name.String()).Msg("duplicate command suppressed")
+		req.skip = true
+	}
+	return nil
+}
+
+// sameValue compares two rig values, numerically when both are numbers so that padding does not matter.
+func sameValue(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if x, err := strconv.ParseInt(a, 10, 64); err == nil {
+		if y, err := strconv.ParseInt(b, 10, 64); err == nil {
+			return x == y
+		}
+	}
+	return strings.EqualFold(a, b)
+}
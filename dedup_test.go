@@ -0,0 +1,33 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateSuppression(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.SuppressDuplicates = true
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000", "MAINMODE": "USB"}, time.Now())
+
+	require.NoError(t, service.Tune(14074000, "USB"))
+	require.Empty(t, drainCommands(service))
+
+	require.NoError(t, service.Tune(14074000, "LSB"))
+	require.Equal(t, []string{"MD01;"}, drainCommands(service))
+
+	require.NoError(t, service.setMode("USB", Force()))
+	require.Equal(t, []string{"MD02;"}, drainCommands(service))
+}
+
+func TestDuplicateSuppressionIgnoresStaleCache(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.SuppressDuplicates = true
+	service.cache.update(types.CatStatus{"MAINMODE": "USB"}, time.Now().Add(-time.Hour))
+
+	require.NoError(t, service.setMode("USB"))
+	require.Equal(t, []string{"MD02;"}, drainCommands(service))
+}
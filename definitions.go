@@ -0,0 +1,111 @@
+package cat
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// DefinitionSource supplies the rig definition (serial settings, CAT commands, states and value mappings) that the
+// Service loads on Initialize and Reload; it only replaces where the definition comes from. The ConfigService is
+// used when no source is set; alternatives include StaticDefinitions for a fixed set and the tests,
+// JSONDefinitionFile for user-supplied definitions, and DefinitionSourceFunc, e.g. for a remote repository.
+type DefinitionSource interface {
+	// RigDefinition returns the definition of the rig with the given ID, or of the default rig when rigID is zero.
+	RigDefinition(rigID int64) (types.RigConfig, error)
+}
+
+// DefinitionSourceFunc adapts a function to the DefinitionSource interface.
+type DefinitionSourceFunc func(rigID int64) (types.RigConfig, error)
+
+// RigDefinition implements DefinitionSource.
+func (f DefinitionSourceFunc) RigDefinition(rigID int64) (types.RigConfig, error) {
+	return f(rigID)
+}
+
+// StaticDefinitions is a fixed set of rig definitions. The first one is the default rig.
+type StaticDefinitions []types.RigConfig
+
+// RigDefinition implements DefinitionSource.
+func (d StaticDefinitions) RigDefinition(rigID int64) (types.RigConfig, error) {
+	const op errors.Op = "cat.StaticDefinitions.RigDefinition"
+	if rigID == 0 && len(d) > 0 {
+		return d[0], nil
+	}
+	for _, rig := range d {
+		if rig.ID == rigID {
+			return rig, nil
+		}
+	}
+	return types.RigConfig{}, errors.New(op).Msgf("Rig %d is not configured.", rigID)
+}
+
+// JSONDefinitionFile reads rig definitions from a JSON file holding one RigConfig object or an array of them.
+// The keys are the field names of types.RigConfig. The file is read on every call, so Reload picks up edits.
+type JSONDefinitionFile string
+
+// RigDefinition implements DefinitionSource.
+func (f JSONDefinitionFile) RigDefinition(rigID int64) (types.RigConfig, error) {
+	const op errors.Op = "cat.JSONDefinitionFile.RigDefinition"
+
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return types.RigConfig{}, errors.New(op).Err(err).Msgf("Cannot read rig definitions from %s.", f)
+	}
+	var rigs []types.RigConfig
+	if err = json.Unmarshal(data, &rigs); err != nil {
+		var rig types.RigConfig
+		if err = json.Unmarshal(data, &rig); err != nil {
+			return types.RigConfig{}, errors.New(op).Err(err).Msgf("Invalid rig definitions in %s.", f)
+		}
+		rigs = []types.RigConfig{rig}
+	}
+
+	rig, err := StaticDefinitions(rigs).RigDefinition(rigID)
+	if err != nil {
+		return types.RigConfig{}, errors.New(op).Err(err).Msgf("Rig %d is not defined in %s.", rigID, f)
+	}
+	return rig, nil
+}
+
+// configServiceDefinitions is the default DefinitionSource, backed by the station's ConfigService.
+type configServiceDefinitions struct {
+	service *config.Service
+}
+
+// RigDefinition implements DefinitionSource.
+func (d configServiceDefinitions) RigDefinition(rigID int64) (types.RigConfig, error) {
+	const op errors.Op = "cat.configServiceDefinitions.RigDefinition"
+
+	id := rigID
+	if id == 0 {
+		rigConfigs, err := d.service.RequiredConfigs()
+		if err != nil {
+			return types.RigConfig{}, errors.New(op).Err(err)
+		}
+		id = rigConfigs.DefaultRigID
+	}
+	if id < 1 {
+		return types.RigConfig{}, errors.New(op).Msg(errMsgInvalidRigID)
+	}
+
+	cfg, err := d.service.RigConfigByID(id)
+	if err != nil {
+		return types.RigConfig{}, errors.New(op).Err(err)
+	}
+	if rigID != 0 && cfg.ID != rigID {
+		return types.RigConfig{}, errors.New(op).Msgf("Rig %d is not configured.", rigID)
+	}
+	return cfg, nil
+}
+
+// definitionSource returns the configured DefinitionSource, falling back to the ConfigService.
+func (s *Service) definitionSource() DefinitionSource {
+	if s.Definitions != nil {
+		return s.Definitions
+	}
+	return configServiceDefinitions{service: s.ConfigService}
+}
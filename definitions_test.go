@@ -0,0 +1,64 @@
+package cat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticDefinitions(t *testing.T) {
+	defs := StaticDefinitions{{ID: 3, Name: "FT-991A"}, {ID: 7, Name: "IC-7300"}}
+
+	rig, err := defs.RigDefinition(0)
+	require.NoError(t, err)
+	require.Equal(t, "FT-991A", rig.Name)
+	rig, err = defs.RigDefinition(7)
+	require.NoError(t, err)
+	require.Equal(t, "IC-7300", rig.Name)
+	_, err = defs.RigDefinition(9)
+	require.Error(t, err)
+}
+
+func TestJSONDefinitionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rigs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"ID": 1, "Name": "TS-590", "CatCommands": [{"Name": "READ", "Cmd": "IF;"}]},
+		{"ID": 2, "Name": "FT-817"}
+	]`), 0o600))
+
+	rig, err := JSONDefinitionFile(path).RigDefinition(1)
+	require.NoError(t, err)
+	require.Equal(t, "TS-590", rig.Name)
+	require.Equal(t, []types.CatCommand{{Name: "READ", Cmd: "IF;"}}, rig.CatCommands)
+
+	single := filepath.Join(t.TempDir(), "rig.json")
+	require.NoError(t, os.WriteFile(single, []byte(`{"ID": 4, "Name": "K3"}`), 0o600))
+	rig, err = JSONDefinitionFile(single).RigDefinition(0)
+	require.NoError(t, err)
+	require.Equal(t, "K3", rig.Name)
+
+	_, err = JSONDefinitionFile(filepath.Join(t.TempDir(), "missing.json")).RigDefinition(0)
+	require.Error(t, err)
+}
+
+func TestServiceInitializesFromDefinitionSource(t *testing.T) {
+	service := &Service{
+		LoggerService: &logging.Service{},
+		Definitions: DefinitionSourceFunc(func(rigID int64) (types.RigConfig, error) {
+			return types.RigConfig{
+				ID:          1,
+				CatConfig:   types.CatConfig{SendChannelSize: 1, ProcessingChannelSize: 1},
+				CatCommands: []types.CatCommand{{Name: "READ", Cmd: "IF;"}},
+			}, nil
+		}),
+	}
+	require.NoError(t, service.Initialize())
+
+	cmd, err := service.commandLookup("READ")
+	require.NoError(t, err)
+	require.Equal(t, "IF;", cmd.Cmd)
+}
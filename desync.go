@@ -0,0 +1,75 @@
+package cat
+
+import (
+	"time"
+)
+
+const (
+	// defaultDesyncWindowSize is used when Options.Desync.WindowSize is zero.
+	defaultDesyncWindowSize = 50
+)
+
+// desyncWindow is a sliding window over the most recent frames recording which ones matched no configured state.
+type desyncWindow struct {
+	unknown []bool
+	next    int
+	filled  bool
+	count   int // number of unknown frames currently in the window
+}
+
+// add records a frame and returns the current unknown ratio and whether the window is full.
+func (w *desyncWindow) add(size int, unknown bool) (float64, bool) {
+	if len(w.unknown) != size {
+		*w = desyncWindow{unknown: make([]bool, size)}
+	}
+	if w.unknown[w.next] {
+		w.count--
+	}
+	w.unknown[w.next] = unknown
+	if unknown {
+		w.count++
+	}
+	w.next++
+	if w.next == size {
+		w.next = 0
+		w.filled = true
+	}
+	return float64(w.count) / float64(size), w.filled
+}
+
+// reset clears the window so that a single burst of garbage is only reported once.
+func (w *desyncWindow) reset() {
+	*w = desyncWindow{}
+}
+
+// checkDesync feeds a frame into the sliding window and emits a ProtocolDesyncEvent when the unknown ratio over a
+// full window reaches the configured threshold, starting a recovery if Options.Desync.Recover is set and none is
+// in progress.
+func (s *Service) checkDesync(shutdown <-chan struct{}, matched bool) {
+	threshold := s.Options.Desync.UnknownRatio
+	if threshold <= 0 {
+		return
+	}
+	size := s.Options.Desync.WindowSize
+	if size <= 0 {
+		size = defaultDesyncWindowSize
+	}
+
+	ratio, full := s.frames.window.add(size, !matched)
+	if !full || ratio < threshold {
+		return
+	}
+	s.frames.window.reset()
+
+	s.logger().WarnWith().Float64("ratio", ratio).Int("window", size).Msg("CAT protocol desync detected")
+	recovering := s.Options.Desync.Recover && s.startRecovery(shutdown)
+	s.emitEvent(ProtocolDesyncEvent{
+		At:                time.Now(),
+		UnknownRatio:      ratio,
+		WindowSize:        size,
+		RecoveryTriggered: recovering,
+	})
+	s.notify(SeverityWarning, "Rig communication out of sync",
+		"Most responses from the rig could not be understood.",
+		"Check that the baud rate and rig model match the radio's CAT settings.")
+}
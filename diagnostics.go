@@ -0,0 +1,215 @@
+package cat
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultWireCaptureSize is used when Options.Diagnostics.WireCaptureSize is zero.
+	defaultWireCaptureSize = 200
+	// defaultErrorHistorySize is used when Options.Diagnostics.ErrorHistorySize is zero.
+	defaultErrorHistorySize = 50
+
+	// historyRecordOverhead approximates the memory used by a history record besides its variable-length fields.
+	historyRecordOverhead = 64
+)
+
+// TrafficDirection tells whether a frame was sent to or received from the rig.
+type TrafficDirection string
+
+const (
+	TrafficTX TrafficDirection = "TX"
+	TrafficRX TrafficDirection = "RX"
+)
+
+// TrafficFrame is a single raw frame exchanged with the rig.
+type TrafficFrame struct {
+	Time      time.Time        `json:"time"`
+	Direction TrafficDirection `json:"direction"`
+	Data      []byte           `json:"data"`
+}
+
+// ErrorRecord is a recent error kept for diagnostics.
+type ErrorRecord struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+}
+
+// WorkerStatus describes the lifecycle of one of the service's worker goroutines.
+type WorkerStatus struct {
+	Name      string    `json:"name"`
+	Running   bool      `json:"running"`
+	StartedAt time.Time `json:"started_at"`
+	StoppedAt time.Time `json:"stopped_at,omitempty"`
+}
+
+// counters are the service's running totals. They are updated atomically from the workers.
+type counters struct {
+	framesReceived  atomic.Uint64
+	framesUnknown   atomic.Uint64
+	framesRejected  atomic.Uint64
+	framesParsed    atomic.Uint64
+	readErrors      atomic.Uint64
+	commandsSent    atomic.Uint64
+	writeErrors     atomic.Uint64
+	writeRetries    atomic.Uint64
+	statusesEmitted atomic.Uint64
+	statusesDropped atomic.Uint64
+	reconnects      atomic.Uint64
+	pollsCoalesced  atomic.Uint64
+	staleDropped    atomic.Uint64
+	verifyFailures  atomic.Uint64
+	// responseRetries counts commands resent under a ResponsePolicy, responseTimeouts those that got no response
+	// after the last attempt.
+	responseRetries  atomic.Uint64
+	responseTimeouts atomic.Uint64
+	// framesOversized counts the runs of bytes the frame assembler discarded for exceeding the longest frame.
+	framesOversized atomic.Uint64
+	// storeDropped counts the audit and QSY records discarded because the store queue was full.
+	storeDropped atomic.Uint64
+}
+
+// snapshot returns the counters keyed by name.
+func (c *counters) snapshot() map[string]uint64 {
+	return map[string]uint64{
+		"frames_received":  c.framesReceived.Load(),
+		"frames_unknown":   c.framesUnknown.Load(),
+		"frames_rejected":  c.framesRejected.Load(),
+		"frames_parsed":    c.framesParsed.Load(),
+		"read_errors":      c.readErrors.Load(),
+		"commands_sent":    c.commandsSent.Load(),
+		"write_errors":     c.writeErrors.Load(),
+		"write_retries":    c.writeRetries.Load(),
+		"statuses_emitted": c.statusesEmitted.Load(),
+		"statuses_dropped": c.statusesDropped.Load(),
+		"reconnects":       c.reconnects.Load(),
+		"polls_coalesced":  c.pollsCoalesced.Load(),
+		"stale_dropped":    c.staleDropped.Load(),
+		"verify_failures":  c.verifyFailures.Load(),
+
+		"response_retries":  c.responseRetries.Load(),
+		"response_timeouts": c.responseTimeouts.Load(),
+		"frames_oversized":  c.framesOversized.Load(),
+		"store_dropped":     c.storeDropped.Load(),
+	}
+}
+
+// metricsSnapshot returns the counters together with the per-origin command totals and the memory used by the
+// cache and the diagnostics history.
+func (s *Service) metricsSnapshot() map[string]uint64 {
+	out := s.counters.snapshot()
+	for k, v := range s.origins.snapshot() {
+		out[k] = v
+	}
+	if s.cache != nil {
+		entries, bytes, evicted := s.cache.usage()
+		out["cache_entries"] = uint64(entries)
+		out["cache_bytes"] = uint64(bytes)
+		out["cache_evictions"] = evicted
+	}
+	for stage, m := range s.latency {
+		out["latency_exceeded."+stage.String()] = m.usage()
+	}
+	if s.diag != nil {
+		bytes, evicted := s.diag.wire.usage()
+		out["wire_capture_bytes"] = uint64(bytes)
+		out["wire_capture_evictions"] = evicted
+		bytes, evicted = s.diag.errors.usage()
+		out["error_history_bytes"] = uint64(bytes)
+		out["error_history_evictions"] = evicted
+	}
+	return out
+}
+
+// diagnostics holds the recent history that is exported by ExportDiagnostics.
+type diagnostics struct {
+	wire   *ring[TrafficFrame]
+	errors *ring[ErrorRecord]
+
+	mu      sync.Mutex
+	workers map[string]WorkerStatus
+}
+
+func newDiagnostics(opts DiagnosticsOptions) *diagnostics {
+	wireSize := opts.WireCaptureSize
+	if wireSize <= 0 {
+		wireSize = defaultWireCaptureSize
+	}
+	errorSize := opts.ErrorHistorySize
+	if errorSize <= 0 {
+		errorSize = defaultErrorHistorySize
+	}
+	return &diagnostics{
+		wire: newBudgetRing(wireSize, opts.WireCaptureMaxBytes, func(f TrafficFrame) int {
+			return len(f.Data) + historyRecordOverhead
+		}),
+		errors: newBudgetRing(errorSize, opts.ErrorHistoryMaxBytes, func(e ErrorRecord) int {
+			return len(e.Source) + len(e.Message) + historyRecordOverhead
+		}),
+		workers: make(map[string]WorkerStatus),
+	}
+}
+
+// workerStarted records that a worker goroutine has started.
+func (d *diagnostics) workerStarted(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.workers[name] = WorkerStatus{Name: name, Running: true, StartedAt: time.Now()}
+}
+
+// workerStopped records that a worker goroutine has exited.
+func (d *diagnostics) workerStopped(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ws := d.workers[name]
+	ws.Name = name
+	ws.Running = false
+	ws.StoppedAt = time.Now()
+	d.workers[name] = ws
+}
+
+// workerStatuses returns a copy of the worker statuses.
+func (d *diagnostics) workerStatuses() []WorkerStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]WorkerStatus, 0, len(d.workers))
+	for _, ws := range d.workers {
+		out = append(out, ws)
+	}
+	return out
+}
+
+// recordTraffic keeps a copy of a raw frame for diagnostics, streams it on the raw traffic channel and queues it
+// for the capture file.
+func (s *Service) recordTraffic(direction TrafficDirection, data []byte) {
+	if s.diag == nil && s.rawTrafficChannel == nil && s.captureChannel == nil {
+		return
+	}
+	frame := TrafficFrame{Time: time.Now(), Direction: direction, Data: append([]byte(nil), data...)}
+	if s.diag != nil {
+		s.diag.wire.add(frame)
+	}
+	offerEvicting(s.rawTrafficChannel, frame)
+	s.offerCapture(frame)
+}
+
+// recordError keeps an error for diagnostics.
+func (s *Service) recordError(source string, err error) {
+	if s.diag == nil || err == nil {
+		return
+	}
+	s.diag.errors.add(ErrorRecord{Time: time.Now(), Source: source, Message: err.Error()})
+}
+
+// reportError records err like recordError and emits it as an ErrorEvent, for errors that no more specific
+// event reports.
+func (s *Service) reportError(source string, err error) {
+	if err == nil {
+		return
+	}
+	s.recordError(source, err)
+	s.emitEvent(ErrorEvent{At: time.Now(), Source: source, Err: err.Error()})
+}
@@ -0,0 +1,267 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	bugst "go.bug.st/serial"
+)
+
+// defaultDiscoveryTimeoutMS is used when DiscoveryOptions.TimeoutMS is zero.
+const defaultDiscoveryTimeoutMS = 300
+
+// probeReadPoll is how often a probe port is read while waiting for a reply.
+const probeReadPoll = 20 * time.Millisecond
+
+// defaultDiscoveryBaudRates are tried, in order, when DiscoveryOptions.BaudRates is empty.
+var defaultDiscoveryBaudRates = []int{38400, 115200, 19200, 9600, 4800}
+
+// Package hooks so the tests can replace the serial ports.
+var (
+	listSerialPorts = bugst.GetPortsList
+	openProbePort   = openQuietPort
+)
+
+// DiscoveryProbe is an identification command sent by DiscoverRigs, with the replies that identify rig models.
+type DiscoveryProbe struct {
+	// Name identifies the probe in DiscoveredRig, e.g. "kenwood".
+	Name string
+	// Command is sent as is; binary commands are written with \xHH escapes, e.g. "\xFE\xFE\x00\xE0\x19\x00\xFD".
+	Command string
+	// Delimiter ends the frames of the reply, e.g. ';' or 0xFD.
+	Delimiter byte
+	// Models maps a reply, without its delimiter and with \xHH escapes, to the rig model it identifies. A reply
+	// of no listed model is reported with an empty model.
+	Models map[string]string
+}
+
+// DiscoveryOptions configures DiscoverRigs.
+type DiscoveryOptions struct {
+	// Ports are the ports probed. Empty requires AllPorts.
+	Ports []string
+	// AllPorts probes every serial port of the system when Ports is empty. Probing writes to each port, which may
+	// disturb the devices on the ports that are not rigs, so a full scan has to be asked for.
+	AllPorts bool
+	// BaudRates are tried in order on every port until a probe is answered. Empty means 38400, 115200, 19200,
+	// 9600 and 4800 baud.
+	BaudRates []int
+	// Probes are sent in order at every baud rate. Empty means DefaultDiscoveryProbes.
+	Probes []DiscoveryProbe
+	// TimeoutMS is how long each probe waits for the reply. The unit is milliseconds.
+	//
+	// Default is 300ms.
+	TimeoutMS time.Duration
+}
+
+// DiscoveredRig is a rig found by DiscoverRigs.
+type DiscoveredRig struct {
+	Port     string
+	BaudRate int
+	// Probe is the name of the probe the rig answered, and Reply the reply without its delimiter.
+	Probe string
+	Reply string
+	// Model is the model identified by the reply; empty if the probe does not list it.
+	Model string
+}
+
+// DefaultDiscoveryProbes returns the probes of the Kenwood and Yaesu ID command and the Icom CI-V transceiver ID
+// request, with the models of the built-in drivers among others.
+func DefaultDiscoveryProbes() []DiscoveryProbe {
+	return []DiscoveryProbe{
+		{Name: "kenwood-yaesu", Command: "ID;", Delimiter: ';', Models: map[string]string{
+			"ID019": "TS-2000", "ID021": "TS-590S", "ID023": "TS-590SG", "ID024": "TS-990S",
+			"ID0570": "FT-991", "ID0670": "FT-991A", "ID0681": "FTDX101D", "ID0761": "FTDX10", "ID0800": "FT-710",
+		}},
+		{Name: "icom", Command: `\xFE\xFE\x00\xE0\x19\x00\xFD`, Delimiter: 0xFD, Models: map[string]string{
+			`\xFE\xFE\xE0\x94\x19\x00\x94`: "IC-7300", `\xFE\xFE\xE0\x98\x19\x00\x98`: "IC-7610",
+			`\xFE\xFE\xE0\xA2\x19\x00\xA2`: "IC-9700", `\xFE\xFE\xE0\xA4\x19\x00\xA4`: "IC-705",
+		}},
+	}
+}
+
+// DiscoverRigs probes serial ports for rigs so that a UI can offer one-click setup. Ports are probed in parallel;
+// on each, the baud rates and probes are tried in turn until a probe is answered. Ports are opened with DTR and
+// RTS released, so that probing does not key a rig or reset an interface wired to them. Ports that cannot be
+// opened, e.g. because another program holds them, are skipped. The rigs found are returned sorted by port,
+// together with ctx's error if it is done before every port was probed.
+func DiscoverRigs(ctx context.Context, opts DiscoveryOptions) ([]DiscoveredRig, error) {
+	const op errors.Op = "cat.DiscoverRigs"
+	ports := opts.Ports
+	if len(ports) == 0 && !opts.AllPorts {
+		return nil, errors.New(op).Msg("No ports to probe: set DiscoveryOptions.Ports, or AllPorts to probe every serial port.")
+	}
+	if len(ports) == 0 {
+		listed, err := listSerialPorts()
+		if err != nil {
+			return nil, errors.New(op).Err(err).Msg("Serial ports could not be listed.")
+		}
+		ports = listed
+	}
+	probes, err := discoveryProbes(opts.Probes)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	bauds := opts.BaudRates
+	if len(bauds) == 0 {
+		bauds = defaultDiscoveryBaudRates
+	}
+	timeout := durationOrDefault(opts.TimeoutMS, defaultDiscoveryTimeoutMS)
+
+	var (
+		mu    sync.Mutex
+		found []DiscoveredRig
+		wg    sync.WaitGroup
+	)
+	for _, port := range ports {
+		wg.Go(func() {
+			if rig, ok := probePort(ctx, port, bauds, probes, timeout); ok {
+				mu.Lock()
+				found = append(found, rig)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	slices.SortFunc(found, func(a, b DiscoveredRig) int { return strings.Compare(a.Port, b.Port) })
+	if err = ctx.Err(); err != nil {
+		return found, errors.New(op).Err(err)
+	}
+	return found, nil
+}
+
+// discoveryProbes returns probes, or the default ones, with their escapes decoded.
+func discoveryProbes(probes []DiscoveryProbe) ([]DiscoveryProbe, error) {
+	const op errors.Op = "cat.discoveryProbes"
+	if len(probes) == 0 {
+		probes = DefaultDiscoveryProbes()
+	}
+	out := make([]DiscoveryProbe, 0, len(probes))
+	for _, p := range probes {
+		cmd, err := unescapeHex(p.Command, false)
+		if err != nil {
+			return nil, errors.New(op).Err(err).Msgf("probe %s", p.Name)
+		}
+		models := make(map[string]string, len(p.Models))
+		for reply, model := range p.Models {
+			key, err := unescapeHex(reply, false)
+			if err != nil {
+				return nil, errors.New(op).Err(err).Msgf("probe %s", p.Name)
+			}
+			models[key] = model
+		}
+		p.Command, p.Models = cmd, models
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// probePort tries every baud rate and probe on port until one is answered.
+func probePort(ctx context.Context, port string, bauds []int, probes []DiscoveryProbe, timeout time.Duration) (DiscoveredRig, bool) {
+	for _, baud := range bauds {
+		for _, probe := range probes {
+			if ctx.Err() != nil {
+				return DiscoveredRig{}, false
+			}
+			reply, ok := sendProbe(ctx, port, baud, probe, timeout)
+			if !ok {
+				continue
+			}
+			return DiscoveredRig{Port: port, BaudRate: baud, Probe: probe.Name, Reply: reply, Model: probe.Models[reply]}, true
+		}
+	}
+	return DiscoveredRig{}, false
+}
+
+// sendProbe opens port at baud, sends the probe and returns the first reply frame that is not the echo of the
+// command, as CI-V interfaces send.
+func sendProbe(ctx context.Context, port string, baud int, probe DiscoveryProbe, timeout time.Duration) (string, bool) {
+	t, err := openProbePort(types.SerialConfig{PortName: port, BaudRate: baud, LineDelimiter: probe.Delimiter})
+	if err != nil {
+		return "", false
+	}
+	defer func() { _ = t.Close() }()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err = t.WriteCommand(ctx, probe.Command); err != nil {
+		return "", false
+	}
+	echo := strings.TrimSuffix(probe.Command, string([]byte{probe.Delimiter}))
+	for {
+		frame, err := t.ReadResponseBytes(ctx)
+		if err != nil {
+			return "", false
+		}
+		if reply := string(frame); reply != "" && reply != echo {
+			return reply, true
+		}
+	}
+}
+
+// quietPort is the Transport of a probe: a port opened with DTR and RTS released, whose reads are split into the
+// frames ended by delimiter.
+type quietPort struct {
+	port      bugst.Port
+	delimiter byte
+	pending   []byte
+}
+
+// openQuietPort opens cfg.PortName for a probe with both modem lines released. serial.Open asserts them, as the
+// OS does by default, which keys rigs whose PTT or CW is wired to a modem line.
+func openQuietPort(cfg types.SerialConfig) (Transport, error) {
+	const op errors.Op = "cat.openQuietPort"
+	port, err := bugst.Open(cfg.PortName, &bugst.Mode{BaudRate: cfg.BaudRate, InitialStatusBits: &bugst.ModemOutputBits{}})
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	if err = port.SetReadTimeout(probeReadPoll); err != nil {
+		_ = port.Close()
+		return nil, errors.New(op).Err(err)
+	}
+	return &quietPort{port: port, delimiter: cfg.LineDelimiter}, nil
+}
+
+// WriteCommand implements Transport, ending cmd with the delimiter if it is not already.
+func (p *quietPort) WriteCommand(_ context.Context, cmd string) error {
+	const op errors.Op = "cat.quietPort.WriteCommand"
+	data := []byte(cmd)
+	if len(data) == 0 || data[len(data)-1] != p.delimiter {
+		data = append(data, p.delimiter)
+	}
+	if _, err := p.port.Write(data); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// ReadResponseBytes implements Transport. It returns the next frame without its delimiter.
+func (p *quietPort) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	const op errors.Op = "cat.quietPort.ReadResponseBytes"
+	buf := make([]byte, 256)
+	for {
+		if end := bytes.IndexByte(p.pending, p.delimiter); end >= 0 {
+			frame := slices.Clone(p.pending[:end])
+			p.pending = p.pending[end+1:]
+			return frame, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		n, err := p.port.Read(buf)
+		if err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		p.pending = append(p.pending, buf[:n]...)
+	}
+}
+
+// Close implements Transport.
+func (p *quietPort) Close() error {
+	return p.port.Close()
+}
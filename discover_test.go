@@ -0,0 +1,80 @@
+package cat
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRigPort answers cmd with reply at baud, echoing the command first like a CI-V interface if echo is set.
+type fakeRigPort struct {
+	baud  int
+	cmd   string
+	reply string
+	echo  bool
+}
+
+func stubSerialPorts(t *testing.T, ports map[string]fakeRigPort) {
+	t.Helper()
+	list, open := listSerialPorts, openProbePort
+	t.Cleanup(func() { listSerialPorts, openProbePort = list, open })
+
+	listSerialPorts = func() ([]string, error) {
+		names := make([]string, 0, len(ports))
+		for name := range ports {
+			names = append(names, name)
+		}
+		return append(names, "/dev/ttyBUSY"), nil
+	}
+	openProbePort = func(cfg types.SerialConfig) (Transport, error) {
+		rig, ok := ports[cfg.PortName]
+		if !ok {
+			return nil, fmt.Errorf("open %s: device or resource busy", cfg.PortName)
+		}
+		port := &answeringTransport{fakeTransport: newFakeTransport()}
+		port.onWrite = func(cmd string) {
+			if rig.echo {
+				port.push(cmd[:len(cmd)-1])
+			}
+			if cfg.BaudRate == rig.baud && cmd == rig.cmd {
+				port.push(rig.reply)
+			}
+		}
+		return port, nil
+	}
+}
+
+func TestDiscoverRigs(t *testing.T) {
+	stubSerialPorts(t, map[string]fakeRigPort{
+		"/dev/ttyUSB0": {baud: 9600, cmd: "ID;", reply: "ID023"},
+		"/dev/ttyUSB1": {baud: 115200, cmd: "\xFE\xFE\x00\xE0\x19\x00\xFD", reply: "\xFE\xFE\xE0\x94\x19\x00\x94", echo: true},
+		"/dev/ttyS0":   {},
+	})
+	_, err := DiscoverRigs(context.Background(), DiscoveryOptions{TimeoutMS: 10})
+	require.Error(t, err, "a full scan has to be asked for")
+
+	rigs, err := DiscoverRigs(context.Background(), DiscoveryOptions{AllPorts: true, TimeoutMS: 10})
+	require.NoError(t, err)
+	require.Equal(t, []DiscoveredRig{
+		{Port: "/dev/ttyUSB0", BaudRate: 9600, Probe: "kenwood-yaesu", Reply: "ID023", Model: "TS-590SG"},
+		{Port: "/dev/ttyUSB1", BaudRate: 115200, Probe: "icom", Reply: "\xFE\xFE\xE0\x94\x19\x00\x94", Model: "IC-7300"},
+	}, rigs)
+}
+
+func TestDiscoverRigsWithCustomProbe(t *testing.T) {
+	stubSerialPorts(t, map[string]fakeRigPort{"/dev/ttyUSB0": {baud: 4800, cmd: "ID;", reply: "ID999"}})
+	rigs, err := DiscoverRigs(context.Background(), DiscoveryOptions{
+		Ports:     []string{"/dev/ttyUSB0"},
+		BaudRates: []int{4800},
+		Probes:    []DiscoveryProbe{{Name: "kenwood", Command: "ID;", Delimiter: ';'}},
+		TimeoutMS: 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []DiscoveredRig{{Port: "/dev/ttyUSB0", BaudRate: 4800, Probe: "kenwood", Reply: "ID999"}}, rigs)
+
+	_, err = DiscoverRigs(context.Background(), DiscoveryOptions{Ports: []string{"/dev/ttyUSB0"}, Probes: []DiscoveryProbe{{Name: "bad", Command: `\xZZ`}}})
+	require.Error(t, err)
+}
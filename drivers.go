@@ -0,0 +1,286 @@
+package cat
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// RigDriver encodes commands for, and decodes frames from, a particular rig model. Commands and frames are the
+// text of the rig definition (see protocolCodec); a driver only deals with the wire format. Drivers for binary
+// protocols whose frames do not end with the serial configuration's delimiter also implement
+// LineDelimiter() byte.
+type RigDriver interface {
+	// Encode converts a formatted command into the bytes written to the rig.
+	Encode(cmd string) (string, error)
+	// Decode converts a received frame into text matched against the state prefixes. It returns false for frames
+	// that must be ignored, such as the echo of our own commands.
+	Decode(frame []byte) ([]byte, bool)
+	// Capabilities describes the rig.
+	Capabilities() Capabilities
+}
+
+// DriverDefinition is implemented by drivers that bring a rig definition of their own. Its commands and states are
+// used wherever the configured rig definition does not define a command of the same name or a state of the same
+// prefix, so a rig with a built-in driver needs little more than its serial configuration.
+type DriverDefinition interface {
+	Definition() RigDefinition
+}
+
+// RigDefinition is the part of a rig definition a driver can supply.
+type RigDefinition struct {
+	CatCommands []types.CatCommand
+	CatStates   []types.CatState
+	// Encodings gives the wire encoding of tags, like Options.StateOptions Encodings, which take precedence.
+	Encodings map[string]ValueEncoding
+}
+
+// Names of the built-in drivers, for Options.Driver.
+const (
+	DriverKenwoodTS590 = "ts-590"
+	DriverYaesuFT991A  = "ft-991a"
+	DriverIcomIC7300   = "ic-7300"
+)
+
+// builtinDrivers holds the constructors of the built-in drivers. They take the Options for protocol settings
+// such as the CI-V address.
+var builtinDrivers = map[string]func(opts Options) RigDriver{
+	DriverKenwoodTS590: newKenwoodTS590Driver,
+	DriverYaesuFT991A:  newYaesuFT991ADriver,
+	DriverIcomIC7300:   newIcomIC7300Driver,
+}
+
+// BuiltinDriverNames returns the names of the built-in drivers, in order.
+func BuiltinDriverNames() []string {
+	names := make([]string, 0, len(builtinDrivers))
+	for name := range builtinDrivers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// resolveDriver returns the driver in use: Service.Driver if set, otherwise the built-in driver named by
+// Options.Driver. It returns nil for the generic, purely config-driven driver.
+func (s *Service) resolveDriver() (RigDriver, error) {
+	const op errors.Op = "cat.Service.resolveDriver"
+	if s.Driver != nil {
+		return s.Driver, nil
+	}
+	name := strings.ToLower(strings.TrimSpace(s.Options.Driver))
+	if name == "" {
+		return nil, nil
+	}
+	newDriver, ok := builtinDrivers[name]
+	if !ok {
+		return nil, errors.New(op).Msgf("unknown rig driver %q; built-in drivers are %s", s.Options.Driver, strings.Join(BuiltinDriverNames(), ", "))
+	}
+	return newDriver(s.Options), nil
+}
+
+// applyDriverDefinition fills in the commands and states of the driver's own definition that cfg does not define.
+func applyDriverDefinition(cfg *types.RigConfig, driver RigDriver) {
+	provider, ok := driver.(DriverDefinition)
+	if !ok {
+		return
+	}
+	def := provider.Definition()
+	// Clip so that appending never writes into slices shared with the definition source.
+	cfg.CatCommands = slices.Clip(cfg.CatCommands)
+	cfg.CatStates = slices.Clip(cfg.CatStates)
+	for _, c := range def.CatCommands {
+		if !slices.ContainsFunc(cfg.CatCommands, func(have types.CatCommand) bool { return strings.EqualFold(have.Name, c.Name) }) {
+			cfg.CatCommands = append(cfg.CatCommands, c)
+		}
+	}
+	for _, st := range def.CatStates {
+		if !slices.ContainsFunc(cfg.CatStates, func(have types.CatState) bool { return strings.EqualFold(have.Prefix, st.Prefix) }) {
+			cfg.CatStates = append(cfg.CatStates, st)
+		}
+	}
+}
+
+// driverEncoding returns the encoding of tag from the driver's own definition, if any.
+func (s *Service) driverEncoding(tag string) (ValueEncoding, bool) {
+	provider, ok := s.driver.(DriverDefinition)
+	if !ok {
+		return "", false
+	}
+	enc, ok := provider.Definition().Encodings[tag]
+	return enc, ok
+}
+
+// driverCodec adapts a RigDriver to the protocolCodec used by the pipeline.
+type driverCodec struct {
+	driver RigDriver
+}
+
+func (d driverCodec) encodeCommand(cmd string) (string, error) { return d.driver.Encode(cmd) }
+
+func (d driverCodec) decodeFrame(frame []byte) ([]byte, bool) { return d.driver.Decode(frame) }
+
+func (d driverCodec) lineDelimiter() byte {
+	if delimited, ok := d.driver.(interface{ LineDelimiter() byte }); ok {
+		return delimited.LineDelimiter()
+	}
+	return 0
+}
+
+// builtinDriver is a built-in driver: a protocol codec together with the rig's definition and capabilities.
+type builtinDriver struct {
+	codec      protocolCodec
+	definition RigDefinition
+	caps       Capabilities
+}
+
+func (b *builtinDriver) Encode(cmd string) (string, error) { return b.codec.encodeCommand(cmd) }
+
+func (b *builtinDriver) Decode(frame []byte) ([]byte, bool) { return b.codec.decodeFrame(frame) }
+
+func (b *builtinDriver) Capabilities() Capabilities { return b.caps }
+
+func (b *builtinDriver) Definition() RigDefinition { return b.definition }
+
+func (b *builtinDriver) LineDelimiter() byte { return b.codec.lineDelimiter() }
+
+// hfBands are the bands from 160m to 6m covered by the built-in drivers' rigs.
+var hfBands = []bands.Band{
+	bands.Band160, bands.Band80, bands.Band60, bands.Band40, bands.Band30, bands.Band20,
+	bands.Band17, bands.Band15, bands.Band12, bands.Band10, bands.Band6,
+}
+
+// modeMappings turns raw/display pairs into value mappings.
+func modeMappings(pairs ...string) []types.ValueMapping {
+	mappings := make([]types.ValueMapping, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		mappings = append(mappings, types.ValueMapping{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return mappings
+}
+
+// mappedValues returns the display values of mappings, in order.
+func mappedValues(mappings []types.ValueMapping) []string {
+	values := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		values = append(values, m.Value)
+	}
+	return values
+}
+
+// newKenwoodTS590Driver returns the driver for the Kenwood TS-590S/SG.
+func newKenwoodTS590Driver(Options) RigDriver {
+	modes := modeMappings("1", "LSB", "2", "USB", "3", "CW", "4", "FM", "5", "AM", "6", "FSK", "7", "CW-R", "9", "FSK-R")
+	return &builtinDriver{
+		codec: asciiLineCodec{},
+		definition: RigDefinition{
+			CatCommands: []types.CatCommand{
+				{Name: cmds.Read.String(), Cmd: "IF;"},
+				{Name: CmdSetVfoAFreq.String(), Cmd: "FA%s;"},
+				{Name: CmdSetVfoBFreq.String(), Cmd: "FB%s;"},
+				{Name: CmdSetMainMode.String(), Cmd: "MD%s;"},
+				{Name: CmdSetTxPower.String(), Cmd: "PC%s;"},
+				{Name: CmdPTTOn.String(), Cmd: "TX;"},
+				{Name: CmdPTTOff.String(), Cmd: "RX;"},
+				{Name: CmdSplitOn.String(), Cmd: "FT1;"},
+				{Name: CmdSplitOff.String(), Cmd: "FT0;"},
+				{Name: CmdAutoInfoOn.String(), Cmd: "AI2;"},
+				{Name: CmdAutoInfoOff.String(), Cmd: "AI0;"},
+				{Name: CmdAutoInfoRead.String(), Cmd: "AI;"},
+			},
+			CatStates: []types.CatState{
+				// IF: frequency(11) step(5) RIT(5) RIT XIT 0 memory(2) TX/RX mode function scan split ...
+				{Prefix: "IF", Markers: []types.Marker{
+					{Tag: "VFOAFREQ", Index: 0, Length: 11},
+					{Tag: "MAINMODE", Index: 27, Length: 1, ValueMappings: modes},
+					{Tag: "SPLIT", Index: 30, Length: 1},
+				}},
+				{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+				{Prefix: "FB", Markers: []types.Marker{{Tag: "VFOBFREQ", Index: 0, Length: 11}}},
+				{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: modes}}},
+				{Prefix: "PC", Markers: []types.Marker{{Tag: "TXPWR", Index: 0, Length: 3}}},
+				{Prefix: "AI", Markers: []types.Marker{{Tag: TagAutoInfo.String(), Index: 0, Length: 1}}},
+			},
+		},
+		caps: Capabilities{Bands: hfBands, Modes: mappedValues(modes), MaxPowerW: 100, Split: true},
+	}
+}
+
+// newYaesuFT991ADriver returns the driver for the Yaesu FT-991A.
+func newYaesuFT991ADriver(Options) RigDriver {
+	modes := modeMappings("1", "LSB", "2", "USB", "3", "CW-U", "4", "FM", "5", "AM", "6", "RTTY-LSB", "7", "CW-L",
+		"8", "DATA-LSB", "9", "RTTY-USB", "A", "DATA-FM", "B", "FM-N", "C", "DATA-USB", "D", "AM-N", "E", "C4FM")
+	return &builtinDriver{
+		codec: asciiLineCodec{},
+		definition: RigDefinition{
+			CatCommands: []types.CatCommand{
+				{Name: cmds.Read.String(), Cmd: "IF;"},
+				{Name: CmdSetVfoAFreq.String(), Cmd: "FA%s;"},
+				{Name: CmdSetVfoBFreq.String(), Cmd: "FB%s;"},
+				{Name: CmdSetMainMode.String(), Cmd: "MD0%s;"},
+				{Name: CmdSetTxPower.String(), Cmd: "PC%s;"},
+				{Name: CmdPTTOn.String(), Cmd: "TX1;"},
+				{Name: CmdPTTOff.String(), Cmd: "TX0;"},
+				{Name: CmdSplitOn.String(), Cmd: "ST1;"},
+				{Name: CmdSplitOff.String(), Cmd: "ST0;"},
+				{Name: CmdSwapVFO.String(), Cmd: "SV;"},
+				{Name: CmdEqualizeVFO.String(), Cmd: "AB;"},
+				{Name: CmdAutoInfoOn.String(), Cmd: "AI1;"},
+				{Name: CmdAutoInfoOff.String(), Cmd: "AI0;"},
+				{Name: CmdAutoInfoRead.String(), Cmd: "AI;"},
+			},
+			CatStates: []types.CatState{
+				// IF: memory(3) frequency(9) clarifier(5) RX-clar TX-clar mode ...
+				{Prefix: "IF", Markers: []types.Marker{
+					{Tag: "VFOAFREQ", Index: 3, Length: 9},
+					{Tag: "MAINMODE", Index: 19, Length: 1, ValueMappings: modes},
+				}},
+				{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 9}}},
+				{Prefix: "FB", Markers: []types.Marker{{Tag: "VFOBFREQ", Index: 0, Length: 9}}},
+				{Prefix: "MD0", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: modes}}},
+				{Prefix: "PC", Markers: []types.Marker{{Tag: "TXPWR", Index: 0, Length: 3}}},
+				{Prefix: "AI", Markers: []types.Marker{{Tag: TagAutoInfo.String(), Index: 0, Length: 1}}},
+				{Prefix: "ST", Markers: []types.Marker{{Tag: "SPLIT", Index: 0, Length: 1}}},
+			},
+		},
+		// The rig also covers 2m and 70cm, which are listed once the bands package has constants for them.
+		caps: Capabilities{Bands: hfBands, Modes: mappedValues(modes), MaxPowerW: 100, Split: true},
+	}
+}
+
+// newIcomIC7300Driver returns the driver for the Icom IC-7300, speaking CI-V at its default address 0x94 unless
+// Options.CIV sets another.
+func newIcomIC7300Driver(opts Options) RigDriver {
+	civ := opts.CIV
+	if civ.RigAddress == 0 {
+		civ.RigAddress = 0x94
+	}
+	modes := modeMappings("00", "LSB", "01", "USB", "02", "AM", "03", "CW", "04", "RTTY", "05", "FM", "07", "CW-R", "08", "RTTY-R")
+	freq := []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 10}}
+	mode := []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 2, ValueMappings: modes}}
+	return &builtinDriver{
+		codec: newCIVCodec(civ),
+		definition: RigDefinition{
+			CatCommands: []types.CatCommand{
+				{Name: cmds.Read.String(), Cmd: "03"},
+				{Name: CmdSetVfoAFreq.String(), Cmd: "05%s"},
+				{Name: CmdSetMainMode.String(), Cmd: "06%s"},
+				{Name: CmdPTTOn.String(), Cmd: "1C0001"},
+				{Name: CmdPTTOff.String(), Cmd: "1C0000"},
+				{Name: CmdSplitOn.String(), Cmd: "0F01"},
+				{Name: CmdSplitOff.String(), Cmd: "0F00"},
+			},
+			CatStates: []types.CatState{
+				{Prefix: "03", Markers: freq}, // read frequency
+				{Prefix: "00", Markers: freq}, // transceive frequency
+				{Prefix: "04", Markers: mode}, // read mode
+				{Prefix: "01", Markers: mode}, // transceive mode
+			},
+			Encodings: map[string]ValueEncoding{"VFOAFREQ": EncodingBCDLittleEndian},
+		},
+		caps: Capabilities{Bands: hfBands, Modes: mappedValues(modes), MaxPowerW: 100, Split: true},
+	}
+}
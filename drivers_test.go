@@ -0,0 +1,91 @@
+package cat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newDriverTestService(cfg types.RigConfig, driver string) *Service {
+	cfg.CatConfig = types.CatConfig{SendChannelSize: 1, ProcessingChannelSize: 1}
+	return &Service{
+		LoggerService: &logging.Service{},
+		Definitions:   StaticDefinitions{cfg},
+		Options:       Options{Driver: driver},
+	}
+}
+
+func TestBuiltinDriverFillsInDefinition(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+	}, "TS-590")
+	require.NoError(t, service.Initialize())
+
+	cmd, err := service.commandLookup("READ")
+	require.NoError(t, err)
+	require.Equal(t, "FA;", cmd.Cmd, "the configured definition wins")
+	cmd, err = service.commandLookup(CmdSplitOn)
+	require.NoError(t, err)
+	require.Equal(t, "FT1;", cmd.Cmd)
+
+	// frequency, step, RIT offset, RIT, XIT, bank, memory, TX/RX, mode, function, scan, split ...
+	state, ok := service.lookupCatState([]byte("IF" + "00014074000" + "     " + "+0000" + "000" + "00" + "0" + "2" + "001" + "0000;"))
+	require.True(t, ok)
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, "00014074000", status["VFOAFREQ"])
+	require.Equal(t, "USB", status["MAINMODE"])
+	require.Equal(t, "1", status["SPLIT"])
+}
+
+func TestUnknownDriverFailsInitialize(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, "ts-2000")
+	err := service.Initialize()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ft-991a, ic-7300, ts-590")
+}
+
+func TestIcomDriverSpeaksCIV(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, DriverIcomIC7300)
+	require.NoError(t, service.Initialize())
+
+	frame, err := service.codec().encodeCommand("03")
+	require.NoError(t, err)
+	require.Equal(t, "\xFE\xFE\x94\xE0\x03\xFD", frame)
+	require.Equal(t, byte(civEnd), service.codec().lineDelimiter())
+	enc, ok := service.tagEncoding("VFOAFREQ")
+	require.True(t, ok)
+	require.Equal(t, EncodingBCDLittleEndian, enc)
+
+	// The driver's encoding applies to received frames as well: 14.074 MHz in transceive.
+	decoded, ok := service.codec().decodeFrame([]byte("\xFE\xFE\xE0\x94\x00\x00\x40\x07\x14\x00"))
+	require.True(t, ok)
+	state, ok := service.lookupCatState(decoded)
+	require.True(t, ok)
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, "14074000", status["VFOAFREQ"])
+}
+
+type upperCaseDriver struct{}
+
+func (upperCaseDriver) Encode(cmd string) (string, error) { return strings.ToUpper(cmd), nil }
+
+func (upperCaseDriver) Decode(frame []byte) ([]byte, bool) { return frame, true }
+
+func (upperCaseDriver) Capabilities() Capabilities { return Capabilities{MaxPowerW: 10} }
+
+func TestCustomDriverOverridesOptions(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, DriverIcomIC7300)
+	service.Driver = upperCaseDriver{}
+	require.NoError(t, service.Initialize())
+
+	frame, err := service.codec().encodeCommand("fa;")
+	require.NoError(t, err)
+	require.Equal(t, "FA;", frame)
+	_, err = service.commandLookup("READ")
+	require.Error(t, err, "a driver without a definition adds no commands")
+}
@@ -2,8 +2,10 @@ package cat
 
 var (
 	errMsgNilConfigService  = "Config service is nil."
-	errMsgNilLoggerService  = "Logger service is nil."
 	errMsgInvalidRigID      = "Invalid default rig ID."
 	errMsgServiceNotInit    = "Service not initialized."
 	errMsgServiceNotStarted = "Service not started."
+	errMsgNilStore          = "Store is not configured."
+	errMsgLinkDown          = "Rig link is down."
+	errMsgLinkClosed        = "Rig link is closed."
 )
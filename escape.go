@@ -0,0 +1,117 @@
+package cat
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// unescapeHex replaces the hex escapes in s, e.g. `\xFE\xFE\x94\xE0`, with the bytes they denote, so that binary
+// protocols can be configured in the same schema as ASCII ones. `\\` stands for a backslash; any other backslash is
+// kept as is. In command templates (template true) an escaped '%' is doubled, so that it is sent rather than
+// taken as a parameter verb.
+func unescapeHex(s string, template bool) (string, error) {
+	const op errors.Op = "cat.unescapeHex"
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '\\':
+			b.WriteByte('\\')
+			i++
+		case 'x', 'X':
+			if i+3 >= len(s) {
+				return "", errors.New(op).Msgf("incomplete hex escape at offset %d in %q", i, s)
+			}
+			hi, ok1 := hexDigit(s[i+2])
+			lo, ok2 := hexDigit(s[i+3])
+			if !ok1 || !ok2 {
+				return "", errors.New(op).Msgf("invalid hex escape %q in %q", s[i:i+4], s)
+			}
+			c := hi<<4 | lo
+			if template && c == '%' {
+				b.WriteByte('%')
+			}
+			b.WriteByte(c)
+			i += 3
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// hexDigit returns the value of the hex digit c.
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// unescapeDefinition resolves the hex escapes in the command templates and state prefixes of cfg. The command and
+// state slices are copied, as they may be shared with the definition source.
+func unescapeDefinition(cfg *types.RigConfig) error {
+	const op errors.Op = "cat.unescapeDefinition"
+	cfg.CatCommands = slices.Clone(cfg.CatCommands)
+	for i, c := range cfg.CatCommands {
+		cmd, err := unescapeHex(c.Cmd, true)
+		if err != nil {
+			return errors.New(op).Err(err).Msgf("command %s", c.Name)
+		}
+		cfg.CatCommands[i].Cmd = cmd
+	}
+	cfg.CatStates = slices.Clone(cfg.CatStates)
+	for i, st := range cfg.CatStates {
+		prefix, err := unescapeHex(st.Prefix, false)
+		if err != nil {
+			return errors.New(op).Err(err).Msgf("state %s", st.Prefix)
+		}
+		cfg.CatStates[i].Prefix = prefix
+	}
+	return nil
+}
+
+// prefixKey returns the lookup key of a state prefix: trimmed and, unless Options.CaseSensitivePrefixes is set,
+// with ASCII letters in upper case.
+func (s *Service) prefixKey(prefix string) string {
+	return s.foldPrefix(strings.TrimSpace(prefix))
+}
+
+// foldPrefix upper-cases the ASCII letters of a prefix or frame start, unless Options.CaseSensitivePrefixes is
+// set. Other bytes, such as those of binary prefixes, are kept as they are.
+func (s *Service) foldPrefix(v string) string {
+	if s.Options.CaseSensitivePrefixes {
+		return v
+	}
+	return asciiUpper(v)
+}
+
+// asciiUpper upper-cases the ASCII letters of s. Unlike strings.ToUpper it leaves bytes that are not valid UTF-8
+// untouched.
+func asciiUpper(s string) string {
+	i := strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' })
+	if i < 0 {
+		return s
+	}
+	b := []byte(s)
+	for j := i; j < len(b); j++ {
+		if c := b[j]; c >= 'a' && c <= 'z' {
+			b[j] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,43 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnescapeHex(t *testing.T) {
+	got, err := unescapeHex(`\xFE\xfe\x94\xE0\x03\xFD`, false)
+	require.NoError(t, err)
+	require.Equal(t, "\xfe\xfe\x94\xe0\x03\xfd", got)
+
+	got, err = unescapeHex(`PC\x25%s;\\n\q`, true)
+	require.NoError(t, err)
+	require.Equal(t, `PC%%%s;\n\q`, got, "an escaped '%' is doubled in templates")
+
+	_, err = unescapeHex(`\xF`, false)
+	require.Error(t, err)
+	_, err = unescapeHex(`\xZZ;`, false)
+	require.Error(t, err)
+}
+
+func TestBinaryDefinitionFromHexEscapes(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: `\xFE\xFE\x94\xE0\x03%s\xFD`}},
+		CatStates: []types.CatState{{Prefix: `\xfe\xfe\xE0\x94\x03`, Markers: []types.Marker{
+			{Tag: "VFOAFREQ", Index: 0, Length: 2},
+		}}},
+	}, "")
+	require.NoError(t, service.Initialize())
+
+	cmd, err := service.formatRequest(newCommandRequest("READ", []string{"\x01"}))
+	require.NoError(t, err)
+	require.Equal(t, "\xfe\xfe\x94\xe0\x03\x01\xfd", cmd.Cmd)
+
+	state, ok := service.lookupCatState([]byte("\xfe\xfe\xe0\x94\x03ab\xfd"))
+	require.True(t, ok, "binary prefixes are not case folded into other bytes")
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, "ab", status["VFOAFREQ"])
+}
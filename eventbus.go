@@ -0,0 +1,57 @@
+package cat
+
+const (
+	// busQueueSize bounds the number of messages waiting to be published on the EventBus.
+	busQueueSize = 64
+)
+
+// Topics used when publishing onto the station-wide EventBus.
+const (
+	TopicStatus       = "cat.status"
+	TopicEvent        = "cat.event"
+	TopicNotification = "cat.notification"
+	// TopicBand carries a BandChangedEvent whenever VFO A moves to another band.
+	TopicBand = "cat.band"
+	// TopicNormalized carries the merged rig state as a NormalizedStatus after every status.
+	TopicNormalized = "cat.normalized"
+)
+
+// EventBus is the station-wide publish interface that other Station-Manager services (rotor, audio, logger)
+// subscribe to. The payloads published by this package are types.CatStatus, NormalizedStatus, BandChangedEvent,
+// CatEvent and Notification values, so consumers do not need the cat package's channel types. The rigs of a
+// Registry wrap them in a RigPayload.
+type EventBus interface {
+	Publish(topic string, payload any) error
+}
+
+// busMessage is a message waiting to be handed to the EventBus.
+type busMessage struct {
+	topic   string
+	payload any
+}
+
+// publish queues payload for the EventBus without blocking the caller. It is a no-op if no bus is configured.
+func (s *Service) publish(topic string, payload any) {
+	if s.EventBus == nil || s.busChannel == nil {
+		return
+	}
+	select {
+	case s.busChannel <- busMessage{topic: topic, payload: payload}:
+	default:
+		s.logger().DebugWith().Str("topic", topic).Msg("dropping event bus message: queue full")
+	}
+}
+
+// eventBusPublisher hands queued messages to the EventBus so that a slow bus never stalls the CAT pipeline.
+func (s *Service) eventBusPublisher(shutdown <-chan struct{}) {
+	for {
+		select {
+		case <-shutdown:
+			return
+		case msg := <-s.busChannel:
+			if err := s.EventBus.Publish(msg.topic, msg.payload); err != nil {
+				s.logger().WarnWith().Err(err).Str("topic", msg.topic).Msg("event bus publish failed")
+			}
+		}
+	}
+}
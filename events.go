@@ -0,0 +1,191 @@
+package cat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultEventChannelSize is used when Options.EventChannelSize is zero.
+	defaultEventChannelSize = 16
+	// dropEventInterval is the minimum interval between two DropEvents of the same kind.
+	dropEventInterval = time.Second
+)
+
+// EventKind identifies the type of CatEvent.
+type EventKind string
+
+const (
+	EventProtocolDesync EventKind = "PROTOCOL_DESYNC"
+	EventCommandFailed  EventKind = "COMMAND_FAILED"
+	EventPTTWatchdog    EventKind = "PTT_WATCHDOG"
+	EventError          EventKind = "ERROR"
+	EventDrop           EventKind = "DROP"
+	EventReconnect      EventKind = "RECONNECT"
+)
+
+// String implements fmt.Stringer.
+func (k EventKind) String() string {
+	return string(k)
+}
+
+// CatEvent is implemented by every event delivered on the Events channel. Consumers switch on the concrete type to
+// access the event-specific fields.
+type CatEvent interface {
+	Kind() EventKind
+	Time() time.Time
+}
+
+// ProtocolDesyncEvent is emitted when the share of frames matching no configured state exceeds the configured
+// threshold, which usually indicates a baud rate mismatch or framing drift.
+type ProtocolDesyncEvent struct {
+	At           time.Time
+	UnknownRatio float64
+	WindowSize   int
+	// RecoveryTriggered is set if the desync started a recovery; it is not while a recovery is still running.
+	RecoveryTriggered bool
+}
+
+func (e ProtocolDesyncEvent) Kind() EventKind { return EventProtocolDesync }
+func (e ProtocolDesyncEvent) Time() time.Time { return e.At }
+
+// ErrorEvent is emitted for errors that no more specific event reports, such as read errors, frames rejected by
+// strict parsing and failed recoveries. Source is the component that failed, e.g. "listener".
+type ErrorEvent struct {
+	At     time.Time
+	Source string
+	Err    string
+}
+
+func (e ErrorEvent) Kind() EventKind { return EventError }
+func (e ErrorEvent) Time() time.Time { return e.At }
+
+// DropKind names what a DropEvent reports as dropped.
+type DropKind string
+
+const (
+	// DropStatus is a status discarded because the status channel was full.
+	DropStatus DropKind = "status"
+	// DropFrame is a received frame discarded because the processor fell behind.
+	DropFrame DropKind = "frame"
+	// DropMeter is a meter reading discarded because the meter channel was full.
+	DropMeter DropKind = "meter"
+)
+
+// DropEvent is emitted when values are dropped because a consumer fell behind. To keep a flood of drops from
+// flooding the events channel as well, it is emitted at most once a second per kind; Count is the number of
+// drops since the previous DropEvent of the kind. Stop emits the count of drops not yet reported.
+type DropEvent struct {
+	At    time.Time
+	What  DropKind
+	Count int
+}
+
+func (e DropEvent) Kind() EventKind { return EventDrop }
+func (e DropEvent) Time() time.Time { return e.At }
+
+// ReconnectEvent is emitted when the link to the rig is lost, with Connected false, and when it is re-established
+// after Attempts attempts, with Connected true. Fault is why the link was lost.
+type ReconnectEvent struct {
+	At        time.Time
+	Connected bool
+	Fault     string
+	Attempts  int
+}
+
+func (e ReconnectEvent) Kind() EventKind { return EventReconnect }
+func (e ReconnectEvent) Time() time.Time { return e.At }
+
+// dropTracker counts drops for the rate-limited DropEvents.
+type dropTracker struct {
+	mu      sync.Mutex
+	pending map[DropKind]int
+	last    map[DropKind]time.Time
+}
+
+// noteDrop counts a dropped value of kind and emits a DropEvent if none was emitted for kind within
+// dropEventInterval.
+func (s *Service) noteDrop(kind DropKind) {
+	now := time.Now()
+	d := &s.drops
+	d.mu.Lock()
+	if d.pending == nil {
+		d.pending = make(map[DropKind]int)
+		d.last = make(map[DropKind]time.Time)
+	}
+	d.pending[kind]++
+	if now.Sub(d.last[kind]) < dropEventInterval {
+		d.mu.Unlock()
+		return
+	}
+	count := d.pending[kind]
+	d.pending[kind] = 0
+	d.last[kind] = now
+	d.mu.Unlock()
+	s.emitEvent(DropEvent{At: now, What: kind, Count: count})
+}
+
+// flushDrops emits a DropEvent for every kind with drops not yet reported, so that the drops of the last interval
+// before Stop are not lost.
+func (s *Service) flushDrops() {
+	now := time.Now()
+	d := &s.drops
+	d.mu.Lock()
+	var events []DropEvent
+	for kind, count := range d.pending {
+		if count > 0 {
+			events = append(events, DropEvent{At: now, What: kind, Count: count})
+			d.pending[kind] = 0
+			d.last[kind] = now
+		}
+	}
+	d.mu.Unlock()
+	for _, e := range events {
+		s.emitEvent(e)
+	}
+}
+
+// Events returns a channel delivering non-status events (desyncs, errors, ...) or an error if the service is
+// uninitialized. When the consumer falls behind, the oldest undelivered event is discarded.
+func (s *Service) Events() (<-chan CatEvent, error) {
+	const op errors.Op = "cat.Service.Events"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	return s.eventChannel, nil
+}
+
+// emitEvent delivers e on the events channel without blocking the caller, evicting the oldest event if needed.
+func (s *Service) emitEvent(e CatEvent) {
+	s.publish(TopicEvent, e)
+	s.routeEvent(e)
+	if !offerEvicting(s.eventChannel, e) {
+		s.logger().WarnWith().Str("kind", e.Kind().String()).Msg("dropping cat event: events channel full")
+	}
+}
+
+// offerEvicting sends v on ch without blocking. If ch is full the oldest queued value is discarded to make room.
+// It returns false if v could not be delivered (nil or unbuffered channel without a ready receiver, or a
+// concurrent producer refilled the channel).
+func offerEvicting[T any](ch chan T, v T) bool {
+	if ch == nil {
+		return false
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		select {
+		case ch <- v:
+			return true
+		default:
+		}
+		if cap(ch) == 0 {
+			return false
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package cat
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropEventsAreRateLimited(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	for range 5 {
+		service.noteDrop(DropStatus)
+	}
+	service.noteDrop(DropMeter)
+
+	first := (<-service.eventChannel).(DropEvent)
+	require.Equal(t, DropStatus, first.What)
+	require.Equal(t, 1, first.Count)
+	meter := (<-service.eventChannel).(DropEvent)
+	require.Equal(t, DropMeter, meter.What)
+	require.Empty(t, service.eventChannel, "further drops are counted until the interval elapses")
+
+	service.drops.last[DropStatus] = first.At.Add(-dropEventInterval)
+	service.noteDrop(DropStatus)
+	require.Equal(t, 5, (<-service.eventChannel).(DropEvent).Count)
+}
+
+func TestStopReportsPendingDrops(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	for range 3 {
+		service.noteDrop(DropFrame)
+	}
+	require.Equal(t, 1, (<-service.eventChannel).(DropEvent).Count)
+
+	require.NoError(t, service.Stop())
+	last := (<-service.eventChannel).(DropEvent)
+	require.Equal(t, DropFrame, last.What)
+	require.Equal(t, 2, last.Count, "the drops counted since the last DropEvent")
+	require.Empty(t, service.eventChannel)
+}
+
+func TestListenerErrorEventsAreRateLimited(t *testing.T) {
+	cfg := &types.RigConfig{}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 1
+	service := newStartedTestService(t, cfg)
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortListener": service.serialPortListener,
+	})
+	service.setLink(&closedTransport{})
+
+	require.Eventually(t, func() bool { return service.counters.readErrors.Load() >= 20 }, 2*time.Second, time.Millisecond)
+	var errs int
+	for len(service.eventChannel) > 0 {
+		if _, ok := (<-service.eventChannel).(ErrorEvent); ok {
+			errs++
+		}
+	}
+	require.Equal(t, 1, errs, "repeated read errors are reported once per ErrorLogIntervalMS")
+	require.GreaterOrEqual(t, len(service.diag.errors.list()), 16, "but every one is recorded")
+}
+
+func TestReportErrorEmitsErrorEvent(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.reportError("listener", fmt.Errorf("read: input/output error"))
+
+	e := (<-service.eventChannel).(ErrorEvent)
+	require.Equal(t, "listener", e.Source)
+	require.Equal(t, "read: input/output error", e.Err)
+	require.Len(t, service.diag.errors.list(), 1)
+}
@@ -0,0 +1,69 @@
+package cat
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+// ExportDiagnostics writes a zip bundle to path containing the current rig definition, the recent wire capture, a
+// metrics snapshot, the worker statuses and the recent errors, so that users can attach a single file to a bug
+// report.
+func (s *Service) ExportDiagnostics(path string) error {
+	const op errors.Op = "cat.Service.ExportDiagnostics"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	zw := zip.NewWriter(file)
+	entries := []struct {
+		name    string
+		payload any
+	}{
+		{"rig_definition.json", s.RigConfig()},
+		{"wire_capture.json", s.diag.wire.list()},
+		{"metrics.json", s.metricsSnapshot()},
+		{"workers.json", s.diag.workerStatuses()},
+		{"errors.json", s.diag.errors.list()},
+		{"info.json", map[string]any{
+			"exported_at": time.Now(),
+			"started":     s.started.Load(),
+			"migrations":  s.MigrationReport(),
+		}},
+	}
+	for _, e := range entries {
+		if err = writeZipJSON(zw, e.name, e.payload); err != nil {
+			_ = zw.Close()
+			_ = file.Close()
+			return errors.New(op).Err(err)
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		_ = file.Close()
+		return errors.New(op).Err(err)
+	}
+	if err = file.Close(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// writeZipJSON adds payload to zw as an indented JSON file.
+func writeZipJSON(zw *zip.Writer, name string, payload any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(payload)
+}
@@ -0,0 +1,54 @@
+package cat
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportDiagnosticsBundle(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{Name: "test rig"})
+	service.recordTraffic(TrafficTX, []byte("FA;"))
+	service.recordTraffic(TrafficRX, []byte("FA014074000"))
+
+	path := filepath.Join(t.TempDir(), "diag.zip")
+	require.NoError(t, service.ExportDiagnostics(path))
+
+	zr, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer func() { _ = zr.Close() }()
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	require.ElementsMatch(t, []string{
+		"rig_definition.json", "wire_capture.json", "metrics.json", "workers.json", "errors.json", "info.json",
+	}, names)
+}
+
+func TestRingKeepsMostRecent(t *testing.T) {
+	r := newRing[int](3)
+	for i := 1; i <= 5; i++ {
+		r.add(i)
+	}
+	require.Equal(t, []int{3, 4, 5}, r.list())
+}
+
+func TestRingByteBudgetDropsOldest(t *testing.T) {
+	r := newBudgetRing(10, 10, func(s string) int { return len(s) })
+	for _, s := range []string{"aaaa", "bbbb", "cccc"} {
+		r.add(s)
+	}
+	require.Equal(t, []string{"bbbb", "cccc"}, r.list())
+	bytes, evicted := r.usage()
+	require.Equal(t, 8, bytes)
+	require.Equal(t, uint64(1), evicted)
+
+	// The newest item is kept even when it alone exceeds the budget.
+	r.add("dddddddddddd")
+	require.Equal(t, []string{"dddddddddddd"}, r.list())
+}
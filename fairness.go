@@ -0,0 +1,105 @@
+package cat
+
+import (
+	"sync"
+
+	"github.com/Station-Manager/errors"
+)
+
+// fairQueue holds normal-priority commands in one lane per origin and hands them to the sender by weighted fair
+// queuing (stride scheduling): every command advances its lane's pass by 1/weight, and the non-empty lane with
+// the lowest pass goes next. A runaway macro therefore fills only its own lane and cannot starve the UI.
+type fairQueue struct {
+	mu      sync.Mutex
+	size    int
+	weights map[Origin]int
+	lanes   []*fairLane
+	// vtime is the pass of the lane served last. A lane that was idle catches up to it, so that idling does not
+	// earn credit.
+	vtime float64
+	// ready is signaled when a command is pushed, to wake the sender.
+	ready chan struct{}
+}
+
+// fairLane is the queue of one origin.
+type fairLane struct {
+	origin Origin
+	ch     chan queuedCommand
+	weight int
+	pass   float64
+}
+
+// newFairQueue returns the fair queue configured by opts, or nil if fair queuing is disabled. Every lane holds up to
+// size commands.
+func newFairQueue(opts FairnessOptions, size int) *fairQueue {
+	if !opts.Enabled {
+		return nil
+	}
+	return &fairQueue{size: size, weights: opts.Weights, ready: make(chan struct{}, 1)}
+}
+
+// lane returns the lane of origin, creating it on first use. The caller holds mu.
+func (q *fairQueue) lane(origin Origin) *fairLane {
+	for _, l := range q.lanes {
+		if l.origin == origin {
+			return l
+		}
+	}
+	weight := q.weights[origin]
+	if weight <= 0 {
+		weight = 1
+	}
+	l := &fairLane{origin: origin, ch: make(chan queuedCommand, q.size), weight: weight, pass: q.vtime}
+	q.lanes = append(q.lanes, l)
+	return l
+}
+
+// push queues cmd in the lane of its origin without blocking.
+func (q *fairQueue) push(cmd queuedCommand) error {
+	const op errors.Op = "cat.fairQueue.push"
+	q.mu.Lock()
+	l := q.lane(cmd.origin)
+	if len(l.ch) == 0 {
+		l.pass = max(l.pass, q.vtime)
+	}
+	select {
+	case l.ch <- cmd:
+	default:
+		q.mu.Unlock()
+		return errors.New(op).Msgf("Send queue of origin %q is full.", cmd.origin)
+	}
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop returns the next command by weighted fair queuing. ok is false if every lane is empty.
+func (q *fairQueue) pop() (cmd queuedCommand, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next *fairLane
+	for _, l := range q.lanes {
+		if len(l.ch) > 0 && (next == nil || l.pass < next.pass) {
+			next = l
+		}
+	}
+	if next == nil {
+		return queuedCommand{}, false
+	}
+	cmd = <-next.ch
+	q.vtime = next.pass
+	next.pass += 1 / float64(next.weight)
+	return cmd, true
+}
+
+// fairReady returns the channel signaled when a command is pushed to the fair queue, or nil if fair queuing is
+// disabled.
+func (s *Service) fairReady() <-chan struct{} {
+	if s.fair == nil {
+		return nil
+	}
+	return s.fair.ready
+}
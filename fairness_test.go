@@ -0,0 +1,51 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFairQueueSharesByWeight(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "MACRO", Cmd: "M%s;"}, {Name: "UI", Cmd: "U%s;"}},
+	})
+	service.fair = newFairQueue(FairnessOptions{Enabled: true, Weights: map[Origin]int{OriginUI: 3}}, 8)
+
+	for _, p := range []string{"1", "2", "3", "4", "5", "6"} {
+		require.NoError(t, service.EnqueueCommandWith("MACRO", []string{p}, WithOrigin(OriginMacro)))
+	}
+	for _, p := range []string{"1", "2", "3"} {
+		require.NoError(t, service.EnqueueCommandWith("UI", []string{p}, WithOrigin(OriginUI)))
+	}
+	require.Equal(t, []string{"M1;", "U1;", "U2;", "U3;", "M2;", "M3;", "M4;", "M5;", "M6;"}, drainCommands(service))
+}
+
+func TestFairQueueLanesAreBoundedPerOrigin(t *testing.T) {
+	q := newFairQueue(FairnessOptions{Enabled: true}, 2)
+	require.NoError(t, q.push(queuedCommand{origin: OriginMacro}))
+	require.NoError(t, q.push(queuedCommand{origin: OriginMacro}))
+	require.Error(t, q.push(queuedCommand{origin: OriginMacro}), "a runaway origin fills only its own lane")
+	require.NoError(t, q.push(queuedCommand{origin: OriginUI}))
+}
+
+func TestFairQueueIdleLaneEarnsNoCredit(t *testing.T) {
+	q := newFairQueue(FairnessOptions{Enabled: true}, 8)
+	require.NoError(t, q.push(queuedCommand{origin: OriginUI}))
+	_, _ = q.pop()
+	for range 4 {
+		require.NoError(t, q.push(queuedCommand{origin: OriginMacro}))
+		_, _ = q.pop()
+	}
+	// UI was idle while the macro ran; it gets its share again, not a burst.
+	for range 3 {
+		require.NoError(t, q.push(queuedCommand{origin: OriginUI}))
+	}
+	require.NoError(t, q.push(queuedCommand{origin: OriginMacro}))
+	var got []Origin
+	for cmd, ok := q.pop(); ok; cmd, ok = q.pop() {
+		got = append(got, cmd.origin)
+	}
+	require.Equal(t, []Origin{OriginUI, OriginUI, OriginMacro, OriginUI}, got)
+}
@@ -0,0 +1,104 @@
+package cat
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+// faultTransport wraps a Transport and injects dropped, corrupted and delayed frames according to a FaultConfig.
+// It exists so that the retry and reconnect paths can be exercised repeatedly without real hardware misbehaving.
+type faultTransport struct {
+	inner Transport
+	cfg   FaultConfig
+
+	mu  sync.Mutex // guards rnd; math/rand.Rand is not safe for concurrent use
+	rnd *rand.Rand
+}
+
+// newFaultTransport wraps inner with the fault injection described by cfg.
+func newFaultTransport(inner Transport, cfg FaultConfig) *faultTransport {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &faultTransport{
+		inner: inner,
+		cfg:   cfg,
+		rnd:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// chance reports whether an event with the given percentage probability should occur.
+func (f *faultTransport) chance(percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Intn(100) < percent
+}
+
+// intn returns a random number in [0, n) using the shared random source.
+func (f *faultTransport) intn(n int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Intn(n)
+}
+
+// WriteCommand implements Transport, optionally delaying or dropping the command.
+func (f *faultTransport) WriteCommand(ctx context.Context, cmd string) error {
+	const op errors.Op = "cat.faultTransport.WriteCommand"
+
+	if f.cfg.WriteDelayMS > 0 {
+		timer := time.NewTimer(f.cfg.WriteDelayMS * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.New(op).Err(ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	if f.chance(f.cfg.DropWritePercent) {
+		return nil
+	}
+
+	return f.inner.WriteCommand(ctx, cmd)
+}
+
+// ReadResponseBytes implements Transport, optionally dropping or corrupting the received frame.
+func (f *faultTransport) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	for {
+		line, err := f.inner.ReadResponseBytes(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.chance(f.cfg.DropReadPercent) {
+			// Behave as if the frame never arrived and wait for the next one.
+			continue
+		}
+
+		if len(line) > 0 && f.chance(f.cfg.CorruptPercent) {
+			corrupted := make([]byte, len(line))
+			copy(corrupted, line)
+			idx := f.intn(len(corrupted))
+			corrupted[idx] ^= byte(1 + f.intn(255))
+			line = corrupted
+		}
+
+		return line, nil
+	}
+}
+
+// Close implements Transport.
+func (f *faultTransport) Close() error {
+	return f.inner.Close()
+}
@@ -0,0 +1,84 @@
+package cat
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransport is an in-memory Transport used by the tests. Frames queued with push are returned by
+// ReadResponseBytes in order, and every written command is recorded.
+type fakeTransport struct {
+	mu      sync.Mutex
+	frames  chan []byte
+	written []string
+	closed  bool
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{frames: make(chan []byte, 64)}
+}
+
+func (f *fakeTransport) push(frame string) {
+	f.frames <- []byte(frame)
+}
+
+func (f *fakeTransport) WriteCommand(_ context.Context, cmd string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.written = append(f.written, cmd)
+	return nil
+}
+
+func (f *fakeTransport) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case b := <-f.frames:
+		return b, nil
+	}
+}
+
+func (f *fakeTransport) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeTransport) writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.written...)
+}
+
+func TestFaultTransportDropsAllWrites(t *testing.T) {
+	inner := newFakeTransport()
+	ft := newFaultTransport(inner, FaultConfig{Enabled: true, Seed: 1, DropWritePercent: 100})
+
+	require.NoError(t, ft.WriteCommand(context.Background(), "FA;"))
+	require.Empty(t, inner.writes())
+}
+
+func TestFaultTransportCorruptsFrames(t *testing.T) {
+	inner := newFakeTransport()
+	ft := newFaultTransport(inner, FaultConfig{Enabled: true, Seed: 1, CorruptPercent: 100})
+
+	inner.push("FA014074000")
+	line, err := ft.ReadResponseBytes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, line, len("FA014074000"))
+	require.NotEqual(t, "FA014074000", string(line))
+}
+
+func TestFaultTransportFailOpens(t *testing.T) {
+	service := &Service{
+		Options: Options{Debug: DebugOptions{Faults: FaultConfig{Enabled: true, FailOpens: 2}}},
+	}
+
+	require.ErrorContains(t, service.initializeTransport(), "fault injection")
+	require.ErrorContains(t, service.initializeTransport(), "fault injection")
+	require.Equal(t, 2, service.openAttempts)
+}
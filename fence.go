@@ -0,0 +1,173 @@
+package cat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// fenceLockStale is the age after which a FileFence lock file is taken to be left behind by a crashed holder.
+	// The lock is only held for a read-modify-write of the lease, so a live holder never gets near it.
+	fenceLockStale = 10 * time.Second
+	// fenceLockWait bounds how long a FileFence operation waits for the lock when ctx has no earlier deadline.
+	fenceLockWait = 2 * time.Second
+	// fenceLockRetry is the interval between attempts to take a busy lock.
+	fenceLockRetry = 20 * time.Millisecond
+)
+
+// fileLease is the content of a FileFence lease file.
+type fileLease struct {
+	Owner   string    `json:"owner"`
+	Token   uint64    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileFence is a Fence backed by a lease file on storage shared by both controllers (e.g. a network share). An
+// exclusive lock file serializes the read-modify-write of the lease between hosts; a busy lock is waited for and
+// one left behind by a crash is broken once it is older than fenceLockStale. The lease is replaced atomically, so
+// a reader never sees it half written.
+type FileFence struct {
+	Path string
+}
+
+// Acquire implements Fence.
+func (f *FileFence) Acquire(ctx context.Context, owner string, ttl time.Duration) (uint64, error) {
+	const op errors.Op = "cat.FileFence.Acquire"
+	var token uint64
+	err := f.withLock(ctx, func(lease fileLease, now time.Time) (*fileLease, error) {
+		if lease.Owner != "" && lease.Owner != owner && now.Before(lease.Expires) {
+			return nil, errors.New(op).Msgf("fence held by %s until %s", lease.Owner, lease.Expires.Format(time.RFC3339))
+		}
+		token = lease.Token + 1
+		return &fileLease{Owner: owner, Token: token, Expires: now.Add(ttl)}, nil
+	})
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+	return token, nil
+}
+
+// Renew implements Fence.
+func (f *FileFence) Renew(ctx context.Context, owner string, token uint64, ttl time.Duration) error {
+	const op errors.Op = "cat.FileFence.Renew"
+	err := f.withLock(ctx, func(lease fileLease, now time.Time) (*fileLease, error) {
+		if lease.Owner != owner || lease.Token != token {
+			return nil, errors.New(op).Err(ErrFenceLost).Msgf("fence is now held by %s (token %d)", lease.Owner, lease.Token)
+		}
+		lease.Expires = now.Add(ttl)
+		return &lease, nil
+	})
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// Release implements Fence.
+func (f *FileFence) Release(ctx context.Context, owner string, token uint64) error {
+	const op errors.Op = "cat.FileFence.Release"
+	err := f.withLock(ctx, func(lease fileLease, _ time.Time) (*fileLease, error) {
+		if lease.Owner != owner || lease.Token != token {
+			return nil, nil // not ours any more; nothing to release
+		}
+		// Keep the token so the next holder still gets a higher one.
+		return &fileLease{Token: lease.Token}, nil
+	})
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// withLock reads the lease under an exclusive lock file and writes back the lease returned by fn, if any.
+func (f *FileFence) withLock(ctx context.Context, fn func(lease fileLease, now time.Time) (*fileLease, error)) error {
+	const op errors.Op = "cat.FileFence.withLock"
+	lockPath := f.Path + ".lock"
+	if err := f.lock(ctx, lockPath); err != nil {
+		return errors.New(op).Err(err)
+	}
+	defer func() { _ = os.Remove(lockPath) }()
+
+	var lease fileLease
+	data, err := os.ReadFile(f.Path)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &lease); err != nil {
+			return errors.New(op).Err(err)
+		}
+	case !os.IsNotExist(err):
+		return errors.New(op).Err(err)
+	}
+
+	updated, err := fn(lease, time.Now())
+	if err != nil || updated == nil {
+		return err
+	}
+	if data, err = json.Marshal(updated); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = f.replace(data); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// lock creates the lock file at lockPath, waiting while another host holds it and breaking a stale one.
+func (f *FileFence) lock(ctx context.Context, lockPath string) error {
+	const op errors.Op = "cat.FileFence.lock"
+	ctx, cancel := context.WithTimeout(ctx, fenceLockWait)
+	defer cancel()
+	host, _ := os.Hostname()
+	for {
+		lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			// The holder is recorded only to help whoever has to investigate a stuck lock.
+			_, _ = fmt.Fprintf(lock, "%s %d\n", host, os.Getpid())
+			return lock.Close()
+		}
+		if !os.IsExist(err) {
+			return errors.New(op).Err(err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > fenceLockStale {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return errors.New(op).Err(ctx.Err()).Msg("fence is locked by another host")
+		case <-time.After(fenceLockRetry):
+		}
+	}
+}
+
+// replace writes data to a temporary file beside the lease and renames it over the lease.
+func (f *FileFence) replace(data []byte) error {
+	const op errors.Op = "cat.FileFence.replace"
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // fails harmlessly once renamed
+	// CreateTemp makes the file private; the other host has to be able to read the lease.
+	if err = tmp.Chmod(0o644); err == nil {
+		if _, err = tmp.Write(data); err == nil {
+			err = tmp.Sync()
+		}
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.Path)
+	}
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cat
+
+import (
+	"context"
+	stderr "errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFenceExclusive(t *testing.T) {
+	ctx := context.Background()
+	fence := &FileFence{Path: filepath.Join(t.TempDir(), "rig.lease")}
+
+	tokenA, err := fence.Acquire(ctx, "host-a", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), tokenA)
+
+	_, err = fence.Acquire(ctx, "host-b", time.Minute)
+	require.Error(t, err)
+	require.Error(t, fence.Renew(ctx, "host-b", tokenA, time.Minute))
+	require.NoError(t, fence.Renew(ctx, "host-a", tokenA, time.Minute))
+
+	require.NoError(t, fence.Release(ctx, "host-a", tokenA))
+	tokenB, err := fence.Acquire(ctx, "host-b", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), tokenB)
+
+	// The old holder is fenced out.
+	err = fence.Renew(ctx, "host-a", tokenA, time.Minute)
+	require.True(t, stderr.Is(err, ErrFenceLost), "%v", err)
+}
+
+func TestFileFenceExpiredLeaseCanBeTaken(t *testing.T) {
+	ctx := context.Background()
+	fence := &FileFence{Path: filepath.Join(t.TempDir(), "rig.lease")}
+
+	_, err := fence.Acquire(ctx, "host-a", time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = fence.Acquire(ctx, "host-b", time.Minute)
+	require.NoError(t, err)
+}
+
+func TestFileFenceBreaksStaleLock(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fence := &FileFence{Path: filepath.Join(dir, "rig.lease")}
+
+	// A fresh lock is waited for, not broken.
+	require.NoError(t, os.WriteFile(fence.Path+".lock", nil, 0o644))
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := fence.Acquire(shortCtx, "host-a", time.Minute)
+	require.Error(t, err)
+	require.False(t, stderr.Is(err, ErrFenceLost), "a busy lock is not a lost fence")
+
+	// One left behind by a crash is broken.
+	old := time.Now().Add(-2 * fenceLockStale)
+	require.NoError(t, os.Chtimes(fence.Path+".lock", old, old))
+	_, err = fence.Acquire(ctx, "host-a", time.Minute)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the lease is written in place of a temporary file, and the lock removed")
+	require.Equal(t, "rig.lease", entries[0].Name())
+}
@@ -0,0 +1,161 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Station-Manager/errors"
+)
+
+// defaultMaxFrameBytes is used when Options.Framing.MaxFrameBytes is zero.
+const defaultMaxFrameBytes = 256
+
+// frameAssembler splits a byte stream into the frames ended by any of its terminators. It is not safe for
+// concurrent use.
+type frameAssembler struct {
+	terminators []byte
+	maxBytes    int
+	// partial holds the bytes read since the last terminator.
+	partial []byte
+	// discarding is set while the rest of an oversized run is skipped up to the next terminator.
+	discarding bool
+}
+
+// newFrameAssembler returns an assembler for frames of at most maxBytes ended by any of terminators.
+func newFrameAssembler(terminators []byte, maxBytes int) *frameAssembler {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxFrameBytes
+	}
+	return &frameAssembler{terminators: terminators, maxBytes: maxBytes}
+}
+
+// feed appends data to the stream and returns the frames it completes, without their terminators, and the number
+// of oversized runs it discarded. Empty frames, as between CR and LF, are skipped.
+func (a *frameAssembler) feed(data []byte) (frames [][]byte, discarded int) {
+	isTerminator := func(b byte) bool { return bytes.IndexByte(a.terminators, b) >= 0 }
+	for len(data) > 0 {
+		end := slices.IndexFunc(data, isTerminator)
+		if end < 0 {
+			if !a.discarding {
+				a.partial = append(a.partial, data...)
+				if len(a.partial) > a.maxBytes {
+					a.partial, a.discarding = a.partial[:0], true
+					discarded++
+				}
+			}
+			return frames, discarded
+		}
+		chunk := data[:end]
+		data = data[end+1:]
+		switch {
+		case a.discarding:
+			a.discarding = false // the end of a run already counted
+		case len(a.partial)+len(chunk) > a.maxBytes:
+			a.partial = a.partial[:0]
+			discarded++
+		case len(a.partial)+len(chunk) > 0:
+			frame := make([]byte, 0, len(a.partial)+len(chunk))
+			frames = append(frames, append(append(frame, a.partial...), chunk...))
+			a.partial = a.partial[:0]
+		}
+	}
+	return frames, discarded
+}
+
+// framedTransport wraps a Transport and returns the frames that a frameAssembler finds in its reads, one per
+// ReadResponseBytes.
+type framedTransport struct {
+	inner Transport
+	// raw is false if every read of inner ends a frame.
+	raw       bool
+	onDiscard func(runs int)
+
+	mu    sync.Mutex
+	asm   *frameAssembler
+	ready [][]byte
+}
+
+// WriteCommand implements Transport.
+func (f *framedTransport) WriteCommand(ctx context.Context, cmd string) error {
+	return f.inner.WriteCommand(ctx, cmd)
+}
+
+// ReadResponseBytes implements Transport. It reads from the wrapped transport until a frame is complete, and
+// returns the frames of a read that holds several in turn before reading again.
+func (f *framedTransport) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.ready) == 0 {
+		data, err := f.inner.ReadResponseBytes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !f.raw {
+			// Copy rather than append into the wrapped transport's buffer.
+			data = append(data[:len(data):len(data)], f.asm.terminators[0])
+		}
+		frames, discarded := f.asm.feed(data)
+		if discarded > 0 && f.onDiscard != nil {
+			f.onDiscard(discarded)
+		}
+		f.ready = append(f.ready, frames...)
+	}
+	frame := f.ready[0]
+	f.ready = f.ready[1:]
+	return frame, nil
+}
+
+// Close implements Transport.
+func (f *framedTransport) Close() error {
+	return f.inner.Close()
+}
+
+// Errors exposes the wrapped transport's asynchronous errors, if it has any, to the reconnect logic.
+func (f *framedTransport) Errors() <-chan error {
+	if src, ok := f.inner.(errorSource); ok {
+		return src.Errors()
+	}
+	return nil
+}
+
+// assembleFrames wraps t in the frame assembler of Options.Framing. t is closed if the terminators are invalid.
+func (s *Service) assembleFrames(t Transport) (Transport, error) {
+	const op errors.Op = "cat.Service.assembleFrames"
+	terminators, err := s.frameTerminators()
+	if err != nil {
+		_ = t.Close()
+		return nil, errors.New(op).Err(err)
+	}
+	opts := s.Options.Framing
+	return &framedTransport{
+		inner: t,
+		raw:   opts.RawReads,
+		onDiscard: func(runs int) {
+			s.count(&s.counters.framesOversized, "frames_oversized", uint64(runs))
+			s.logger().DebugWith().Int("runs", runs).Msg("discarded oversized input without a terminator")
+		},
+		asm: newFrameAssembler(terminators, opts.MaxFrameBytes),
+	}, nil
+}
+
+// frameTerminators returns the terminators of Options.Framing, defaulting to the protocol's delimiter, the serial
+// configuration's, or ';'.
+func (s *Service) frameTerminators() ([]byte, error) {
+	const op errors.Op = "cat.Service.frameTerminators"
+	if configured := s.Options.Framing.Terminators; configured != "" {
+		terminators, err := unescapeHex(configured, false)
+		if err != nil {
+			return nil, errors.New(op).Err(err).Msg("Options.Framing.Terminators is invalid.")
+		}
+		return []byte(terminators), nil
+	}
+	if d := s.codec().lineDelimiter(); d != 0 {
+		return []byte{d}, nil
+	}
+	if d := s.rigConfig().SerialConfig.LineDelimiter; d != 0 {
+		return []byte{d}, nil
+	}
+	return []byte{';'}, nil
+}
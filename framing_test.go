@@ -0,0 +1,132 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func frameStrings(frames [][]byte) []string {
+	out := make([]string, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, string(f))
+	}
+	return out
+}
+
+func TestFrameAssemblerJoinsAndSplitsReads(t *testing.T) {
+	asm := newFrameAssembler([]byte(";\r\n"), 16)
+
+	frames, _ := asm.feed([]byte("FA000140"))
+	require.Empty(t, frames, "a partial read completes no frame")
+	frames, _ = asm.feed([]byte("74000;MD2;IF"))
+	require.Equal(t, []string{"FA00014074000", "MD2"}, frameStrings(frames))
+	frames, _ = asm.feed([]byte("1\r\n"))
+	require.Equal(t, []string{"IF1"}, frameStrings(frames), "CR LF ends one frame")
+
+	civ := newFrameAssembler([]byte{civEnd}, 0)
+	frames, _ = civ.feed([]byte("\xFE\xFE\xE0\x94\x03\x00\x40\x07\x14\x00\xFD\xFE\xFE"))
+	require.Equal(t, []string{"\xFE\xFE\xE0\x94\x03\x00\x40\x07\x14\x00"}, frameStrings(frames))
+	frames, _ = civ.feed([]byte("\xE0\x94\xFB\xFD"))
+	require.Equal(t, []string{"\xFE\xFE\xE0\x94\xFB"}, frameStrings(frames))
+}
+
+func TestFrameAssemblerDiscardsOversizedGarbage(t *testing.T) {
+	asm := newFrameAssembler([]byte(";"), 8)
+
+	frames, discarded := asm.feed([]byte(strings.Repeat("\x80", 10)))
+	require.Empty(t, frames)
+	require.Equal(t, 1, discarded)
+	frames, discarded = asm.feed([]byte("\x81\x82;MD2;"))
+	require.Equal(t, []string{"MD2"}, frameStrings(frames), "the tail of the run is skipped")
+	require.Zero(t, discarded, "a run is counted once")
+
+	frames, discarded = asm.feed([]byte("ABCDEFGHIJ;FA1;"))
+	require.Equal(t, []string{"FA1"}, frameStrings(frames))
+	require.Equal(t, 1, discarded)
+}
+
+func FuzzFrameAssembler(f *testing.F) {
+	f.Add([]byte("FA00014074000;MD2;"), 3)
+	f.Add([]byte("\xFE\xFE\xE0\x94\x03\xFD\xFF\xFF;;\r\n"), 1)
+	f.Add([]byte(strings.Repeat("x", 300)+";ID023;"), 7)
+	f.Fuzz(func(t *testing.T, data []byte, split int) {
+		asm := newFrameAssembler([]byte(";\xFD"), 32)
+		var frames [][]byte
+		for chunk := range chunks(data, max(1, split%64)) {
+			got, _ := asm.feed(chunk)
+			frames = append(frames, got...)
+		}
+		for _, frame := range frames {
+			require.NotEmpty(t, frame)
+			require.LessOrEqual(t, len(frame), 32)
+			require.Negative(t, bytes.IndexByte(frame, ';'), "frames hold no terminator")
+			require.Negative(t, bytes.IndexByte(frame, 0xFD), "frames hold no terminator")
+		}
+		require.LessOrEqual(t, len(asm.partial), 32)
+	})
+}
+
+// chunks yields data in chunks of n bytes, as a serial link might deliver it.
+func chunks(data []byte, n int) func(func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		for len(data) > 0 {
+			end := min(n, len(data))
+			if !yield(data[:end]) {
+				return
+			}
+			data = data[end:]
+		}
+	}
+}
+
+func TestFramedTransportFeedsPipeline(t *testing.T) {
+	cfg := &types.RigConfig{CatStates: []types.CatState{
+		{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+		{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1}}},
+	}}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 1
+	service := newStartedTestService(t, cfg)
+	service.processingChannel = make(chan receivedState, 4)
+	service.statusChannel = make(chan types.CatStatus, 4)
+	service.Options.Framing = FramingOptions{Enabled: true, RawReads: true, MaxFrameBytes: 20}
+	rig := newFakeTransport()
+	service.Dial = func() (Transport, error) { return rig, nil }
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortListener": service.serialPortListener,
+		"lineProcessor":      service.lineProcessor,
+	})
+	require.NoError(t, service.initializeTransport())
+
+	rig.push("FA0001")
+	rig.push("4074000;" + strings.Repeat("\xFF", 40))
+	rig.push("\xFF;MD2;")
+	require.Eventually(t, func() bool { return service.counters.framesParsed.Load() == 2 }, 2*time.Second, 5*time.Millisecond)
+	freq, _ := service.cache.get("VFOAFREQ")
+	require.Equal(t, "00014074000", freq.Value)
+	mode, _ := service.cache.get("MAINMODE")
+	require.Equal(t, "2", mode.Value)
+	require.Equal(t, uint64(1), service.counters.framesOversized.Load())
+}
+
+func TestFramedTransportSplitsLineReads(t *testing.T) {
+	rig := newFakeTransport()
+	framed := &framedTransport{inner: rig, asm: newFrameAssembler([]byte(";"), 0)}
+	rig.push("FA00014074000;MD2")
+	rig.push("ID023")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var got []string
+	for range 3 {
+		frame, err := framed.ReadResponseBytes(ctx)
+		require.NoError(t, err)
+		got = append(got, string(frame))
+	}
+	require.Equal(t, []string{"FA00014074000", "MD2", "ID023"}, got, "every read ends a frame")
+}
@@ -0,0 +1,123 @@
+package cat
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/errors"
+)
+
+// frequencyUnits maps the unit suffixes accepted by ParseFrequency to their multiplier in Hz.
+var frequencyUnits = []struct {
+	suffix string
+	hz     float64
+}{
+	{"mhz", 1e6}, {"khz", 1e3}, {"hz", 1}, {"m", 1e6}, {"k", 1e3},
+}
+
+// ParseFrequency parses a frequency typed by the operator and returns it in Hz, so that every frontend interprets
+// entries the same way:
+//
+//   - An explicit unit is honoured: "14.074 MHz", "14074k", "14074000 Hz".
+//   - Without a unit, values below 1000 are MHz ("14.074", "7"), values below 1 000 000 are kHz ("14074.5") and
+//     larger values are Hz. A decimal comma is accepted: "14,074".
+//   - With a band, partial entries are completed within it: ".074" and "74" on 20m are 14.074 MHz. Numbers that
+//     name the start of a band ("7", "14", "21") are still MHz. Pass an empty band to disable shortcuts.
+func ParseFrequency(input string, band bands.Band) (int64, error) {
+	const op errors.Op = "cat.ParseFrequency"
+
+	text := strings.ToLower(strings.TrimSpace(input))
+	if text == "" {
+		return 0, errors.New(op).Msg("Enter a frequency, e.g. 14.074 or 14074 kHz.")
+	}
+
+	multiplier := 0.0
+	for _, u := range frequencyUnits {
+		if rest, ok := strings.CutSuffix(text, u.suffix); ok {
+			text, multiplier = strings.TrimSpace(rest), u.hz
+			break
+		}
+	}
+	if strings.Count(text, ",") == 1 && !strings.Contains(text, ".") {
+		text = strings.Replace(text, ",", ".", 1)
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || strings.ContainsAny(text, "eE+-") || math.IsNaN(value) {
+		return 0, errors.New(op).Msgf("%q is not a frequency; use e.g. 14.074, 14074 kHz or 14074000 Hz.", input)
+	}
+	if value <= 0 {
+		return 0, errors.New(op).Msgf("%q is not a positive frequency.", input)
+	}
+
+	if multiplier == 0 && band != "" {
+		if hz, ok := bandRelativeFrequency(text, value, band); ok {
+			return hz, nil
+		}
+	}
+	if multiplier == 0 {
+		switch {
+		case value < 1e3:
+			multiplier = 1e6
+		case value < 1e6:
+			multiplier = 1e3
+		default:
+			multiplier = 1
+		}
+	}
+
+	hz := math.Round(value * multiplier)
+	if hz > math.MaxInt64/2 {
+		return 0, errors.New(op).Msgf("%q is too large to be a frequency.", input)
+	}
+	if hz < 1 {
+		return 0, errors.New(op).Msgf("%q is below 1 Hz; check the unit.", input)
+	}
+	return int64(hz), nil
+}
+
+// bandRelativeFrequency completes a partial entry within band: ".074" is a fraction of the band's first MHz and a
+// short whole number is kHz above it, unless it names the start of a band in MHz. The result must lie in band.
+func bandRelativeFrequency(text string, value float64, band bands.Band) (int64, bool) {
+	r, ok := bandRange(band)
+	if !ok {
+		return 0, false
+	}
+	baseMHz := r.MinHz / 1_000_000 * 1_000_000
+
+	var hz int64
+	switch {
+	case strings.HasPrefix(text, "."):
+		hz = baseMHz + int64(math.Round(value*1e6))
+	case !strings.Contains(text, ".") && len(text) <= 3:
+		if isBandMHz(int64(value)) {
+			return 0, false // "7" or "14" means MHz
+		}
+		hz = baseMHz + int64(value)*1_000
+	default:
+		return 0, false
+	}
+	return hz, hz >= r.MinHz && hz <= r.MaxHz
+}
+
+// isBandMHz reports whether mhz is the whole-MHz part of the start of a band, e.g. 7 or 14.
+func isBandMHz(mhz int64) bool {
+	for _, r := range defaultBandPlan {
+		if r.MinHz/1_000_000 == mhz {
+			return true
+		}
+	}
+	return false
+}
+
+// bandRange returns the range of band in the built-in band plan.
+func bandRange(band bands.Band) (BandRange, bool) {
+	for _, r := range defaultBandPlan {
+		if r.Band == band {
+			return r, true
+		}
+	}
+	return BandRange{}, false
+}
@@ -0,0 +1,46 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrequency(t *testing.T) {
+	cases := []struct {
+		input string
+		band  bands.Band
+		want  int64
+	}{
+		{"14.074", "", 14074000},
+		{"14,074", "", 14074000},
+		{" 7 ", "", 7000000},
+		{"14074.5", "", 14074500},
+		{"14074000", "", 14074000},
+		{"14.074 MHz", "", 14074000},
+		{"14074k", "", 14074000},
+		{"14074 kHz", "", 14074000},
+		{"3573000hz", "", 3573000},
+		{".074", bands.Band20, 14074000},
+		{"74", bands.Band20, 14074000},
+		{"840", bands.Band160, 1840000},
+		{"7", bands.Band20, 7000000},
+		{"21", bands.Band20, 21000000},
+		{"14.2", bands.Band20, 14200000},
+	}
+	for _, tc := range cases {
+		got, err := ParseFrequency(tc.input, tc.band)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.want, got, tc.input)
+	}
+}
+
+func TestParseFrequencyErrors(t *testing.T) {
+	for _, input := range []string{"", "abc", "-14.074", "14.0.74", "1e6", "0", "0.1 Hz"} {
+		_, err := ParseFrequency(input, "")
+		require.Error(t, err, input)
+	}
+	_, err := ParseFrequency("14.07q", "")
+	require.ErrorContains(t, err, "use e.g. 14.074")
+}
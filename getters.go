@@ -0,0 +1,211 @@
+package cat
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// defaultCacheTTLMS is used when Options.CacheTTLMS is zero.
+	defaultCacheTTLMS = 500
+)
+
+// VFO identifies one of the rig's variable frequency oscillators.
+type VFO int
+
+const (
+	VFOA VFO = iota
+	VFOB
+)
+
+// String implements fmt.Stringer.
+func (v VFO) String() string {
+	switch v {
+	case VFOA:
+		return "VFO-A"
+	case VFOB:
+		return "VFO-B"
+	default:
+		return "VFO-?"
+	}
+}
+
+// frequencyTag returns the state tag carrying the frequency of the given VFO.
+func (v VFO) frequencyTag() (tags.CatStateTag, error) {
+	const op errors.Op = "cat.VFO.frequencyTag"
+	switch v {
+	case VFOA:
+		return tags.VfoAFreq, nil
+	case VFOB:
+		return tags.VfoBFreq, nil
+	default:
+		return "", errors.New(op).Msgf("unknown VFO: %d", v)
+	}
+}
+
+// GetFrequencyHz returns the frequency of the given VFO in Hz. A cached value is returned if it is fresh, otherwise
+// the configured read command is issued and the call waits for the rig to answer or for ctx to be done.
+func (s *Service) GetFrequencyHz(ctx context.Context, vfo VFO) (int64, error) {
+	const op errors.Op = "cat.Service.GetFrequencyHz"
+
+	tag, err := vfo.frequencyTag()
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+
+	value, err := s.readTag(ctx, tag)
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+
+	hz, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, errors.New(op).Msgf("invalid frequency value %q for %s", value, vfo)
+	}
+	return hz, nil
+}
+
+// GetMode returns the current (mapped) main mode, e.g. "USB". A cached value is returned if it is fresh, otherwise
+// the configured read command is issued and the call waits for the rig to answer or for ctx to be done.
+func (s *Service) GetMode(ctx context.Context) (string, error) {
+	const op errors.Op = "cat.Service.GetMode"
+
+	value, err := s.readTag(ctx, tags.MainMode)
+	if err != nil {
+		return "", errors.New(op).Err(err)
+	}
+	return value, nil
+}
+
+// GetTag returns the current value of an arbitrary tag, using the cache when the value is within the tag's
+// freshness window and querying the rig otherwise.
+func (s *Service) GetTag(ctx context.Context, tag tags.CatStateTag) (string, error) {
+	const op errors.Op = "cat.Service.GetTag"
+
+	value, err := s.readTag(ctx, tag)
+	if err != nil {
+		return "", errors.New(op).Err(err)
+	}
+	return value, nil
+}
+
+// CurrentState returns a copy of the latest value reported for every tag, merged across all frames received so far,
+// without consuming the status stream. It is empty before Initialize and before the rig has reported anything.
+func (s *Service) CurrentState() types.CatStatus {
+	if s.cache == nil {
+		return types.CatStatus{}
+	}
+	return s.cache.snapshot()
+}
+
+// StateDelta is the answer of StateSince.
+type StateDelta struct {
+	// Version is the state version the delta brings the client to; pass it to the next StateSince call.
+	Version uint64
+	// Changed holds the tags whose values changed since the requested version.
+	Changed types.CatStatus
+	// Full is set if Changed is the complete state and replaces the client's state, because tags the client may
+	// hold have since been evicted from the cache or the version is unknown.
+	Full bool
+}
+
+// StateSince returns the tags that changed since version, so that a client reconnecting after a blip can resync
+// without replaying the full state. Version 0 returns the full state. The version increases with every status
+// update that changes a value; a version issued by another Service, as before a restart, returns the full state.
+func (s *Service) StateSince(version uint64) StateDelta {
+	if s.cache == nil {
+		return StateDelta{Changed: types.CatStatus{}, Full: true}
+	}
+	changed, current, full := s.cache.since(version)
+	return StateDelta{Version: current, Changed: changed, Full: full || version == 0}
+}
+
+// LastUpdated returns, for every tag in CurrentState, when its value was last reported.
+func (s *Service) LastUpdated() map[string]time.Time {
+	if s.cache == nil {
+		return map[string]time.Time{}
+	}
+	return s.cache.updatedTimes()
+}
+
+// readTag returns the value of tag from the state cache if it is fresh; otherwise it enqueues the read command for
+// the tag and waits for a newer value to arrive.
+func (s *Service) readTag(ctx context.Context, tag tags.CatStateTag) (string, error) {
+	const op errors.Op = "cat.Service.readTag"
+	if !s.initialized.Load() {
+		return "", errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	if cached, ok := s.cache.get(tag.String()); ok && s.isFresh(tag, cached) {
+		return cached.Value, nil
+	}
+	return s.queryTag(ctx, tag)
+}
+
+// queryTag enqueues the read command for tag and waits for a value newer than the request, ignoring the cache.
+func (s *Service) queryTag(ctx context.Context, tag tags.CatStateTag) (string, error) {
+	return s.queryTagWith(ctx, tag)
+}
+
+// queryTagWith behaves like queryTag, applying opts to the read command.
+func (s *Service) queryTagWith(ctx context.Context, tag tags.CatStateTag, opts ...CommandOption) (string, error) {
+	const op errors.Op = "cat.Service.queryTag"
+
+	requested := time.Now()
+	// Grab the change channel before sending so an answer arriving immediately is not missed.
+	changed := s.cache.changed()
+
+	if err := s.EnqueueCommandWith(s.readCommandFor(tag), nil, opts...); err != nil {
+		return "", errors.New(op).Err(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", errors.New(op).Err(ctx.Err()).Msgf("timed out waiting for %s", tag)
+		case <-changed:
+			if cached, ok := s.cache.get(tag.String()); ok && !cached.Updated.Before(requested) {
+				return cached.Value, nil
+			}
+			changed = s.cache.changed()
+		}
+	}
+}
+
+// readCommandFor returns the command used to refresh tag, falling back to the general READ command.
+func (s *Service) readCommandFor(tag tags.CatStateTag) cmds.CatCmdName {
+	if name, ok := s.Options.ReadCommands[tag.String()]; ok {
+		return name
+	}
+	return cmds.Read
+}
+
+// isFresh reports whether a cached value for tag is still within its freshness window.
+func (s *Service) isFresh(tag tags.CatStateTag, cached cachedValue) bool {
+	ttl := s.tagTTL(tag)
+	if ttl < 0 {
+		return true
+	}
+	return time.Since(cached.Updated) <= ttl
+}
+
+// tagTTL returns how long a cached value for tag is considered fresh; negative means forever.
+func (s *Service) tagTTL(tag tags.CatStateTag) time.Duration {
+	if ttl, ok := s.Options.TagTTLMS[tag.String()]; ok {
+		if ttl < 0 {
+			return ttl
+		}
+		return ttl * time.Millisecond
+	}
+	if s.Options.CacheTTLMS > 0 {
+		return s.Options.CacheTTLMS * time.Millisecond
+	}
+	return defaultCacheTTLMS * time.Millisecond
+}
@@ -0,0 +1,125 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newStartedTestService builds a Service that is marked initialized and started, bypassing the ConfigService and
+// serial port, with the given rig configuration.
+func newStartedTestService(t *testing.T, cfg *types.RigConfig) *Service {
+	t.Helper()
+	cfg.CatConfig.Enabled = true
+	if cfg.CatConfig.SendChannelSize == 0 {
+		cfg.CatConfig.SendChannelSize = 8
+	}
+	service := &Service{
+		ConfigService: &config.Service{},
+		LoggerService: &logging.Service{},
+		config:        cfg,
+		cache:         newStateCache(),
+		diag:          newDiagnostics(DiagnosticsOptions{}),
+		sendChannel:   make(chan queuedCommand, cfg.CatConfig.SendChannelSize),
+		highChannel:   make(chan queuedCommand, cfg.CatConfig.SendChannelSize),
+		lowChannel:    make(chan queuedCommand, cfg.CatConfig.SendChannelSize),
+		bulkChannel:   make(chan bulkItem, bulkChannelSize),
+		eventChannel:  make(chan CatEvent, defaultEventChannelSize),
+
+		notificationChannel: make(chan Notification, defaultNotificationChannelSize),
+	}
+	require.NoError(t, service.initializeStateSet())
+	service.initialized.Store(true)
+	service.started.Store(true)
+	return service
+}
+
+// runFakeRig hands every command queued on the send channel to answer, in the order a rig would receive them, until
+// the test ends. The commands are recorded as written, as by the sender.
+func runFakeRig(t *testing.T, service *Service, answer func(cmd string)) {
+	t.Helper()
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case cmd := <-service.sendChannel:
+				cmd.outcome.writing(time.Now())
+				answer(cmd.Cmd)
+				cmd.outcome.written(false)
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-exited
+	})
+}
+
+func TestGetFrequencyHzUsesFreshCache(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000"}, time.Now())
+
+	hz, err := service.GetFrequencyHz(context.Background(), VFOA)
+	require.NoError(t, err)
+	require.Equal(t, int64(14074000), hz)
+	require.Len(t, service.sendChannel, 0)
+}
+
+func TestGetModeQueriesRigWhenStale(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: cmds.Read.String(), Cmd: "MD0;"}},
+	})
+	service.cache.update(types.CatStatus{"MAINMODE": "LSB"}, time.Now().Add(-time.Hour))
+
+	go func() {
+		cmd := <-service.sendChannel
+		require.Equal(t, "MD0;", cmd.Cmd)
+		service.cache.update(types.CatStatus{"MAINMODE": "USB"}, time.Now())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	mode, err := service.GetMode(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "USB", mode)
+}
+
+func TestTagTTLOverrides(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.TagTTLMS = map[string]time.Duration{"IDENTITY": -1, "VFOAFREQ": 10}
+
+	old := cachedValue{Value: "x", Updated: time.Now().Add(-time.Hour)}
+	require.True(t, service.isFresh("IDENTITY", old))
+	require.False(t, service.isFresh("VFOAFREQ", old))
+	require.Equal(t, 10*time.Millisecond, service.tagTTL("VFOAFREQ"))
+	require.Equal(t, defaultCacheTTLMS*time.Millisecond, service.tagTTL("MAINMODE"))
+}
+
+func TestCurrentStateAndLastUpdated(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	require.Empty(t, (&Service{}).CurrentState())
+	require.Empty(t, (&Service{}).LastUpdated())
+
+	first := time.Now().Add(-time.Second)
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000", "MAINMODE": "USB"}, first)
+	now := time.Now()
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014076000"}, now)
+
+	state := service.CurrentState()
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "014076000", "MAINMODE": "USB"}, state)
+	state["MAINMODE"] = "LSB"
+	require.Equal(t, "USB", service.CurrentState()["MAINMODE"], "CurrentState returns a copy")
+
+	updated := service.LastUpdated()
+	require.True(t, updated["VFOAFREQ"].Equal(now))
+	require.True(t, updated["MAINMODE"].Equal(first))
+}
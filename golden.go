@@ -0,0 +1,168 @@
+package cat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// GoldenCase is one frame of a golden file, with the status the service must emit when it reads the frame.
+//
+// Golden files capture the traffic of a particular rig so that changes to the parsing are checked against it. A
+// line starting with '>' holds a frame as read from the rig, with \xHH escapes for binary protocols; the lines
+// starting with '<' that follow it hold the expected status, one TAG=value per line, as translated for display.
+// A frame without '<' lines must not emit a status. Blank lines and lines starting with '#' are ignored:
+//
+//	# VFO A on 20m
+//	> FA00014074000;
+//	< VFOAFREQ=00014074000
+//	> MD2;
+//	< MAINMODE=USB
+type GoldenCase struct {
+	// Line is the line of the frame in the golden file.
+	Line   int
+	Frame  []byte
+	Status types.CatStatus
+}
+
+// GoldenMismatch is a case of a golden file for which the service emitted another status than expected. Want and
+// Got are nil when no status was expected or emitted.
+type GoldenMismatch struct {
+	Line  int
+	Frame []byte
+	Want  types.CatStatus
+	Got   types.CatStatus
+}
+
+// String describes the mismatch for a test failure.
+func (m GoldenMismatch) String() string {
+	return fmt.Sprintf("line %d: frame %q: want %s, got %s", m.Line, m.Frame, formatGoldenStatus(m.Want), formatGoldenStatus(m.Got))
+}
+
+// ReadGoldenFile reads the golden file at path.
+func ReadGoldenFile(path string) ([]GoldenCase, error) {
+	const op errors.Op = "cat.ReadGoldenFile"
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(op).Err(err).Msgf("Cannot read golden file %s.", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	cases, err := ParseGolden(f)
+	if err != nil {
+		return nil, errors.New(op).Err(err).Msgf("Golden file %s is invalid.", path)
+	}
+	return cases, nil
+}
+
+// ParseGolden parses golden file content; see GoldenCase for the format.
+func ParseGolden(r io.Reader) ([]GoldenCase, error) {
+	const op errors.Op = "cat.ParseGolden"
+	var cases []GoldenCase
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		switch text[0] {
+		case '>':
+			frame, err := unescapeHex(strings.TrimSpace(text[1:]), false)
+			if err != nil {
+				return nil, errors.New(op).Err(err).Msgf("line %d", line)
+			}
+			cases = append(cases, GoldenCase{Line: line, Frame: []byte(frame)})
+		case '<':
+			if len(cases) == 0 {
+				return nil, errors.New(op).Msgf("line %d: status before the first frame", line)
+			}
+			tag, value, ok := strings.Cut(strings.TrimSpace(text[1:]), "=")
+			if !ok || strings.TrimSpace(tag) == "" {
+				return nil, errors.New(op).Msgf("line %d: status %q is not TAG=value", line, text)
+			}
+			last := &cases[len(cases)-1]
+			if last.Status == nil {
+				last.Status = make(types.CatStatus)
+			}
+			last.Status[strings.TrimSpace(tag)] = strings.TrimSpace(value)
+		default:
+			return nil, errors.New(op).Msgf("line %d: expected a frame (>) or a status (<)", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return cases, nil
+}
+
+// CheckGolden feeds the frames of cases, in order, through the decoding and processing of a service for the rig
+// definition cfg with opts, and returns the cases whose emitted status differs from the expected one. It is meant
+// for tests, so that users can check their own rig definitions against golden files:
+//
+//	cases, err := cat.ReadGoldenFile("testdata/my-rig.golden")
+//	require.NoError(t, err)
+//	mismatches, err := cat.CheckGolden(myRig, cat.Options{}, cases)
+//	require.NoError(t, err)
+//	for _, m := range mismatches {
+//		t.Error(m)
+//	}
+//
+// No serial port is opened. The error is set if the service cannot be initialized.
+func CheckGolden(cfg types.RigConfig, opts Options, cases []GoldenCase) ([]GoldenMismatch, error) {
+	const op errors.Op = "cat.CheckGolden"
+	cfg.CatConfig.SendChannelSize = max(cfg.CatConfig.SendChannelSize, 1)
+	cfg.CatConfig.ProcessingChannelSize = max(cfg.CatConfig.ProcessingChannelSize, 1)
+	s := &Service{Definitions: StaticDefinitions{cfg}, Options: opts}
+	if err := s.Initialize(); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	shutdown := make(chan struct{})
+	defer close(shutdown)
+	var mismatches []GoldenMismatch
+	for _, c := range cases {
+		got := s.goldenStatus(shutdown, c.Frame)
+		if !maps.Equal(got, c.Status) {
+			mismatches = append(mismatches, GoldenMismatch{Line: c.Line, Frame: c.Frame, Want: c.Status, Got: got})
+		}
+	}
+	return mismatches, nil
+}
+
+// goldenStatus hands frame to the listener's frame handling and the processor in turn and returns the status
+// emitted for it, or nil.
+func (s *Service) goldenStatus(shutdown <-chan struct{}, frame []byte) types.CatStatus {
+	s.handleFrame(shutdown, frame, time.Now())
+	select {
+	case state := <-s.processingChannel:
+		s.processState(state, shutdown)
+	default:
+		return nil
+	}
+	select {
+	case status := <-s.statusChannel:
+		return status
+	default:
+		return nil
+	}
+}
+
+// formatGoldenStatus formats status with its tags in order, or "no status" for nil.
+func formatGoldenStatus(status types.CatStatus) string {
+	if status == nil {
+		return "no status"
+	}
+	pairs := make([]string, 0, len(status))
+	for _, tag := range slices.Sorted(maps.Keys(status)) {
+		pairs = append(pairs, tag+"="+status[tag])
+	}
+	return "{" + strings.Join(pairs, " ") + "}"
+}
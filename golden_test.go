@@ -0,0 +1,44 @@
+package cat
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuiltinDriversGolden checks every built-in driver against its golden file in testdata/golden.
+func TestBuiltinDriversGolden(t *testing.T) {
+	for _, name := range BuiltinDriverNames() {
+		t.Run(name, func(t *testing.T) {
+			cases, err := ReadGoldenFile(filepath.Join("testdata", "golden", name+".golden"))
+			require.NoError(t, err, "every built-in driver needs a golden file")
+			require.NotEmpty(t, cases)
+			mismatches, err := CheckGolden(types.RigConfig{}, Options{Driver: name}, cases)
+			require.NoError(t, err)
+			for _, m := range mismatches {
+				t.Error(m)
+			}
+		})
+	}
+}
+
+func TestCheckGoldenReportsMismatches(t *testing.T) {
+	cases, err := ParseGolden(strings.NewReader("> FA00014074000;\n< VFOAFREQ=00014075000\n> MD2;\n"))
+	require.NoError(t, err)
+	mismatches, err := CheckGolden(types.RigConfig{}, Options{Driver: DriverKenwoodTS590}, cases)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	require.Equal(t, `line 1: frame "FA00014074000;": want {VFOAFREQ=00014075000}, got {VFOAFREQ=00014074000}`, mismatches[0].String())
+	require.Equal(t, types.CatStatus{"MAINMODE": "USB"}, mismatches[1].Got)
+	require.Nil(t, mismatches[1].Want)
+}
+
+func TestParseGoldenRejectsMalformedLines(t *testing.T) {
+	for _, content := range []string{"< VFOAFREQ=1\n", "> FA;\n< VFOAFREQ\n", "FA;\n", `> \xZZ` + "\n"} {
+		_, err := ParseGolden(strings.NewReader(content))
+		require.Error(t, err, content)
+	}
+}
@@ -0,0 +1,80 @@
+package cat
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+// CommandGuard declares which rigs support a command. Guards are checked once the rig's identity is known, i.e.
+// once it has reported the IDENTITY tag (and Options.FirmwareTag for firmware guards); until then every command is
+// sent.
+type CommandGuard struct {
+	// Models lists the identity values, as reported by the IDENTITY tag, that support the command. Empty means
+	// every model does.
+	Models []string
+	// MinFirmware is the oldest firmware version supporting the command, e.g. "1.10". Empty means any version.
+	MinFirmware string
+}
+
+// guardFilter rejects commands that the detected rig model or firmware does not support.
+func (s *Service) guardFilter(req *commandRequest) error {
+	const op errors.Op = "cat.Service.guardFilter"
+	guard, ok := s.Options.CommandGuards[req.name]
+	if !ok || s.cache == nil {
+		return nil
+	}
+
+	if len(guard.Models) > 0 {
+		if model, ok := s.cache.get(tags.Identity.String()); ok {
+			if !slices.ContainsFunc(guard.Models, func(m string) bool { return strings.EqualFold(m, model.Value) }) {
+				return errors.New(op).Msgf("%s is not supported by rig model %s (supported: %s)",
+					req.name, model.Value, strings.Join(guard.Models, ", "))
+			}
+		}
+	}
+
+	if guard.MinFirmware != "" && s.Options.FirmwareTag != "" {
+		if firmware, ok := s.cache.get(s.Options.FirmwareTag); ok && compareVersions(firmware.Value, guard.MinFirmware) < 0 {
+			return errors.New(op).Msgf("%s requires firmware %s or later; the rig reports %s",
+				req.name, guard.MinFirmware, firmware.Value)
+		}
+	}
+	return nil
+}
+
+// compareVersions compares dotted version strings such as "1.02" and "1.10", numerically where both parts are
+// numbers; a missing part counts as zero, so "1.1" equals "1.1.0". It returns -1, 0 or +1.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimSpace(a), ".")
+	bs := strings.Split(strings.TrimSpace(b), ".")
+	for i := range max(len(as), len(bs)) {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		if xerr == nil && yerr == nil {
+			if xn != yn {
+				return cmpSign(xn - yn)
+			}
+			continue
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// cmpSign returns the sign of n.
+func cmpSign(n int) int {
+	return min(max(n, -1), 1)
+}
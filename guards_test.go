@@ -0,0 +1,48 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardRejectsUnsupportedModel(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.CommandGuards = map[cmds.CatCmdName]CommandGuard{CmdSetVfoBFreq: {Models: []string{"FT-991A"}}}
+
+	// Identity unknown: the command is sent.
+	require.NoError(t, service.SetFrequencyHz(VFOB, 7074000))
+	require.Len(t, drainCommands(service), 1)
+
+	service.cache.update(types.CatStatus{"IDENTITY": "FT-817"}, time.Now())
+	err := service.SetFrequencyHz(VFOB, 7074000)
+	require.Error(t, err)
+	require.ErrorContains(t, errors.Root(err), "FT-817")
+	require.Empty(t, drainCommands(service))
+
+	service.cache.update(types.CatStatus{"IDENTITY": "ft-991a"}, time.Now())
+	require.NoError(t, service.SetFrequencyHz(VFOB, 7074000))
+}
+
+func TestGuardRejectsOldFirmware(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.FirmwareTag = "FIRMWARE"
+	service.Options.CommandGuards = map[cmds.CatCmdName]CommandGuard{CmdSetMainMode: {MinFirmware: "1.10"}}
+
+	service.cache.update(types.CatStatus{"FIRMWARE": "1.02"}, time.Now())
+	require.Error(t, service.SetMode("USB"))
+
+	service.cache.update(types.CatStatus{"FIRMWARE": "1.10.1"}, time.Now())
+	require.NoError(t, service.SetMode("USB"))
+}
+
+func TestCompareVersions(t *testing.T) {
+	require.Equal(t, -1, compareVersions("1.02", "1.10"))
+	require.Equal(t, 0, compareVersions("1.1", "1.1.0"))
+	require.Equal(t, 1, compareVersions("2.0", "1.99"))
+	require.Equal(t, 1, compareVersions("1.0b", "1.0a"))
+}
@@ -0,0 +1,153 @@
+package cat
+
+import (
+	"sync"
+	"time"
+)
+
+// EventHealthChanged is the kind of HealthChangedEvent.
+const EventHealthChanged EventKind = "HEALTH_CHANGED"
+
+const (
+	// defaultHealthDegradedMS is used when Options.Health.DegradedAfterMS is zero.
+	defaultHealthDegradedMS = 3000
+	// defaultHealthDisconnectedMS is used when Options.Health.DisconnectedAfterMS is zero.
+	defaultHealthDisconnectedMS = 10000
+	// defaultHealthIntervalMS is used when Options.Health.IntervalMS is zero.
+	defaultHealthIntervalMS = 1000
+)
+
+// HealthState is the health of the link to the rig, judged by how long commands have gone unanswered.
+type HealthState string
+
+const (
+	// HealthHealthy means the rig answers, or nothing was asked of it.
+	HealthHealthy HealthState = "HEALTHY"
+	// HealthDegraded means the rig has not answered for Options.Health.DegradedAfterMS.
+	HealthDegraded HealthState = "DEGRADED"
+	// HealthDisconnected means the rig has not answered for Options.Health.DisconnectedAfterMS, or the link is
+	// down.
+	HealthDisconnected HealthState = "DISCONNECTED"
+)
+
+// String implements fmt.Stringer.
+func (h HealthState) String() string {
+	return string(h)
+}
+
+// Health is a snapshot of the health monitor.
+type Health struct {
+	State HealthState
+	// Since is when State was entered.
+	Since time.Time
+	// LastResponse is when the rig last sent a recognised frame; zero if it has not since Start.
+	LastResponse time.Time
+}
+
+// HealthChangedEvent is emitted when the health monitor changes state.
+type HealthChangedEvent struct {
+	At           time.Time
+	Previous     HealthState
+	State        HealthState
+	LastResponse time.Time
+}
+
+func (e HealthChangedEvent) Kind() EventKind { return EventHealthChanged }
+func (e HealthChangedEvent) Time() time.Time { return e.At }
+
+// healthTracker holds the state of the health monitor.
+type healthTracker struct {
+	mu      sync.Mutex
+	current Health
+}
+
+// Health returns the health of the link to the rig. Without Options.Health.Enabled it is always HealthHealthy.
+func (s *Service) Health() Health {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	h := s.health.current
+	if h.State == "" {
+		h.State = HealthHealthy
+	}
+	if last := s.lastValidFrame.Load(); last != 0 {
+		h.LastResponse = time.Unix(0, last)
+	}
+	return h
+}
+
+// noteValidFrame records that the rig sent a frame matching a configured state.
+func (s *Service) noteValidFrame(at time.Time) {
+	s.lastValidFrame.Store(at.UnixNano())
+	s.noteCommandAnswered()
+}
+
+// healthMonitor checks every Options.Health.IntervalMS how long the oldest command awaiting a response has gone
+// unanswered and moves the health through HealthDegraded to HealthDisconnected, requesting a reopen of the port on
+// disconnect if Options.Health.Reconnect is set. Set commands are not awaited, see expectsResponse. Any recognised
+// frame makes the link healthy again.
+func (s *Service) healthMonitor(shutdown <-chan struct{}) {
+	opts := s.Options.Health
+	degradedAfter := durationOrDefault(opts.DegradedAfterMS, defaultHealthDegradedMS)
+	disconnectedAfter := durationOrDefault(opts.DisconnectedAfterMS, defaultHealthDisconnectedMS)
+
+	// The silence window starts no earlier than the monitor itself.
+	started := time.Now()
+	s.setHealth(HealthHealthy, started)
+
+	ticker := time.NewTicker(durationOrDefault(opts.IntervalMS, defaultHealthIntervalMS))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case now := <-ticker.C:
+			var silence time.Duration
+			since, unanswered := s.unansweredSince()
+			if unanswered {
+				if since.Before(started) {
+					since = started
+				}
+				silence = now.Sub(since)
+			}
+
+			state := HealthHealthy
+			switch {
+			case s.linkDown.Load(), unanswered && silence >= disconnectedAfter:
+				state = HealthDisconnected
+			case unanswered && silence >= degradedAfter:
+				state = HealthDegraded
+			}
+			if !s.setHealth(state, now) || state != HealthDisconnected {
+				continue
+			}
+			s.logger().WarnWith().Dur("silence", silence).Msg("rig not answering; link considered disconnected")
+			if opts.Reconnect && !s.linkDown.Load() {
+				s.reopenPort.Store(true)
+			}
+		}
+	}
+}
+
+// setHealth moves the health monitor to state and emits a HealthChangedEvent. It reports whether the state
+// changed.
+func (s *Service) setHealth(state HealthState, now time.Time) bool {
+	s.health.mu.Lock()
+	previous := s.health.current.State
+	if previous == state {
+		s.health.mu.Unlock()
+		return false
+	}
+	s.health.current = Health{State: state, Since: now}
+	s.health.mu.Unlock()
+
+	if previous == "" {
+		return true
+	}
+	var last time.Time
+	if v := s.lastValidFrame.Load(); v != 0 {
+		last = time.Unix(0, v)
+	}
+	s.logger().InfoWith().Str("from", previous.String()).Str("to", state.String()).Msg("CAT link health changed")
+	s.emitEvent(HealthChangedEvent{At: now, Previous: previous, State: state, LastResponse: last})
+	return true
+}
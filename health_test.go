@@ -0,0 +1,84 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func nextHealthEvent(t *testing.T, service *Service) HealthChangedEvent {
+	t.Helper()
+	select {
+	case e := <-service.eventChannel:
+		return e.(HealthChangedEvent)
+	case <-time.After(time.Second):
+		t.Fatal("no health event")
+		return HealthChangedEvent{}
+	}
+}
+
+func newHealthTestService(t *testing.T) *Service {
+	t.Helper()
+	return newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READVFOA", Cmd: "FA;"}, {Name: "SETVFOAFREQ", Cmd: "FA%s;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+}
+
+func writtenCommand(name string) queuedCommand {
+	return queuedCommand{CatCommand: types.CatCommand{Name: name}}
+}
+
+func TestHealthMonitorDegradesAndRecovers(t *testing.T) {
+	service := newHealthTestService(t)
+	service.Options.Health = HealthOptions{Enabled: true, DegradedAfterMS: 20, DisconnectedAfterMS: 60, IntervalMS: 5, Reconnect: true}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"healthMonitor": service.healthMonitor})
+	require.Equal(t, HealthHealthy, service.Health().State)
+
+	service.noteCommandWritten(writtenCommand("READVFOA"))
+	e := nextHealthEvent(t, service)
+	require.Equal(t, HealthHealthy, e.Previous)
+	require.Equal(t, HealthDegraded, e.State)
+
+	e = nextHealthEvent(t, service)
+	require.Equal(t, HealthDisconnected, e.State)
+	require.True(t, service.reopenPort.Load(), "the port is reopened on disconnect")
+	require.Equal(t, HealthDisconnected, service.Health().State)
+
+	service.noteValidFrame(time.Now())
+	e = nextHealthEvent(t, service)
+	require.Equal(t, HealthDisconnected, e.Previous)
+	require.Equal(t, HealthHealthy, e.State)
+	require.False(t, service.Health().LastResponse.IsZero())
+}
+
+func TestHealthMonitorIgnoresIdleLink(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Health = HealthOptions{Enabled: true, DegradedAfterMS: 5, IntervalMS: 2}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"healthMonitor": service.healthMonitor})
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, HealthHealthy, service.Health().State, "silence without commands is not a fault")
+	require.Empty(t, service.eventChannel)
+}
+
+func TestHealthMonitorIgnoresUnacknowledgedSets(t *testing.T) {
+	service := newHealthTestService(t)
+	service.Options.Health = HealthOptions{Enabled: true, DegradedAfterMS: 20, IntervalMS: 2}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"healthMonitor": service.healthMonitor})
+
+	for range 10 {
+		service.noteCommandWritten(writtenCommand("SETVFOAFREQ"))
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.Equal(t, HealthHealthy, service.Health().State, "sets the rig does not answer are no fault")
+	require.Empty(t, service.eventChannel)
+
+	service.noteCommandWritten(writtenCommand("READVFOA"))
+	time.Sleep(5 * time.Millisecond)
+	service.noteCommandWritten(writtenCommand("READVFOA"))
+	e := nextHealthEvent(t, service)
+	require.Equal(t, HealthDegraded, e.State, "the silence is measured from the oldest unanswered read")
+}
@@ -8,79 +8,198 @@ import (
 	"strings"
 )
 
-// getRigConfig retrieves the default rig configuration based on the default rig ID from the required configurations.
-// It returns an error if no valid default rig ID is set or if fetching the rig configuration fails.
+// getRigConfig retrieves the definition of the rig selected by RigID or, when RigID is zero, of the default rig from
+// the definition source.
 func (s *Service) getRigConfig() (*types.RigConfig, error) {
 	const op errors.Op = "cat.Service.getRigConfig"
-	rigConfigs, err := s.ConfigService.RequiredConfigs()
+
+	cfg, err := s.definitionSource().RigDefinition(s.RigID)
 	if err != nil {
 		return nil, errors.New(op).Err(err)
 	}
+	return &cfg, nil
+}
 
-	defaultRigID := rigConfigs.DefaultRigID
-	if defaultRigID < 1 {
-		return nil, errors.New(op).Msg(errMsgInvalidRigID)
+// initializeTransport opens the serial port using the provided configuration in the Service instance and, when
+// enabled in the debug options, wraps it with the fault-injection transport.
+// It returns an error if the serial port cannot be opened.
+func (s *Service) initializeTransport() error {
+	const op errors.Op = "cat.Service.initializeTransport"
+
+	faults := s.Options.Debug.Faults
+	s.openAttempts++
+	if faults.Enabled && s.openAttempts <= faults.FailOpens {
+		return errors.New(op).Msgf("fault injection: open attempt %d of %d forced to fail", s.openAttempts, faults.FailOpens)
 	}
 
-	cfg, err := s.ConfigService.RigConfigByID(defaultRigID)
+	t, err := s.openPort()
 	if err != nil {
-		return nil, errors.New(op).Err(err)
+		return errors.New(op).Err(err)
 	}
 
-	return &cfg, nil
+	if s.Options.Framing.Enabled {
+		if t, err = s.assembleFrames(t); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	if faults.Enabled {
+		s.logger().WarnWith().Msg("CAT fault injection is enabled; do not use in production")
+		t = newFaultTransport(t, faults)
+	}
+	s.setLink(t)
+	s.metricsSink().Gauge("link_up", 1)
+
+	return nil
 }
 
-// initializeSerialPort initializes the serial port using the provided configuration in the Service instance.
-// It returns an error if the serial port cannot be opened.
-func (s *Service) initializeSerialPort() error {
-	const op errors.Op = "cat.Service.initializeSerialPort"
+// openPort connects to rigctld if configured, and otherwise opens the configured serial port, resolving aliases
+// first, failing early with the name of any other process holding the device, and claiming the device through the
+// PortManager when one is set. Options.Transport, Service.Dial and the test dialer replace both when set.
+func (s *Service) openPort() (Transport, error) {
+	const op errors.Op = "cat.Service.openPort"
+	if s.dialer != nil {
+		return s.dialer()
+	}
+	if s.Dial != nil {
+		return s.Dial()
+	}
+	switch strings.ToLower(strings.TrimSpace(s.Options.Transport)) {
+	case "":
+	case TransportSimulator:
+		return s.openSimulator()
+	default:
+		return nil, errors.New(op).Msgf("unknown transport %q; the only alternative transport is %q", s.Options.Transport, TransportSimulator)
+	}
+	if addr, ok := s.rigctldAddress(); ok {
+		return dialRigctld(addr, durationOrDefault(s.Options.Rigctld.DialTimeoutMS, defaultRigctldDialTimeoutMS),
+			durationOrDefault(s.Options.Rigctld.ReplyTimeoutMS, defaultRigctldReplyTimeoutMS))
+	}
 
-	var err error
-	s.serialPort, err = serial.Open(s.config.SerialConfig)
+	cfg := s.rigConfig().SerialConfig
+	resolved, err := resolvePortName(cfg.PortName)
 	if err != nil {
-		return errors.New(op).Err(err)
+		return nil, err
+	}
+	if resolved != cfg.PortName {
+		s.logger().InfoWith().Str("port", cfg.PortName).Str("device", resolved).Msg("resolved serial port alias")
+		cfg.PortName = resolved
 	}
 
-	return nil
+	if d := s.codec().lineDelimiter(); d != 0 {
+		cfg.LineDelimiter = d
+	}
+
+	if err = s.checkPortConflict(cfg.PortName); err != nil {
+		return nil, err
+	}
+
+	if s.PortManager == nil {
+		port, err := serial.Open(cfg)
+		if err != nil {
+			return nil, s.explainOpenError(cfg.PortName, err)
+		}
+		return port, nil
+	}
+
+	owner := s.portOwner()
+	if err = s.PortManager.Acquire(cfg.PortName, owner); err != nil {
+		return nil, err
+	}
+	port, err := serial.Open(cfg)
+	if err != nil {
+		_ = s.PortManager.Release(cfg.PortName, owner)
+		return nil, s.explainOpenError(cfg.PortName, err)
+	}
+	return &managedTransport{
+		Transport: port,
+		release:   func() error { return s.PortManager.Release(cfg.PortName, owner) },
+	}, nil
 }
 
 // initializeStateSet initializes the supportedCatStates map based on the configured CatState values in the service.
 func (s *Service) initializeStateSet() error {
+	states, maxLen, err := buildStateSet(s.config, s.prefixKey)
+	if err != nil {
+		return err
+	}
+	s.supportedCatStates = states
+	s.maxCatPrefixLen = maxLen
+	return nil
+}
+
+// buildStateSet returns the states of cfg keyed by their prefix as returned by keyOf, and the length of the longest
+// prefix.
+func buildStateSet(cfg *types.RigConfig, keyOf func(string) string) (map[string]types.CatState, int, error) {
 	const op errors.Op = "cat.Service.initializeStateSet"
-	s.supportedCatStates = make(map[string]types.CatState, len(s.config.CatStates))
+	states := make(map[string]types.CatState, len(cfg.CatStates))
 
 	maxLen := 0
-	for _, state := range s.config.CatStates {
-		key := strings.ToUpper(strings.TrimSpace(state.Prefix))
+	for _, state := range cfg.CatStates {
+		key := keyOf(state.Prefix)
 		if key == "" {
 			// Treat empty prefixes as configuration errors instead of silently logging.
-			return errors.New(op).Msg("CAT state entry has an empty prefix")
+			return nil, 0, errors.New(op).Msg("CAT state entry has an empty prefix")
 		}
-		s.supportedCatStates[key] = state
+		states[key] = state
 		if l := len(key); l > maxLen {
 			maxLen = l
 		}
 	}
-
-	s.maxCatPrefixLen = maxLen
-	return nil
+	return states, maxLen, nil
 }
 
 // launchWorkerThread starts a new goroutine for the given worker function and manages its lifecycle using a wait group.
 func (s *Service) launchWorkerThread(run *runState, workerFunc func(<-chan struct{}), workerName string) {
 	run.wg.Add(1)
+	run.workerStarted(workerName)
 	go func() {
 		defer run.wg.Done()
-		s.LoggerService.InfoWith().Str("worker", workerName).Msg("CAT starting")
+		defer run.workerExited(workerName)
+		s.logger().InfoWith().Str("worker", workerName).Msg("CAT starting")
+		if s.diag != nil {
+			s.diag.workerStarted(workerName)
+			defer s.diag.workerStopped(workerName)
+		}
 		workerFunc(run.shutdownChannel)
-		s.LoggerService.InfoWith().Str("worker", workerName).Msg("CAT stopped")
+		s.logger().InfoWith().Str("worker", workerName).Msg("CAT stopped")
+	}()
+}
+
+// setRun makes run the current run. The caller holds mu.
+func (s *Service) setRun(run *runState) {
+	s.currentRun = run
+	s.liveRun.Store(run)
+}
+
+// runShutdown returns the shutdown channel of the current run, or nil if there is none, for code that runs on a
+// worker of the run without being handed the channel.
+func (s *Service) runShutdown() <-chan struct{} {
+	if run := s.liveRun.Load(); run != nil {
+		return run.shutdownChannel
+	}
+	return nil
+}
+
+// launchTask runs fn in the background as part of the run whose shutdown channel is shutdown, so that Stop waits
+// for it as it does for the workers; fn must return soon after shutdown is closed. It must be called from a worker
+// of that run, and reports false, without running fn, if the run has ended.
+func (s *Service) launchTask(shutdown <-chan struct{}, fn func()) bool {
+	run := s.liveRun.Load()
+	if run == nil || run.shutdownChannel != shutdown {
+		return false
+	}
+	run.wg.Add(1)
+	go func() {
+		defer run.wg.Done()
+		fn()
 	}()
+	return true
 }
 
 // commandLookup retrieves a CatCommand by its name from the service configuration. Returns an error if the command is not found.
 func (s *Service) commandLookup(name cmds.CatCmdName) (types.CatCommand, error) {
 	const op errors.Op = "cat.Service.commandLookup"
-	for _, c := range s.config.CatCommands {
+	for _, c := range s.rigConfig().CatCommands {
 		if c.Name == name.String() {
 			return c, nil
 		}
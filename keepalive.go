@@ -0,0 +1,54 @@
+package cat
+
+import (
+	"time"
+)
+
+const (
+	// defaultKeepaliveIdleMS is used when Options.Keepalive.IdleMS is zero.
+	defaultKeepaliveIdleMS = 30000
+)
+
+// markActivity records that something was written to or read from the link.
+func (s *Service) markActivity() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long the link has been quiet.
+func (s *Service) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, s.lastActivity.Load()))
+}
+
+// keepalive writes the configured harmless command whenever the link has been quiet for Options.Keepalive.IdleMS,
+// so that USB serial adapters and rigs that drop an idle virtual port stay awake.
+func (s *Service) keepalive(shutdown <-chan struct{}) {
+	idle := s.Options.Keepalive.IdleMS
+	if idle <= 0 {
+		idle = defaultKeepaliveIdleMS
+	}
+	idle *= time.Millisecond
+
+	s.markActivity()
+	ticker := time.NewTicker(max(idle/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case now := <-ticker.C:
+			if s.idleFor(now) < idle {
+				continue
+			}
+			if err := s.EnqueueCommandWith(s.Options.Keepalive.Command, nil, WithOrigin(OriginInternal)); err != nil {
+				s.logger().WarnWith().Err(err).Msg("keepalive command not queued")
+			}
+			// Count the attempt as activity so a full queue does not cause a write every tick.
+			s.markActivity()
+		}
+	}
+}
+
+// keepaliveEnabled reports whether the keepalive worker should run.
+func (s *Service) keepaliveEnabled() bool {
+	return s.Options.Keepalive.Command != ""
+}
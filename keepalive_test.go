@@ -0,0 +1,23 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepaliveWritesWhenIdle(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "IDENTIFY", Cmd: "ID;"}},
+	})
+	service.Options.Keepalive = KeepaliveOptions{Command: "IDENTIFY", IdleMS: 40}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortSender": service.serialPortSender,
+		"keepalive":        service.keepalive,
+	})
+
+	require.Eventually(t, func() bool { return len(fake.writes()) >= 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "ID;", fake.writes()[0])
+}
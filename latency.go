@@ -0,0 +1,128 @@
+package cat
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// defaultLatencySamples is used when Options.Latency.Samples is zero.
+	defaultLatencySamples = 10
+)
+
+// receivedState is a matched frame on its way to the processor, with the time it was read off the port. For a
+// status inferred from a written command, synthetic is set instead; see Options.SyntheticStates.
+type receivedState struct {
+	types.CatState
+	received  time.Time
+	synthetic types.CatStatus
+}
+
+// LatencyStage names a measured stage of the pipeline.
+type LatencyStage string
+
+const (
+	// LatencyFrameToStatus is the time from reading a frame off the port to emitting its status. It is spent
+	// entirely in this service, so a slow stage means pipeline congestion, e.g. slow status consumers.
+	LatencyFrameToStatus LatencyStage = "frame_to_status"
+	// LatencyQueueToWrite is the time a command waits between being queued and being written. A slow stage means
+	// the link or the rig cannot keep up with the commands being sent.
+	LatencyQueueToWrite LatencyStage = "queue_to_write"
+)
+
+// String implements fmt.Stringer.
+func (l LatencyStage) String() string {
+	return string(l)
+}
+
+// latencyMonitor checks the samples of one stage against its budget. The stage is degraded after Samples
+// consecutive samples over budget, and recovers after Samples consecutive samples within it.
+type latencyMonitor struct {
+	stage   LatencyStage
+	budget  time.Duration
+	samples int
+
+	mu       sync.Mutex
+	run      int // consecutive samples on the other side of the budget from the current state
+	degraded bool
+	exceeded uint64
+}
+
+// newLatencyMonitors returns a monitor for every stage with a budget.
+func newLatencyMonitors(opts LatencyOptions) map[LatencyStage]*latencyMonitor {
+	samples := opts.Samples
+	if samples <= 0 {
+		samples = defaultLatencySamples
+	}
+	monitors := make(map[LatencyStage]*latencyMonitor)
+	for stage, budget := range map[LatencyStage]time.Duration{
+		LatencyFrameToStatus: opts.FrameToStatusMS,
+		LatencyQueueToWrite:  opts.QueueToWriteMS,
+	} {
+		if budget > 0 {
+			monitors[stage] = &latencyMonitor{stage: stage, budget: budget * time.Millisecond, samples: samples}
+		}
+	}
+	return monitors
+}
+
+// observe records one sample and reports whether the stage just became degraded or recovered.
+func (m *latencyMonitor) observe(d time.Duration) (degraded, recovered bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	over := d > m.budget
+	if over {
+		m.exceeded++
+	}
+	if over == m.degraded {
+		m.run = 0
+		return false, false
+	}
+	if m.run++; m.run < m.samples {
+		return false, false
+	}
+	m.run = 0
+	m.degraded = over
+	return over, !over
+}
+
+// usage returns the number of samples over budget so far.
+func (m *latencyMonitor) usage() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.exceeded
+}
+
+// observeLatency records a sample for stage and notifies the operator when the stage persistently exceeds its
+// budget or is back within it. Every sample goes to the MetricsSink; stages without a budget are not monitored.
+func (s *Service) observeLatency(stage LatencyStage, d time.Duration) {
+	s.metricsSink().Observe(stage.String()+"_seconds", d.Seconds())
+	m := s.latency[stage]
+	if m == nil {
+		return
+	}
+	degraded, recovered := m.observe(d)
+	switch {
+	case degraded:
+		s.logger().WarnWith().Str("stage", stage.String()).Dur("latency", d).Msg("latency budget exceeded")
+		title, message, action := latencyDegradedText(stage, d, m.budget)
+		s.notify(SeverityWarning, title, message, action)
+	case recovered:
+		s.notify(SeverityInfo, "CAT latency normal", fmt.Sprintf("The %s latency is back within its %s budget.", stage, m.budget), "")
+	}
+}
+
+// latencyDegradedText describes a slow stage, pointing at its likely cause.
+func latencyDegradedText(stage LatencyStage, d, budget time.Duration) (title, message, action string) {
+	if stage == LatencyFrameToStatus {
+		return "CAT pipeline congested",
+			fmt.Sprintf("Rig frames take %s from receipt to status, over the %s budget. The rig is answering in time; the delay is in processing.", d.Round(time.Millisecond), budget),
+			"Check for slow status consumers or reduce polling."
+	}
+	return "CAT commands delayed",
+		fmt.Sprintf("Commands wait %s before being written, over the %s budget. The rig or the link is not keeping up.", d.Round(time.Millisecond), budget),
+		"Reduce polling or raise the rate limits, and check the rig's CAT baud rate."
+}
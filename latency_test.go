@@ -0,0 +1,53 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyMonitorNeedsPersistentSamples(t *testing.T) {
+	m := newLatencyMonitors(LatencyOptions{QueueToWriteMS: 10, Samples: 3})[LatencyQueueToWrite]
+	require.NotNil(t, m)
+
+	slow, fast := 20*time.Millisecond, time.Millisecond
+	for _, d := range []time.Duration{slow, slow, fast, slow, slow} {
+		degraded, _ := m.observe(d)
+		require.False(t, degraded, "an isolated fast sample restarts the count")
+	}
+	degraded, _ := m.observe(slow)
+	require.True(t, degraded)
+	require.Equal(t, uint64(5), m.usage())
+
+	m.observe(fast)
+	m.observe(fast)
+	_, recovered := m.observe(fast)
+	require.True(t, recovered)
+}
+
+func TestLatencyBudgetNotifiesSlowStage(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.latency = newLatencyMonitors(LatencyOptions{FrameToStatusMS: 5, Samples: 2})
+
+	service.observeLatency(LatencyQueueToWrite, time.Second) // no budget
+	service.observeLatency(LatencyFrameToStatus, 50*time.Millisecond)
+	require.Empty(t, service.notificationChannel)
+	service.observeLatency(LatencyFrameToStatus, 50*time.Millisecond)
+
+	select {
+	case n := <-service.notificationChannel:
+		require.Equal(t, SeverityWarning, n.Severity)
+		require.Equal(t, "CAT pipeline congested", n.Title)
+		require.Contains(t, n.Message, "50ms from receipt to status")
+	default:
+		t.Fatal("no notification for the slow stage")
+	}
+	require.Equal(t, uint64(2), service.metricsSnapshot()["latency_exceeded.frame_to_status"])
+
+	service.observeLatency(LatencyFrameToStatus, time.Millisecond)
+	service.observeLatency(LatencyFrameToStatus, time.Millisecond)
+	n := <-service.notificationChannel
+	require.Equal(t, SeverityInfo, n.Severity)
+}
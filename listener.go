@@ -1,7 +1,6 @@
 package cat
 
 import (
-	"bytes"
 	"context"
 	stderr "errors"
 	"github.com/Station-Manager/types"
@@ -15,56 +14,124 @@ const (
 
 // serialPortListener listens for and processes data from a serial port at a set interval until a shutdown signal is received.
 func (s *Service) serialPortListener(shutdown <-chan struct{}) {
-	readTicker := time.NewTicker(s.config.CatConfig.ListenerRateLimiterIntervalMS * time.Millisecond)
+	interval := s.rigConfig().CatConfig.ListenerRateLimiterIntervalMS
+	readTicker := time.NewTicker(interval * time.Millisecond)
 	defer readTicker.Stop()
 
-	readTimeout := s.config.CatConfig.ListenerReadTimeoutMS
-	if readTimeout <= 0 {
-		readTimeout = defaultListenerReadTimeoutMS
-	}
-	readTimeout *= time.Millisecond
+	errorLogs := newLogLimiter(durationOrDefault(s.Options.Reconnect.ErrorLogIntervalMS, defaultErrorLogIntervalMS))
 
 	for {
 		select {
 		case <-shutdown:
 			return
 		case <-readTicker.C:
-			ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
+			// Timing parameters may change through Reload.
+			cfg := s.rigConfig().CatConfig
+			if cfg.ListenerRateLimiterIntervalMS != interval {
+				interval = cfg.ListenerRateLimiterIntervalMS
+				readTicker.Reset(interval * time.Millisecond)
+			}
+			if s.reopenPort.CompareAndSwap(true, false) && !s.reopenRequestedPort(shutdown) {
+				return
+			}
 
-			lineBytes, err := s.serialPort.ReadResponseBytes(ctx)
+			readTimeout := cfg.ListenerReadTimeoutMS
+			if readTimeout <= 0 {
+				readTimeout = defaultListenerReadTimeoutMS
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), readTimeout*time.Millisecond)
+
+			lineBytes, err := s.readLink(ctx)
 			cancel()
+			received := time.Now()
 
 			if err != nil {
 				if stderr.Is(err, context.DeadlineExceeded) {
 					continue
 				}
-				s.LoggerService.ErrorWith().Err(err).Msg("serial read failed")
-				continue
-			}
-
-			if len(lineBytes) == 0 {
-				continue
-			}
+				s.count(&s.counters.readErrors, "read_errors", 1)
+				// A dead port fails on every tick; report it periodically rather than flooding the log and the
+				// events channel.
+				suppressed, report := errorLogs.allow(time.Now())
+				if report {
+					s.reportError("listener", err)
+				} else {
+					s.recordError("listener", err)
+				}
 
-			state, ok := s.lookupCatState(lineBytes)
-			if !ok {
+				if fault := s.classifyLinkError(err); fault != linkFaultTransient && s.Options.Reconnect.Enabled {
+					if !s.reconnect(shutdown, fault) {
+						return
+					}
+					continue
+				}
+				if report {
+					s.logger().ErrorWith().Err(err).Int("suppressed", suppressed).Msg("serial read failed")
+				}
 				continue
 			}
 
-			// We are interested in this state, so send it for processing
-			select {
-			case <-shutdown:
+			if !s.handleFrame(shutdown, lineBytes, received) || !s.readAutoInfoBurst(shutdown) {
 				return
-			case s.processingChannel <- state:
-				// delivered to the processing goroutine
-			default:
-				// Drop to avoid blocking/backpressure
-				s.LoggerService.DebugWith().Str("prefix", state.Prefix).Msg("dropping cat state: processing channel full")
 			}
 		}
 	}
 }
 
+// readLink reads a frame from the current transport, failing once Stop has closed it.
+func (s *Service) readLink(ctx context.Context) ([]byte, error) {
+	t, err := s.openLink()
+	if err != nil {
+		return nil, err
+	}
+	return t.ReadResponseBytes(ctx)
+}
+
+// handleFrame decodes a frame read at received and hands a recognised state to the waiters and the processor. It
+// returns false if shutdown was signaled.
+func (s *Service) handleFrame(shutdown <-chan struct{}, lineBytes []byte, received time.Time) bool {
+	if len(lineBytes) == 0 {
+		return true
+	}
+
+	s.count(&s.counters.framesReceived, "frames_received", 1)
+	s.recordTraffic(TrafficRX, lineBytes)
+	if s.isEcho(lineBytes) {
+		return true // our own command, not a sign of life from the rig
+	}
+	s.markActivity()
+	s.noteFrameReceived()
+
+	frame, ok := s.codec().decodeFrame(lineBytes)
+	if !ok {
+		return true
+	}
+
+	state, ok := s.lookupCatState(frame)
+	s.noteFrame(shutdown, ok)
+	if !ok {
+		s.count(&s.counters.framesUnknown, "frames_unknown", 1)
+		return true
+	}
+	s.noteValidFrame(received)
+	s.noteRoundTripResponse(state.Prefix, received)
+
+	s.deliverToWaiters(state, received)
+
+	// We are interested in this state, so send it for processing
+	select {
+	case <-shutdown:
+		return false
+	case s.processingChannel <- receivedState{CatState: state, received: received}:
+		s.metricsSink().Gauge("processing_queue_depth", float64(len(s.processingChannel)))
+	default:
+		// Drop to avoid blocking/backpressure
+		s.logger().DebugWith().Str("prefix", state.Prefix).Msg("dropping cat state: processing channel full")
+		s.noteDrop(DropFrame)
+	}
+	return true
+}
+
 // lookupCatState attempts to find a CatState based on the byte slice prefix, returning the state and a success indicator.
 func (s *Service) lookupCatState(line []byte) (types.CatState, bool) {
 	const minPrefix = 2
@@ -73,6 +140,9 @@ func (s *Service) lookupCatState(line []byte) (types.CatState, bool) {
 		return types.CatState{}, false
 	}
 
+	s.definitionMu.RLock()
+	defer s.definitionMu.RUnlock()
+
 	// determine how many bytes to inspect
 	maxLen := s.maxCatPrefixLen
 	if maxLen > len(line) {
@@ -83,7 +153,7 @@ func (s *Service) lookupCatState(line []byte) (types.CatState, bool) {
 	}
 
 	// take the slice once, uppercase it for consistent lookup
-	prefixSlice := string(bytes.ToUpper(line[:maxLen]))
+	prefixSlice := s.foldPrefix(string(line[:maxLen]))
 
 	// try longest first to match multi-char prefixes (3..8) before 2-char ones
 	for l := maxLen; l >= minPrefix; l-- {
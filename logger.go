@@ -0,0 +1,35 @@
+package cat
+
+import "github.com/Station-Manager/logging"
+
+// noopLogger discards all log events. The logging package's event builders are nil-safe, so a nil service is a
+// logger that logs nothing.
+var noopLogger logging.Logger = (*logging.Service)(nil)
+
+// loggerRef holds the logger set by SetLogger, so that it can be swapped atomically while the workers run.
+type loggerRef struct {
+	logging.Logger
+}
+
+// SetLogger replaces the logger, e.g. to inject one after the Service was built or when embedding the Service in a
+// tool without the logging service. It takes precedence over LoggerService and may be called at any time; nil
+// reverts to LoggerService.
+func (s *Service) SetLogger(logger logging.Logger) {
+	if logger == nil {
+		s.customLogger.Store(nil)
+		return
+	}
+	s.customLogger.Store(&loggerRef{Logger: logger})
+}
+
+// logger returns the logger all logging goes through: the one set by SetLogger, otherwise LoggerService, otherwise
+// one that discards everything. It never returns nil.
+func (s *Service) logger() logging.Logger {
+	if ref := s.customLogger.Load(); ref != nil {
+		return ref.Logger
+	}
+	if s.LoggerService != nil {
+		return s.LoggerService
+	}
+	return noopLogger
+}
@@ -0,0 +1,390 @@
+package cat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// CmdWriteMemory is the command name for the rig's memory-write template. It takes three parameters: the
+	// channel number, the frequency and the (raw) mode, e.g. {Name: "WRITEMEMORY", Cmd: "MW%s%s%s;"}.
+	CmdWriteMemory cmds.CatCmdName = "WRITEMEMORY"
+	// CmdWriteMemoryName and CmdWriteMemoryTone, if the rig definition provides them, are written after
+	// WRITEMEMORY by WriteMemories and WriteMemoryChannel. They take the channel number and the name or the tone.
+	// A channel with a name or a tone cannot be written to a rig without them.
+	CmdWriteMemoryName cmds.CatCmdName = "WRITEMEMORYNAME"
+	CmdWriteMemoryTone cmds.CatCmdName = "WRITEMEMORYTONE"
+	// CmdReadMemory reads one channel; it takes the channel number, e.g. {Name: "READMEMORY", Cmd: "MR0%s;"}. The
+	// rig's reply is parsed by the state answering the command, whose markers report the Tag* memory tags.
+	CmdReadMemory cmds.CatCmdName = "READMEMORY"
+
+	// defaultMemoryChannelDigits is used when Options.Memory.ChannelDigits is zero.
+	defaultMemoryChannelDigits = 3
+	// defaultMemoryReadIntervalMS is used when Options.Memory.ReadIntervalMS is zero.
+	defaultMemoryReadIntervalMS = 50
+)
+
+// Tags reported by the reply to READMEMORY. The mode and the tone are mapped to display values by the value
+// mappings of their markers, e.g. "08" to "88.5"; a mode marker without mappings uses those of MAINMODE. A tone of
+// "OFF", or one that is not a number, means no tone.
+const (
+	TagMemoryChannel = "MEMCHANNEL"
+	TagMemoryFreq    = "MEMFREQ"
+	TagMemoryMode    = "MEMMODE"
+	TagMemoryName    = "MEMNAME"
+	TagMemoryTone    = "MEMTONE"
+)
+
+// memoryToneOff is the display value of a channel without a tone.
+const memoryToneOff = "OFF"
+
+// MemoryChannel is the content of one rig memory channel.
+type MemoryChannel struct {
+	Number      int
+	FrequencyHz int64
+	Mode        string
+	Name        string
+	// ToneHz is the CTCSS tone, zero if none.
+	ToneHz float64
+}
+
+// MemoryDiff describes how restoring a backup changes one channel. Before is nil for a new channel.
+type MemoryDiff struct {
+	Number int
+	Before *MemoryChannel
+	After  MemoryChannel
+}
+
+// String renders the diff as a single preview line.
+func (d MemoryDiff) String() string {
+	if d.Before == nil {
+		return fmt.Sprintf("channel %d: (empty) -> %d Hz %s %q", d.Number, d.After.FrequencyHz, d.After.Mode, d.After.Name)
+	}
+	return fmt.Sprintf("channel %d: %d Hz %s %q -> %d Hz %s %q", d.Number,
+		d.Before.FrequencyHz, d.Before.Mode, d.Before.Name, d.After.FrequencyHz, d.After.Mode, d.After.Name)
+}
+
+// ValidateMemories checks a set of channels before they are written, returning an error describing every problem
+// found.
+func ValidateMemories(channels []MemoryChannel) error {
+	const op errors.Op = "cat.ValidateMemories"
+
+	var problems []string
+	seen := make(map[int]bool, len(channels))
+	for _, ch := range channels {
+		if ch.Number < 0 {
+			problems = append(problems, fmt.Sprintf("channel %d: invalid channel number", ch.Number))
+		}
+		if seen[ch.Number] {
+			problems = append(problems, fmt.Sprintf("channel %d: duplicate channel number", ch.Number))
+		}
+		seen[ch.Number] = true
+		if ch.FrequencyHz <= 0 {
+			problems = append(problems, fmt.Sprintf("channel %d: invalid frequency %d Hz", ch.Number, ch.FrequencyHz))
+		}
+		if strings.TrimSpace(ch.Mode) == "" {
+			problems = append(problems, fmt.Sprintf("channel %d: missing mode", ch.Number))
+		}
+		if ch.ToneHz < 0 {
+			problems = append(problems, fmt.Sprintf("channel %d: invalid tone %.1f Hz", ch.Number, ch.ToneHz))
+		}
+		if i := strings.IndexFunc(ch.Name, invalidMemoryNameRune); i >= 0 {
+			problems = append(problems, fmt.Sprintf("channel %d: name %q holds the invalid character %q", ch.Number, ch.Name, ch.Name[i:i+1]))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid memory channels: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// invalidMemoryNameRune reports whether r may not appear in a memory name: names are printable ASCII, without the
+// ';' that ends commands on most rigs, since they are written into the command unchanged.
+func invalidMemoryNameRune(r rune) bool {
+	return r < 0x20 || r > 0x7e || r == ';'
+}
+
+// validateMemories checks channels like ValidateMemories, and that their names fit the rig: no longer than the
+// marker reporting MEMNAME and free of the rig's line delimiter.
+func (s *Service) validateMemories(channels []MemoryChannel) error {
+	const op errors.Op = "cat.Service.validateMemories"
+	if err := ValidateMemories(channels); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	marker, ok := s.markerFor(TagMemoryName)
+	delimiter := s.codec().lineDelimiter()
+	if delimiter == 0 {
+		delimiter = s.rigConfig().SerialConfig.LineDelimiter
+	}
+	var problems []string
+	for _, ch := range channels {
+		if ok && marker.Length > 0 && len(ch.Name) > marker.Length {
+			problems = append(problems, fmt.Sprintf("channel %d: name %q is longer than the %d characters of the rig", ch.Number, ch.Name, marker.Length))
+		}
+		if delimiter != 0 && strings.IndexByte(ch.Name, delimiter) >= 0 {
+			problems = append(problems, fmt.Sprintf("channel %d: name %q holds the line delimiter of the rig", ch.Number, ch.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(op).Msgf("invalid memory channels: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// DiffMemories compares the channels currently in the rig with the ones about to be written and returns the
+// channels that would change, for previewing a restore.
+func DiffMemories(current, incoming []MemoryChannel) []MemoryDiff {
+	byNumber := make(map[int]MemoryChannel, len(current))
+	for _, ch := range current {
+		byNumber[ch.Number] = ch
+	}
+
+	var diffs []MemoryDiff
+	for _, ch := range incoming {
+		before, ok := byNumber[ch.Number]
+		switch {
+		case !ok:
+			diffs = append(diffs, MemoryDiff{Number: ch.Number, After: ch})
+		case before != ch:
+			b := before
+			diffs = append(diffs, MemoryDiff{Number: ch.Number, Before: &b, After: ch})
+		}
+	}
+	return diffs
+}
+
+// WriteMemories validates channels and writes them to the rig as a bulk transfer, so regular polling carries on
+// while the memories are programmed.
+func (s *Service) WriteMemories(channels []MemoryChannel) (*BulkTransfer, error) {
+	const op errors.Op = "cat.Service.WriteMemories"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if err := s.validateMemories(channels); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	requests := make([]CatCommandRequest, 0, len(channels))
+	for _, ch := range channels {
+		chRequests, err := s.memoryWriteRequests(ch)
+		if err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		requests = append(requests, chRequests...)
+	}
+
+	transfer, err := s.EnqueueBulk("memory write", requests)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return transfer, nil
+}
+
+// memoryWriteRequests renders ch as the commands that write it: WRITEMEMORY, then WRITEMEMORYNAME and
+// WRITEMEMORYTONE if the rig definition provides them. It returns an error if ch has a name or a tone that the
+// rig definition cannot write, rather than dropping it.
+func (s *Service) memoryWriteRequests(ch MemoryChannel) ([]CatCommandRequest, error) {
+	const op errors.Op = "cat.Service.memoryWriteRequests"
+
+	freq, err := s.formatFrequency(tags.VfoAFreq, ch.FrequencyHz)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	mode, err := s.encodeMappedValue(s.memoryModeTag(), ch.Mode)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	number := s.formatMemoryNumber(ch.Number)
+	requests := []CatCommandRequest{{Name: CmdWriteMemory, Params: []string{number, freq, mode}}}
+
+	if _, err = s.commandLookup(CmdWriteMemoryName); err == nil {
+		requests = append(requests, CatCommandRequest{Name: CmdWriteMemoryName, Params: []string{number, ch.Name}})
+	} else if ch.Name != "" {
+		return nil, errors.New(op).Err(err).Msgf("memory channel %d: the rig definition cannot write its name", ch.Number)
+	}
+	if _, err = s.commandLookup(CmdWriteMemoryTone); err == nil {
+		tone, err := s.encodeMappedValue(TagMemoryTone, formatMemoryTone(ch.ToneHz))
+		if err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		requests = append(requests, CatCommandRequest{Name: CmdWriteMemoryTone, Params: []string{number, tone}})
+	} else if ch.ToneHz > 0 {
+		return nil, errors.New(op).Err(err).Msgf("memory channel %d: the rig definition cannot write its tone", ch.Number)
+	}
+	return requests, nil
+}
+
+// formatMemoryNumber renders a channel number in the rig's width.
+func (s *Service) formatMemoryNumber(n int) string {
+	digits := s.Options.Memory.ChannelDigits
+	if digits <= 0 {
+		digits = defaultMemoryChannelDigits
+	}
+	value := strconv.Itoa(n)
+	if len(value) < digits {
+		value = strings.Repeat("0", digits-len(value)) + value
+	}
+	return value
+}
+
+// ReadMemoryChannel reads channel n from the rig. An empty channel is returned with a zero FrequencyHz. If ctx has
+// no deadline, Options.ResponseTimeoutMS applies.
+func (s *Service) ReadMemoryChannel(ctx context.Context, n int) (MemoryChannel, error) {
+	const op errors.Op = "cat.Service.ReadMemoryChannel"
+	status, err := s.SendCommand(ctx, CmdReadMemory, s.formatMemoryNumber(n))
+	if err != nil {
+		return MemoryChannel{}, errors.New(op).Err(err)
+	}
+	ch, err := s.parseMemoryChannel(n, status)
+	if err != nil {
+		return MemoryChannel{}, errors.New(op).Err(err)
+	}
+	return ch, nil
+}
+
+// WriteMemoryChannel writes ch to channel n as a batch: WRITEMEMORY, then WRITEMEMORYNAME and WRITEMEMORYTONE if
+// the rig definition provides them. It returns once the batch has been written, or when ctx is done; the batch
+// is then still queued and may be written later.
+func (s *Service) WriteMemoryChannel(ctx context.Context, n int, ch MemoryChannel) error {
+	const op errors.Op = "cat.Service.WriteMemoryChannel"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	ch.Number = n
+	if err := s.validateMemories([]MemoryChannel{ch}); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	requests, err := s.memoryWriteRequests(ch)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.runBatch(ctx, requests); err != nil {
+		if ctx.Err() != nil {
+			// The batch stays queued, so the channel may yet be written.
+			return errors.New(op).Err(err).Msgf("stopped waiting for memory channel %d to be written", n)
+		}
+		return errors.New(op).Err(err).Msgf("memory channel %d not written", n)
+	}
+	return nil
+}
+
+// DumpAllMemories reads the Options.Memory.Channels channels from Options.Memory.FirstChannel, pausing
+// Options.Memory.ReadIntervalMS between reads, and returns those that are not empty, e.g. for a backup to restore
+// with WriteMemories, which writes their names and tones as well. On error the channels read so far are returned
+// with it.
+func (s *Service) DumpAllMemories(ctx context.Context) ([]MemoryChannel, error) {
+	const op errors.Op = "cat.Service.DumpAllMemories"
+	if s.Options.Memory.Channels <= 0 {
+		return nil, errors.New(op).Msg("Options.Memory.Channels is not set.")
+	}
+	channels, err := s.dumpMemories(ctx, nil)
+	if err != nil {
+		return channels, errors.New(op).Err(err)
+	}
+	return channels, nil
+}
+
+// dumpMemories implements DumpAllMemories, calling progress, if set, after every channel read.
+func (s *Service) dumpMemories(ctx context.Context, progress func(done, total int)) ([]MemoryChannel, error) {
+	const op errors.Op = "cat.Service.dumpMemories"
+	opts := s.Options.Memory
+	interval := durationOrDefault(opts.ReadIntervalMS, defaultMemoryReadIntervalMS)
+
+	var channels []MemoryChannel
+	for i := 0; i < opts.Channels; i++ {
+		if i > 0 {
+			if err := pause(ctx, interval); err != nil {
+				return channels, errors.New(op).Err(err)
+			}
+		}
+		ch, err := s.ReadMemoryChannel(ctx, opts.FirstChannel+i)
+		if err != nil {
+			return channels, errors.New(op).Err(err)
+		}
+		if ch.FrequencyHz > 0 {
+			channels = append(channels, ch)
+		}
+		if progress != nil {
+			progress(i+1, opts.Channels)
+		}
+	}
+	return channels, nil
+}
+
+// pause waits for d, or until ctx is done.
+func pause(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseMemoryChannel builds channel n from the parsed reply to READMEMORY.
+func (s *Service) parseMemoryChannel(n int, status types.CatStatus) (MemoryChannel, error) {
+	const op errors.Op = "cat.Service.parseMemoryChannel"
+	ch := MemoryChannel{Number: n}
+	if raw, ok := status[TagMemoryChannel]; ok {
+		if got, err := strconv.Atoi(strings.TrimSpace(raw)); err != nil || got != n {
+			return MemoryChannel{}, errors.New(op).Msgf("reply is for memory channel %q, not %d", raw, n)
+		}
+	}
+	if raw := strings.TrimSpace(status[TagMemoryFreq]); raw != "" {
+		hz, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return MemoryChannel{}, errors.New(op).Msgf("invalid memory frequency %q", raw)
+		}
+		ch.FrequencyHz = hz
+	}
+	ch.Mode = strings.TrimSpace(status[TagMemoryMode])
+	if tag := s.memoryModeTag(); tag != TagMemoryMode {
+		ch.Mode = s.decodeMappedValue(tag, ch.Mode)
+	}
+	ch.Name = strings.TrimSpace(status[TagMemoryName])
+	if tone, err := strconv.ParseFloat(strings.TrimSpace(status[TagMemoryTone]), 64); err == nil && tone > 0 {
+		ch.ToneHz = tone
+	}
+	return ch, nil
+}
+
+// memoryModeTag returns the tag whose value mappings translate memory modes: MEMMODE if its marker has mappings,
+// which the parser has then already applied, and MAINMODE otherwise.
+func (s *Service) memoryModeTag() tags.CatStateTag {
+	if marker, ok := s.markerFor(TagMemoryMode); ok && len(marker.ValueMappings) > 0 {
+		return TagMemoryMode
+	}
+	return tags.MainMode
+}
+
+// decodeMappedValue converts a raw rig value into its display value using the value mappings of the marker that
+// reports tag. Values without a mapping are returned unchanged.
+func (s *Service) decodeMappedValue(tag tags.CatStateTag, raw string) string {
+	marker, _ := s.markerFor(tag)
+	for _, vm := range marker.ValueMappings {
+		if vm.Key == raw {
+			return vm.Value
+		}
+	}
+	return raw
+}
+
+// formatMemoryTone renders a tone as the display value mapped to the rig's tone code.
+func formatMemoryTone(hz float64) string {
+	if hz <= 0 {
+		return memoryToneOff
+	}
+	return strconv.FormatFloat(hz, 'f', 1, 64)
+}
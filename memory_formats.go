@@ -0,0 +1,193 @@
+package cat
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+)
+
+// memoryCSVHeader is the header of the native CSV memory format.
+var memoryCSVHeader = []string{"Number", "FrequencyHz", "Mode", "Name", "ToneHz"}
+
+// chirpHeader is the column layout of a CHIRP CSV export.
+var chirpHeader = []string{
+	"Location", "Name", "Frequency", "Duplex", "Offset", "Tone", "rToneFreq", "cToneFreq", "DtcsCode",
+	"DtcsPolarity", "Mode", "TStep", "Skip", "Comment", "URCALL", "RPT1CALL", "RPT2CALL", "DVCODE",
+}
+
+// ExportMemoriesCSV writes channels in the native CSV format.
+func ExportMemoriesCSV(w io.Writer, channels []MemoryChannel) error {
+	const op errors.Op = "cat.ExportMemoriesCSV"
+	cw := csv.NewWriter(w)
+	if err := cw.Write(memoryCSVHeader); err != nil {
+		return errors.New(op).Err(err)
+	}
+	for _, ch := range channels {
+		record := []string{
+			strconv.Itoa(ch.Number),
+			strconv.FormatInt(ch.FrequencyHz, 10),
+			ch.Mode,
+			ch.Name,
+			strconv.FormatFloat(ch.ToneHz, 'f', 1, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// ImportMemoriesCSV reads channels written by ExportMemoriesCSV and validates them.
+func ImportMemoriesCSV(r io.Reader) ([]MemoryChannel, error) {
+	const op errors.Op = "cat.ImportMemoriesCSV"
+	records, err := readCSV(r, memoryCSVHeader[0])
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	channels := make([]MemoryChannel, 0, len(records))
+	for line, rec := range records {
+		if len(rec) < len(memoryCSVHeader) {
+			return nil, errors.New(op).Msgf("line %d: expected %d fields, got %d", line+2, len(memoryCSVHeader), len(rec))
+		}
+		number, err := strconv.Atoi(strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, errors.New(op).Msgf("line %d: invalid channel number %q", line+2, rec[0])
+		}
+		hz, err := strconv.ParseInt(strings.TrimSpace(rec[1]), 10, 64)
+		if err != nil {
+			return nil, errors.New(op).Msgf("line %d: invalid frequency %q", line+2, rec[1])
+		}
+		tone, err := parseOptionalFloat(rec[4])
+		if err != nil {
+			return nil, errors.New(op).Msgf("line %d: invalid tone %q", line+2, rec[4])
+		}
+		channels = append(channels, MemoryChannel{
+			Number: number, FrequencyHz: hz, Mode: strings.TrimSpace(rec[2]), Name: rec[3], ToneHz: tone,
+		})
+	}
+
+	if err = ValidateMemories(channels); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return channels, nil
+}
+
+// ExportMemoriesCHIRP writes channels as a CHIRP-compatible CSV file.
+func ExportMemoriesCHIRP(w io.Writer, channels []MemoryChannel) error {
+	const op errors.Op = "cat.ExportMemoriesCHIRP"
+	cw := csv.NewWriter(w)
+	if err := cw.Write(chirpHeader); err != nil {
+		return errors.New(op).Err(err)
+	}
+	for _, ch := range channels {
+		toneMode, tone := "", "88.5"
+		if ch.ToneHz > 0 {
+			toneMode, tone = "Tone", strconv.FormatFloat(ch.ToneHz, 'f', 1, 64)
+		}
+		record := []string{
+			strconv.Itoa(ch.Number), ch.Name, strconv.FormatFloat(float64(ch.FrequencyHz)/1e6, 'f', 6, 64),
+			"", "0.000000", toneMode, tone, tone, "023", "NN", ch.Mode, "5.00", "", "", "", "", "", "",
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// ImportMemoriesCHIRP reads a CHIRP CSV export and validates it. Columns are located by header name, so exports
+// from different CHIRP versions are accepted.
+func ImportMemoriesCHIRP(r io.Reader) ([]MemoryChannel, error) {
+	const op errors.Op = "cat.ImportMemoriesCHIRP"
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New(op).Msg("empty CHIRP file")
+	}
+
+	col := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		col[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"Location", "Name", "Frequency", "Mode"} {
+		if _, ok := col[required]; !ok {
+			return nil, errors.New(op).Msgf("CHIRP file is missing the %s column", required)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	channels := make([]MemoryChannel, 0, len(rows)-1)
+	for line, rec := range rows[1:] {
+		number, err := strconv.Atoi(field(rec, "Location"))
+		if err != nil {
+			return nil, errors.New(op).Msgf("line %d: invalid location %q", line+2, field(rec, "Location"))
+		}
+		mhz, err := strconv.ParseFloat(field(rec, "Frequency"), 64)
+		if err != nil {
+			return nil, errors.New(op).Msgf("line %d: invalid frequency %q", line+2, field(rec, "Frequency"))
+		}
+		var tone float64
+		if field(rec, "Tone") == "Tone" {
+			if tone, err = parseOptionalFloat(field(rec, "rToneFreq")); err != nil {
+				return nil, errors.New(op).Msgf("line %d: invalid tone %q", line+2, field(rec, "rToneFreq"))
+			}
+		}
+		channels = append(channels, MemoryChannel{
+			Number:      number,
+			FrequencyHz: int64(math.Round(mhz * 1e6)),
+			Mode:        field(rec, "Mode"),
+			Name:        field(rec, "Name"),
+			ToneHz:      tone,
+		})
+	}
+
+	if err = ValidateMemories(channels); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return channels, nil
+}
+
+// readCSV reads all records from r, dropping the header row if its first field is firstHeader.
+func readCSV(r io.Reader, firstHeader string) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && len(records[0]) > 0 && strings.TrimSpace(records[0][0]) == firstHeader {
+		records = records[1:]
+	}
+	return records, nil
+}
+
+// parseOptionalFloat parses s as a float, treating an empty string as zero.
+func parseOptionalFloat(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,209 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoriesCSVRoundTrip(t *testing.T) {
+	channels := []MemoryChannel{
+		{Number: 1, FrequencyHz: 14074000, Mode: "USB", Name: "FT8"},
+		{Number: 2, FrequencyHz: 145500000, Mode: "FM", Name: "S20", ToneHz: 88.5},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportMemoriesCSV(&buf, channels))
+	got, err := ImportMemoriesCSV(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, channels, got)
+
+	buf.Reset()
+	require.NoError(t, ExportMemoriesCHIRP(&buf, channels))
+	got, err = ImportMemoriesCHIRP(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, channels, got)
+}
+
+func TestImportMemoriesRejectsInvalid(t *testing.T) {
+	input := "Number,FrequencyHz,Mode,Name,ToneHz\n1,0,USB,bad,0\n1,7074000,,dup,0\n"
+	_, err := ImportMemoriesCSV(strings.NewReader(input))
+	require.Error(t, err)
+}
+
+func TestDiffMemories(t *testing.T) {
+	current := []MemoryChannel{
+		{Number: 1, FrequencyHz: 14074000, Mode: "USB", Name: "FT8"},
+		{Number: 2, FrequencyHz: 7074000, Mode: "USB", Name: "FT8 40"},
+	}
+	incoming := []MemoryChannel{
+		{Number: 1, FrequencyHz: 14074000, Mode: "USB", Name: "FT8"},
+		{Number: 2, FrequencyHz: 7047500, Mode: "USB", Name: "FT4 40"},
+		{Number: 3, FrequencyHz: 3573000, Mode: "USB", Name: "FT8 80"},
+	}
+
+	diffs := DiffMemories(current, incoming)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, 2, diffs[0].Number)
+	require.NotNil(t, diffs[0].Before)
+	assert.Equal(t, "FT8 40", diffs[0].Before.Name)
+	assert.Equal(t, 3, diffs[1].Number)
+	assert.Nil(t, diffs[1].Before)
+}
+
+func newMemoryTestService(t *testing.T) *Service {
+	t.Helper()
+	modes := []types.ValueMapping{{Key: "2", Value: "USB"}, {Key: "4", Value: "FM"}}
+	tones := []types.ValueMapping{{Key: "00", Value: "OFF"}, {Key: "08", Value: "88.5"}}
+	return newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "READMEMORY", Cmd: "MR0%s;"},
+			{Name: "WRITEMEMORY", Cmd: "MW%s%s%s;"},
+			{Name: "WRITEMEMORYTONE", Cmd: "MT%s%s;"},
+		},
+		CatStates: []types.CatState{
+			{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: modes}}},
+			{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+			{Prefix: "MR0", Markers: []types.Marker{
+				{Tag: TagMemoryChannel, Index: 0, Length: 3},
+				{Tag: TagMemoryFreq, Index: 3, Length: 11},
+				{Tag: TagMemoryMode, Index: 14, Length: 1},
+				{Tag: TagMemoryTone, Index: 15, Length: 2, ValueMappings: tones},
+				{Tag: TagMemoryName, Index: 17, Length: 8},
+			}},
+		},
+	})
+}
+
+func TestReadMemoryChannelsAndDump(t *testing.T) {
+	service := newMemoryTestService(t)
+	service.Options.Memory = MemoryOptions{FirstChannel: 1, Channels: 3, ReadIntervalMS: 1}
+	replies := map[string]string{
+		"MR0001;": "00100014074000200FT8     ;",
+		"MR0002;": "00200000000000000        ;",
+		"MR0003;": "00300145500000408S20     ;",
+	}
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if data, ok := replies[cmd]; ok {
+			state, _ := service.lookupCatState([]byte("MR0" + data))
+			service.deliverToWaiters(state, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+
+	ch, err := service.ReadMemoryChannel(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, MemoryChannel{Number: 3, FrequencyHz: 145500000, Mode: "FM", Name: "S20", ToneHz: 88.5}, ch)
+
+	_, err = service.ReadMemoryChannel(context.Background(), 2)
+	require.NoError(t, err)
+
+	channels, err := service.DumpAllMemories(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []MemoryChannel{
+		{Number: 1, FrequencyHz: 14074000, Mode: "USB", Name: "FT8"},
+		{Number: 3, FrequencyHz: 145500000, Mode: "FM", Name: "S20", ToneHz: 88.5},
+	}, channels, "the empty channel is skipped")
+
+	// The dump restores as it was read, names and tones included.
+	restore := newMemoryTestService(t)
+	restore.config.CatCommands = append(restore.config.CatCommands, types.CatCommand{Name: "WRITEMEMORYNAME", Cmd: "MN%s%s;"})
+	fake := startTestWorkers(t, restore, map[string]func(<-chan struct{}){"serialPortSender": restore.serialPortSender})
+	transfer, err := restore.WriteMemories(channels)
+	require.NoError(t, err)
+	select {
+	case <-transfer.Done():
+	case <-time.After(time.Second):
+		t.Fatal("memory restore did not complete")
+	}
+	require.NoError(t, transfer.Err())
+	assert.Equal(t, []string{"MW001000140740002;", "MN001FT8;", "MT00100;", "MW003001455000004;", "MN003S20;", "MT00308;"}, fake.writes())
+}
+
+func TestWriteMemoryChannelSequencesCommands(t *testing.T) {
+	service := newMemoryTestService(t)
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, service.WriteMemoryChannel(ctx, 7, MemoryChannel{FrequencyHz: 145500000, Mode: "FM", ToneHz: 88.5}))
+	require.NoError(t, service.WriteMemoryChannel(ctx, 8, MemoryChannel{FrequencyHz: 14074000, Mode: "USB"}))
+	assert.Equal(t, []string{"MW007001455000004;", "MT00708;", "MW008000140740002;", "MT00800;"}, fake.writes())
+
+	require.Error(t, service.WriteMemoryChannel(ctx, 9, MemoryChannel{FrequencyHz: 145500000, Mode: "FM", ToneHz: 67}))
+}
+
+func TestMemoryModeUsesTheSameMappingsBothWays(t *testing.T) {
+	service := newMemoryTestService(t)
+	memState := &service.config.CatStates[2]
+	memState.Markers[2].ValueMappings = []types.ValueMapping{{Key: "1", Value: "USB"}, {Key: "3", Value: "FM"}}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	state, ok := service.lookupCatState([]byte("MR000300145500000308        "))
+	require.True(t, ok)
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	ch, err := service.parseMemoryChannel(3, status)
+	require.NoError(t, err)
+	require.Equal(t, "FM", ch.Mode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, service.WriteMemoryChannel(ctx, 3, ch))
+	assert.Equal(t, "MW003001455000003;", fake.writes()[0], "encoded with the MEMMODE mappings it was decoded with")
+}
+
+func TestWriteMemoryChannelGivingUpDoesNotClaimNotWritten(t *testing.T) {
+	service := newMemoryTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := service.WriteMemoryChannel(ctx, 7, MemoryChannel{FrequencyHz: 14074000, Mode: "USB"})
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "not written")
+	require.Len(t, service.sendChannel, 1, "the batch stays queued")
+}
+
+func TestWriteMemoriesWritesNamesAndTones(t *testing.T) {
+	service := newMemoryTestService(t)
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	transfer, err := service.WriteMemories([]MemoryChannel{{Number: 3, FrequencyHz: 145500000, Mode: "FM", ToneHz: 88.5}})
+	require.NoError(t, err)
+	select {
+	case <-transfer.Done():
+	case <-time.After(time.Second):
+		t.Fatal("memory write did not complete")
+	}
+	require.NoError(t, transfer.Err())
+	assert.Equal(t, []string{"MW003001455000004;", "MT00308;"}, fake.writes())
+
+	_, err = service.WriteMemories([]MemoryChannel{{Number: 4, FrequencyHz: 14074000, Mode: "USB", Name: "FT8"}})
+	require.Error(t, err, "the rig definition has no WRITEMEMORYNAME")
+}
+
+func TestWriteMemoriesRejectsNamesThatBreakTheCommand(t *testing.T) {
+	service := newMemoryTestService(t)
+	for _, name := range []string{"S20;TX1", "S20\r", "REPEATER9"} {
+		_, err := service.WriteMemories([]MemoryChannel{{Number: 1, FrequencyHz: 145500000, Mode: "FM", Name: name}})
+		require.Error(t, err, name)
+	}
+	require.Empty(t, service.bulkChannel, "nothing is queued")
+	require.ErrorContains(t, service.validateMemories([]MemoryChannel{{Number: 1, FrequencyHz: 145500000, Mode: "FM", Name: "REPEATER9"}}), "longer than the 8 characters")
+
+	require.Error(t, ValidateMemories([]MemoryChannel{{Number: 1, FrequencyHz: 7074000, Mode: "USB", Name: "FT8;"}}))
+}
+
+func TestWriteMemoriesBeforeInitialize(t *testing.T) {
+	_, err := (&Service{}).WriteMemories([]MemoryChannel{{Number: 1, FrequencyHz: 7074000, Mode: "USB"}})
+	require.ErrorContains(t, err, errMsgServiceNotInit)
+
+	err = (&Service{}).WriteMemoryChannel(context.Background(), 1, MemoryChannel{FrequencyHz: 7074000, Mode: "USB"})
+	require.ErrorContains(t, err, errMsgServiceNotInit)
+}
@@ -0,0 +1,154 @@
+package cat
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Command names reading the meters, e.g. "SM0;" on Kenwood rigs. They are polled for MeterChannel unless
+// Options.Meters.Commands names others.
+const (
+	CmdReadSMeter     cmds.CatCmdName = "READSMETER"
+	CmdReadPowerMeter cmds.CatCmdName = "READPOWERMETER"
+)
+
+// TagPowerMeter is the tag reporting the output power meter, as opposed to TXPWR, the power setting.
+const TagPowerMeter = "POWERMETER"
+
+const (
+	// defaultMeterIntervalMS is used when Options.Meters.IntervalMS is zero.
+	defaultMeterIntervalMS = 100
+	// defaultMeterChannelSize is used when Options.Meters.ChannelSize is zero.
+	defaultMeterChannelSize = 16
+)
+
+// MeterReading is one sample of a meter, with the smoothed and peak-held values computed for rendering. The values
+// are converted with Options.Conversions, e.g. to dB for the S-meter.
+type MeterReading struct {
+	Tag string
+	// Raw is the value as reported by the rig.
+	Raw   string
+	Value float64
+	Unit  Unit
+	// Smoothed follows Value with Options.Meters.Smoothing applied.
+	Smoothed float64
+	// Peak is the highest Value within Options.Meters.PeakHoldMS.
+	Peak float64
+	At   time.Time
+}
+
+// meterState is the smoothing and peak-hold state of one meter tag.
+type meterState struct {
+	smoothed float64
+	peak     float64
+	peakAt   time.Time
+}
+
+// meterFeed turns meter values reported by the rig into readings. It is only used by the processor.
+type meterFeed struct {
+	tags   []string
+	states map[string]*meterState
+}
+
+// newMeterFeed returns the meter feed of opts, or nil if meter streaming is disabled.
+func newMeterFeed(opts MeterOptions) (*meterFeed, chan MeterReading) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+	tags := opts.Tags
+	if len(tags) == 0 {
+		tags = []string{TagSMeter, TagPowerMeter}
+	}
+	size := opts.ChannelSize
+	if size <= 0 {
+		size = defaultMeterChannelSize
+	}
+	return &meterFeed{tags: tags, states: make(map[string]*meterState)}, make(chan MeterReading, size)
+}
+
+// MeterChannel returns a channel of meter readings, sampled at Options.Meters.IntervalMS. It must be enabled
+// with Options.Meters.Enabled. When the consumer falls behind the oldest readings are discarded.
+func (s *Service) MeterChannel() (<-chan MeterReading, error) {
+	const op errors.Op = "cat.Service.MeterChannel"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if s.meterChannel == nil {
+		return nil, errors.New(op).Msg("Meter streaming is not enabled.")
+	}
+	return s.meterChannel, nil
+}
+
+// meterPolls returns the poll entries reading the meters: Options.Meters.Commands, or those of READSMETER and
+// READPOWERMETER that the rig definition provides.
+func (s *Service) meterPolls() []PollEntry {
+	if s.meters == nil {
+		return nil
+	}
+	names := s.Options.Meters.Commands
+	if len(names) == 0 {
+		for _, name := range []cmds.CatCmdName{CmdReadSMeter, CmdReadPowerMeter} {
+			if _, err := s.commandLookup(name); err == nil {
+				names = append(names, name)
+			}
+		}
+	}
+	interval := s.Options.Meters.IntervalMS
+	if interval <= 0 {
+		interval = defaultMeterIntervalMS
+	}
+	polls := make([]PollEntry, 0, len(names))
+	for _, name := range names {
+		polls = append(polls, PollEntry{Command: name, IntervalMS: interval})
+	}
+	return polls
+}
+
+// feedMeters delivers the meter values in status on the meter channel and returns status without them, so that
+// high-rate meter samples do not flood the status consumers. status is unchanged if meter streaming is disabled.
+func (s *Service) feedMeters(status types.CatStatus, now time.Time) types.CatStatus {
+	if s.meters == nil {
+		return status
+	}
+	for tag, raw := range status {
+		if !slices.Contains(s.meters.tags, tag) {
+			continue
+		}
+		delete(status, tag)
+		conv := s.conversionFor(tag)
+		value, ok := conv.apply(raw)
+		if !ok {
+			s.logger().DebugWith().Str("tag", tag).Str("value", raw).Msg("meter value is not a number")
+			continue
+		}
+		reading := s.meters.sample(tag, value, now, s.Options.Meters)
+		reading.Raw, reading.Unit = raw, conv.Unit
+		if s.Options.Remote.Enabled {
+			s.offerRemoteMeter(reading)
+		}
+		if !offerEvicting(s.meterChannel, reading) {
+			s.logger().DebugWith().Str("tag", tag).Msg("dropping meter reading: meter channel full")
+			s.noteDrop(DropMeter)
+		}
+	}
+	return status
+}
+
+// sample updates the smoothing and peak-hold state of tag with value.
+func (f *meterFeed) sample(tag string, value float64, now time.Time, opts MeterOptions) MeterReading {
+	st, ok := f.states[tag]
+	if !ok {
+		st = &meterState{smoothed: value, peak: value, peakAt: now}
+		f.states[tag] = st
+	}
+	k := min(max(opts.Smoothing, 0), 1)
+	st.smoothed = st.smoothed*k + value*(1-k)
+	if hold := opts.PeakHoldMS * time.Millisecond; value >= st.peak || now.Sub(st.peakAt) >= hold {
+		st.peak, st.peakAt = value, now
+	}
+	return MeterReading{Tag: tag, Value: value, Smoothed: st.smoothed, Peak: st.peak, At: now}
+}
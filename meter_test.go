@@ -0,0 +1,47 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeterSmoothingAndPeakHold(t *testing.T) {
+	feed, _ := newMeterFeed(MeterOptions{Enabled: true})
+	opts := MeterOptions{Smoothing: 0.5, PeakHoldMS: 300}
+	start := time.Now()
+
+	r := feed.sample(TagSMeter, 10, start, opts)
+	require.Equal(t, 10.0, r.Smoothed)
+	r = feed.sample(TagSMeter, 20, start.Add(100*time.Millisecond), opts)
+	require.Equal(t, 15.0, r.Smoothed)
+	require.Equal(t, 20.0, r.Peak)
+	r = feed.sample(TagSMeter, 4, start.Add(200*time.Millisecond), opts)
+	require.Equal(t, 9.5, r.Smoothed)
+	require.Equal(t, 20.0, r.Peak, "the peak is held")
+	r = feed.sample(TagSMeter, 4, start.Add(400*time.Millisecond), opts)
+	require.Equal(t, 4.0, r.Peak, "the hold expired")
+}
+
+func TestMeterValuesLeaveTheStatusStream(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: CmdReadSMeter.String(), Cmd: "SM0;"}},
+	})
+	service.Options.Meters = MeterOptions{Enabled: true, IntervalMS: 50}
+	service.Options.Conversions = map[string]Conversion{TagSMeter: {Scale: 2, Offset: -127, Unit: UnitDB}}
+	service.meters, service.meterChannel = newMeterFeed(service.Options.Meters)
+
+	require.Equal(t, []PollEntry{{Command: CmdReadSMeter, IntervalMS: 50}}, service.meterPolls())
+
+	status := service.feedMeters(types.CatStatus{"VFOAFREQ": "014074000", TagSMeter: "0027"}, time.Now())
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "014074000"}, status)
+	ch, err := service.MeterChannel()
+	require.NoError(t, err)
+	reading := <-ch
+	require.Equal(t, TagSMeter, reading.Tag)
+	require.Equal(t, "0027", reading.Raw)
+	require.Equal(t, -73.0, reading.Value)
+	require.Equal(t, UnitDB, reading.Unit)
+}
@@ -0,0 +1,185 @@
+package cat
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// roundTripBuckets are the upper bounds of the round-trip histogram buckets.
+var roundTripBuckets = [...]time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second, 2500 * time.Millisecond,
+}
+
+// Metrics are the running totals of the service, for monitoring a station that runs unattended.
+type Metrics struct {
+	CommandsSent uint64
+	// ResponsesParsed counts the frames parsed into a status.
+	ResponsesParsed uint64
+	// ParseFailures counts the frames matching no state, or rejected by strict parsing.
+	ParseFailures uint64
+	// StatusesDropped counts the statuses discarded because the status channel was full.
+	StatusesDropped uint64
+	// Reconnects counts the times the link was re-established after it was lost.
+	Reconnects uint64
+	// RoundTrip is the time from writing a command to receiving the state that answers it.
+	RoundTrip Histogram
+	// Counters holds every counter of the service by name, including those above and the ones of
+	// ExportDiagnostics.
+	Counters map[string]uint64
+}
+
+// Histogram is a latency histogram with cumulative buckets, as Prometheus has them.
+type Histogram struct {
+	// Buckets count the samples at or below each upper bound. Count is the implicit +Inf bucket.
+	Buckets []HistogramBucket
+	Count   uint64
+	Sum     time.Duration
+}
+
+// HistogramBucket is one bucket of a Histogram.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// roundTripTracker pairs written commands with the states answering them.
+type roundTripTracker struct {
+	mu sync.Mutex
+	// pending holds when a command expecting each response prefix was last written. Timing from the last write
+	// means that a set command the rig does not answer is not charged for the reply to a later read.
+	pending map[string]time.Time
+	counts  [len(roundTripBuckets) + 1]uint64
+	count   uint64
+	sum     time.Duration
+}
+
+// Metrics returns the running totals of the service.
+func (s *Service) Metrics() Metrics {
+	counters := s.metricsSnapshot()
+	return Metrics{
+		CommandsSent:    counters["commands_sent"],
+		ResponsesParsed: counters["frames_parsed"],
+		ParseFailures:   counters["frames_unknown"] + counters["frames_rejected"],
+		StatusesDropped: counters["statuses_dropped"],
+		Reconnects:      counters["reconnects"],
+		RoundTrip:       s.roundTrips.histogram(),
+		Counters:        counters,
+	}
+}
+
+// expvarMu makes the check and the publication of PublishExpvar atomic, as expvar.Publish panics on a duplicate.
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes Metrics under name with the expvar package, so that it is served on /debug/vars.
+func (s *Service) PublishExpvar(name string) error {
+	const op errors.Op = "cat.Service.PublishExpvar"
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if expvar.Get(name) != nil {
+		return errors.New(op).Msgf("expvar %q is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any { return s.Metrics() }))
+	return nil
+}
+
+// WritePrometheus writes Metrics to w in the Prometheus text exposition format, with the names prefixed "cat_",
+// for a /metrics handler.
+func (s *Service) WritePrometheus(w io.Writer) error {
+	const op errors.Op = "cat.Service.WritePrometheus"
+	m := s.Metrics()
+	bw := bufio.NewWriter(w)
+	for _, name := range sortedKeys(m.Counters) {
+		metric := "cat_" + prometheusName(name)
+		fmt.Fprintf(bw, "# TYPE %s counter\n%s %d\n", metric, metric, m.Counters[name])
+	}
+
+	const metric = "cat_round_trip_seconds"
+	fmt.Fprintf(bw, "# TYPE %s histogram\n", metric)
+	for _, b := range m.RoundTrip.Buckets {
+		fmt.Fprintf(bw, "%s_bucket{le=\"%g\"} %d\n", metric, b.UpperBound.Seconds(), b.Count)
+	}
+	fmt.Fprintf(bw, "%s_bucket{le=\"+Inf\"} %d\n", metric, m.RoundTrip.Count)
+	fmt.Fprintf(bw, "%s_sum %g\n%s_count %d\n", metric, m.RoundTrip.Sum.Seconds(), metric, m.RoundTrip.Count)
+	if err := bw.Flush(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// prometheusName replaces the characters of a counter name that Prometheus does not allow in metric names.
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// noteRoundTripSent records that cmd was written at, if a configured state answers it.
+func (s *Service) noteRoundTripSent(cmd queuedCommand, at time.Time) {
+	prefix, err := s.responsePrefixFor(cmds.CatCmdName(cmd.Name))
+	if err != nil {
+		return
+	}
+	t := &s.roundTrips
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = make(map[string]time.Time)
+	}
+	t.pending[prefix] = at
+}
+
+// noteRoundTripResponse records the round trip of the command answered by a state with prefix, received at.
+// Commands left unanswered for longer than the response timeout are not counted.
+func (s *Service) noteRoundTripResponse(prefix string, at time.Time) {
+	expiry := durationOrDefault(s.Options.ResponseTimeoutMS, defaultResponseTimeoutMS)
+	key := s.prefixKey(prefix)
+	t := &s.roundTrips
+	t.mu.Lock()
+	sent, ok := t.pending[key]
+	delete(t.pending, key)
+	d := at.Sub(sent)
+	timed := ok && d >= 0 && d <= expiry
+	if timed {
+		t.observe(d)
+	}
+	t.mu.Unlock()
+	if timed {
+		s.metricsSink().Observe("round_trip_seconds", d.Seconds())
+	}
+}
+
+// observe adds a sample to the histogram. The caller holds mu.
+func (t *roundTripTracker) observe(d time.Duration) {
+	i := 0
+	for i < len(roundTripBuckets) && d > roundTripBuckets[i] {
+		i++
+	}
+	t.counts[i]++
+	t.count++
+	t.sum += d
+}
+
+// histogram returns the round-trip histogram with cumulative buckets.
+func (t *roundTripTracker) histogram() Histogram {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := Histogram{Buckets: make([]HistogramBucket, len(roundTripBuckets)), Count: t.count, Sum: t.sum}
+	var cumulative uint64
+	for i, bound := range roundTripBuckets {
+		cumulative += t.counts[i]
+		h.Buckets[i] = HistogramBucket{UpperBound: bound, Count: cumulative}
+	}
+	return h
+}
@@ -0,0 +1,112 @@
+package cat
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// MetricsSink receives the instrumentation of the service, so that embedders can route it into their telemetry
+// stack. Counters are named as in Metrics.Counters, e.g. "frames_received"; gauges and histograms after what they
+// measure, e.g. "send_queue_depth" and "round_trip_seconds". The workers call the sink directly, so
+// implementations must be safe for concurrent use and must not block.
+type MetricsSink interface {
+	// Count adds delta to the counter name.
+	Count(name string, delta uint64)
+	// Gauge sets the gauge name to value.
+	Gauge(name string, value float64)
+	// Observe adds a sample to the histogram name.
+	Observe(name string, value float64)
+}
+
+// MemorySink is the in-memory MetricsSink, used by the service when Service.MetricsSink is not set. The zero
+// value is ready to use.
+type MemorySink struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+	gauges   map[string]float64
+	samples  map[string]SampleSummary
+}
+
+// MemorySnapshot is the content of a MemorySink.
+type MemorySnapshot struct {
+	Counters   map[string]uint64
+	Gauges     map[string]float64
+	Histograms map[string]SampleSummary
+}
+
+// SampleSummary summarises the samples of a histogram.
+type SampleSummary struct {
+	Count    uint64
+	Sum      float64
+	Min, Max float64
+}
+
+// Count implements MetricsSink.
+func (m *MemorySink) Count(name string, delta uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counters == nil {
+		m.counters = make(map[string]uint64)
+	}
+	m.counters[name] += delta
+}
+
+// Gauge implements MetricsSink.
+func (m *MemorySink) Gauge(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.gauges == nil {
+		m.gauges = make(map[string]float64)
+	}
+	m.gauges[name] = value
+}
+
+// Observe implements MetricsSink.
+func (m *MemorySink) Observe(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.samples == nil {
+		m.samples = make(map[string]SampleSummary)
+	}
+	summary, ok := m.samples[name]
+	if !ok || value < summary.Min {
+		summary.Min = value
+	}
+	if !ok || value > summary.Max {
+		summary.Max = value
+	}
+	summary.Count++
+	summary.Sum += value
+	m.samples[name] = summary
+}
+
+// Snapshot returns a copy of the recorded metrics.
+func (m *MemorySink) Snapshot() MemorySnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MemorySnapshot{
+		Counters:   maps.Clone(m.counters),
+		Gauges:     maps.Clone(m.gauges),
+		Histograms: maps.Clone(m.samples),
+	}
+}
+
+// MemoryMetrics returns what the built-in MemorySink recorded. It is empty when Service.MetricsSink is set.
+func (s *Service) MemoryMetrics() MemorySnapshot {
+	return s.memoryMetrics.Snapshot()
+}
+
+// metricsSink returns Service.MetricsSink, or the built-in MemorySink.
+func (s *Service) metricsSink() MetricsSink {
+	if s.MetricsSink != nil {
+		return s.MetricsSink
+	}
+	return &s.memoryMetrics
+}
+
+// count adds delta to the running total c and reports it to the sink as the counter name.
+func (s *Service) count(c *atomic.Uint64, name string, delta uint64) {
+	c.Add(delta)
+	s.metricsSink().Count(name, delta)
+}
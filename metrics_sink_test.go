@@ -0,0 +1,70 @@
+package cat
+
+import (
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySinkIsTheDefault(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{CatStates: []types.CatState{{Prefix: "FA"}}})
+	service.processingChannel = make(chan receivedState, 1)
+	require.True(t, service.handleFrame(make(chan struct{}), []byte("FA00014074000"), time.Now()))
+	service.handleFrame(make(chan struct{}), []byte("XX"), time.Now())
+
+	m := service.MemoryMetrics()
+	require.Equal(t, map[string]uint64{"frames_received": 2, "frames_unknown": 1}, m.Counters)
+	require.Equal(t, map[string]float64{"processing_queue_depth": 1}, m.Gauges)
+	require.Equal(t, service.Metrics().Counters["frames_received"], m.Counters["frames_received"])
+}
+
+func TestMetricsSinkReplacesMemorySink(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	sink := &MemorySink{}
+	service.MetricsSink = sink
+	service.observeLatency(LatencyQueueToWrite, 20*time.Millisecond)
+	service.observeLatency(LatencyQueueToWrite, 40*time.Millisecond)
+
+	require.Empty(t, service.MemoryMetrics().Histograms)
+	summary := sink.Snapshot().Histograms["queue_to_write_seconds"]
+	require.Equal(t, uint64(2), summary.Count)
+	require.InDelta(t, 0.06, summary.Sum, 1e-9)
+	require.InDelta(t, 0.02, summary.Min, 1e-9)
+	require.InDelta(t, 0.04, summary.Max, 1e-9)
+}
+
+// expvarSink adapts MetricsSink to the expvar package; an adapter for another telemetry stack looks the same.
+type expvarSink struct {
+	vars *expvar.Map
+}
+
+func (e expvarSink) Count(name string, delta uint64)  { e.vars.Add(name, int64(delta)) }
+func (e expvarSink) Gauge(name string, value float64) { e.vars.AddFloat(name, value-e.value(name)) }
+func (e expvarSink) Observe(name string, value float64) {
+	e.vars.AddFloat(name+"_sum", value)
+	e.vars.Add(name+"_count", 1)
+}
+
+func (e expvarSink) value(name string) float64 {
+	if v, ok := e.vars.Get(name).(*expvar.Float); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func ExampleMetricsSink() {
+	vars := new(expvar.Map) // expvar.NewMap("cat") to serve it on /debug/vars
+	var sink MetricsSink = expvarSink{vars: vars}
+	_ = &Service{MetricsSink: sink}
+
+	// The service reports like this from its workers.
+	sink.Count("commands_sent", 3)
+	sink.Gauge("send_queue_depth", 2)
+	sink.Observe("round_trip_seconds", 0.05)
+	fmt.Println(vars.String())
+	// Output: {"commands_sent": 3, "round_trip_seconds_count": 1, "round_trip_seconds_sum": 0.05, "send_queue_depth": 2}
+}
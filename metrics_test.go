@@ -0,0 +1,69 @@
+package cat
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRoundTripHistogram(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READVFOA", Cmd: "FA;"}, {Name: "SETVFOAFREQ", Cmd: "FA%s;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	shutdown := make(chan struct{})
+	sent := time.Now()
+
+	service.noteRoundTripSent(queuedCommand{CatCommand: types.CatCommand{Name: "SETVFOAFREQ"}}, sent.Add(-time.Second))
+	service.noteRoundTripSent(queuedCommand{CatCommand: types.CatCommand{Name: "READVFOA"}}, sent)
+	require.True(t, service.handleFrame(shutdown, []byte("FA00014074000"), sent.Add(30*time.Millisecond)))
+	require.True(t, service.handleFrame(shutdown, []byte("FA00014074000"), sent.Add(60*time.Millisecond)), "unsolicited")
+	require.True(t, service.handleFrame(shutdown, []byte("ZZ1"), sent))
+
+	m := service.Metrics()
+	require.Equal(t, uint64(1), m.ParseFailures)
+	require.Equal(t, uint64(1), m.RoundTrip.Count, "only the frame after the last write is timed")
+	require.Equal(t, 30*time.Millisecond, m.RoundTrip.Sum)
+	for _, b := range m.RoundTrip.Buckets {
+		want := uint64(0)
+		if b.UpperBound >= 50*time.Millisecond {
+			want = 1
+		}
+		require.Equal(t, want, b.Count, b.UpperBound)
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, service.WritePrometheus(&out))
+	require.Contains(t, out.String(), "cat_frames_unknown 1\n")
+	require.Contains(t, out.String(), "cat_round_trip_seconds_bucket{le=\"0.05\"} 1\n")
+	require.Contains(t, out.String(), "cat_round_trip_seconds_count 1\n")
+}
+
+// expvarSeq keeps the expvar names of the tests unique, as expvar has no way to unpublish, e.g. under -count=2.
+var expvarSeq atomic.Int64
+
+func TestPublishExpvarRejectsDuplicateName(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	name := fmt.Sprintf("%s_%d", t.Name(), expvarSeq.Add(1))
+
+	var wg sync.WaitGroup
+	var published atomic.Int64
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if service.PublishExpvar(name) == nil {
+				published.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(1), published.Load())
+	require.Error(t, service.PublishExpvar(name))
+}
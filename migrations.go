@@ -0,0 +1,104 @@
+package cat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+// currentSchemaVersion is the version of the cat-specific rig definition schema understood by this package.
+const currentSchemaVersion = 2
+
+// MigrationReport describes the migrations applied to a rig definition during Initialize.
+type MigrationReport struct {
+	FromVersion int
+	ToVersion   int
+	// Applied holds a human-readable description of every migration that changed the definition.
+	Applied []string
+}
+
+// migration upgrades a rig definition from version `from` to `from+1`. The apply function reports whether it
+// changed anything, so that no-op steps are not reported as applied.
+type migration struct {
+	from        int
+	description string
+	apply       func(cfg *types.RigConfig) bool
+}
+
+var migrations = []migration{
+	{
+		from:        0,
+		description: "converted legacy time.Duration CAT/serial timings (ListenerRateLimiterInterval etc.) to milliseconds",
+		apply:       migrateLegacyDurations,
+	},
+	{
+		from:        1,
+		description: "normalized CAT command names and state prefixes to trimmed upper case",
+		apply:       migrateNormalizeNames,
+	},
+}
+
+// migrateConfig upgrades cfg in place from the given schema version to currentSchemaVersion and returns a report of
+// the migrations that were applied. Versions newer than currentSchemaVersion are left untouched.
+func migrateConfig(cfg *types.RigConfig, fromVersion int) MigrationReport {
+	report := MigrationReport{FromVersion: fromVersion, ToVersion: fromVersion}
+	for _, m := range migrations {
+		if m.from < report.ToVersion {
+			continue
+		}
+		if m.apply(cfg) {
+			report.Applied = append(report.Applied, m.description)
+		}
+		report.ToVersion = m.from + 1
+	}
+	return report
+}
+
+// legacyDurationToMS converts a value that was written as a full time.Duration (nanoseconds) into the millisecond
+// count expected by the *MS fields. Values below one millisecond are assumed to already be in milliseconds.
+func legacyDurationToMS(d *time.Duration) bool {
+	if *d < time.Millisecond {
+		return false
+	}
+	*d /= time.Millisecond
+	return true
+}
+
+// migrateLegacyDurations handles definitions written before the timing fields gained the MS suffix, when they were
+// decoded as plain time.Duration values (e.g. ListenerRateLimiterInterval: 10000000 for 10ms).
+func migrateLegacyDurations(cfg *types.RigConfig) bool {
+	changed := false
+	for _, d := range []*time.Duration{
+		&cfg.CatConfig.ListenerRateLimiterIntervalMS,
+		&cfg.CatConfig.ListenerReadTimeoutMS,
+		&cfg.SerialConfig.ReadTimeoutMS,
+		&cfg.SerialConfig.WriteTimeoutMS,
+	} {
+		if legacyDurationToMS(d) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// migrateNormalizeNames brings command names in line with the cmds enum and prefixes in line with state lookup,
+// both of which are upper case.
+func migrateNormalizeNames(cfg *types.RigConfig) bool {
+	changed := false
+	for i := range cfg.CatCommands {
+		name := strings.ToUpper(strings.TrimSpace(cfg.CatCommands[i].Name))
+		if name != cfg.CatCommands[i].Name {
+			cfg.CatCommands[i].Name = name
+			changed = true
+		}
+	}
+	for i := range cfg.CatStates {
+		prefix := asciiUpper(strings.TrimSpace(cfg.CatStates[i].Prefix))
+		if prefix != "" && prefix != cfg.CatStates[i].Prefix {
+			cfg.CatStates[i].Prefix = prefix
+			changed = true
+		}
+	}
+	return changed
+}
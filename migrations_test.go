@@ -0,0 +1,38 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateConfigFromLegacy(t *testing.T) {
+	cfg := &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: " init ", Cmd: "ID;"}},
+		CatStates:   []types.CatState{{Prefix: "fa"}},
+		CatConfig: types.CatConfig{
+			ListenerRateLimiterIntervalMS: 10 * time.Millisecond, // legacy nanosecond value
+			ListenerReadTimeoutMS:         8,                     // already in milliseconds
+		},
+	}
+
+	report := migrateConfig(cfg, 0)
+	require.Equal(t, 0, report.FromVersion)
+	require.Equal(t, currentSchemaVersion, report.ToVersion)
+	require.Len(t, report.Applied, 2)
+
+	require.Equal(t, time.Duration(10), cfg.CatConfig.ListenerRateLimiterIntervalMS)
+	require.Equal(t, time.Duration(8), cfg.CatConfig.ListenerReadTimeoutMS)
+	require.Equal(t, "INIT", cfg.CatCommands[0].Name)
+	require.Equal(t, "FA", cfg.CatStates[0].Prefix)
+}
+
+func TestMigrateConfigCurrentVersionIsNoop(t *testing.T) {
+	cfg := &types.RigConfig{CatStates: []types.CatState{{Prefix: "fa"}}}
+
+	report := migrateConfig(cfg, currentSchemaVersion)
+	require.Empty(t, report.Applied)
+	require.Equal(t, "fa", cfg.CatStates[0].Prefix)
+}
@@ -0,0 +1,154 @@
+package cat
+
+import (
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/modes"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/types"
+)
+
+// TagSMeter is the tag normalized to NormalizedStatus.SMeterDB. Rig definitions report the S-meter under this
+// tag; its conversion to dB is rig-specific and must be declared in Options.Conversions.
+const TagSMeter = "SMETER"
+
+// Unit is the unit of a normalized value.
+type Unit string
+
+const (
+	UnitNone  Unit = ""
+	UnitHz    Unit = "Hz"
+	UnitWatts Unit = "W"
+	UnitDB    Unit = "dB"
+)
+
+// Conversion turns the raw value of a tag into a number: value = raw * Scale + Offset. For example, a rig
+// reporting power as 0-255 for 0-100W uses Scale 100/255.
+type Conversion struct {
+	// Encoding is the wire encoding of the raw value. BCD values are decoded when the frame is parsed, as with
+	// StateOptions.Encodings. Empty means ASCII decimal.
+	Encoding ValueEncoding
+	// Scale multiplies the raw value. Zero means 1.
+	Scale float64
+	// Offset is added after scaling.
+	Offset float64
+	Unit   Unit
+}
+
+// apply converts a raw value. ok is false if the value is not a number.
+func (c Conversion) apply(raw string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0, false
+	}
+	scale := c.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return v*scale + c.Offset, true
+}
+
+// defaultConversions apply to the frequency and power tags unless Options.Conversions overrides them.
+var defaultConversions = map[string]Conversion{
+	tags.VfoAFreq.String(): {Unit: UnitHz},
+	tags.VfoBFreq.String(): {Unit: UnitHz},
+	tags.TxPwr.String():    {Unit: UnitWatts},
+}
+
+// NormalizedValue is a converted tag value.
+type NormalizedValue struct {
+	Value float64
+	Unit  Unit
+}
+
+// NormalizedStatus is the rig state as typed values instead of raw substrings of the rig's frames. Fields of tags
+// not reported, or not convertible, are zero; Values tells which tags were converted.
+type NormalizedStatus struct {
+	VfoAHz int64
+	VfoBHz int64
+	// Mode is the ADIF mode of MAINMODE, e.g. SSB for "USB", and SubMode its submode if it has one.
+	Mode    modes.Mode
+	SubMode modes.SubMode
+	PowerW  float64
+	// SMeterDB is the S-meter reading, if a conversion for TagSMeter is declared.
+	SMeterDB float64
+	// Values holds every converted tag, including those without a field of their own.
+	Values map[string]NormalizedValue
+}
+
+// Normalized returns the cached rig state as typed values.
+func (s *Service) Normalized() NormalizedStatus {
+	return s.Normalize(s.CurrentState())
+}
+
+// Normalize converts status, as held by CurrentState or published on TopicStatus, into typed values using
+// Options.Conversions. Values translated for display, as on the status channel, may not normalize.
+func (s *Service) Normalize(status types.CatStatus) NormalizedStatus {
+	conversions := maps.Clone(defaultConversions)
+	maps.Copy(conversions, s.Options.Conversions)
+
+	n := NormalizedStatus{Values: make(map[string]NormalizedValue)}
+	for tag, conv := range conversions {
+		raw, ok := status[tag]
+		if !ok {
+			continue
+		}
+		v, ok := conv.apply(raw)
+		if !ok {
+			s.logger().DebugWith().Str("tag", tag).Str("value", raw).Msg("value not normalized: not a number")
+			continue
+		}
+		n.Values[tag] = NormalizedValue{Value: v, Unit: conv.Unit}
+		switch tag {
+		case tags.VfoAFreq.String():
+			n.VfoAHz = int64(math.Round(v))
+		case tags.VfoBFreq.String():
+			n.VfoBHz = int64(math.Round(v))
+		case tags.TxPwr.String():
+			n.PowerW = v
+		case TagSMeter:
+			n.SMeterDB = v
+		}
+	}
+	if raw, ok := status[tags.MainMode.String()]; ok {
+		n.Mode, n.SubMode = normalizeMode(raw)
+	}
+	return n
+}
+
+// normalizeMode maps a mode value such as "USB", "CW-R" or "RTTY-LSB" to its ADIF mode and submode. Suffixes
+// after "-" select rig variants of the same mode and are dropped. Unknown values give an empty mode.
+func normalizeMode(value string) (modes.Mode, modes.SubMode) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	if mode, ok := modes.GetModeBySubmode(v); ok {
+		return mode, modes.SubMode(v)
+	}
+	if modes.IsValidMode(v) {
+		return modes.Mode(v), ""
+	}
+	if i := strings.IndexByte(v, '-'); i > 0 {
+		return normalizeMode(v[:i])
+	}
+	return "", ""
+}
+
+// conversionFor returns the conversion of tag from Options.Conversions or the defaults. A tag without one is read
+// as an unscaled decimal.
+func (s *Service) conversionFor(tag string) Conversion {
+	if conv, ok := s.Options.Conversions[tag]; ok {
+		return conv
+	}
+	return defaultConversions[tag]
+}
+
+// conversionEncoding returns the wire encoding declared for tag in Options.Conversions.
+func (s *Service) conversionEncoding(tag string) (ValueEncoding, bool) {
+	conv, ok := s.Options.Conversions[tag]
+	if !ok || conv.Encoding == "" {
+		return "", false
+	}
+	return conv.Encoding, true
+}
@@ -0,0 +1,60 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/modes"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeConvertsTypedFields(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Conversions = map[string]Conversion{
+		"TXPWR":   {Scale: 100.0 / 255, Unit: UnitWatts},
+		TagSMeter: {Scale: 2, Offset: -127, Unit: UnitDB},
+	}
+
+	n := service.Normalize(types.CatStatus{
+		"VFOAFREQ": "00014074000",
+		"VFOBFREQ": "garbage",
+		"MAINMODE": "USB",
+		"TXPWR":    "255",
+		TagSMeter:  "0027",
+		"SPLIT":    "1",
+	})
+	require.Equal(t, int64(14074000), n.VfoAHz)
+	require.Zero(t, n.VfoBHz)
+	require.Equal(t, modes.SSB, n.Mode)
+	require.Equal(t, modes.SubMode("USB"), n.SubMode)
+	require.InDelta(t, 100, n.PowerW, 1e-9)
+	require.Equal(t, -73.0, n.SMeterDB)
+	require.Equal(t, NormalizedValue{Value: 14074000, Unit: UnitHz}, n.Values["VFOAFREQ"])
+	require.NotContains(t, n.Values, "VFOBFREQ", "values that are not numbers are left out")
+	require.NotContains(t, n.Values, "SPLIT", "tags without a conversion are left out")
+}
+
+func TestNormalizeMode(t *testing.T) {
+	for value, want := range map[string]modes.Mode{
+		"LSB":      modes.SSB,
+		"cw":       modes.CW,
+		"CW-R":     modes.CW,
+		"RTTY-LSB": modes.RTTY,
+		"DATA-U":   "",
+	} {
+		mode, _ := normalizeMode(value)
+		require.Equal(t, want, mode, value)
+	}
+}
+
+func TestDriverEncodingAppliesToReceivedFrames(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, DriverIcomIC7300)
+	require.NoError(t, service.Initialize())
+
+	state, ok := service.lookupCatState([]byte("030040071400"))
+	require.True(t, ok)
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, "14074000", status["VFOAFREQ"])
+	require.Equal(t, int64(14074000), service.Normalize(status).VfoAHz)
+}
@@ -0,0 +1,57 @@
+package cat
+
+import (
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultNotificationChannelSize is used when Options.NotificationChannelSize is zero.
+	defaultNotificationChannelSize = 16
+)
+
+// Severity classifies how urgently a notification needs the operator's attention.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "INFO"
+	SeverityWarning  Severity = "WARNING"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+// Notification is an operator-facing message, e.g. "Rig reconnected" or "TX lockout engaged". Unlike log entries
+// these are meant to be shown in the UI, so they carry a short title and, where possible, a suggested action.
+type Notification struct {
+	Time            time.Time
+	Severity        Severity
+	Title           string
+	Message         string
+	SuggestedAction string
+}
+
+// Notifications returns a channel of operator-facing notifications or an error if the service is uninitialized.
+// When the consumer falls behind, the oldest undelivered notification is discarded.
+func (s *Service) Notifications() (<-chan Notification, error) {
+	const op errors.Op = "cat.Service.Notifications"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	return s.notificationChannel, nil
+}
+
+// notify queues a notification for the operator without blocking the caller.
+func (s *Service) notify(severity Severity, title, message, action string) {
+	n := Notification{
+		Time:            time.Now(),
+		Severity:        severity,
+		Title:           title,
+		Message:         message,
+		SuggestedAction: action,
+	}
+	s.publish(TopicNotification, n)
+	s.route(TopicSegmentNotification, n, n.Time)
+	if !offerEvicting(s.notificationChannel, n) {
+		s.logger().DebugWith().Str("title", title).Msg("dropping cat notification: channel unavailable")
+	}
+}
@@ -0,0 +1,922 @@
+package cat
+
+import (
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+)
+
+// Options holds cat-specific settings that are not part of types.RigConfig. The zero value is valid and leaves
+// every optional feature disabled, so embedders only need to set the sections they use.
+type Options struct {
+	// SchemaVersion is the version of the cat-specific rig definition being supplied. Definitions older than the
+	// version understood by this package are migrated automatically at Initialize; zero means unversioned (legacy).
+	SchemaVersion int
+
+	// CacheTTLMS is how long a cached tag value is considered fresh by the typed getters. The unit is milliseconds.
+	//
+	// Default is 500ms.
+	CacheTTLMS time.Duration
+
+	// TagTTLMS overrides CacheTTLMS for individual tags, so that slow-changing values (identity, firmware, antenna)
+	// are not re-polled unnecessarily. A negative value means a cached value never expires once it has been read.
+	// The unit is milliseconds.
+	TagTTLMS map[string]time.Duration
+
+	// ReadCommands maps a state tag to the command that makes the rig report it. Tags without an entry are
+	// refreshed with the general READ command.
+	ReadCommands map[string]cmds.CatCmdName
+
+	// CommandGuards declares, per command, the rig models and firmware versions that support it. Commands are
+	// rejected once the rig's identity shows they are unsupported.
+	CommandGuards map[cmds.CatCmdName]CommandGuard
+	// FirmwareTag is the state tag reporting the rig's firmware version, for CommandGuards.MinFirmware.
+	FirmwareTag string
+
+	// Rigctld connects to a Hamlib rigctld endpoint instead of a serial port.
+	Rigctld RigctldOptions
+
+	// Protocol is the rig's wire protocol. Empty means ProtocolASCIILine.
+	Protocol Protocol
+	// CIV configures the CI-V protocol.
+	CIV CIVOptions
+	// Capabilities, when set, describes what the rig supports in place of the driver's capabilities, e.g. for a rig
+	// without a built-in driver. See Service.Capabilities.
+	Capabilities *Capabilities
+	// Quirks declares quirks of the rig in addition to those of its driver, e.g. QuirkSlowModeChange.
+	Quirks []Quirk
+	// QuirkOptions tunes the workarounds of the quirks.
+	QuirkOptions QuirkOptions
+	// Driver selects a built-in rig driver by name, e.g. DriverKenwoodTS590, which supplies the wire protocol and
+	// a rig definition for whatever the configured one leaves out. Empty means the generic driver, configured
+	// entirely by the rig definition and Protocol.
+	Driver string
+	// Transport replaces the serial port: TransportSimulator simulates the rig in process, for tests and demos
+	// without hardware. Empty means the serial port, or rigctld if Rigctld is configured.
+	Transport string
+	// Simulator configures the simulated rig of TransportSimulator.
+	Simulator SimulatorOptions
+	// Framing reassembles the frames of the transport's reads, for links that split or merge responses.
+	Framing FramingOptions
+
+	// ParseMode is how marker violations in received frames are handled. Empty means ParseLenient.
+	ParseMode ParseMode
+	// CaseSensitivePrefixes matches state prefixes exactly instead of ignoring the case of ASCII letters, for
+	// protocols in which "fa" and "FA" are different frames. Definitions relying on it must declare
+	// SchemaVersion 2 or later, as older definitions have their prefixes upper-cased by migration.
+	CaseSensitivePrefixes bool
+	// StateOptions holds per-state settings, keyed by state prefix, e.g. a strict parse mode for one state.
+	StateOptions map[string]StateOptions
+
+	// ResponsePrefixes overrides the state prefix SendCommand waits for, for commands whose response prefix
+	// cannot be derived from the command template.
+	ResponsePrefixes map[cmds.CatCmdName]string
+	// ResponseTimeoutMS is how long SendCommand waits for a response when its context has no deadline. The unit
+	// is milliseconds.
+	//
+	// Default is 1000ms.
+	ResponseTimeoutMS time.Duration
+
+	// Automation declares rig actions run at times of day.
+	Automation AutomationOptions
+
+	// Polls are commands the service enqueues periodically to keep the rig state fresh, e.g. frequency every
+	// 250ms and mode every second. Empty disables the built-in poller.
+	Polls []PollEntry
+
+	// Priority configures the priority queues of the sender.
+	Priority PriorityOptions
+	// Fairness shares the normal priority between command origins.
+	Fairness FairnessOptions
+
+	// RateLimit paces the sender for rigs that lock up when commands arrive back-to-back.
+	RateLimit RateLimitOptions
+
+	// Presence configures detection of a powered-off rig.
+	Presence PresenceOptions
+	// Health configures the health monitor, see Service.Health.
+	Health HealthOptions
+
+	// AutoInfo switches the rig to auto-information mode, in which it reports changes without being polled.
+	AutoInfo AutoInfoOptions
+
+	// Banner emits a RigConnectedEvent once Start has connected to the rig.
+	Banner BannerOptions
+
+	// BaudProbe negotiates the baud rate on Start, for setups where the configured rate may be wrong.
+	BaudProbe BaudProbeOptions
+
+	// Latency sets budgets for the pipeline stages and notifies the operator when they are persistently exceeded.
+	Latency LatencyOptions
+
+	// Tune describes how Tune sequences the frequency and mode commands for this rig.
+	Tune TuneOptions
+
+	// QuickSplit configures the offsets used by QuickSplit.
+	QuickSplit QuickSplitOptions
+
+	// Recovery configures the soft reset sequence used by RecoverRig.
+	Recovery RecoveryOptions
+
+	// Desync configures detection of protocol desync from the ratio of unknown frames.
+	Desync DesyncOptions
+
+	// StatusDiff emits only the fields that changed instead of a full status for every frame.
+	StatusDiff StatusDiffOptions
+	// Topics names the topics of SubscribeTopic.
+	Topics TopicOptions
+
+	// EventChannelSize is the buffer size of the Events channel.
+	//
+	// Default is 16.
+	EventChannelSize int
+
+	// NotificationChannelSize is the buffer size of the Notifications channel.
+	//
+	// Default is 16.
+	NotificationChannelSize int
+
+	// Transverters convert the rig's IF to bands it does not cover; see Transverter.
+	Transverters []Transverter
+	// CalibrationPPM corrects the rig's frequency reference: a rig whose actual frequency is 1.5 ppm above its
+	// display uses 1.5. It is applied to every frequency written and read.
+	CalibrationPPM float64
+
+	// BandPlan maps frequencies to bands. Empty means the built-in plan covering the widest IARU allocations.
+	BandPlan []BandRange
+	// BandMap keeps the recently visited frequencies of each band, see Service.BandMap.
+	BandMap BandMapOptions
+
+	// PowerLimits is the maximum transmit power in watts per band, e.g. for licence or amplifier constraints.
+	PowerLimits map[bands.Band]int
+	// PowerLimitAction decides whether requests above the limit are clamped or rejected. Empty means clamp.
+	PowerLimitAction PowerLimitAction
+
+	// AvoidRanges are frequency ranges that tuning and transmit commands must not enter without confirmation.
+	AvoidRanges []AvoidRange
+	// TxCommands lists the commands, besides PTTON, that key the transmitter, checked against the avoid ranges.
+	TxCommands []cmds.CatCmdName
+
+	// AutoModeSegments are frequency segments that select their mode automatically when VFO A is tuned into them.
+	// Empty disables the feature.
+	AutoModeSegments []ModeSegment
+
+	// SuppressDuplicates skips set commands whose value equals the fresh cached rig state, e.g. setting USB while
+	// already in USB. Use Force to send such a command anyway.
+	SuppressDuplicates bool
+	// DuplicateTags maps further set commands to the tag reporting the value they set, for duplicate suppression
+	// and read-after-write verification. The frequency, mode and power commands of this package are covered
+	// without an entry.
+	DuplicateTags map[cmds.CatCmdName]tags.CatStateTag
+
+	// Verify reads set commands back from the rig to catch commands that are silently ignored.
+	Verify VerifyOptions
+	// OutcomeEvents emits a CommandOutcomeEvent with the final outcome of every command that is not a poll.
+	OutcomeEvents bool
+	// PTT configures the keying watchdog.
+	PTT PTTOptions
+	// BreakIn configures CW break-in keying with Key and KeyAt.
+	BreakIn BreakInOptions
+	// CW configures sending text with the rig's CW keyer; see SendCW.
+	CW CWOptions
+	// ControlLines keys PTT and CW with serial control lines instead of CAT commands.
+	ControlLines ControlLineOptions
+	// SyntheticStates derive status values from the commands sent, for values the rig cannot report.
+	SyntheticStates []SyntheticState
+	// Conversions declare how raw tag values are converted into the typed values of Normalized, keyed by tag.
+	// VFOAFREQ and VFOBFREQ default to Hz and TXPWR to watts, unscaled.
+	Conversions map[string]Conversion
+
+	// Rotator emits beam heading suggestions as VFO A changes band.
+	Rotator RotatorOptions
+
+	// Meters streams high-rate meter readings on MeterChannel.
+	Meters MeterOptions
+	// Remote configures the updates of SubscribeRemote for operation over high-latency links.
+	Remote RemoteOptions
+
+	// Persistence selects which features write to the Service's Store.
+	Persistence PersistenceOptions
+
+	// Diagnostics sizes the history kept for ExportDiagnostics.
+	Diagnostics DiagnosticsOptions
+
+	// CacheBudget bounds the memory used by the rig state cache.
+	CacheBudget CacheBudgetOptions
+
+	// WriteRetry configures retrying of transient write errors.
+	WriteRetry WriteRetryOptions
+
+	// RawTraffic enables RawTrafficChannel.
+	RawTraffic RawTrafficOptions
+	// TrafficCapture records the raw traffic to rotating capture files, for replay with a ReplayTransport.
+	TrafficCapture TrafficCaptureOptions
+
+	// Bulk configures how bulk transfers are time-sliced against regular traffic.
+	Bulk BulkOptions
+
+	// ReadModifyWrite controls the masked read-modify-write helpers.
+	ReadModifyWrite ReadModifyWriteOptions
+
+	// Reconnect configures how a lost serial link is re-established.
+	Reconnect ReconnectOptions
+
+	// Keepalive configures writes during quiet periods for adapters that drop an idle port.
+	Keepalive KeepaliveOptions
+
+	// Memory describes the rig's memory channel layout.
+	Memory MemoryOptions
+	// ResponsePolicies make the sender wait for the response to the listed commands and resend them if none
+	// arrives. The response is recognised as by SendCommand.
+	ResponsePolicies map[cmds.CatCmdName]ResponsePolicy
+	// Backup lists the menu and level settings saved by ExportRigState.
+	Backup BackupOptions
+	// Station declares the sequences run by PowerUpStation and PowerDownStation.
+	Station StationOptions
+
+	// Debug contains settings intended for development and resilience testing only.
+	Debug DebugOptions
+}
+
+// TuneOptions holds the rig-specific ordering quirks for a coordinated frequency and mode change.
+type TuneOptions struct {
+	// Order is the order in which the frequency and mode are sent. Empty means TuneFrequencyFirst.
+	Order TuneOrder
+	// DelayMS is the pause the sender keeps between the two commands, for rigs that need time to settle. The unit
+	// is milliseconds.
+	DelayMS time.Duration
+}
+
+// RemoteOptions configures remote mode, in which SubscribeRemote sends coalesced status deltas and rate-limited
+// meter readings, for frontends on mobile or satellite links with round trips of 300ms and more.
+type RemoteOptions struct {
+	Enabled bool
+	// FlushIntervalMS is the interval at which pending changes are sent as one update. The unit is milliseconds.
+	//
+	// Default is 250ms.
+	FlushIntervalMS time.Duration
+	// MeterIntervalMS is the minimum interval between two updates carrying meter readings; the remote side
+	// predicts the meters in between, see MeterPredictor. The unit is milliseconds.
+	//
+	// Default is 1000ms.
+	MeterIntervalMS time.Duration
+}
+
+// BannerOptions configures the RigConnectedEvent emitted after Start.
+type BannerOptions struct {
+	Enabled bool
+	// Command is sent to measure the response latency and to read the identity. Empty means the read command of
+	// IDENTITY, see Options.ReadCommands.
+	Command cmds.CatCmdName
+	// TimeoutMS is how long the rig may take to answer Command. The unit is milliseconds.
+	//
+	// Default is 2000ms.
+	TimeoutMS time.Duration
+}
+
+// BaudProbeOptions configures the baud rate negotiation of Start. The configured rate is tried first, then the
+// candidates in order; the port stays open at the first rate the rig answers, and a BaudRateEvent reports it. If
+// no rate is answered, e.g. because the rig is off, the configured rate is used.
+type BaudProbeOptions struct {
+	Enabled bool
+	// BaudRates are the candidate rates. Empty means 38400, 115200, 19200, 9600 and 4800 baud.
+	BaudRates []int
+	// Command is sent at every rate; a reply matching a configured state locks the rate. Empty means the read
+	// command of IDENTITY, see Options.ReadCommands.
+	Command cmds.CatCmdName
+	// TimeoutMS is how long the rig may take to answer Command at each rate. The unit is milliseconds.
+	//
+	// Default is 300ms.
+	TimeoutMS time.Duration
+}
+
+// TopicOptions names the topics of SubscribeTopic, "cat.<rig>.<name>".
+type TopicOptions struct {
+	// Rig is the rig segment of the topic names. Empty means "rig" followed by the rig ID, e.g. "rig1".
+	Rig string
+	// Names maps a state tag to the last segment of its topic, overriding the defaults ("freq" for VFOAFREQ,
+	// "mode" for MAINMODE, ...). Tags without a name use the tag in lower case, e.g. "ptt" for PTT.
+	Names map[string]string
+}
+
+// StatusDiffOptions configures status diffing. Statuses carry only the tags whose values changed since they were
+// last emitted; frames that change nothing emit no status.
+type StatusDiffOptions struct {
+	Enabled bool
+	// HeartbeatMS re-emits every last emitted value at this interval, even if nothing changed, so that consumers
+	// can tell a quiet rig from a dead link. Zero disables heartbeats. The unit is milliseconds.
+	HeartbeatMS time.Duration
+}
+
+// QuickSplitOptions configures the VFO B offset chosen by QuickSplit.
+type QuickSplitOptions struct {
+	// Offsets are tried in order; the first one matching the current band and mode is used.
+	Offsets []SplitOffset
+	// DefaultOffsetHz is used when no offset matches.
+	//
+	// Default is 5000Hz.
+	DefaultOffsetHz int64
+}
+
+// RecoveryOptions configures the rig recovery sequence and when it runs automatically.
+type RecoveryOptions struct {
+	// Commands is the recovery sequence. Empty means INIT followed by READ.
+	Commands []cmds.CatCmdName
+	// StepDelayMS is the pause between recovery commands. The unit is milliseconds.
+	StepDelayMS time.Duration
+	// UnknownFrameThreshold triggers an automatic recovery after this many consecutive frames that match no
+	// configured state. Zero disables automatic recovery.
+	UnknownFrameThreshold int
+}
+
+// DesyncOptions configures the sliding-window desync detector.
+type DesyncOptions struct {
+	// UnknownRatio is the share (0-1) of unknown frames over a full window that counts as a desync. Zero disables
+	// detection.
+	UnknownRatio float64
+	// WindowSize is the number of most recent frames considered.
+	//
+	// Default is 50.
+	WindowSize int
+	// Recover runs RecoverRig automatically when a desync is detected.
+	Recover bool
+}
+
+// PersistenceOptions selects the persistence features. They are only active when a Store is set on the Service.
+type PersistenceOptions struct {
+	// LastState saves the merged rig state while it changes, at most once per LastStateIntervalMS, and when the
+	// service stops.
+	LastState bool
+	// LastStateIntervalMS is the minimum interval between two saves of the last state while the service runs.
+	// The unit is milliseconds.
+	//
+	// Default is 2000ms.
+	LastStateIntervalMS time.Duration
+	// AuditLog appends every command written to the rig.
+	AuditLog bool
+	// QSYHistory appends every VFO A frequency change.
+	QSYHistory bool
+	// QueueSize is the number of audit and QSY records waiting to be appended; records arriving while it is full
+	// are dropped and counted, so that a slow Store never slows down the sender or the processor.
+	//
+	// Default is 256.
+	QueueSize int
+}
+
+// DiagnosticsOptions sizes the recent history kept for diagnostics bundles.
+type DiagnosticsOptions struct {
+	// WireCaptureSize is the number of most recent raw frames kept.
+	//
+	// Default is 200.
+	WireCaptureSize int
+	// ErrorHistorySize is the number of most recent errors kept.
+	//
+	// Default is 50.
+	ErrorHistorySize int
+	// WireCaptureMaxBytes additionally drops the oldest raw frames while the capture holds more than this many
+	// (approximate) bytes, e.g. during bulk memory transfers. Zero means no byte budget.
+	WireCaptureMaxBytes int
+	// ErrorHistoryMaxBytes does the same for the error history. Zero means no byte budget.
+	ErrorHistoryMaxBytes int
+}
+
+// CacheBudgetOptions bounds the rig state cache for long-running or memory-constrained deployments. When either
+// limit is exceeded the least recently updated or read tags are evicted; they are read from the rig again when next
+// needed. The zero value leaves the cache unbounded.
+type CacheBudgetOptions struct {
+	// MaxEntries is the maximum number of cached tags.
+	MaxEntries int
+	// MaxBytes is the maximum approximate size of the cached tags and values.
+	MaxBytes int
+}
+
+// BulkOptions configures the time-slicing of bulk transfers.
+type BulkOptions struct {
+	// SliceSize is the number of bulk commands written before pausing for regular traffic.
+	//
+	// Default is 4.
+	SliceSize int
+	// YieldMS is the pause after each slice, leaving the rig time to answer regular polls. The unit is
+	// milliseconds.
+	//
+	// Default is 50ms.
+	YieldMS time.Duration
+}
+
+// WriteRetryOptions configures retrying of transient write errors, such as a full driver buffer or a write
+// timeout. Fatal errors are never retried.
+type WriteRetryOptions struct {
+	// Attempts is the total number of times a frame is written before the error is surfaced.
+	//
+	// Default is 3.
+	Attempts int
+	// DelayMS is the delay before the first retry; it doubles for every further retry. The unit is milliseconds.
+	//
+	// Default is 10ms.
+	DelayMS time.Duration
+}
+
+// PriorityOptions configures the priority queues of the sender.
+type PriorityOptions struct {
+	// LowStaleMS drops low-priority commands, e.g. polls, that waited longer than this behind higher priorities.
+	// Zero never drops them. The unit is milliseconds.
+	LowStaleMS time.Duration
+}
+
+// FairnessOptions configures weighted fair queuing of normal-priority commands between origins, so that a
+// runaway macro or network client cannot starve interactive UI commands. High-priority commands and polls are not
+// affected.
+type FairnessOptions struct {
+	Enabled bool
+	// Weights are the shares of the origins, e.g. 4 for OriginUI and 1 for OriginMacro. Origins without an entry
+	// have weight 1.
+	Weights map[Origin]int
+}
+
+// RateLimitOptions paces the sender. The zero value writes commands as fast as the link allows.
+type RateLimitOptions struct {
+	// InterCommandDelayMS is the minimum gap between two commands. The unit is milliseconds.
+	InterCommandDelayMS time.Duration
+	// Origins limits the rate of commands per origin, e.g. 10 per second for OriginPoller. Origins without an
+	// entry are not limited.
+	Origins map[Origin]RateLimit
+}
+
+// PresenceOptions configures detection of a powered-off rig: commands are written successfully but nothing is
+// received for TimeoutMS. Polling is then suspended and ProbeCommand is sent every ProbeIntervalMS until the rig
+// answers again.
+type PresenceOptions struct {
+	Enabled bool
+	// TimeoutMS is how long the rig may stay silent while commands are written. The unit is milliseconds.
+	//
+	// Default is 5000ms.
+	TimeoutMS time.Duration
+	// ProbeIntervalMS is the interval of the presence probe while the rig is off. The unit is milliseconds.
+	//
+	// Default is 5000ms.
+	ProbeIntervalMS time.Duration
+	// ProbeCommand is the command used to probe the rig. Empty means READ.
+	ProbeCommand cmds.CatCmdName
+}
+
+// HealthOptions configures the health monitor, which judges the link by how long written commands have gone
+// without a recognised response.
+type HealthOptions struct {
+	Enabled bool
+	// DegradedAfterMS is how long the rig may leave commands unanswered before the link is degraded. The unit is
+	// milliseconds.
+	//
+	// Default is 3000ms.
+	DegradedAfterMS time.Duration
+	// DisconnectedAfterMS is how long the rig may leave commands unanswered before the link is considered
+	// disconnected. The unit is milliseconds.
+	//
+	// Default is 10000ms.
+	DisconnectedAfterMS time.Duration
+	// IntervalMS is the interval of the health check. The unit is milliseconds.
+	//
+	// Default is 1000ms.
+	IntervalMS time.Duration
+	// Reconnect reopens the port when the link is considered disconnected, falling back to the reconnect logic
+	// of Options.Reconnect if that fails.
+	Reconnect bool
+}
+
+// VerifyOptions configures read-after-write verification, for rigs (many Yaesu models) that silently ignore
+// invalid commands. After a set command with a known tag (see Options.DuplicateTags) has been written, the tag is
+// read back and a CommandFailedEvent is emitted if the rig does not report the value that was set.
+type VerifyOptions struct {
+	Enabled bool
+	// TimeoutMS is how long to wait for the rig to report the tag after the write. The unit is milliseconds.
+	//
+	// Default is 500ms.
+	TimeoutMS time.Duration
+}
+
+// PTTOptions configures the PTT watchdog, which unkeys a transmitter left keyed, e.g. by a crashed frontend.
+type PTTOptions struct {
+	// MaxTxMS is the longest the transmitter may stay keyed before the watchdog sends PTTOFF. The unit is
+	// milliseconds. Zero disables the watchdog.
+	MaxTxMS time.Duration
+	// RetryMS is how long the watchdog waits for PTTOFF to be written and the transmitter to read back unkeyed
+	// before it sends PTTOFF again. It keeps trying while the transmitter stays keyed. The unit is milliseconds.
+	//
+	// Default is 1000.
+	RetryMS time.Duration
+}
+
+// BreakInOptions configures CW break-in (QSK) keying through CAT commands.
+type BreakInOptions struct {
+	// KeyDownCommand and KeyUpCommand name the commands keying and unkeying the transmitter. Empty means
+	// KEYDOWN and KEYUP.
+	KeyDownCommand cmds.CatCmdName
+	KeyUpCommand   cmds.CatCmdName
+	// SpinMS is how long before a scheduled transition KeyAt stops sleeping and spins. The unit is milliseconds.
+	//
+	// Default is 2ms.
+	SpinMS time.Duration
+}
+
+// CWOptions configures how SendCW hands text to the rig's keyer.
+type CWOptions struct {
+	// ChunkSize is the number of characters per SENDCW command.
+	//
+	// Default is 24.
+	ChunkSize int
+	// Pad pads every chunk to ChunkSize with spaces, as Kenwood's KY command requires.
+	Pad bool
+	// Hex sends chunks as hex-encoded ASCII, as Icom's 0x17 command requires with CI-V.
+	Hex bool
+	// BufferPrefix is the prefix of the response to READCWBUFFER, e.g. "KY"; it must be a configured state.
+	// Empty means buffer space is estimated from the keyer speed.
+	BufferPrefix string
+	// BufferFullValue is the value of that response while the buffer is full. Empty means "1".
+	BufferFullValue string
+	// BufferPollMS is how often a full buffer is asked again. The unit is milliseconds.
+	//
+	// Default is 100ms.
+	BufferPollMS time.Duration
+	// LeadMS is how long before the estimated end of a chunk the next one is sent, without BufferPrefix. The
+	// unit is milliseconds.
+	//
+	// Default is 500ms.
+	LeadMS time.Duration
+	// DefaultWPM is the keyer speed of the rig, used to pace the chunks when SendCW keeps the rig's speed and
+	// BufferPrefix is empty. Without either, SendCW requires a speed.
+	DefaultWPM int
+	// MaxLength is the longest text SendCW accepts, in characters.
+	//
+	// Default is 500.
+	MaxLength int
+}
+
+// ControlLineOptions selects the serial control lines keying the rig, e.g. RTS for PTT and DTR for a straight key
+// on a common interface. Keying by line is immediate and is not queued; PTT and CW keyed by line are covered by
+// the PTT watchdog like CAT PTT.
+type ControlLineOptions struct {
+	// PTT is the line keying the transmitter for SetPTT and PTT. Empty means the PTTON and PTTOFF commands.
+	PTT ControlLine
+	// CW is the line keying CW for Key and KeyAt. Empty means Service.KeyLine or CAT commands.
+	CW ControlLine
+	// Port is the serial port carrying the lines, e.g. "/dev/ttyUSB1". It is required with PTT or CW, as the
+	// lines of the CAT port cannot be set through the CAT transport. It is opened with the lines released.
+	Port string
+	// Invert keys with the lines low, for interfaces with an inverting driver.
+	Invert bool
+}
+
+// AutomationOptions declares scheduled rig actions; see ScheduledActions.
+type AutomationOptions struct {
+	Actions []ScheduledAction
+	// Location is the time zone of the actions' times of day. Nil means the local time zone.
+	Location *time.Location
+}
+
+// MeterOptions configures meter streaming; see MeterChannel.
+type MeterOptions struct {
+	Enabled bool
+	// Commands are polled at IntervalMS to read the meters. Empty means READSMETER and READPOWERMETER, if the
+	// rig definition provides them.
+	Commands []cmds.CatCmdName
+	// IntervalMS is the meter polling interval, independent of Options.Polls. The unit is milliseconds.
+	//
+	// Default is 100ms.
+	IntervalMS time.Duration
+	// Tags are the tags delivered as meter readings instead of status updates. Empty means SMETER and
+	// POWERMETER.
+	Tags []string
+	// Smoothing is the weight (0-1) of the previous smoothed value in MeterReading.Smoothed. Zero disables
+	// smoothing; 0.8 gives a slow needle.
+	Smoothing float64
+	// PeakHoldMS is how long MeterReading.Peak holds the highest value. Zero disables peak hold. The unit is
+	// milliseconds.
+	PeakHoldMS time.Duration
+	// ChannelSize is the buffer size of the meter channel.
+	//
+	// Default is 16.
+	ChannelSize int
+}
+
+// RotatorOptions configures the rotator-follow events; see RotatorSuggestionEvent.
+type RotatorOptions struct {
+	Enabled bool
+	// Presets are the headings suggested per band. More can be registered with SetHeadingPreset.
+	Presets map[bands.Band]HeadingPreset
+}
+
+// QuirkOptions tunes the workarounds of Options.Quirks.
+type QuirkOptions struct {
+	// WakePreamble is written before a command after an idle period, for QuirkNeedsWakePreamble. Empty means a
+	// run of 0xFE bytes with CI-V and ";" otherwise.
+	WakePreamble string
+	// WakeIdleMS is how long the link must have been quiet for the preamble to be written. The unit is
+	// milliseconds.
+	//
+	// Default is 1000ms.
+	WakeIdleMS time.Duration
+	// WakeDelayMS is how long to wait after the preamble. The unit is milliseconds.
+	//
+	// Default is 50ms.
+	WakeDelayMS time.Duration
+	// ModeChangeDelayMS is how long the sender waits after a mode change, for QuirkSlowModeChange. The unit is
+	// milliseconds.
+	//
+	// Default is 300ms.
+	ModeChangeDelayMS time.Duration
+}
+
+// SyntheticState reports the parameter of a set command as the value of a tag once the command was written, e.g.
+// the power set with SETTXPWR on a rig without TX power readback. The status that carries such values is preceded
+// by an InferredEvent listing them.
+type SyntheticState struct {
+	// Command is the name of the set command, e.g. "SETTXPWR". Only its first parameter is used.
+	Command cmds.CatCmdName
+	// Tag is the tag the value is reported under. Empty means the tag set by Command; see DuplicateTags.
+	Tag tags.CatStateTag
+}
+
+// AutoInfoOptions configures auto-information mode (AI2; on Kenwood and Elecraft rigs), in which the rig pushes
+// every change as an unsolicited frame, so that polling can be reduced or disabled.
+type AutoInfoOptions struct {
+	Enabled bool
+	// Command enables auto-information mode. It is sent on Start and after every reconnect. Empty means
+	// AUTOINFOON.
+	Command cmds.CatCmdName
+	// DisableCommand is written on Stop if the rig definition provides it. Empty means AUTOINFOOFF.
+	DisableCommand cmds.CatCmdName
+	// ConfirmTimeoutMS bounds how long the rig has to report the mode on, in answer to READAUTOINFO, once Command
+	// was queued. The mode stays inactive, and polling unchanged, until it does. The unit is milliseconds.
+	//
+	// Default is 1000ms.
+	ConfirmTimeoutMS time.Duration
+	// BurstSize is the maximum number of frames read in one listener tick while the mode is active.
+	//
+	// Default is 32.
+	BurstSize int
+	// BurstGapMS is how long the listener waits for the next frame of a burst. The unit is milliseconds.
+	//
+	// Default is 5ms.
+	BurstGapMS time.Duration
+	// PollIntervalScale multiplies the intervals of Options.Polls while the mode is active, e.g. 10 to poll ten
+	// times less often. A negative value suspends polling; zero leaves it unchanged.
+	PollIntervalScale int
+}
+
+// LatencyOptions sets the latency budgets of the pipeline stages. A stage without a budget is not monitored.
+type LatencyOptions struct {
+	// FrameToStatusMS is the budget from reading a frame to emitting its status. The unit is milliseconds.
+	FrameToStatusMS time.Duration
+	// QueueToWriteMS is the budget from queueing a command to writing it. The unit is milliseconds.
+	QueueToWriteMS time.Duration
+	// Samples is the number of consecutive samples over budget that raise a notification, and within budget that
+	// clear it.
+	//
+	// Default is 10.
+	Samples int
+}
+
+// RawTrafficOptions configures the live raw traffic stream.
+type RawTrafficOptions struct {
+	// Enabled creates the channel returned by RawTrafficChannel.
+	Enabled bool
+	// ChannelSize is the buffer size of the channel.
+	//
+	// Default is 256.
+	ChannelSize int
+}
+
+// BandMapOptions configures the band map of recently visited frequencies.
+type BandMapOptions struct {
+	Enabled bool
+	// Size is the number of frequencies kept per band; the least recently visited are dropped.
+	//
+	// Default is 10.
+	Size int
+	// MinDwellMS is how long VFO A must stay on a frequency for the visit to count, so that tuning across a band
+	// does not fill the map. The unit is milliseconds.
+	//
+	// Default is 5000ms.
+	MinDwellMS time.Duration
+	// MergeHz is the distance within which frequencies are the same entry, so that fine tuning on a signal is one
+	// visit.
+	//
+	// Default is 500Hz.
+	MergeHz int64
+}
+
+// SimulatorOptions configures the simulated rig.
+type SimulatorOptions struct {
+	// State holds the initial raw values of tags, e.g. {"VFOAFREQ": "00007074000"}. Tags not set start at 14.074
+	// MHz for VFO A, 14.076 MHz for VFO B, USB, 100W and zero otherwise.
+	State map[string]string
+	// DelayMS is the typical time the rig takes to answer; every answer takes between half and one and a half
+	// times as long. The unit is milliseconds.
+	//
+	// Default is 20ms.
+	DelayMS time.Duration
+	// NoiseRate is the probability that line noise, a garbage frame, precedes an answer, e.g. 0.01. Zero means
+	// no noise.
+	NoiseRate float64
+	// Seed makes the delays and the noise reproducible. Zero means a random seed.
+	Seed uint64
+}
+
+// FramingOptions configures the frame assembler between the transport and the listener. It splits reads that
+// hold several responses on any of the terminators and discards garbage longer than any frame. With RawReads, it
+// also joins the partial reads of transports that return byte chunks rather than lines.
+type FramingOptions struct {
+	// Enabled turns the frame assembler on.
+	Enabled bool
+	// Terminators are the bytes that end a frame, with \xHH escapes for binary protocols, e.g. ";\r" or
+	// `\xFD`. Empty means the protocol's delimiter, or the serial configuration's, or ';'.
+	Terminators string
+	// RawReads means that reads return byte chunks including the terminators, as a TCP serial bridge returned by
+	// Service.Dial does, so a frame may span several reads. Otherwise every read is taken to end a frame, as the
+	// serial port strips the delimiter it reads up to.
+	RawReads bool
+	// MaxFrameBytes is the longest frame kept; longer runs without a terminator are discarded up to the next
+	// terminator.
+	//
+	// Default is 256.
+	MaxFrameBytes int
+}
+
+// TrafficCaptureOptions configures the capture files of the raw traffic. Every frame written to or read from
+// the rig is appended to Path as one JSON TrafficFrame per line. When the file would exceed MaxBytes it is
+// renamed to Path.1, the older files shift up to Path.<MaxFiles>, and a new file is started.
+type TrafficCaptureOptions struct {
+	// Path is the capture file. Empty disables the capture.
+	Path string
+	// MaxBytes is the size at which the file is rotated.
+	//
+	// Default is 10 MiB.
+	MaxBytes int64
+	// MaxFiles is the number of rotated files kept.
+	//
+	// Default is 5.
+	MaxFiles int
+	// QueueSize is the number of frames waiting to be written; frames arriving while it is full are dropped, so
+	// that a slow disk never slows down the rig link.
+	//
+	// Default is 1024.
+	QueueSize int
+}
+
+// ReadModifyWriteOptions controls the masked read-modify-write helpers.
+type ReadModifyWriteOptions struct {
+	// Retries is how many times a masked change is retried when the readback shows that the setting was changed
+	// concurrently, e.g. from the front panel.
+	//
+	// Default is 3.
+	Retries int
+}
+
+// ReconnectOptions configures how a lost serial link is re-established.
+type ReconnectOptions struct {
+	// Enabled reopens the port automatically when it is closed, removed or fails, instead of leaving the listener
+	// reporting read errors until the service is restarted.
+	Enabled bool
+	// RetryMS is the delay between reopen attempts. The unit is milliseconds.
+	//
+	// Default is 1000ms.
+	RetryMS time.Duration
+	// ReenumerationWaitMS is the delay used instead of RetryMS after a device was removed or its port was still
+	// held, giving the OS (notably Windows) time to re-enumerate the adapter. The unit is milliseconds.
+	//
+	// Default is 5000ms.
+	ReenumerationWaitMS time.Duration
+	// ErrorLogIntervalMS limits how often repeated read and reopen errors are logged, and read errors emitted as
+	// ErrorEvents. The unit is milliseconds.
+	//
+	// Default is 10000ms.
+	ErrorLogIntervalMS time.Duration
+	// PortWatchMS is how often a port that another program held when it was opened is checked, to emit
+	// PortAvailableEvent once it is released. The unit is milliseconds.
+	//
+	// Default is 1000ms.
+	PortWatchMS time.Duration
+}
+
+// KeepaliveOptions configures the idle link keepalive.
+type KeepaliveOptions struct {
+	// Command is a harmless command, such as an identity query, written when the link is idle. Empty disables the
+	// keepalive.
+	Command cmds.CatCmdName
+	// IdleMS is how long the link must be quiet before the keepalive is written. The unit is milliseconds.
+	//
+	// Default is 30000ms.
+	IdleMS time.Duration
+}
+
+// MemoryOptions describes the rig's memory channel layout.
+type MemoryOptions struct {
+	// ChannelDigits is the width of the channel number in memory commands.
+	//
+	// Default is 3.
+	ChannelDigits int
+	// FirstChannel is the lowest channel number, and Channels the number of channels read by DumpAllMemories.
+	FirstChannel int
+	Channels     int
+	// ReadIntervalMS is the pause between channel reads of DumpAllMemories, so that a dump does not crowd out
+	// polling and user commands. The unit is milliseconds.
+	//
+	// Default is 50ms.
+	ReadIntervalMS time.Duration
+}
+
+// ResponsePolicy is the timeout and retry policy of a command the rig answers.
+type ResponsePolicy struct {
+	// RetryCount is how often the command is resent when no response arrives in time.
+	RetryCount int
+	// TimeoutMS is how long the sender waits for the response to each attempt. The unit is milliseconds.
+	//
+	// Default is 500ms.
+	TimeoutMS time.Duration
+}
+
+// BackupOptions lists the settings saved by ExportRigState besides the memory channels, which Options.Memory
+// describes.
+type BackupOptions struct {
+	// Menus are the menu numbers saved, as passed to READMENU and WRITEMENU, e.g. "001".
+	Menus []string
+	// Levels are the level settings saved, e.g. AF gain or mic gain.
+	Levels []BackupLevel
+	// ReadIntervalMS is the pause between reads, so that a backup does not crowd out polling and user commands.
+	// The unit is milliseconds.
+	//
+	// Default is 50ms.
+	ReadIntervalMS time.Duration
+}
+
+// BackupLevel is a level setting saved by ExportRigState.
+type BackupLevel struct {
+	// Name is the key of the level in RigBackup.Levels, e.g. "AFGAIN".
+	Name string
+	// Read is the command reading the level; its reply reports Tag.
+	Read cmds.CatCmdName
+	Tag  string
+	// Write is the command restoring the level; it takes the value.
+	Write cmds.CatCmdName
+}
+
+// StationOptions declares the station power sequences: rig power, amplifier enable, tuner and the like, in the
+// order the station needs them.
+type StationOptions struct {
+	PowerUp   []StationStep
+	PowerDown []StationStep
+}
+
+// StationStep is one step of a station sequence. Its commands are sent first, then the profile is applied, then
+// the step waits and checks the rig before the next step runs.
+type StationStep struct {
+	// Name identifies the step in events and errors, e.g. "amplifier".
+	Name string
+	// Commands are queued as one batch, in order.
+	Commands []CatCommandRequest
+	// Profile is a profile saved with SaveProfile whose frequency, mode and power are applied. It requires
+	// Service.Store.
+	Profile string
+	// WaitMS is the pause after the commands, e.g. for a rig to boot or an amplifier to warm up. The unit is
+	// milliseconds.
+	WaitMS time.Duration
+	// Check is the checkpoint that must pass before the sequence goes on. Nil means none.
+	Check *StationCheck
+	// Rollback are the commands undoing the step, sent in order if this or a later step fails.
+	Rollback []CatCommandRequest
+}
+
+// StationCheck is a checkpoint of a station step: Command is sent until its reply reports Value for Tag.
+type StationCheck struct {
+	Command cmds.CatCmdName
+	Tag     string
+	Value   string
+	// TimeoutMS is how long the check may take. The unit is milliseconds.
+	//
+	// Default is 5000ms.
+	TimeoutMS time.Duration
+}
+
+// DebugOptions groups the development-only settings.
+type DebugOptions struct {
+	// Faults configures the fault-injection wrapper around the transport.
+	Faults FaultConfig
+}
+
+// FaultConfig controls the fault-injection transport. Percentages are in the range 0-100.
+type FaultConfig struct {
+	Enabled bool
+	// Seed seeds the random source so a chaos run can be repeated. Zero uses a time-based seed.
+	Seed int64
+	// DropReadPercent is the chance that a received frame is silently discarded.
+	DropReadPercent int
+	// DropWritePercent is the chance that an outgoing command is silently discarded.
+	DropWritePercent int
+	// CorruptPercent is the chance that a received frame has one of its bytes altered.
+	CorruptPercent int
+	// WriteDelayMS delays every write by the given number of milliseconds.
+	WriteDelayMS time.Duration
+	// FailOpens causes the first N attempts to open the transport to fail.
+	FailOpens int
+}
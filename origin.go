@@ -0,0 +1,130 @@
+package cat
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/types"
+)
+
+// Origin tells where a command came from, e.g. the UI or the poller. It is carried through the pipeline into the
+// audit log, the metrics and the QSY history, to answer questions such as "what keeps changing my VFO?".
+type Origin string
+
+const (
+	OriginUnspecified Origin = ""
+	OriginUI          Origin = "ui"
+	OriginPoller      Origin = "poller"
+	OriginMacro       Origin = "macro"
+	OriginScheduler   Origin = "scheduler"
+	// OriginInternal marks commands issued by the service itself, e.g. keepalives, recovery or power clamping.
+	OriginInternal Origin = "internal"
+	// OriginRig is reported when a change was not caused by any of our commands, e.g. the front panel.
+	OriginRig Origin = "rig"
+)
+
+// NetworkOrigin returns the origin of commands from the network client with the given ID.
+func NetworkOrigin(clientID string) Origin {
+	return Origin("network:" + clientID)
+}
+
+// String implements fmt.Stringer.
+func (o Origin) String() string {
+	if o == OriginUnspecified {
+		return "unspecified"
+	}
+	return string(o)
+}
+
+// WithOrigin tags a command with its origin.
+func WithOrigin(origin Origin) CommandOption {
+	return func(req *commandRequest) {
+		req.origin = origin
+	}
+}
+
+// queuedCommand is a formatted command on its way to the sender, together with its origin and priority.
+type queuedCommand struct {
+	types.CatCommand
+	origin   Origin
+	priority Priority
+	queued   time.Time
+	// batch is set instead of CatCommand for a batch queued by EnqueueBatch.
+	batch *Batch
+	// verify is the read-after-write check of a set command; see Options.Verify.
+	verify *readBack
+	// synthetic is the status value inferred from the command once written; see Options.SyntheticStates.
+	synthetic types.CatStatus
+	// outcome is the handle of the request the command belongs to; nil if it is not tracked.
+	outcome *CommandHandle
+	// transverter is the transverter state to activate once the command is written; nil leaves it.
+	transverter *int32
+	// settle is how long the sender holds after writing the command; see settleFor.
+	settle time.Duration
+}
+
+const (
+	// originAttributionWindow is how long after a set command a change reported by the rig is attributed to it.
+	originAttributionWindow = 2 * time.Second
+)
+
+// originTracker counts the commands written per origin and remembers who last wrote each command.
+type originTracker struct {
+	mu         sync.Mutex
+	sent       map[Origin]*atomic.Uint64
+	lastWriter map[cmds.CatCmdName]originWrite
+}
+
+type originWrite struct {
+	origin Origin
+	at     time.Time
+}
+
+// noteWritten records that cmd was written to the rig.
+func (t *originTracker) noteWritten(cmd queuedCommand, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sent == nil {
+		t.sent = make(map[Origin]*atomic.Uint64)
+		t.lastWriter = make(map[cmds.CatCmdName]originWrite)
+	}
+	n, ok := t.sent[cmd.origin]
+	if !ok {
+		n = &atomic.Uint64{}
+		t.sent[cmd.origin] = n
+	}
+	n.Add(1)
+	t.lastWriter[cmds.CatCmdName(cmd.Name)] = originWrite{origin: cmd.origin, at: at}
+}
+
+// attribute returns the origin of the last write of name if it happened within the attribution window before
+// at, and OriginRig otherwise.
+func (t *originTracker) attribute(name cmds.CatCmdName, at time.Time) Origin {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.lastWriter[name]
+	if !ok || at.Sub(w.at) > originAttributionWindow {
+		return OriginRig
+	}
+	return w.origin
+}
+
+// snapshot returns the number of commands written per origin, keyed for the metrics export.
+func (t *originTracker) snapshot() map[string]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	origins := make([]Origin, 0, len(t.sent))
+	for o := range t.sent {
+		origins = append(origins, o)
+	}
+	sort.Slice(origins, func(i, j int) bool { return origins[i] < origins[j] })
+
+	out := make(map[string]uint64, len(origins))
+	for _, o := range origins {
+		out["commands_sent."+o.String()] = t.sent[o].Load()
+	}
+	return out
+}
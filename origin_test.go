@@ -0,0 +1,33 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginIsCarriedToFollowUps(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.AutoModeSegments = []ModeSegment{{Label: "FT8", MinHz: 14074000, MaxHz: 14074000, Mode: "USB"}}
+
+	require.NoError(t, service.setFrequencyHz(VFOA, 14074000, WithOrigin(OriginMacro)))
+	require.Len(t, service.sendChannel, 2)
+	for len(service.sendChannel) > 0 {
+		require.Equal(t, OriginMacro, (<-service.sendChannel).origin)
+	}
+}
+
+func TestOriginTrackerAttributesChanges(t *testing.T) {
+	var tracker originTracker
+	now := time.Now()
+	tracker.noteWritten(queuedCommand{CatCommand: types.CatCommand{Name: CmdSetVfoAFreq.String()}, origin: NetworkOrigin("n1mm")}, now)
+	tracker.noteWritten(queuedCommand{CatCommand: types.CatCommand{Name: "READ"}, origin: OriginPoller}, now)
+	tracker.noteWritten(queuedCommand{CatCommand: types.CatCommand{Name: "READ"}, origin: OriginPoller}, now)
+
+	require.Equal(t, NetworkOrigin("n1mm"), tracker.attribute(CmdSetVfoAFreq, now.Add(time.Second)))
+	require.Equal(t, OriginRig, tracker.attribute(CmdSetVfoAFreq, now.Add(time.Minute)))
+	require.Equal(t, OriginRig, tracker.attribute(CmdSetVfoBFreq, now))
+	require.Equal(t, map[string]uint64{"commands_sent.network:n1mm": 1, "commands_sent.poller": 2}, tracker.snapshot())
+}
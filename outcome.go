@@ -0,0 +1,297 @@
+package cat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// EventCommandOutcome is the kind of CommandOutcomeEvent.
+const EventCommandOutcome EventKind = "COMMAND_OUTCOME"
+
+// OutcomeState is the progress of a command through the pipeline. A command moves from OutcomeAccepted (or
+// OutcomeClamped) to OutcomeSent and ends in OutcomeSent, OutcomeConfirmed, OutcomeFailed or OutcomeTimedOut.
+type OutcomeState string
+
+const (
+	// OutcomeAccepted means the command passed the policy filters and is queued.
+	OutcomeAccepted OutcomeState = "ACCEPTED"
+	// OutcomeClamped means the command is queued, but a policy changed it, e.g. reduced the power to the band
+	// limit. CommandOutcome.Note says how.
+	OutcomeClamped OutcomeState = "CLAMPED"
+	// OutcomeSent means the command was written to the rig. It is final unless the command is verified.
+	OutcomeSent OutcomeState = "SENT"
+	// OutcomeConfirmed means the rig reports the value that was set, by read-after-write verification or because
+	// the command was suppressed as a duplicate of the current state.
+	OutcomeConfirmed OutcomeState = "CONFIRMED"
+	// OutcomeFailed means the command was rejected, could not be written, was dropped from the queue, or the
+	// rig reports another value than the one set.
+	OutcomeFailed OutcomeState = "FAILED"
+	// OutcomeTimedOut means the rig did not report the value within Options.Verify.TimeoutMS.
+	OutcomeTimedOut OutcomeState = "TIMED_OUT"
+)
+
+// String implements fmt.Stringer.
+func (o OutcomeState) String() string {
+	return string(o)
+}
+
+// CommandOutcome describes what happened to a command, for precise feedback such as UI toasts. It covers the
+// follow-up commands that policies add to the command, e.g. a power reduction after a band change.
+type CommandOutcome struct {
+	ID      uint64
+	Command cmds.CatCmdName
+	Origin  Origin
+	State   OutcomeState
+	// Clamped stays set once a policy changed the command; Note describes the changes.
+	Clamped bool
+	Note    string
+	// Err is why the command failed or timed out.
+	Err string
+	// At is the time of the last change of State.
+	At time.Time
+}
+
+// CommandOutcomeEvent is emitted with the final outcome of every command that is not a poll, if
+// Options.OutcomeEvents is set.
+type CommandOutcomeEvent struct {
+	CommandOutcome
+}
+
+func (e CommandOutcomeEvent) Kind() EventKind { return EventCommandOutcome }
+func (e CommandOutcomeEvent) Time() time.Time { return e.At }
+
+// CommandHandle follows one command queued by EnqueueTracked.
+type CommandHandle struct {
+	mu      sync.Mutex
+	outcome CommandOutcome
+	// pending counts the commands of the request not yet written, plus those written but not yet verified.
+	pending  int
+	verified bool
+	final    bool
+	// writeStarted is when the last of the commands began to be written.
+	writeStarted time.Time
+	// onFirstWrite is called when the first of the commands begins to be written.
+	onFirstWrite func(at time.Time)
+	done         chan struct{}
+	report       func(CommandOutcome)
+}
+
+// Outcome returns the current outcome of the command.
+func (h *CommandHandle) Outcome() CommandOutcome {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.outcome
+}
+
+// Done returns a channel that is closed once the outcome is final.
+func (h *CommandHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the outcome is final or ctx is done, and returns the outcome at that point.
+func (h *CommandHandle) Wait(ctx context.Context) (CommandOutcome, error) {
+	const op errors.Op = "cat.CommandHandle.Wait"
+	select {
+	case <-h.done:
+		return h.Outcome(), nil
+	case <-ctx.Done():
+		return h.Outcome(), errors.New(op).Err(ctx.Err())
+	}
+}
+
+// newCommandHandle returns the handle following req, in no state until it is queued.
+func (s *Service) newCommandHandle(req *commandRequest) *CommandHandle {
+	return &CommandHandle{
+		outcome: CommandOutcome{ID: s.outcomeSeq.Add(1), Command: req.name, Origin: req.origin, At: time.Now()},
+		done:    make(chan struct{}),
+		report:  s.reportOutcome,
+
+		onFirstWrite: req.onFirstWrite,
+	}
+}
+
+// reportOutcome emits the final outcome of a command, unless it is a poll.
+func (s *Service) reportOutcome(o CommandOutcome) {
+	if !s.Options.OutcomeEvents || o.Origin == OriginPoller {
+		return
+	}
+	s.emitEvent(CommandOutcomeEvent{CommandOutcome: o})
+}
+
+// clamp records that a policy changed the command. The methods of the handle are no-ops on a nil handle, so
+// that commands written outside of EnqueueTracked and EnqueueBatch need no special casing.
+func (h *CommandHandle) clamp(note string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.outcome.Clamped = true
+	h.addNote(note)
+}
+
+// addNote appends note to the outcome. The caller holds mu.
+func (h *CommandHandle) addNote(note string) {
+	if h.outcome.Note != "" {
+		note = h.outcome.Note + "; " + note
+	}
+	h.outcome.Note = note
+}
+
+// expect sets the number of commands the request was prepared into. It is called before they are queued, so
+// that no write is missed. A request prepared into no commands was suppressed as a duplicate and is confirmed.
+func (h *CommandHandle) expect(n int) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.pending = n
+	if n == 0 {
+		h.addNote("already set; not sent")
+	}
+	h.mu.Unlock()
+	if n == 0 {
+		h.finish(OutcomeConfirmed, nil)
+	}
+}
+
+// accepted records that the commands are queued, unless the sender got to them first.
+func (h *CommandHandle) accepted() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.final || h.outcome.State != "" {
+		return
+	}
+	h.outcome.State = OutcomeAccepted
+	if h.outcome.Clamped {
+		h.outcome.State = OutcomeClamped
+	}
+	h.outcome.At = time.Now()
+}
+
+// writing records that one of the commands is about to be written.
+func (h *CommandHandle) writing(at time.Time) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	first := h.writeStarted.IsZero()
+	h.writeStarted = at
+	h.mu.Unlock()
+	if first && h.onFirstWrite != nil {
+		h.onFirstWrite(at)
+	}
+}
+
+// lastWrite returns when the last of the commands began to be written; zero if none was.
+func (h *CommandHandle) lastWrite() time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.writeStarted
+}
+
+// written records that one of the commands was written; verifying commands stay pending until verified.
+func (h *CommandHandle) written(verifying bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	if h.final {
+		h.mu.Unlock()
+		return
+	}
+	h.outcome.State, h.outcome.At = OutcomeSent, time.Now()
+	if !verifying {
+		h.pending--
+	}
+	h.mu.Unlock()
+	h.settle()
+}
+
+// confirmed records that the rig reported the value set by one of the commands.
+func (h *CommandHandle) confirmed() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.pending--
+	h.verified = true
+	h.mu.Unlock()
+	h.settle()
+}
+
+// settle ends the outcome once no command is pending.
+func (h *CommandHandle) settle() {
+	h.mu.Lock()
+	state := OutcomeSent
+	if h.verified {
+		state = OutcomeConfirmed
+	}
+	done := h.pending <= 0
+	h.mu.Unlock()
+	if done {
+		h.finish(state, nil)
+	}
+}
+
+// fail ends the outcome with OutcomeFailed.
+func (h *CommandHandle) fail(err error) {
+	if h != nil {
+		h.finish(OutcomeFailed, err)
+	}
+}
+
+// timedOut ends the outcome with OutcomeTimedOut.
+func (h *CommandHandle) timedOut(err error) {
+	if h != nil {
+		h.finish(OutcomeTimedOut, err)
+	}
+}
+
+// finish makes the outcome final and reports it. Only the first call has an effect.
+func (h *CommandHandle) finish(state OutcomeState, err error) {
+	h.mu.Lock()
+	if h.final {
+		h.mu.Unlock()
+		return
+	}
+	h.final = true
+	h.outcome.State, h.outcome.At = state, time.Now()
+	if err != nil {
+		h.outcome.Err = err.Error()
+	}
+	outcome := h.outcome
+	close(h.done)
+	h.mu.Unlock()
+	if h.report != nil {
+		h.report(outcome)
+	}
+}
+
+// EnqueueTracked behaves like EnqueueCommandWith and returns a handle on the command's outcome. A command rejected
+// by a policy returns an error, and its failed outcome is still reported as an event.
+func (s *Service) EnqueueTracked(cmdName cmds.CatCmdName, params []string, opts ...CommandOption) (*CommandHandle, error) {
+	const op errors.Op = "cat.Service.EnqueueTracked"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if !s.started.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+
+	handle, err := s.submitTracked(newCommandRequest(cmdName, params, opts...))
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return handle, nil
+}
@@ -0,0 +1,72 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func waitOutcome(t *testing.T, handle *CommandHandle) CommandOutcome {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	outcome, err := handle.Wait(ctx)
+	require.NoError(t, err)
+	return outcome
+}
+
+func TestOutcomeConfirmedByVerification(t *testing.T) {
+	service := newVerifyTestService(t, false)
+
+	handle, err := service.EnqueueTracked(CmdSetVfoAFreq, []string{"014250000"})
+	require.NoError(t, err)
+	outcome := waitOutcome(t, handle)
+	require.Equal(t, OutcomeConfirmed, outcome.State)
+	require.Equal(t, CmdSetVfoAFreq, outcome.Command)
+	require.Empty(t, outcome.Err)
+}
+
+func TestOutcomeFailedWhenRigIgnoresCommand(t *testing.T) {
+	service := newVerifyTestService(t, true)
+
+	handle, err := service.EnqueueTracked(CmdSetVfoAFreq, []string{"014250000"})
+	require.NoError(t, err)
+	outcome := waitOutcome(t, handle)
+	require.Equal(t, OutcomeFailed, outcome.State)
+	require.Contains(t, outcome.Err, "instead of 014250000")
+}
+
+func TestOutcomeClampedThenSent(t *testing.T) {
+	service := newPowerTestService(t)
+	service.Options.OutcomeEvents = true
+	service.cache.update(types.CatStatus{"VFOAFREQ": "050313000"}, time.Now())
+
+	handle, err := service.EnqueueTracked(CmdSetTxPower, []string{"100"})
+	require.NoError(t, err)
+	require.Equal(t, OutcomeClamped, handle.Outcome().State)
+
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	outcome := waitOutcome(t, handle)
+	require.Equal(t, OutcomeSent, outcome.State)
+	require.True(t, outcome.Clamped)
+	require.Contains(t, outcome.Note, "100 W to the 10 W limit")
+
+	event := (<-service.eventChannel).(CommandOutcomeEvent)
+	require.Equal(t, outcome, event.CommandOutcome)
+}
+
+func TestOutcomeOfRejectedCommandIsReported(t *testing.T) {
+	service := newPowerTestService(t)
+	service.Options.OutcomeEvents = true
+	service.Options.PowerLimitAction = PowerLimitReject
+	service.cache.update(types.CatStatus{"VFOAFREQ": "050313000"}, time.Now())
+
+	_, err := service.EnqueueTracked(CmdSetTxPower, []string{"100"})
+	require.Error(t, err)
+	event := (<-service.eventChannel).(CommandOutcomeEvent)
+	require.Equal(t, OutcomeFailed, event.State)
+	require.Contains(t, event.Err, "exceeds the 10 W limit")
+}
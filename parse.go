@@ -0,0 +1,349 @@
+package cat
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// ParseMode selects how marker violations in a received frame are handled.
+type ParseMode string
+
+const (
+	// ParseLenient skips markers that fall outside the frame, clamps markers that run past its end and reports
+	// unmapped values as empty strings. This is the default.
+	ParseLenient ParseMode = "lenient"
+	// ParseStrict rejects the whole frame on any marker violation and records an error, so that a truncated or
+	// misaligned frame never produces a partial status.
+	ParseStrict ParseMode = "strict"
+)
+
+// StateOptions holds cat-specific settings for one configured state.
+type StateOptions struct {
+	// Parse overrides Options.ParseMode for this state.
+	Parse ParseMode
+	// Pattern is a regular expression matched against the frame data after the prefix; each named capture group
+	// becomes a tag, e.g. `^(?P<CALLSIGN>[A-Z0-9/]+);`. It covers variable-length values that fixed markers cannot
+	// express. Patterns are compiled at Initialize.
+	Pattern string
+	// Layouts are alternative marker sets for a prefix whose frame layout varies, selected by frame length or a
+	// discriminator character. The first matching layout replaces the state's markers; if none matches, the
+	// state's markers are used (lenient) or the frame is rejected (strict).
+	Layouts []StateLayout
+	// Encodings declares tags whose raw value is BCD-encoded (e.g. CI-V frequencies), keyed by tag. The value is
+	// decoded to decimal before value mappings are applied, and frequencies written for the tag are encoded the
+	// same way.
+	Encodings map[string]ValueEncoding
+	// FieldMarkers extract values by field position in delimited frames, in addition to the state's fixed
+	// Index/Length markers.
+	FieldMarkers []FieldMarker
+	// Charsets declares tags carrying text, such as a callsign or memory name, keyed by tag. The raw value is
+	// converted from the charset to UTF-8 and its padding is trimmed before value mappings are applied.
+	Charsets map[string]Charset
+}
+
+// StateLayout is one frame layout of a state, e.g. one type of a Yaesu information response.
+type StateLayout struct {
+	// Length matches frames whose data (after the prefix) has exactly this length. Zero matches any length.
+	Length int
+	// Discriminator matches frames with this value at DiscriminatorIndex of the data. Empty matches any frame.
+	Discriminator      string
+	DiscriminatorIndex int
+	Markers            []types.Marker
+}
+
+// matches reports whether the layout applies to data.
+func (l StateLayout) matches(data string) bool {
+	if l.Length > 0 && len(data) != l.Length {
+		return false
+	}
+	if l.Discriminator != "" {
+		end := l.DiscriminatorIndex + len(l.Discriminator)
+		if l.DiscriminatorIndex < 0 || end > len(data) || data[l.DiscriminatorIndex:end] != l.Discriminator {
+			return false
+		}
+	}
+	return true
+}
+
+// selectLayout returns the first layout matching data.
+func selectLayout(layouts []StateLayout, data string) (StateLayout, bool) {
+	for _, l := range layouts {
+		if l.matches(data) {
+			return l, true
+		}
+	}
+	return StateLayout{}, false
+}
+
+// FieldMarker extracts a value from a delimited frame, such as a comma- or space-separated status string, where
+// fixed offsets break whenever a value changes width.
+type FieldMarker struct {
+	Tag string
+	// Field is the zero-based position of the value after splitting the frame on Delimiter.
+	Field int
+	// Delimiter separates the fields. A single space also treats runs of whitespace as one separator. Empty
+	// means ",".
+	Delimiter string
+	// ValueMappings map raw field values to display values, as for fixed markers.
+	ValueMappings []types.ValueMapping
+}
+
+// splitFields splits data into the fields addressed by m.
+func (m FieldMarker) splitFields(data string) []string {
+	switch m.Delimiter {
+	case "":
+		return strings.Split(data, ",")
+	case " ":
+		return strings.Fields(data)
+	default:
+		return strings.Split(data, m.Delimiter)
+	}
+}
+
+// stateOptions returns the options configured for the state with the given prefix.
+func (s *Service) stateOptions(prefix string) StateOptions {
+	return s.Options.StateOptions[s.prefixKey(prefix)]
+}
+
+// parseMode returns the parse mode in effect for the state with the given prefix.
+func (s *Service) parseMode(prefix string) ParseMode {
+	if mode := s.stateOptions(prefix).Parse; mode != "" {
+		return mode
+	}
+	if s.Options.ParseMode != "" {
+		return s.Options.ParseMode
+	}
+	return ParseLenient
+}
+
+// parseState extracts the marker values of a matched frame. In strict mode the first violation is returned as an
+// error and no status is produced.
+func (s *Service) parseState(state types.CatState) (types.CatStatus, error) {
+	const op errors.Op = "cat.Service.parseState"
+	strict := s.parseMode(state.Prefix) == ParseStrict
+
+	markers := state.Markers
+	if layouts := s.stateOptions(state.Prefix).Layouts; len(layouts) > 0 {
+		layout, ok := selectLayout(layouts, state.Data)
+		switch {
+		case ok:
+			markers = layout.Markers
+		case strict:
+			return nil, errors.New(op).Msgf("%s: no layout matches %q", state.Prefix, state.Data)
+		default:
+			s.logger().DebugWith().Str("prefix", state.Prefix).Msg("no layout matched; using the state's markers")
+		}
+	}
+
+	status := types.CatStatus{}
+	for _, marker := range markers {
+		start := marker.Index
+		if start < 0 || start >= len(state.Data) {
+			if strict {
+				return nil, errors.New(op).Msgf("%s: marker %s index %d out of range for %q", state.Prefix, marker.Tag, start, state.Data)
+			}
+			s.logger().WarnWith().Int("index", start).Msg("marker index out of range; skipping marker")
+			continue
+		}
+
+		end := marker.Index + marker.Length
+		if end > len(state.Data) {
+			if strict {
+				return nil, errors.New(op).Msgf("%s: marker %s runs past the end of %q", state.Prefix, marker.Tag, state.Data)
+			}
+			s.logger().WarnWith().Int("index", start).Int("length", marker.Length).Msg("marker end out of range; clamping to line end")
+			end = len(state.Data)
+		}
+		if start >= end {
+			if strict {
+				return nil, errors.New(op).Msgf("%s: marker %s is empty", state.Prefix, marker.Tag)
+			}
+			s.logger().DebugWith().Int("index", start).Int("length", marker.Length).Msg("empty slice for marker; skipping")
+			continue
+		}
+
+		value, err := s.markerValue(state.Prefix, marker, state.Data[start:end], strict)
+		if err != nil {
+			return nil, errors.New(op).Msgf("%s: %v", state.Prefix, err)
+		}
+		status[marker.Tag] = value
+	}
+
+	if re := s.statePattern(state.Prefix); re != nil {
+		match := re.FindStringSubmatch(state.Data)
+		if match == nil {
+			if strict {
+				return nil, errors.New(op).Msgf("%s: pattern does not match %q", state.Prefix, state.Data)
+			}
+			s.logger().WarnWith().Str("prefix", state.Prefix).Msg("state pattern did not match; skipping pattern")
+		}
+		for i, name := range re.SubexpNames() {
+			if name != "" && match != nil {
+				value, err := s.decodeText(state.Prefix, name, match[i], strict)
+				if err != nil {
+					return nil, errors.New(op).Err(err)
+				}
+				status[name] = value
+			}
+		}
+	}
+
+	for _, fm := range s.stateOptions(state.Prefix).FieldMarkers {
+		fields := fm.splitFields(state.Data)
+		if fm.Field < 0 || fm.Field >= len(fields) {
+			if strict {
+				return nil, errors.New(op).Msgf("%s: field %d for %s missing in %q", state.Prefix, fm.Field, fm.Tag, state.Data)
+			}
+			s.logger().WarnWith().Int("field", fm.Field).Msg("marker field out of range; skipping marker")
+			continue
+		}
+		marker := types.Marker{Tag: fm.Tag, ValueMappings: fm.ValueMappings}
+		value, err := s.markerValue(state.Prefix, marker, strings.TrimSpace(fields[fm.Field]), strict)
+		if err != nil {
+			return nil, errors.New(op).Msgf("%s: %v", state.Prefix, err)
+		}
+		status[fm.Tag] = value
+	}
+	return status, nil
+}
+
+// hasMarkers reports whether any fixed markers, layouts, field markers or a pattern are configured for state.
+func (s *Service) hasMarkers(state types.CatState) bool {
+	opts := s.stateOptions(state.Prefix)
+	return len(state.Markers) > 0 || len(opts.Layouts) > 0 || len(opts.FieldMarkers) > 0 || opts.Pattern != ""
+}
+
+// statePattern returns the compiled pattern for the state with the given prefix, if any.
+func (s *Service) statePattern(prefix string) *regexp.Regexp {
+	s.definitionMu.RLock()
+	defer s.definitionMu.RUnlock()
+	return s.patterns[s.prefixKey(prefix)]
+}
+
+// compilePatterns compiles the state patterns in Options.StateOptions. Every pattern must belong to a configured
+// state and define at least one named group.
+func (s *Service) compilePatterns() error {
+	patterns, err := s.buildPatterns(s.supportedCatStates)
+	if err != nil {
+		return err
+	}
+	s.patterns = patterns
+	return nil
+}
+
+// buildPatterns compiles the state patterns in Options.StateOptions against the given state set.
+func (s *Service) buildPatterns(states map[string]types.CatState) (map[string]*regexp.Regexp, error) {
+	const op errors.Op = "cat.Service.buildPatterns"
+
+	patterns := make(map[string]*regexp.Regexp)
+	for prefix, opts := range s.Options.StateOptions {
+		if opts.Pattern == "" {
+			continue
+		}
+		key := s.prefixKey(prefix)
+		if _, ok := states[key]; !ok {
+			return nil, errors.New(op).Msgf("pattern for unknown CAT state %s", prefix)
+		}
+		re, err := regexp.Compile(opts.Pattern)
+		if err != nil {
+			return nil, errors.New(op).Err(err).Msgf("invalid pattern for CAT state %s", prefix)
+		}
+		named := false
+		for _, name := range re.SubexpNames() {
+			named = named || name != ""
+		}
+		if !named {
+			return nil, errors.New(op).Msgf("pattern for CAT state %s has no named groups", prefix)
+		}
+		patterns[key] = re
+	}
+	return patterns, nil
+}
+
+// markerValue decodes a raw marker slice according to the tag's encoding or charset, if any, and applies the value
+// mappings.
+func (s *Service) markerValue(prefix string, marker types.Marker, raw string, strict bool) (string, error) {
+	const op errors.Op = "cat.Service.markerValue"
+	if enc, ok := s.markerEncoding(prefix, marker.Tag); ok {
+		decoded, err := decodeBCD(raw, enc)
+		if err != nil {
+			if strict {
+				return "", errors.New(op).Msgf("marker %s: %v", marker.Tag, err)
+			}
+			s.logger().WarnWith().Err(err).Str("tag", marker.Tag).Msg("marker value could not be decoded")
+			return "", nil
+		}
+		raw = decoded
+	}
+	raw, err := s.decodeText(prefix, marker.Tag, raw, strict)
+	if err != nil {
+		return "", errors.New(op).Err(err)
+	}
+	return mapMarkerValue(marker, raw, strict)
+}
+
+// decodeText converts the raw value of tag to UTF-8 if the state with the given prefix declares a charset for it.
+// A value that cannot be decoded is an error in strict mode and an empty string otherwise.
+func (s *Service) decodeText(prefix, tag, raw string, strict bool) (string, error) {
+	const op errors.Op = "cat.Service.decodeText"
+	cs, ok := s.stateOptions(prefix).Charsets[tag]
+	if !ok {
+		return raw, nil
+	}
+	text, err := cs.decode(raw)
+	if err != nil {
+		if strict {
+			return "", errors.New(op).Msgf("marker %s: %v", tag, err)
+		}
+		s.logger().WarnWith().Err(err).Str("tag", tag).Msg("marker text could not be decoded")
+		return "", nil
+	}
+	return text, nil
+}
+
+// markerEncoding returns the wire encoding of tag in the state with the given prefix, falling back to the
+// conversion declared for the tag and then to the driver's own.
+func (s *Service) markerEncoding(prefix, tag string) (ValueEncoding, bool) {
+	if enc, ok := s.stateOptions(prefix).Encodings[tag]; ok {
+		return enc, true
+	}
+	if enc, ok := s.conversionEncoding(tag); ok {
+		return enc, true
+	}
+	return s.driverEncoding(tag)
+}
+
+// tagEncoding returns the wire encoding configured for tag in any state, falling back to the conversion declared
+// for the tag and then to the driver's own.
+func (s *Service) tagEncoding(tag string) (ValueEncoding, bool) {
+	for _, opts := range s.Options.StateOptions {
+		if enc, ok := opts.Encodings[tag]; ok {
+			return enc, true
+		}
+	}
+	if enc, ok := s.conversionEncoding(tag); ok {
+		return enc, true
+	}
+	return s.driverEncoding(tag)
+}
+
+// mapMarkerValue applies the marker's value mappings to a raw slice. An unmapped value is an error in strict mode
+// and an empty string otherwise.
+func mapMarkerValue(marker types.Marker, raw string, strict bool) (string, error) {
+	const op errors.Op = "cat.mapMarkerValue"
+	if len(marker.ValueMappings) == 0 {
+		return raw, nil
+	}
+	for _, vm := range marker.ValueMappings {
+		if raw == vm.Key {
+			return vm.Value, nil
+		}
+	}
+	if strict {
+		return "", errors.New(op).Msgf("marker %s has no mapping for %q", marker.Tag, raw)
+	}
+	return "", nil // empty string if no mapping matched
+}
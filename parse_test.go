@@ -0,0 +1,170 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStateLenientClampsAndSkips(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	state := types.CatState{Prefix: "IF", Data: "0140740", Markers: []types.Marker{
+		{Tag: "VFOAFREQ", Index: 0, Length: 9},
+		{Tag: "MAINMODE", Index: 20, Length: 1},
+	}}
+
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "0140740"}, status)
+}
+
+func TestParseStateStrictRejects(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.StateOptions = map[string]StateOptions{"IF": {Parse: ParseStrict}}
+
+	truncated := types.CatState{Prefix: "IF", Data: "0140740", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 9}}}
+	_, err := service.parseState(truncated)
+	require.ErrorContains(t, err, "runs past the end")
+
+	unmapped := types.CatState{Prefix: "if", Data: "9", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1,
+		ValueMappings: []types.ValueMapping{{Key: "2", Value: "USB"}}}}}
+	_, err = service.parseState(unmapped)
+	require.ErrorContains(t, err, "no mapping")
+
+	// Other states keep the lenient default.
+	other := types.CatState{Prefix: "FA", Data: "0140740", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 9}}}
+	_, err = service.parseState(other)
+	require.NoError(t, err)
+}
+
+func TestParseStateFieldMarkers(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.StateOptions = map[string]StateOptions{
+		"RPRT": {FieldMarkers: []FieldMarker{
+			{Tag: "VFOAFREQ", Field: 0, Delimiter: " "},
+			{Tag: "MAINMODE", Field: 1, Delimiter: " ", ValueMappings: []types.ValueMapping{{Key: "USB", Value: "USB"}}},
+		}},
+		"ST": {FieldMarkers: []FieldMarker{{Tag: "TXPWR", Field: 3}}},
+	}
+
+	status, err := service.parseState(types.CatState{Prefix: "RPRT", Data: " 7074000   USB"})
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "7074000", "MAINMODE": "USB"}, status)
+
+	status, err = service.parseState(types.CatState{Prefix: "ST", Data: "a,b,c,100"})
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"TXPWR": "100"}, status)
+	require.True(t, service.hasMarkers(types.CatState{Prefix: "st"}))
+}
+
+func newPatternTestService(t testing.TB) *Service {
+	cfg := &types.RigConfig{CatStates: []types.CatState{{Prefix: "CS"}}}
+	service := &Service{config: cfg, LoggerService: &logging.Service{}}
+	service.Options.StateOptions = map[string]StateOptions{"CS": {Pattern: `^(?P<CALLSIGN>[A-Z0-9/]+),(?P<TEXT>[^;]*);`}}
+	if err := service.initializeStateSet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.compilePatterns(); err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func TestParseStatePattern(t *testing.T) {
+	service := newPatternTestService(t)
+
+	status, err := service.parseState(types.CatState{Prefix: "CS", Data: "VK2/G4ABC,hello world;"})
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"CALLSIGN": "VK2/G4ABC", "TEXT": "hello world"}, status)
+}
+
+func TestCompilePatternsRejectsInvalid(t *testing.T) {
+	service := &Service{config: &types.RigConfig{CatStates: []types.CatState{{Prefix: "CS"}}}}
+	require.NoError(t, service.initializeStateSet())
+
+	service.Options.StateOptions = map[string]StateOptions{"CS": {Pattern: `([A-Z]+`}}
+	require.ErrorContains(t, service.compilePatterns(), "invalid pattern")
+
+	service.Options.StateOptions = map[string]StateOptions{"CS": {Pattern: `([A-Z]+)`}}
+	require.ErrorContains(t, service.compilePatterns(), "no named groups")
+
+	service.Options.StateOptions = map[string]StateOptions{"XX": {Pattern: `(?P<A>x)`}}
+	require.ErrorContains(t, service.compilePatterns(), "unknown CAT state")
+}
+
+func BenchmarkParseStateFixed(b *testing.B) {
+	service := newPatternTestService(b)
+	state := types.CatState{Prefix: "FA", Data: "00014074000;", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}
+	for b.Loop() {
+		_, _ = service.parseState(state)
+	}
+}
+
+func BenchmarkParseStatePattern(b *testing.B) {
+	service := newPatternTestService(b)
+	state := types.CatState{Prefix: "CS", Data: "VK2/G4ABC,hello world;"}
+	for b.Loop() {
+		_, _ = service.parseState(state)
+	}
+}
+
+func TestParseStateSelectsLayout(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.StateOptions = map[string]StateOptions{"IF": {Layouts: []StateLayout{
+		{Discriminator: "1", DiscriminatorIndex: 0, Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 1, Length: 9}}},
+		{Discriminator: "2", DiscriminatorIndex: 0, Markers: []types.Marker{{Tag: "VFOBFREQ", Index: 1, Length: 9}}},
+		{Length: 3, Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 3}}},
+	}}}
+
+	status, err := service.parseState(types.CatState{Prefix: "IF", Data: "1014074000"})
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "014074000"}, status)
+
+	status, err = service.parseState(types.CatState{Prefix: "IF", Data: "2007074000"})
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOBFREQ": "007074000"}, status)
+
+	status, err = service.parseState(types.CatState{Prefix: "IF", Data: "USB"})
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"MAINMODE": "USB"}, status)
+
+	service.Options.ParseMode = ParseStrict
+	_, err = service.parseState(types.CatState{Prefix: "IF", Data: "9x"})
+	require.ErrorContains(t, err, "no layout matches")
+}
+
+func TestParseStateCharsets(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.StateOptions = map[string]StateOptions{"MN": {
+		Charsets: map[string]Charset{"NAME": CharsetLatin1, "CALLSIGN": CharsetASCII, "NOTE": CharsetUTF8},
+		Pattern:  `;(?P<CALLSIGN>[^;]*);(?P<NOTE>.*)$`,
+	}}
+	service.supportedCatStates = map[string]types.CatState{"MN": {Prefix: "MN"}}
+	require.NoError(t, service.compilePatterns())
+
+	state := types.CatState{Prefix: "MN", Data: "M\xfcnchen \x00;DL1\x01ABC  ;caf\xc3\xa9\xff", Markers: []types.Marker{
+		{Tag: "NAME", Index: 0, Length: 9},
+	}}
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"NAME": "München", "CALLSIGN": "DL1ABC", "NOTE": "café�"}, status)
+}
+
+func TestCaseSensitivePrefixes(t *testing.T) {
+	cfg := &types.RigConfig{CatStates: []types.CatState{{Prefix: "fa"}, {Prefix: "FA"}}}
+	service := newStartedTestService(t, cfg)
+	state, ok := service.lookupCatState([]byte("fa123"))
+	require.True(t, ok)
+	require.Equal(t, "FA", state.Prefix, "prefixes are folded by default")
+
+	service.Options.CaseSensitivePrefixes = true
+	require.NoError(t, service.initializeStateSet())
+	state, ok = service.lookupCatState([]byte("fa123"))
+	require.True(t, ok)
+	require.Equal(t, "fa", state.Prefix)
+	require.Equal(t, "123", state.Data)
+	_, ok = service.lookupCatState([]byte("Fa123"))
+	require.False(t, ok)
+}
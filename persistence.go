@@ -0,0 +1,205 @@
+package cat
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// auditRecord is one line of the command audit log.
+type auditRecord struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Cmd     string    `json:"cmd"`
+	Origin  string    `json:"origin"`
+}
+
+// qsyRecord is one line of the QSY history.
+type qsyRecord struct {
+	Time   time.Time `json:"time"`
+	FromHz string    `json:"from"`
+	ToHz   string    `json:"to"`
+	// Origin is who caused the change: the origin of our last frequency command, or "rig" for the front panel.
+	Origin string `json:"origin"`
+}
+
+const (
+	// defaultLastStateIntervalMS is used when Options.Persistence.LastStateIntervalMS is zero.
+	defaultLastStateIntervalMS = 2000
+	// defaultStoreQueueSize is used when Options.Persistence.QueueSize is zero.
+	defaultStoreQueueSize = 256
+)
+
+// storeRecord is an encoded record waiting to be appended to the log under key.
+type storeRecord struct {
+	key  string
+	data []byte
+}
+
+// newStoreChannel creates the queue of the store writer if a persistence feature appending records is selected.
+func newStoreChannel(opts PersistenceOptions) chan storeRecord {
+	if !opts.AuditLog && !opts.QSYHistory {
+		return nil
+	}
+	size := opts.QueueSize
+	if size <= 0 {
+		size = defaultStoreQueueSize
+	}
+	return make(chan storeRecord, size)
+}
+
+// storeWriter appends the queued records to the Store until shutdown.
+func (s *Service) storeWriter(shutdown <-chan struct{}) {
+	logs := newLogLimiter(time.Minute)
+	for {
+		select {
+		case <-shutdown:
+			// Keep what was queued before the shutdown.
+			for len(s.storeChannel) > 0 {
+				s.writeRecord(<-s.storeChannel, logs)
+			}
+			return
+		case record := <-s.storeChannel:
+			s.writeRecord(record, logs)
+		}
+	}
+}
+
+// writeRecord appends record to the Store, rate-limiting the logs of failures.
+func (s *Service) writeRecord(record storeRecord, logs *logLimiter) {
+	if err := s.Store.Append(record.key, record.data); err != nil {
+		if suppressed, ok := logs.allow(time.Now()); ok {
+			s.logger().ErrorWith().Err(err).Str("key", record.key).Int("suppressed", suppressed).Msg("failed to append store record")
+		}
+	}
+}
+
+// stateSaver saves the last state after a value of the rig state changes, coalescing the changes of every
+// Options.Persistence.LastStateIntervalMS into one save so that the processor never waits on the Store. Stop saves
+// the final state once the workers have exited.
+func (s *Service) stateSaver(shutdown <-chan struct{}) {
+	interval := durationOrDefault(s.Options.Persistence.LastStateIntervalMS, defaultLastStateIntervalMS)
+	saved := s.cache.initialVersion()
+	for {
+		changed := s.cache.changed()
+		if s.cache.currentVersion() == saved {
+			select {
+			case <-shutdown:
+				return
+			case <-changed:
+			}
+			continue
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-shutdown:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		saved = s.cache.currentVersion()
+		s.saveLastState()
+	}
+}
+
+// saveLastState persists the merged rig state so it can be inspected (or restored) after a restart.
+func (s *Service) saveLastState() {
+	if s.Store == nil || !s.Options.Persistence.LastState || s.cache == nil {
+		return
+	}
+	data, err := json.Marshal(s.cache.snapshot())
+	if err != nil {
+		s.logger().ErrorWith().Err(err).Msg("failed to encode last rig state")
+		return
+	}
+	if err = s.Store.Save(storeKeyLastState, data); err != nil {
+		s.logger().ErrorWith().Err(err).Msg("failed to save last rig state")
+	}
+}
+
+// LastSavedState returns the rig state saved when the service was last stopped.
+func (s *Service) LastSavedState() (types.CatStatus, error) {
+	const op errors.Op = "cat.Service.LastSavedState"
+	return s.loadStatus(op, storeKeyLastState)
+}
+
+// SaveProfile stores the current rig state under name, e.g. "contest" or "ft8-20m".
+func (s *Service) SaveProfile(name string) error {
+	const op errors.Op = "cat.Service.SaveProfile"
+	if s.Store == nil {
+		return errors.New(op).Msg(errMsgNilStore)
+	}
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	data, err := json.Marshal(s.cache.snapshot())
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.Store.Save(storeProfilePrefix+name, data); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// Profile returns the rig state saved under name.
+func (s *Service) Profile(name string) (types.CatStatus, error) {
+	const op errors.Op = "cat.Service.Profile"
+	return s.loadStatus(op, storeProfilePrefix+name)
+}
+
+// loadStatus decodes a CatStatus saved under key.
+func (s *Service) loadStatus(op errors.Op, key string) (types.CatStatus, error) {
+	if s.Store == nil {
+		return nil, errors.New(op).Msg(errMsgNilStore)
+	}
+	data, err := s.Store.Load(key)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	var status types.CatStatus
+	if err = json.Unmarshal(data, &status); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return status, nil
+}
+
+// auditCommand appends a written command to the audit log.
+func (s *Service) auditCommand(cmd queuedCommand) {
+	if s.Store == nil || !s.Options.Persistence.AuditLog {
+		return
+	}
+	s.appendRecord(storeKeyAuditLog, auditRecord{Time: time.Now(), Command: cmd.Name, Cmd: cmd.Cmd, Origin: cmd.origin.String()})
+}
+
+// recordQSY appends a VFO A frequency change to the QSY history. previous is the value before the update.
+func (s *Service) recordQSY(previous cachedValue, hadPrevious bool, status types.CatStatus) {
+	if s.Store == nil || !s.Options.Persistence.QSYHistory {
+		return
+	}
+	freq, ok := status[tags.VfoAFreq.String()]
+	if !ok || (hadPrevious && previous.Value == freq) {
+		return
+	}
+	now := time.Now()
+	origin := s.origins.attribute(CmdSetVfoAFreq, now)
+	s.appendRecord(storeKeyQSYHistory, qsyRecord{Time: now, FromHz: previous.Value, ToHz: freq, Origin: origin.String()})
+}
+
+// appendRecord encodes record as JSON and queues it for the log under key without blocking, dropping it if the
+// queue is full.
+func (s *Service) appendRecord(key string, record any) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		s.logger().ErrorWith().Err(err).Str("key", key).Msg("failed to encode store record")
+		return
+	}
+	select {
+	case s.storeChannel <- storeRecord{key: key, data: data}:
+	default:
+		s.count(&s.counters.storeDropped, "store_dropped", 1)
+	}
+}
@@ -0,0 +1,212 @@
+package cat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// commandRequest is a command on its way from one of the public APIs to the send channel. Filters may rewrite the
+// parameters, reject the request, or queue follow-up requests that are submitted once this one has been queued.
+type commandRequest struct {
+	name   cmds.CatCmdName
+	params []string
+	then   []*commandRequest
+	// stateParams are the parameters as the rig state reports them, if a filter rewrote params for the wire,
+	// e.g. a transverter frequency sent as its IF.
+	stateParams []string
+
+	// confirmAvoid acknowledges tuning or transmitting inside an avoid range.
+	confirmAvoid bool
+	// skipAutoMode suppresses automatic mode selection because the caller sets the mode itself.
+	skipAutoMode bool
+	// force bypasses duplicate suppression.
+	force bool
+	// skip is set by a filter to drop the command itself while keeping its follow-ups.
+	skip bool
+	// origin is where the command came from; follow-up requests inherit it.
+	origin Origin
+	// priority decides how soon the command is written; PriorityDefault derives it from the command. Follow-up
+	// requests inherit it.
+	priority Priority
+	// outcome follows the request and its follow-ups through the pipeline; nil if it is not tracked.
+	outcome *CommandHandle
+	// transverter is the transverter state that a VFO A frequency command activates once written; see
+	// transverterFilter.
+	transverter *int32
+	// onFirstWrite, if set, is called when the first of the commands begins to be written.
+	onFirstWrite func(at time.Time)
+	// settle is how long the sender holds after writing the last of the commands; see settleFor.
+	settle time.Duration
+}
+
+// settleFor makes the sender hold for d after writing the command and its follow-ups, for rigs that need time to
+// settle before the next command, e.g. between the two commands of Tune.
+func settleFor(d time.Duration) CommandOption {
+	return func(req *commandRequest) {
+		req.settle = d
+	}
+}
+
+// CatCommandRequest names a configured command and its parameters, for APIs that take several commands at once.
+type CatCommandRequest struct {
+	Name   cmds.CatCmdName
+	Params []string
+}
+
+// CommandOption sets a per-call policy flag on a command, e.g. ConfirmAvoidRange.
+type CommandOption func(req *commandRequest)
+
+// reportedParams returns the parameters as the rig state reports them.
+func (r *commandRequest) reportedParams() []string {
+	if r.stateParams != nil {
+		return r.stateParams
+	}
+	return r.params
+}
+
+// newCommandRequest builds a request and applies opts to it.
+func newCommandRequest(name cmds.CatCmdName, params []string, opts ...CommandOption) *commandRequest {
+	req := &commandRequest{name: name, params: params}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+// commandFilter inspects or modifies a request before it is formatted and queued. Returning an error rejects it.
+type commandFilter func(req *commandRequest) error
+
+// commandFilters returns the policy filters applied to every command, in order.
+func (s *Service) commandFilters() []commandFilter {
+	return []commandFilter{
+		s.guardFilter,
+		s.avoidRangeFilter,
+		s.powerLimitFilter,
+		s.autoModeFilter,
+		s.duplicateFilter,
+		s.transverterFilter,
+	}
+}
+
+// submit runs req through the command filters, formats it and queues it on the send channel, followed by any
+// follow-up requests added by the filters.
+func (s *Service) submit(req *commandRequest) error {
+	_, err := s.submitTracked(req)
+	return err
+}
+
+// submitTracked is submit, returning the handle on the outcome of req.
+func (s *Service) submitTracked(req *commandRequest) (*CommandHandle, error) {
+	const op errors.Op = "cat.Service.submit"
+
+	req.outcome = s.newCommandHandle(req)
+	prepared, err := s.prepare(req)
+	if err != nil {
+		req.outcome.fail(err)
+		return nil, err
+	}
+	if len(prepared) > 0 {
+		prepared[len(prepared)-1].settle = req.settle
+	}
+	req.outcome.expect(len(prepared))
+	for _, catCmd := range prepared {
+		if err = s.queueCommand(catCmd); err != nil {
+			req.outcome.fail(err)
+			return nil, errors.New(op).Err(err)
+		}
+	}
+	req.outcome.accepted()
+	return req.outcome, nil
+}
+
+// prepare runs req through the command filters and formats it, returning the command followed by any follow-up
+// commands added by the filters, in the order they must be written.
+func (s *Service) prepare(req *commandRequest) ([]queuedCommand, error) {
+	const op errors.Op = "cat.Service.prepare"
+
+	for _, filter := range s.commandFilters() {
+		if err := filter(req); err != nil {
+			return nil, errors.New(op).Err(err).Msgf("Command %s rejected: %v", req.name, err)
+		}
+	}
+
+	var prepared []queuedCommand
+	priority := s.priorityFor(req)
+	if !req.skip {
+		catCmd, err := s.formatRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		prepared = append(prepared, queuedCommand{CatCommand: catCmd, origin: req.origin, priority: priority, verify: s.readBackFor(req), synthetic: s.syntheticFor(req), outcome: req.outcome, transverter: req.transverter})
+	}
+	for _, next := range req.then {
+		if next.origin == OriginUnspecified {
+			next.origin = req.origin
+		}
+		if next.outcome == nil {
+			next.outcome = req.outcome
+		}
+		// Follow-ups share the queue of their request so that they are written after it.
+		if next.priority == PriorityDefault {
+			next.priority = priority
+		}
+		more, err := s.prepare(next)
+		if err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		prepared = append(prepared, more...)
+	}
+	return prepared, nil
+}
+
+// formatRequest looks up the template for req and fills in its parameters.
+func (s *Service) formatRequest(req *commandRequest) (types.CatCommand, error) {
+	const op errors.Op = "cat.Service.formatRequest"
+
+	catCmd, err := s.commandLookup(req.name)
+	if err != nil {
+		return types.CatCommand{}, errors.New(op).Msgf("Command lookup failed: %v", err)
+	}
+
+	paramsInterface := make([]interface{}, len(req.params))
+	for i, v := range req.params {
+		paramsInterface[i] = v
+	}
+
+	// Validate the format string against provided parameters to avoid runtime panics from fmt.Sprintf.
+	if err = s.validateCommandFormat(catCmd.Cmd, paramsInterface...); err != nil {
+		return types.CatCommand{}, errors.New(op).Err(err).Msg("Command parameter validation failed")
+	}
+
+	catCmd.Cmd = fmt.Sprintf(catCmd.Cmd, paramsInterface...)
+
+	// Command is fully defined in configuration and already validated for format/arity,
+	// so no additional sanitization is required here.
+
+	return catCmd, nil
+}
+
+// queueCommand places a fully formatted command on the send channel for its priority without blocking. With
+// Options.Fairness, normal-priority commands go to the lane of their origin instead.
+func (s *Service) queueCommand(catCmd queuedCommand) error {
+	const op errors.Op = "cat.Service.queueCommand"
+	catCmd.queued = time.Now()
+	s.notePTTOffQueued(catCmd)
+	if s.fair != nil && catCmd.priority != PriorityHigh && catCmd.priority != PriorityLow {
+		return s.fair.push(catCmd)
+	}
+	if ch := s.channelFor(catCmd.priority); ch != nil {
+		select {
+		case ch <- catCmd:
+			s.metricsSink().Gauge("send_queue_depth", float64(len(ch)))
+			return nil
+		default:
+			return errors.New(op).Msg("Send channel is full.")
+		}
+	}
+	return errors.New(op).Msg("Send channel is closed.")
+}
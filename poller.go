@@ -0,0 +1,118 @@
+package cat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+)
+
+// PollEntry is a command the built-in poller enqueues periodically to keep the cached rig state fresh.
+type PollEntry struct {
+	Command cmds.CatCmdName
+	// IntervalMS is the polling interval. The unit is milliseconds.
+	IntervalMS time.Duration
+}
+
+// pollTracker remembers which poll commands are queued but not yet written, so that a backed-up send channel does
+// not accumulate copies of the same poll.
+type pollTracker struct {
+	mu      sync.Mutex
+	pending map[cmds.CatCmdName]bool
+}
+
+// claim marks name as pending and reports whether it was not pending already.
+func (p *pollTracker) claim(name cmds.CatCmdName) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = make(map[cmds.CatCmdName]bool)
+	}
+	if p.pending[name] {
+		return false
+	}
+	p.pending[name] = true
+	return true
+}
+
+// release clears the pending mark of name.
+func (p *pollTracker) release(name cmds.CatCmdName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, name)
+}
+
+// reset forgets all pending polls, e.g. those left in the queue by a previous run.
+func (p *pollTracker) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = nil
+}
+
+// poller enqueues the entries of Options.Polls and the meter polls of Options.Meters at their intervals. An entry
+// whose previous poll has not been written yet is skipped (coalesced) rather than queued again.
+func (s *Service) poller(shutdown <-chan struct{}) {
+	entries := make([]PollEntry, 0, len(s.Options.Polls))
+	for _, e := range s.Options.Polls {
+		if e.IntervalMS > 0 {
+			entries = append(entries, e)
+		}
+	}
+	// Rigs do not report their meters in auto-information mode, so meter polls are never scaled.
+	regular := len(entries)
+	entries = append(entries, s.meterPolls()...)
+	if len(entries) == 0 {
+		return
+	}
+	s.polls.reset()
+
+	now := time.Now()
+	due := make([]time.Time, len(entries))
+	for i := range due {
+		due[i] = now
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case now = <-timer.C:
+		}
+
+		next := now.Add(time.Hour)
+		for i, e := range entries {
+			if !now.Before(due[i]) {
+				interval, ok := s.pollInterval(e.IntervalMS * time.Millisecond)
+				if i >= regular {
+					interval, ok = e.IntervalMS*time.Millisecond, !s.pollsSuspended()
+				}
+				if ok {
+					s.poll(e.Command)
+				}
+				due[i] = now.Add(interval)
+			}
+			if due[i].Before(next) {
+				next = due[i]
+			}
+		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// poll enqueues one poll command unless a previous one is still waiting to be written. Polling is suspended while
+// the rig is off; the presence monitor probes it instead.
+func (s *Service) poll(name cmds.CatCmdName) {
+	if s.rigOff.Load() {
+		return
+	}
+	if !s.polls.claim(name) {
+		s.count(&s.counters.pollsCoalesced, "polls_coalesced", 1)
+		return
+	}
+	if err := s.EnqueueCommandWith(name, nil, WithOrigin(OriginPoller)); err != nil {
+		s.polls.release(name)
+		s.logger().DebugWith().Err(err).Str("command", name.String()).Msg("poll not queued")
+	}
+}
@@ -0,0 +1,35 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollerCoalescesWhileQueued(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READFREQ", Cmd: "FA;"}, {Name: "READMODE", Cmd: "MD;"}},
+	})
+	service.Options.Polls = []PollEntry{{Command: "READFREQ", IntervalMS: 5}, {Command: "READMODE", IntervalMS: 5}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"poller": service.poller})
+
+	// Nothing drains the send channels, so each entry is queued once, at low priority, and then coalesced.
+	require.Eventually(t, func() bool { return service.counters.pollsCoalesced.Load() >= 4 }, time.Second, 5*time.Millisecond)
+	require.Len(t, service.lowChannel, 2)
+}
+
+func TestPollerResumesAfterWrite(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READFREQ", Cmd: "FA;"}},
+	})
+	service.Options.Polls = []PollEntry{{Command: "READFREQ", IntervalMS: 5}}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"poller":           service.poller,
+		"serialPortSender": service.serialPortSender,
+	})
+
+	require.Eventually(t, func() bool { return len(fake.writes()) >= 3 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "FA;", fake.writes()[0])
+}
@@ -0,0 +1,184 @@
+package cat
+
+import (
+	stderr "errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	bugst "go.bug.st/serial"
+)
+
+// EventPortAvailable is the kind of PortAvailableEvent.
+const EventPortAvailable EventKind = "PORT_AVAILABLE"
+
+// defaultPortWatchMS is used when Options.Reconnect.PortWatchMS is zero.
+const defaultPortWatchMS = 1000
+
+// PortAvailableEvent is emitted when a serial port that failed to open because another process held it has been
+// released, so that the user interface can offer to connect again.
+type PortAvailableEvent struct {
+	At   time.Time
+	Port string
+}
+
+func (e PortAvailableEvent) Kind() EventKind { return EventPortAvailable }
+func (e PortAvailableEvent) Time() time.Time { return e.At }
+
+// portHolder is another process holding a serial device open.
+type portHolder struct {
+	PID     int
+	Process string
+}
+
+// String renders the holder for error messages, e.g. `"wsjtx" (pid 4211)`.
+func (h portHolder) String() string {
+	if h.Process == "" {
+		return fmt.Sprintf("pid %d", h.PID)
+	}
+	return fmt.Sprintf("%q (pid %d)", h.Process, h.PID)
+}
+
+// lookupPortHolders finds the processes holding a device open; replaced by the tests. It returns no holders where
+// the OS offers no cheap way to tell.
+var lookupPortHolders = findPortHolders
+
+// otherPortHolders returns the processes other than this one holding device open.
+func otherPortHolders(device string) []portHolder {
+	holders, err := lookupPortHolders(device)
+	if err != nil {
+		return nil
+	}
+	self := os.Getpid()
+	others := holders[:0]
+	for _, h := range holders {
+		if h.PID != self {
+			others = append(others, h)
+		}
+	}
+	return others
+}
+
+// checkPortConflict fails if another process holds device, naming the process, and watches the device so that
+// PortAvailableEvent is emitted once it is released.
+func (s *Service) checkPortConflict(device string) error {
+	const op errors.Op = "cat.Service.checkPortConflict"
+	holders := otherPortHolders(device)
+	if len(holders) == 0 {
+		return nil
+	}
+	names := make([]string, len(holders))
+	for i, h := range holders {
+		names[i] = h.String()
+	}
+	s.watchPortRelease(device)
+	return errors.New(op).Msgf("Serial port %s is in use by %s. Close that program, or select another port.", device, strings.Join(names, ", "))
+}
+
+// explainOpenError adds a targeted hint to err, a failure to open device, if it is caused by the port being busy
+// or inaccessible.
+func (s *Service) explainOpenError(device string, err error) error {
+	const op errors.Op = "cat.Service.explainOpenError"
+	if classifyPortError(err) != linkFaultInUse {
+		return err
+	}
+	if isPermissionError(err) {
+		// Waiting does not help: the user needs to be given access to the port.
+		return errors.New(op).Err(err).Msgf("Serial port %s could not be opened: %s", device, portPermissionHint)
+	}
+	s.watchPortRelease(device)
+	return errors.New(op).Err(err).Msgf("Serial port %s could not be opened: %s", device, portBusyHint)
+}
+
+// isPermissionError reports whether err means the user may not open the port, as opposed to the port being busy.
+func isPermissionError(err error) bool {
+	var pe *bugst.PortError
+	if stderr.As(err, &pe) {
+		return pe.Code() == bugst.PermissionDenied
+	}
+	return isOSPermissionError(err)
+}
+
+// portWatch is the run of watchPortRelease while it waits for another process to release the port.
+type portWatch struct {
+	mu  sync.Mutex
+	run *runState
+}
+
+// watchPortRelease polls device every Options.Reconnect.PortWatchMS until no other process holds it, then emits
+// PortAvailableEvent. Only one watch runs at a time, in a run of its own that Start and Stop shut down. None is
+// started while the reconnect logic reopens the port, as it reports the outcome itself.
+func (s *Service) watchPortRelease(device string) {
+	if s.linkDown.Load() {
+		return
+	}
+	w := &s.portWatch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.run != nil {
+		return
+	}
+	run := &runState{shutdownChannel: make(chan struct{})}
+	w.run = run
+	interval := durationOrDefault(s.Options.Reconnect.PortWatchMS, defaultPortWatchMS)
+	s.launchWorkerThread(run, func(shutdown <-chan struct{}) {
+		defer s.endPortWatch(run)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-shutdown:
+				return
+			case <-ticker.C:
+			}
+			if !portReleased(device) {
+				continue
+			}
+			s.logger().InfoWith().Str("port", device).Msg("serial port released by the other program")
+			s.emitEvent(PortAvailableEvent{At: time.Now(), Port: device})
+			return
+		}
+	}, "portWatch")
+}
+
+// endPortWatch forgets run once its watch has returned.
+func (s *Service) endPortWatch(run *runState) {
+	w := &s.portWatch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.run == run {
+		w.run = nil
+	}
+}
+
+// stopPortWatch shuts the watch down, if one runs, and waits for it to return.
+func (s *Service) stopPortWatch() {
+	w := &s.portWatch
+	w.mu.Lock()
+	run := w.run
+	w.run = nil
+	w.mu.Unlock()
+	if run == nil {
+		return
+	}
+	close(run.shutdownChannel)
+	run.wg.Wait()
+}
+
+// portReleased reports whether no other process holds device. Where holders can be listed the device is not
+// opened, since opening a port asserts DTR and RTS and would key the rig; elsewhere, it is released once it can
+// be opened, with the lines released, and closed again.
+var portReleased = func(device string) bool {
+	if holdersListed {
+		return len(otherPortHolders(device)) == 0 && portAccessible(device)
+	}
+	port, err := bugst.Open(device, &bugst.Mode{InitialStatusBits: &bugst.ModemOutputBits{}})
+	if err != nil {
+		return false
+	}
+	_ = port.Close()
+	return true
+}
@@ -0,0 +1,133 @@
+//go:build linux
+
+package cat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// holdersListed reports whether findPortHolders can list the processes holding a device.
+const holdersListed = true
+
+// procRoot is the mount point of procfs.
+const procRoot = "/proc"
+
+// accessReadWrite is the R_OK|W_OK mode of access(2).
+const accessReadWrite = 0x4 | 0x2
+
+// lockDirs hold the UUCP lock files (LCK..ttyUSB0) written by programs such as minicom.
+var lockDirs = []string{"/var/lock", "/run/lock"}
+
+// findPortHolders lists the processes with an open descriptor on device, the processes holding a lock on it, and
+// the owner of its UUCP lock file. The descriptors of other users' processes cannot be inspected, so those are
+// only found through their locks.
+func findPortHolders(device string) ([]portHolder, error) {
+	target, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	var holders []portHolder
+	add := func(pid int) {
+		if !seen[pid] {
+			seen[pid] = true
+			holders = append(holders, portHolder{PID: pid, Process: processName(pid)})
+		}
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(procRoot, e.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(procRoot, e.Name(), "fd", fd.Name())); err == nil && link == target {
+				add(pid)
+				break
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(procRoot, "locks")); err == nil {
+		if id, ok := lockID(target); ok {
+			for _, pid := range lockHolders(data, id) {
+				add(pid)
+			}
+		}
+	}
+
+	for _, dir := range lockDirs {
+		data, err := os.ReadFile(filepath.Join(dir, "LCK.."+filepath.Base(target)))
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			continue
+		}
+		if _, err = os.Stat(filepath.Join(procRoot, strconv.Itoa(pid))); err == nil {
+			add(pid)
+		}
+	}
+	return holders, nil
+}
+
+// processName returns the command name of pid, or "" if it cannot be read.
+func processName(pid int) string {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// lockID returns the identity of path in /proc/locks: the major and minor number of its file system, in hex,
+// and its inode, e.g. "00:05:1043".
+func lockID(path string) (string, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return "", false
+	}
+	dev := uint64(st.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+	return fmt.Sprintf("%02x:%02x:%d", major, minor, st.Ino), true
+}
+
+// lockHolders returns the processes holding or waiting for a lock on the file id in data, the content of
+// /proc/locks, whose lines read e.g. "1: FLOCK  ADVISORY  WRITE 4211 00:05:1043 0 EOF".
+func lockHolders(data []byte, id string) []int {
+	var pids []int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(strings.Replace(scanner.Text(), "->", "", 1))
+		if len(fields) < 6 || fields[5] != id {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[4]); err == nil && pid > 0 {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// portAccessible reports whether this process may open device for reading and writing, without opening it.
+func portAccessible(device string) bool {
+	return syscall.Access(device, accessReadWrite) == nil
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package cat
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPortHoldersListsOpenDescriptors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttyFAKE0")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	holders, err := findPortHolders(path)
+	require.NoError(t, err)
+	require.Contains(t, holders, portHolder{PID: os.Getpid(), Process: processName(os.Getpid())})
+	require.Empty(t, otherPortHolders(path), "this process is not a conflict")
+}
+
+func TestExplainOpenErrorHints(t *testing.T) {
+	probe := portReleased
+	portReleased = func(string) bool { return false }
+	t.Cleanup(func() { portReleased = probe })
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Reconnect.PortWatchMS = time.Hour
+
+	t.Cleanup(service.stopPortWatch)
+
+	err := service.explainOpenError("/dev/ttyUSB0", syscall.EACCES)
+	require.ErrorContains(t, err, "dialout")
+	require.Equal(t, linkFaultInUse, classifyPortError(err))
+	require.False(t, portWatching(service), "waiting for a port without access does not help")
+
+	err = service.explainOpenError("/dev/ttyUSB0", syscall.EBUSY)
+	require.ErrorContains(t, err, "in use by another program")
+
+	require.Equal(t, syscall.ENOENT, service.explainOpenError("/dev/ttyUSB0", syscall.ENOENT))
+}
+
+func TestLockHoldersMatchDevice(t *testing.T) {
+	locks := []byte("1: FLOCK  ADVISORY  WRITE 4211 00:05:1043 0 EOF\n" +
+		"1: -> FLOCK  ADVISORY  WRITE 4212 00:05:1043 0 EOF\n" +
+		"2: POSIX  ADVISORY  WRITE 999 fd:01:1043 0 EOF\n")
+	require.Equal(t, []int{4211, 4212}, lockHolders(locks, "00:05:1043"))
+}
+
+func TestFindPortHoldersListsLocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttyFAKE1")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, syscall.Flock(int(f.Fd()), syscall.LOCK_EX))
+
+	id, ok := lockID(path)
+	require.True(t, ok)
+	locks, err := os.ReadFile("/proc/locks")
+	require.NoError(t, err)
+	require.Contains(t, lockHolders(locks, id), os.Getpid())
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package cat
+
+// holdersListed reports whether findPortHolders can list the processes holding a device.
+const holdersListed = false
+
+// findPortHolders cannot list the holders of a device on this OS without enumerating every process handle, so a
+// conflict is only diagnosed when the open fails.
+func findPortHolders(string) ([]portHolder, error) {
+	return nil, nil
+}
+
+// portAccessible is only consulted where holders are listed.
+func portAccessible(string) bool {
+	return true
+}
@@ -0,0 +1,76 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortConflictNamesHolderAndReportsRelease(t *testing.T) {
+	released := make(chan struct{})
+	lookup, probe := lookupPortHolders, portReleased
+	lookupPortHolders = func(string) ([]portHolder, error) {
+		return []portHolder{{PID: 4211, Process: "wsjtx"}}, nil
+	}
+	portReleased = func(string) bool {
+		select {
+		case <-released:
+			return true
+		default:
+			return false
+		}
+	}
+	t.Cleanup(func() { lookupPortHolders, portReleased = lookup, probe })
+
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Reconnect.PortWatchMS = 1
+
+	err := service.checkPortConflict("/dev/ttyUSB0")
+	require.ErrorContains(t, errors.Root(err), `in use by "wsjtx" (pid 4211)`)
+	require.Error(t, service.checkPortConflict("/dev/ttyUSB0"), "a second attempt does not start another watch")
+
+	close(released)
+	select {
+	case e := <-service.eventChannel:
+		require.Equal(t, PortAvailableEvent{At: e.(PortAvailableEvent).At, Port: "/dev/ttyUSB0"}, e)
+	case <-time.After(time.Second):
+		t.Fatal("no PortAvailableEvent")
+	}
+	require.Eventually(t, func() bool { return !portWatching(service) }, time.Second, time.Millisecond)
+}
+
+func portWatching(service *Service) bool {
+	service.portWatch.mu.Lock()
+	defer service.portWatch.mu.Unlock()
+	return service.portWatch.run != nil
+}
+
+func TestPortWatchEndsOnStop(t *testing.T) {
+	lookup, probe := lookupPortHolders, portReleased
+	lookupPortHolders = func(string) ([]portHolder, error) { return []portHolder{{PID: 4211}}, nil }
+	portReleased = func(string) bool { return false }
+	t.Cleanup(func() { lookupPortHolders, portReleased = lookup, probe })
+
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Reconnect.PortWatchMS = 1
+	require.Error(t, service.checkPortConflict("/dev/ttyUSB0"))
+	require.True(t, portWatching(service))
+
+	service.started.Store(false) // as after a Start that failed to open the port
+	require.NoError(t, service.Stop())
+	require.False(t, portWatching(service))
+}
+
+func TestNoPortWatchWhileReconnecting(t *testing.T) {
+	lookup := lookupPortHolders
+	lookupPortHolders = func(string) ([]portHolder, error) { return []portHolder{{PID: 4211}}, nil }
+	t.Cleanup(func() { lookupPortHolders = lookup })
+
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.linkDown.Store(true)
+	require.Error(t, service.checkPortConflict("/dev/ttyUSB0"))
+	require.False(t, portWatching(service), "the reconnect logic reopens the port itself")
+}
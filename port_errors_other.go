@@ -0,0 +1,36 @@
+//go:build !windows
+
+package cat
+
+import (
+	stderr "errors"
+	"syscall"
+)
+
+// classifyOSError recognises the errors reported when a USB serial device is unplugged or held by another process.
+func classifyOSError(err error) linkFault {
+	var errno syscall.Errno
+	if !stderr.As(err, &errno) {
+		return linkFaultTransient
+	}
+	switch errno {
+	case syscall.ENXIO, syscall.ENODEV, syscall.ENOENT, syscall.EIO:
+		return linkFaultRemoved
+	case syscall.EBUSY, syscall.EACCES:
+		return linkFaultInUse
+	default:
+		return linkFaultTransient
+	}
+}
+
+// Hints added to a failure to open a busy or inaccessible port.
+const (
+	portBusyHint       = "it is in use by another program. Close that program, or select another port."
+	portPermissionHint = "permission denied. Add your user to the group owning the device (usually dialout or uucp) and log in again."
+)
+
+// isOSPermissionError reports whether err is a permission error rather than a busy port.
+func isOSPermissionError(err error) bool {
+	var errno syscall.Errno
+	return stderr.As(err, &errno) && (errno == syscall.EACCES || errno == syscall.EPERM)
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package cat
+
+import (
+	stderr "errors"
+	"syscall"
+)
+
+// Windows error codes reported when a USB serial adapter is pulled while the port is open.
+const (
+	errorGenFailure         syscall.Errno = 31
+	errorBadCommand         syscall.Errno = 22
+	errorOperationAborted   syscall.Errno = 995
+	errorDeviceNotConnected syscall.Errno = 1167
+	errorDeviceRemoved      syscall.Errno = 1617
+)
+
+// classifyOSError recognises the Windows surprise-removal errors. ERROR_FILE_NOT_FOUND means the COM port has not
+// been re-enumerated yet, while ERROR_ACCESS_DENIED usually means the old handle has not been released.
+func classifyOSError(err error) linkFault {
+	var errno syscall.Errno
+	if !stderr.As(err, &errno) {
+		return linkFaultTransient
+	}
+	switch errno {
+	case syscall.ERROR_FILE_NOT_FOUND, syscall.ERROR_PATH_NOT_FOUND, errorGenFailure, errorBadCommand,
+		errorOperationAborted, errorDeviceNotConnected, errorDeviceRemoved:
+		return linkFaultRemoved
+	case syscall.ERROR_ACCESS_DENIED:
+		return linkFaultInUse
+	default:
+		return linkFaultTransient
+	}
+}
+
+// Hints added to a failure to open a busy port. Windows reports a COM port open in another program as access
+// denied, so both hints are the same.
+const (
+	portBusyHint       = "it is in use by another program, or a previous connection has not been released yet. Close any other program using the port, or select another port."
+	portPermissionHint = portBusyHint
+)
+
+// isOSPermissionError reports false: access denied on a COM port means it is held by another program.
+func isOSPermissionError(error) bool {
+	return false
+}
@@ -0,0 +1,92 @@
+package cat
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Station-Manager/errors"
+)
+
+// PortManager coordinates ownership of serial devices between the services of a station (cat, rotator, keyer
+// ...), so that a device is never opened twice. Ports are identified by their resolved device path.
+type PortManager interface {
+	// Acquire claims port for owner. It fails if another owner holds the port.
+	Acquire(port, owner string) error
+	// Release gives up owner's claim on port.
+	Release(port, owner string) error
+}
+
+// LocalPortManager is a PortManager for services running in the same process. The zero value is ready to use.
+type LocalPortManager struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+// Acquire implements PortManager. Acquiring a port again for its current owner succeeds.
+func (m *LocalPortManager) Acquire(port, owner string) error {
+	const op errors.Op = "cat.LocalPortManager.Acquire"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.owners[port]; ok && current != owner {
+		return errors.New(op).Msgf("Serial port %s is in use by %s.", port, current)
+	}
+	if m.owners == nil {
+		m.owners = make(map[string]string)
+	}
+	m.owners[port] = owner
+	return nil
+}
+
+// Release implements PortManager.
+func (m *LocalPortManager) Release(port, owner string) error {
+	const op errors.Op = "cat.LocalPortManager.Release"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.owners[port]; ok && current != owner {
+		return errors.New(op).Msgf("Serial port %s is owned by %s, not %s.", port, current, owner)
+	}
+	delete(m.owners, port)
+	return nil
+}
+
+// Owner returns the current owner of port, if any.
+func (m *LocalPortManager) Owner(port string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	owner, ok := m.owners[port]
+	return owner, ok
+}
+
+// portOwner is the name under which the service claims its port.
+func (s *Service) portOwner() string {
+	if s.RigID != 0 {
+		return fmt.Sprintf("%s/rig-%d", ServiceName, s.RigID)
+	}
+	return ServiceName
+}
+
+// managedTransport releases the port's claim when the transport is closed.
+type managedTransport struct {
+	Transport
+	release func() error
+	once    sync.Once
+}
+
+// Close closes the transport and then releases the claim.
+func (m *managedTransport) Close() error {
+	err := m.Transport.Close()
+	m.once.Do(func() {
+		if rerr := m.release(); rerr != nil && err == nil {
+			err = rerr
+		}
+	})
+	return err
+}
+
+// Errors exposes the wrapped transport's asynchronous errors, if it has any, to the reconnect logic.
+func (m *managedTransport) Errors() <-chan error {
+	if src, ok := m.Transport.(errorSource); ok {
+		return src.Errors()
+	}
+	return nil
+}
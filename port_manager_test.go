@@ -0,0 +1,36 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPortManagerOwnership(t *testing.T) {
+	var m LocalPortManager
+
+	require.NoError(t, m.Acquire("/dev/ttyUSB0", "cat"))
+	require.NoError(t, m.Acquire("/dev/ttyUSB0", "cat"))
+	require.Error(t, m.Acquire("/dev/ttyUSB0", "rotator"))
+	require.Error(t, m.Release("/dev/ttyUSB0", "rotator"))
+
+	require.NoError(t, m.Release("/dev/ttyUSB0", "cat"))
+	require.NoError(t, m.Acquire("/dev/ttyUSB0", "rotator"))
+	owner, ok := m.Owner("/dev/ttyUSB0")
+	require.True(t, ok)
+	require.Equal(t, "rotator", owner)
+}
+
+func TestManagedTransportReleasesOnClose(t *testing.T) {
+	var m LocalPortManager
+	require.NoError(t, m.Acquire("/dev/ttyUSB0", "cat"))
+
+	inner := newFakeTransport()
+	transport := &managedTransport{Transport: inner, release: func() error { return m.Release("/dev/ttyUSB0", "cat") }}
+	require.NoError(t, transport.Close())
+	require.NoError(t, transport.Close())
+	require.True(t, inner.closed)
+
+	_, ok := m.Owner("/dev/ttyUSB0")
+	require.False(t, ok)
+}
@@ -0,0 +1,25 @@
+package cat
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+)
+
+// resolvePortName follows symlinks in a port path, so that stable names such as udev aliases (/dev/rig-main) or
+// /dev/serial/by-id entries can be configured instead of enumeration-order names like /dev/ttyUSB0. The link is
+// resolved on every open, so a reconnect finds the device even if it came back under a different node. Names
+// without a path separator, such as Windows COM ports, are returned unchanged.
+func resolvePortName(name string) (string, error) {
+	const op errors.Op = "cat.resolvePortName"
+	if !strings.ContainsRune(name, '/') {
+		return name, nil
+	}
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		// Keep the cause so that a missing device is classified as removed by the reconnect logic.
+		return "", errors.New(op).Err(err).Msgf("cannot resolve serial port %s", name)
+	}
+	return resolved, nil
+}
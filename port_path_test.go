@@ -0,0 +1,33 @@
+package cat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePortNameFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "ttyUSB3")
+	require.NoError(t, os.WriteFile(device, nil, 0o600))
+	alias := filepath.Join(dir, "rig-main")
+	require.NoError(t, os.Symlink(device, alias))
+
+	resolved, err := resolvePortName(alias)
+	require.NoError(t, err)
+	want, err := filepath.EvalSymlinks(device)
+	require.NoError(t, err)
+	require.Equal(t, want, resolved)
+
+	name, err := resolvePortName("COM7")
+	require.NoError(t, err)
+	require.Equal(t, "COM7", name)
+}
+
+func TestResolvePortNameMissingDeviceIsRemoval(t *testing.T) {
+	_, err := resolvePortName(filepath.Join(t.TempDir(), "rig-main"))
+	require.Error(t, err)
+	require.Equal(t, linkFaultRemoved, classifyPortError(err))
+}
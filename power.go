@@ -0,0 +1,183 @@
+package cat
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// CmdSetTxPower is the command name for the rig's power-set template, e.g. {Name: "SETTXPWR", Cmd: "PC%s;"}.
+const CmdSetTxPower cmds.CatCmdName = "SETTXPWR"
+
+// PowerLimitAction decides what happens to a power request that exceeds the limit for the current band.
+type PowerLimitAction string
+
+const (
+	// PowerLimitClamp reduces the requested power to the limit and notifies the operator. This is the default.
+	PowerLimitClamp PowerLimitAction = "clamp"
+	// PowerLimitReject refuses the request with an error.
+	PowerLimitReject PowerLimitAction = "reject"
+)
+
+// SetPower sets the transmit power in watts, subject to the per-band limits in Options.PowerLimits.
+func (s *Service) SetPower(watts int, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.SetPower"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if watts < 0 {
+		return errors.New(op).Msgf("invalid power: %d W", watts)
+	}
+	if err := s.EnqueueCommandWith(CmdSetTxPower, []string{s.formatPower(watts)}, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// formatPower renders watts in the width of the marker that reports the transmit power.
+func (s *Service) formatPower(watts int) string {
+	value := strconv.Itoa(watts)
+	if marker, ok := s.markerFor(tags.TxPwr); ok && len(value) < marker.Length {
+		value = strings.Repeat("0", marker.Length-len(value)) + value
+	}
+	return value
+}
+
+// powerLimit returns the configured limit for band, if any.
+func (s *Service) powerLimit(band bands.Band) (int, bool) {
+	limit, ok := s.Options.PowerLimits[band]
+	return limit, ok && limit > 0
+}
+
+// cachedBand returns the band of the last reported VFO A frequency.
+func (s *Service) cachedBand() (bands.Band, bool) {
+	hz, ok := s.cachedFrequency()
+	if !ok {
+		return "", false
+	}
+	return s.bandForFrequency(hz)
+}
+
+// cachedPower returns the last reported transmit power in watts.
+func (s *Service) cachedPower() (int, bool) {
+	if s.cache == nil {
+		return 0, false
+	}
+	cached, ok := s.cache.get(tags.TxPwr.String())
+	if !ok {
+		return 0, false
+	}
+	watts, err := strconv.Atoi(strings.TrimSpace(cached.Value))
+	if err != nil {
+		return 0, false
+	}
+	return watts, true
+}
+
+// powerLimitFilter enforces the per-band limits on power requests, and on frequency changes that move the rig to a
+// band whose limit is below the current power.
+func (s *Service) powerLimitFilter(req *commandRequest) error {
+	const op errors.Op = "cat.Service.powerLimitFilter"
+	if len(s.Options.PowerLimits) == 0 || len(req.params) != 1 {
+		return nil
+	}
+
+	switch req.name {
+	case CmdSetTxPower:
+		band, ok := s.cachedBand()
+		if !ok {
+			return nil
+		}
+		limit, ok := s.powerLimit(band)
+		if !ok {
+			return nil
+		}
+		watts, err := strconv.Atoi(strings.TrimSpace(req.params[0]))
+		if err != nil || watts <= limit {
+			return nil
+		}
+		if s.Options.PowerLimitAction == PowerLimitReject {
+			return errors.New(op).Msgf("%d W exceeds the %d W limit on %s", watts, limit, band)
+		}
+		// Copy rather than clamp the caller's parameters in place.
+		req.params = slices.Clone(req.params)
+		req.params[0] = s.formatPower(limit)
+		req.outcome.clamp(fmt.Sprintf("power reduced from %d W to the %d W limit on %s", watts, limit, band))
+		s.notifyPowerClamped(band, watts, limit)
+
+	case CmdSetVfoAFreq:
+		hz, err := strconv.ParseInt(strings.TrimSpace(req.params[0]), 10, 64)
+		if err != nil {
+			return nil
+		}
+		band, ok := s.bandForFrequency(hz)
+		if !ok {
+			return nil
+		}
+		limit, ok := s.powerLimit(band)
+		if !ok {
+			return nil
+		}
+		if watts, ok := s.cachedPower(); ok && watts > limit {
+			req.then = append(req.then, &commandRequest{name: CmdSetTxPower, params: []string{s.formatPower(limit)}})
+			req.outcome.clamp(fmt.Sprintf("power reduced from %d W to the %d W limit on %s", watts, limit, band))
+			s.notifyPowerClamped(band, watts, limit)
+		}
+	}
+	return nil
+}
+
+// enforcePowerLimit checks a status update reported by the rig (e.g. a band change made on the front panel) and
+// reduces the power if it now exceeds the limit for the current band.
+func (s *Service) enforcePowerLimit(status types.CatStatus) {
+	if len(s.Options.PowerLimits) == 0 {
+		return
+	}
+	_, freqChanged := status[tags.VfoAFreq.String()]
+	_, powerChanged := status[tags.TxPwr.String()]
+	if !freqChanged && !powerChanged {
+		return
+	}
+
+	band, ok := s.cachedBand()
+	if !ok {
+		return
+	}
+	limit, ok := s.powerLimit(band)
+	if !ok {
+		return
+	}
+	watts, ok := s.cachedPower()
+	if !ok || watts <= limit {
+		return
+	}
+
+	// The rig will keep reporting the old power until it has processed the correction, so avoid re-sending it
+	// for every frame in the meantime.
+	now := time.Now()
+	if now.Sub(s.lastPowerClamp) < time.Second {
+		return
+	}
+	s.lastPowerClamp = now
+
+	if err := s.SetPower(limit, WithOrigin(OriginInternal)); err != nil {
+		s.logger().ErrorWith().Err(err).Msg("failed to enforce band power limit")
+		return
+	}
+	s.notifyPowerClamped(band, watts, limit)
+}
+
+func (s *Service) notifyPowerClamped(band bands.Band, watts, limit int) {
+	s.logger().InfoWith().Str("band", band.String()).Int("requested", watts).Int("limit", limit).Msg("CAT power clamped to band limit")
+	s.notify(SeverityWarning, "Power limited",
+		fmt.Sprintf("Transmit power %d W exceeds the %d W limit for %s; reduced to the limit.", watts, limit, band),
+		"")
+}
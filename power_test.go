@@ -0,0 +1,60 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newPowerTestService(t *testing.T) *Service {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands, types.CatCommand{Name: CmdSetTxPower.String(), Cmd: "PC%s;"})
+	cfg.CatStates = append(cfg.CatStates, types.CatState{Prefix: "PC", Markers: []types.Marker{{Tag: "TXPWR", Index: 0, Length: 3}}})
+	service := newStartedTestService(t, cfg)
+	service.Options.PowerLimits = map[bands.Band]int{bands.Band6: 10}
+	return service
+}
+
+func TestSetPowerClampedOnLimitedBand(t *testing.T) {
+	service := newPowerTestService(t)
+	service.cache.update(types.CatStatus{"VFOAFREQ": "050313000"}, time.Now())
+
+	require.NoError(t, service.SetPower(100))
+	require.Equal(t, []string{"PC010;"}, drainCommands(service))
+	require.Len(t, service.notificationChannel, 1)
+}
+
+func TestPowerClampLeavesCallerParamsAlone(t *testing.T) {
+	service := newPowerTestService(t)
+	service.cache.update(types.CatStatus{"VFOAFREQ": "050313000"}, time.Now())
+
+	params := []string{"100"}
+	require.NoError(t, service.EnqueueCommand(CmdSetTxPower, params...))
+	require.Equal(t, []string{"PC010;"}, drainCommands(service))
+	require.Equal(t, []string{"100"}, params)
+}
+
+func TestSetPowerRejectedOnLimitedBand(t *testing.T) {
+	service := newPowerTestService(t)
+	service.Options.PowerLimitAction = PowerLimitReject
+	service.cache.update(types.CatStatus{"VFOAFREQ": "050313000"}, time.Now())
+
+	require.Error(t, service.SetPower(50))
+	require.NoError(t, service.SetPower(5))
+	require.Equal(t, []string{"PC005;"}, drainCommands(service))
+}
+
+func TestBandChangeReducesPower(t *testing.T) {
+	service := newPowerTestService(t)
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000", "TXPWR": "100"}, time.Now())
+
+	require.NoError(t, service.Tune(50313000, "USB"))
+	require.Equal(t, []string{"FA050313000;", "PC010;", "MD02;"}, drainCommands(service))
+}
+
+func TestSetPowerBeforeInitialize(t *testing.T) {
+	require.ErrorContains(t, (&Service{}).SetPower(50), errMsgServiceNotInit)
+}
@@ -0,0 +1,119 @@
+package cat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+)
+
+const (
+	// defaultPresenceTimeoutMS is used when Options.Presence.TimeoutMS is zero.
+	defaultPresenceTimeoutMS = 5000
+	// defaultPresenceProbeMS is used when Options.Presence.ProbeIntervalMS is zero.
+	defaultPresenceProbeMS = 5000
+)
+
+// RigOff reports whether the rig has been detected as powered off: commands are written but nothing is answered.
+func (s *Service) RigOff() bool {
+	return s.rigOff.Load()
+}
+
+// noteFrameReceived records that the rig answered and, if it had been detected as off, resumes full operation.
+func (s *Service) noteFrameReceived() {
+	if s.rigOff.CompareAndSwap(true, false) {
+		s.logger().InfoWith().Msg("rig is answering again; resuming polling")
+		s.notify(SeverityInfo, "Rig responding", "The rig is answering again.", "")
+	}
+}
+
+// noteCommandWritten records a successful write of cmd for rig-off and health detection.
+func (s *Service) noteCommandWritten(cmd queuedCommand) {
+	if s.expectsResponse(cmd) {
+		s.awaitedSince.CompareAndSwap(0, time.Now().UnixNano())
+	}
+}
+
+// noteCommandAnswered records that the rig answered with a recognised frame, so no command awaits a response.
+func (s *Service) noteCommandAnswered() {
+	s.awaitedSince.Store(0)
+}
+
+// unansweredSince returns when the oldest command still awaiting a response was written, or false if none is.
+func (s *Service) unansweredSince() (time.Time, bool) {
+	since := s.awaitedSince.Load()
+	if since == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, since), true
+}
+
+// expectsResponse reports whether the rig answers cmd: it has a response policy, or it is a query without
+// parameters that a configured state answers. Set commands, which most rigs do not acknowledge, are not awaited.
+func (s *Service) expectsResponse(cmd queuedCommand) bool {
+	name := cmds.CatCmdName(cmd.Name)
+	if _, ok := s.Options.ResponsePolicies[name]; ok {
+		return true
+	}
+	if _, set := s.commandTag(name); set {
+		return false
+	}
+	def, err := s.commandLookup(name)
+	if err != nil || strings.Contains(def.Cmd, "%") {
+		return false
+	}
+	_, err = s.responsePrefixFor(name)
+	return err == nil
+}
+
+// presenceMonitor detects a powered-off rig, whose serial adapter still accepts writes but which sends nothing
+// back, and then quiesces the poller and probes the rig at a low rate until it answers again. The silence is
+// measured from the oldest command still awaiting a response, so that a write after an idle spell is given the
+// whole timeout to be answered.
+func (s *Service) presenceMonitor(shutdown <-chan struct{}) {
+	opts := s.Options.Presence
+	timeout := durationOrDefault(opts.TimeoutMS, defaultPresenceTimeoutMS)
+	probeEvery := durationOrDefault(opts.ProbeIntervalMS, defaultPresenceProbeMS)
+	probe := opts.ProbeCommand
+	if probe == "" {
+		probe = cmds.Read
+	}
+
+	// The silence window starts no earlier than the monitor itself.
+	started := time.Now().UnixNano()
+
+	ticker := time.NewTicker(max(timeout/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	var lastProbe time.Time
+	for {
+		select {
+		case <-shutdown:
+			return
+		case now := <-ticker.C:
+			if s.rigOff.Load() {
+				if now.Sub(lastProbe) >= probeEvery {
+					lastProbe = now
+					if err := s.EnqueueCommandWith(probe, nil, WithOrigin(OriginInternal)); err != nil {
+						s.logger().DebugWith().Err(err).Msg("presence probe not queued")
+					}
+				}
+				continue
+			}
+
+			since, awaiting := s.unansweredSince()
+			if !awaiting {
+				continue
+			}
+			silentSince := time.Unix(0, max(since.UnixNano(), started))
+			if now.Sub(silentSince) >= timeout {
+				if s.rigOff.CompareAndSwap(false, true) {
+					lastProbe = now
+					s.logger().WarnWith().Dur("silence", now.Sub(silentSince)).Msg("rig not answering; assuming it is off")
+					s.notify(SeverityWarning, "Rig not responding",
+						"Commands are being sent but the rig does not answer; it is probably switched off.",
+						"Switch the rig on; polling resumes automatically when it answers.")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresenceMonitorQuiescesAndResumes(t *testing.T) {
+	cfg := &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOA_FREQ", Index: 2, Length: 11}}}},
+	}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 5
+	service := newStartedTestService(t, cfg)
+	service.Options.Presence = PresenceOptions{Enabled: true, TimeoutMS: 40, ProbeIntervalMS: 20}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortSender":   service.serialPortSender,
+		"serialPortListener": service.serialPortListener,
+		"presenceMonitor":    service.presenceMonitor,
+	})
+
+	service.poll("READ")
+	require.Eventually(t, service.RigOff, time.Second, 5*time.Millisecond)
+
+	// Polls are suspended; only the probe is written.
+	written := len(fake.writes())
+	service.poll("READ")
+	require.Eventually(t, func() bool { return len(fake.writes()) > written }, time.Second, 5*time.Millisecond)
+	require.Zero(t, len(service.lowChannel))
+
+	fake.push("FA00014074000;")
+	require.Eventually(t, func() bool { return !service.RigOff() }, time.Second, 5*time.Millisecond)
+
+	notes, err := service.Notifications()
+	require.NoError(t, err)
+	require.Equal(t, "Rig not responding", (<-notes).Title)
+	require.Equal(t, "Rig responding", (<-notes).Title)
+}
+
+func TestPresenceMonitorTimesTheWriteAfterAnIdleSpell(t *testing.T) {
+	cfg := &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOA_FREQ", Index: 2, Length: 11}}}},
+	}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 5
+	service := newStartedTestService(t, cfg)
+	service.Options.Presence = PresenceOptions{Enabled: true, TimeoutMS: 40, ProbeIntervalMS: 20}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortSender":   service.serialPortSender,
+		"serialPortListener": service.serialPortListener,
+		"presenceMonitor":    service.presenceMonitor,
+	})
+
+	time.Sleep(100 * time.Millisecond) // nothing written for longer than the timeout
+	service.poll("READ")
+	require.Eventually(t, func() bool { return len(fake.writes()) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(15 * time.Millisecond)
+	fake.push("FA00014074000;")
+	require.Never(t, service.RigOff, 100*time.Millisecond, 5*time.Millisecond, "the rig answered within the timeout")
+}
@@ -0,0 +1,144 @@
+package cat
+
+import (
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// Priority decides the order in which queued commands are written. Higher priorities are always written first;
+// within a priority commands keep their queue order.
+type Priority int
+
+const (
+	// PriorityDefault derives the priority from the command: PTTOFF is high, polls are low and everything else
+	// is normal. Transmit commands are normal too, so that they are written after the frequency and mode changes
+	// queued before them rather than keying the rig on the old frequency; they still preempt queued polls.
+	// PTTOFF jumps the queue, and the keying commands it overtakes are dropped.
+	PriorityDefault Priority = iota
+	PriorityLow
+	PriorityNormal
+	PriorityHigh
+)
+
+// String implements fmt.Stringer.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "default"
+	}
+}
+
+// WithPriority sets the priority of a command, e.g. PriorityHigh for an emergency unkey.
+func WithPriority(p Priority) CommandOption {
+	return func(req *commandRequest) {
+		req.priority = p
+	}
+}
+
+// priorityFor resolves the priority of req.
+func (s *Service) priorityFor(req *commandRequest) Priority {
+	switch {
+	case req.priority != PriorityDefault:
+		return req.priority
+	case req.name == CmdPTTOff:
+		return PriorityHigh
+	case req.origin == OriginPoller:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// channelFor returns the send channel holding commands of priority p.
+func (s *Service) channelFor(p Priority) chan queuedCommand {
+	switch p {
+	case PriorityHigh:
+		return s.highChannel
+	case PriorityLow:
+		return s.lowChannel
+	default:
+		return s.sendChannel
+	}
+}
+
+// nextRegular returns the next regular command without waiting, highest priority first and, within the normal
+// priority, by fair queuing if enabled. Stale low-priority commands are dropped on the way. ok is false when no command is queued.
+func (s *Service) nextRegular() (cmd queuedCommand, ok bool) {
+	for _, ch := range []chan queuedCommand{s.highChannel, s.sendChannel, s.lowChannel} {
+		if ch == s.sendChannel && s.fair != nil {
+			if cmd, ok = s.fair.pop(); ok {
+				return cmd, true
+			}
+		}
+	drain:
+		for {
+			select {
+			case cmd = <-ch:
+				if s.isStale(cmd) {
+					s.dropStale(cmd)
+					continue
+				}
+				return cmd, true
+			default:
+				break drain
+			}
+		}
+	}
+	return queuedCommand{}, false
+}
+
+// writeRegular writes a regular command or batch, unless it went stale. It returns false on shutdown.
+func (s *Service) writeRegular(shutdown <-chan struct{}, throttle *sendThrottle, cmd queuedCommand) bool {
+	if s.isStale(cmd) {
+		s.dropStale(cmd)
+		return true
+	}
+	if s.unkeyedSince(cmd) {
+		s.dropUnkeyed(cmd)
+		return true
+	}
+	if cmd.batch != nil {
+		s.observeLatency(LatencyQueueToWrite, time.Since(cmd.queued))
+		return s.writeBatch(shutdown, throttle, cmd.batch)
+	}
+	if !throttle.wait(shutdown, cmd.origin) {
+		return false
+	}
+	s.observeLatency(LatencyQueueToWrite, time.Since(cmd.queued))
+	if s.writeCommand(cmd) == nil {
+		s.afterWrite(shutdown, cmd)
+	}
+	return true
+}
+
+// isStale reports whether cmd is a low-priority command that waited longer than Options.Priority.LowStaleMS.
+func (s *Service) isStale(cmd queuedCommand) bool {
+	staleAfter := s.Options.Priority.LowStaleMS
+	return staleAfter > 0 && cmd.priority == PriorityLow && time.Since(cmd.queued) > staleAfter*time.Millisecond
+}
+
+// dropStale discards a stale command; a fresh one is queued by the next poll. A stale batch fails as a whole.
+func (s *Service) dropStale(cmd queuedCommand) {
+	const op errors.Op = "cat.Service.dropStale"
+	if cmd.batch != nil {
+		err := errors.New(op).Msg("batch dropped after waiting too long behind higher priorities")
+		for _, c := range cmd.batch.cmds {
+			c.outcome.fail(err)
+		}
+		cmd.batch.finish(err)
+	}
+	cmd.outcome.fail(errors.New(op).Msg("dropped after waiting too long behind higher priorities"))
+	if cmd.origin == OriginPoller {
+		s.polls.release(cmds.CatCmdName(cmd.Name))
+	}
+	s.count(&s.counters.staleDropped, "stale_dropped", 1)
+	s.logger().DebugWith().Str("cmd", cmd.Name).Msg("stale low-priority command dropped")
+}
@@ -0,0 +1,64 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newPriorityTestService(t *testing.T) *Service {
+	return newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "READFREQ", Cmd: "FA;"},
+			{Name: "READMODE", Cmd: "MD;"},
+			{Name: CmdPTTOff.String(), Cmd: "RX;"},
+		},
+	})
+}
+
+func TestPTTPreemptsQueuedPolls(t *testing.T) {
+	service := newPriorityTestService(t)
+
+	service.poll("READFREQ")
+	service.poll("READMODE")
+	require.NoError(t, service.SetPTT(false))
+
+	require.Equal(t, []string{"RX;", "FA;", "MD;"}, drainCommands(service))
+}
+
+func TestWithPriorityOverridesDefault(t *testing.T) {
+	service := newPriorityTestService(t)
+
+	require.NoError(t, service.EnqueueCommandWith("READFREQ", nil, WithPriority(PriorityLow)))
+	require.NoError(t, service.EnqueueCommandWith("READMODE", nil, WithPriority(PriorityHigh)))
+
+	require.Equal(t, []string{"MD;", "FA;"}, drainCommands(service))
+}
+
+func TestStaleLowPriorityCommandsAreDropped(t *testing.T) {
+	service := newPriorityTestService(t)
+	service.Options.Priority.LowStaleMS = 10
+
+	service.poll("READFREQ")
+	time.Sleep(20 * time.Millisecond)
+	service.poll("READMODE")
+
+	require.Equal(t, []string{"MD;"}, drainCommands(service))
+	require.Equal(t, uint64(1), service.counters.staleDropped.Load())
+
+	// The dropped poll no longer counts as pending.
+	service.poll("READFREQ")
+	require.Equal(t, []string{"FA;"}, drainCommands(service))
+}
+
+func TestWatchdogUnkeyJumpsTheQueue(t *testing.T) {
+	service := newPriorityTestService(t)
+
+	require.NoError(t, service.EnqueueCommand("READMODE"))
+	_, err := service.queueUnkey()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"RX;", "MD;"}, drainCommands(service))
+}
@@ -1,67 +1,101 @@
 package cat
 
 import (
+	"maps"
+	"time"
+
 	"github.com/Station-Manager/types"
 )
 
 func (s *Service) lineProcessor(shutdown <-chan struct{}) {
+	var heartbeat <-chan time.Time
+	if s.Options.StatusDiff.Enabled && s.Options.StatusDiff.HeartbeatMS > 0 {
+		ticker := time.NewTicker(s.Options.StatusDiff.HeartbeatMS * time.Millisecond)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
 	for {
 		select {
 		case <-shutdown:
 			return
-		case state := <-s.processingChannel:
-			if len(state.Markers) == 0 {
-				s.LoggerService.ErrorWith().Str("line", state.Data).Msg("Bad catState configuration; no markers defined. Skipping line.")
-				continue
+		case <-heartbeat:
+			if len(s.emitted) > 0 && !s.emitStatus(maps.Clone(s.emitted), shutdown) {
+				return
 			}
-
-			status := types.CatStatus{}
-
-			for _, marker := range state.Markers {
-				start := marker.Index
-				if start < 0 || start >= len(state.Data) {
-					s.LoggerService.WarnWith().Int("index", start).Msg("marker index out of range; skipping marker")
-					continue
+		case frame := <-s.processingChannel:
+			if frame.synthetic != nil {
+				if !s.processSynthetic(frame.synthetic, shutdown) {
+					return
 				}
+				continue
+			}
+			if !s.processState(frame, shutdown) {
+				return // Shutdown signaled
+			}
+		}
+	}
+}
 
-				end := marker.Index + marker.Length
-				if end > len(state.Data) {
-					s.LoggerService.WarnWith().Int("index", start).Int("length", marker.Length).Msg("marker end out of range; clamping to line end")
-					end = len(state.Data)
-				}
-				if start >= end {
-					s.LoggerService.DebugWith().Int("index", start).Int("length", marker.Length).Msg("empty slice for marker; skipping")
-					continue
-				}
+// processState runs a received state through the response pipeline and emits the resulting status. It returns
+// false if shutdown was signaled.
+func (s *Service) processState(frame receivedState, shutdown <-chan struct{}) bool {
+	r := &response{receivedState: frame, at: time.Now()}
+	if !s.runResponsePipeline(r) {
+		return true
+	}
+	if !s.emitStatus(r.status, shutdown) {
+		return false // Shutdown signaled
+	}
+	if !frame.received.IsZero() {
+		s.observeLatency(LatencyFrameToStatus, time.Since(frame.received))
+	}
+	return true
+}
 
-				slice := state.Data[start:end]
+// emitStatus publishes status on the EventBus and hands its translation to the subscribers, the topic and
+// remote subscribers and the status channel. It returns false if shutdown was signaled.
+func (s *Service) emitStatus(status types.CatStatus, shutdown <-chan struct{}) bool {
+	s.publish(TopicStatus, status)
+	if s.EventBus != nil {
+		s.publish(TopicNormalized, s.Normalized())
+	}
 
-				if len(marker.ValueMappings) == 0 {
-					status[marker.Tag] = slice
-				} else {
-					mapped := ""
-					for _, vm := range marker.ValueMappings {
-						if slice == vm.Key {
-							mapped = vm.Value
-							break
-						}
-					}
-					status[marker.Tag] = mapped // empty string if no mapping matched
-				}
-			}
+	display := s.translateStatus(status)
+	s.offerToSubscribers(display)
+	s.routeStatus(display)
+	if s.Options.Remote.Enabled {
+		s.offerRemote(display)
+	}
+	if !s.sendStatusWithEviction(display, shutdown) {
+		return false
+	}
+	s.count(&s.counters.statusesEmitted, "statuses_emitted", 1)
+	return true
+}
 
-			if !s.sendStatusWithEviction(status, shutdown) {
-				return // Shutdown signaled
-			}
+// changedFields returns the fields of status whose values differ from the last emitted ones, and remembers them.
+func (s *Service) changedFields(status types.CatStatus) types.CatStatus {
+	if s.emitted == nil {
+		s.emitted = make(types.CatStatus, len(status))
+	}
+	changed := make(types.CatStatus, len(status))
+	for tag, value := range status {
+		if last, ok := s.emitted[tag]; !ok || last != value {
+			changed[tag] = value
+			s.emitted[tag] = value
 		}
 	}
+	return changed
 }
 
 // sendStatusWithEviction attempts to send a status update to the status channel.
-// If the channel is full, it evicts the oldest status and retries.
-// For unbuffered channels, it drops the status with a warning.
+// If the channel is full, it evicts the oldest status and retries. With Options.StatusDiff the evicted diff is
+// merged into status, as changedFields has already taken its values as delivered.
+// For unbuffered channels, it drops the status with a warning, and changedFields forgets its values.
 // Returns true if sent successfully, false if shutdown was signaled.
 func (s *Service) sendStatusWithEviction(status types.CatStatus, shutdown <-chan struct{}) bool {
+	merged := false
 	for {
 		select {
 		case <-shutdown:
@@ -69,31 +103,52 @@ func (s *Service) sendStatusWithEviction(status types.CatStatus, shutdown <-chan
 		case s.statusChannel <- status:
 			return true
 		default:
-			if !s.tryEvictOldestStatus(shutdown) {
+			evicted, ok := s.tryEvictOldestStatus(shutdown)
+			if !ok {
+				if s.Options.StatusDiff.Enabled {
+					for tag := range status {
+						delete(s.emitted, tag)
+					}
+				}
 				return false
 			}
+			if s.Options.StatusDiff.Enabled && len(evicted) > 0 {
+				if !merged {
+					// status is shared with the subscribers; merge into a copy.
+					status, merged = maps.Clone(status), true
+				}
+				for tag, value := range evicted {
+					if _, newer := status[tag]; !newer {
+						status[tag] = value
+					}
+				}
+			}
 			// Successfully evicted, loop will retry send
 		}
 	}
 }
 
-// tryEvictOldestStatus attempts to remove one item from the status channel to make room.
+// tryEvictOldestStatus attempts to remove one item from the status channel to make room, and returns it.
 // Returns false if the channel is unbuffered or shutdown is signaled, true otherwise.
-func (s *Service) tryEvictOldestStatus(shutdown <-chan struct{}) bool {
+func (s *Service) tryEvictOldestStatus(shutdown <-chan struct{}) (types.CatStatus, bool) {
 	if cap(s.statusChannel) == 0 {
-		s.LoggerService.WarnWith().Msg("No consumer on unbuffered status channel, dropping status.")
-		return false
+		s.logger().WarnWith().Msg("No consumer on unbuffered status channel, dropping status.")
+		s.count(&s.counters.statusesDropped, "statuses_dropped", 1)
+		s.noteDrop(DropStatus)
+		return nil, false
 	}
 
 	select {
 	case <-shutdown:
-		return false
-	case <-s.statusChannel:
-		s.LoggerService.DebugWith().Msg("Evicted oldest status from full channel")
-		return true
+		return nil, false
+	case evicted := <-s.statusChannel:
+		s.logger().DebugWith().Msg("Evicted oldest status from full channel")
+		s.count(&s.counters.statusesDropped, "statuses_dropped", 1)
+		s.noteDrop(DropStatus)
+		return evicted, true
 	default:
 		// Channel became empty between checks (race condition)
 		// Return true to retry send - the channel likely has space now
-		return true
+		return nil, true
 	}
 }
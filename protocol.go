@@ -0,0 +1,60 @@
+package cat
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+// Protocol identifies the wire protocol spoken by a rig.
+type Protocol string
+
+const (
+	// ProtocolASCIILine is the line-oriented ASCII protocol used by Kenwood, Yaesu, Elecraft and similar rigs.
+	// This is the default.
+	ProtocolASCIILine Protocol = "ascii-line"
+	// ProtocolCIV is Icom's binary CI-V protocol: frames of the form FE FE <to> <from> <cmd> <data...> FD with
+	// BCD-encoded values.
+	ProtocolCIV Protocol = "ci-v"
+)
+
+// protocolCodec translates between the package's text representation of commands and frames and the wire
+// format. Commands and states are always configured as text so that the rest of the pipeline (prefix lookup,
+// markers, mappings) is shared by all protocols.
+type protocolCodec interface {
+	// encodeCommand converts a formatted command into the bytes written to the rig.
+	encodeCommand(cmd string) (string, error)
+	// decodeFrame converts a received frame into text that can be matched against the state prefixes. It returns
+	// false for frames that must be ignored, such as the echo of our own commands.
+	decodeFrame(frame []byte) ([]byte, bool)
+	// lineDelimiter is the byte that terminates a frame, or zero to keep the serial configuration's delimiter.
+	lineDelimiter() byte
+}
+
+// newProtocolCodec returns the codec for the protocol selected in opts.
+func newProtocolCodec(opts Options) (protocolCodec, error) {
+	const op errors.Op = "cat.newProtocolCodec"
+	switch opts.Protocol {
+	case "", ProtocolASCIILine:
+		return asciiLineCodec{}, nil
+	case ProtocolCIV:
+		return newCIVCodec(opts.CIV), nil
+	default:
+		return nil, errors.New(op).Msgf("unknown protocol %q", opts.Protocol)
+	}
+}
+
+// codec returns the protocol codec in use, defaulting to ASCII lines.
+func (s *Service) codec() protocolCodec {
+	if s.protocol == nil {
+		return asciiLineCodec{}
+	}
+	return s.protocol
+}
+
+// asciiLineCodec passes commands and frames through unchanged.
+type asciiLineCodec struct{}
+
+func (asciiLineCodec) encodeCommand(cmd string) (string, error) { return cmd, nil }
+
+func (asciiLineCodec) decodeFrame(frame []byte) ([]byte, bool) { return frame, true }
+
+func (asciiLineCodec) lineDelimiter() byte { return 0 }
@@ -0,0 +1,305 @@
+package cat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultPTTRetryMS is used when Options.PTT.RetryMS is zero.
+	defaultPTTRetryMS = 1000
+	// pttReadBackPoll is how often unkeyNow checks whether the transmitter reads back unkeyed.
+	pttReadBackPoll = 5 * time.Millisecond
+)
+
+// PTTState is the keying state of the transmitter, as last commanded through this service.
+type PTTState struct {
+	On bool
+	// Since is when the transmitter was last keyed or unkeyed; zero if it never was.
+	Since time.Time
+}
+
+// PTTWatchdogEvent is emitted when the watchdog unkeyed a transmitter that stayed keyed longer than
+// Options.PTT.MaxTxMS.
+type PTTWatchdogEvent struct {
+	At       time.Time
+	KeyedFor time.Duration
+	// Err is set if the transmitter could not be unkeyed. The watchdog keeps trying, and emits another event
+	// once it succeeds.
+	Err string
+}
+
+func (e PTTWatchdogEvent) Kind() EventKind { return EventPTTWatchdog }
+func (e PTTWatchdogEvent) Time() time.Time { return e.At }
+
+// pttTracker follows the PTT commands written to the rig and runs the watchdog while keyed.
+type pttTracker struct {
+	mu    sync.Mutex
+	state PTTState
+	timer *time.Timer
+	// release unkeys a transmitter keyed other than by PTT, e.g. by the CW key line; nil means by PTT.
+	release func() error
+	// stuck is set once the watchdog reported that it could not unkey the transmitter, so that its retries do not
+	// report again.
+	stuck bool
+	// offQueued is when PTTOFF was last queued. As it jumps the queue, keying commands queued before it are
+	// dropped rather than written after it.
+	offQueued time.Time
+}
+
+// PTT keys (on) or unkeys the transmitter, like SetPTT without options.
+func (s *Service) PTT(on bool) error {
+	return s.SetPTT(on)
+}
+
+// PTTState returns the keying state of the transmitter. It follows the PTTON and PTTOFF commands, and the
+// transmit commands of Options.TxCommands, once they were written to the rig, and the PTT and CW control lines.
+func (s *Service) PTTState() PTTState {
+	s.ptt.mu.Lock()
+	defer s.ptt.mu.Unlock()
+	return s.ptt.state
+}
+
+// trackPTT updates the keying state after cmd was written.
+func (s *Service) trackPTT(cmd queuedCommand) {
+	name := cmds.CatCmdName(cmd.Name)
+	if keyed := s.isTxCommand(name); keyed || name == CmdPTTOff {
+		s.notePTT(keyed)
+	}
+}
+
+// notePTTOffQueued records when cmd was queued if it is PTTOFF.
+func (s *Service) notePTTOffQueued(cmd queuedCommand) {
+	if cmds.CatCmdName(cmd.Name) != CmdPTTOff {
+		return
+	}
+	s.ptt.mu.Lock()
+	defer s.ptt.mu.Unlock()
+	s.ptt.offQueued = cmd.queued
+}
+
+// unkeyedSince reports whether cmd keys the transmitter and a PTTOFF was queued after it.
+func (s *Service) unkeyedSince(cmd queuedCommand) bool {
+	if cmd.batch != nil || !s.isTxCommand(cmds.CatCmdName(cmd.Name)) {
+		return false
+	}
+	s.ptt.mu.Lock()
+	defer s.ptt.mu.Unlock()
+	return s.ptt.offQueued.After(cmd.queued)
+}
+
+// dropUnkeyed discards a keying command overtaken by a PTTOFF, so that the transmitter is not keyed after it.
+func (s *Service) dropUnkeyed(cmd queuedCommand) {
+	const op errors.Op = "cat.Service.dropUnkeyed"
+	cmd.outcome.fail(errors.New(op).Msg("dropped: PTTOFF was queued after it"))
+	s.logger().DebugWith().Str("cmd", cmd.Name).Msg("keying command overtaken by PTTOFF dropped")
+}
+
+// setPTTLine keys or unkeys the transmitter with Options.ControlLines.PTT.
+func (s *Service) setPTTLine(on bool, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.setPTTLine"
+	if !s.started.Load() {
+		return errors.New(op).Msg(errMsgServiceNotStarted)
+	}
+	if on {
+		if err := s.avoidRangeFilter(newCommandRequest(CmdPTTOn, nil, opts...)); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	if err := s.setControlLine(s.Options.ControlLines.PTT, on); err != nil {
+		return errors.New(op).Err(err)
+	}
+	s.notePTT(on)
+	return nil
+}
+
+// notePTT records that the transmitter was keyed or unkeyed and arms or disarms the watchdog.
+func (s *Service) notePTT(keyed bool) {
+	s.ptt.mu.Lock()
+	defer s.ptt.mu.Unlock()
+	if keyed == s.ptt.state.On {
+		return // keep the watchdog running from the first keying
+	}
+	s.ptt.state = PTTState{On: keyed, Since: time.Now()}
+	s.ptt.stuck = false
+	if !keyed {
+		s.ptt.release = nil
+	}
+	if s.ptt.timer != nil {
+		s.ptt.timer.Stop()
+		s.ptt.timer = nil
+	}
+	if keyed && s.Options.PTT.MaxTxMS > 0 {
+		since := s.ptt.state.Since
+		s.ptt.timer = time.AfterFunc(s.Options.PTT.MaxTxMS*time.Millisecond, func() { s.pttWatchdog(since) })
+	}
+}
+
+// noteKeyedBy records that the transmitter was keyed other than by PTT, by a key that release lets go of, and
+// arms the watchdog, which then unkeys with release. A transmitter already keyed is unkeyed as it was keyed.
+func (s *Service) noteKeyedBy(release func() error) {
+	s.ptt.mu.Lock()
+	if !s.ptt.state.On {
+		s.ptt.release = release
+	}
+	s.ptt.mu.Unlock()
+	s.notePTT(true)
+}
+
+// noteReleased records that a key noted by noteKeyedBy was released. A transmitter keyed by PTT stays keyed.
+func (s *Service) noteReleased() {
+	s.ptt.mu.Lock()
+	byKey := s.ptt.state.On && s.ptt.release != nil
+	s.ptt.mu.Unlock()
+	if byKey {
+		s.notePTT(false)
+	}
+}
+
+// pttWatchdog unkeys the transmitter keyed at since, unless it was unkeyed or keyed again in the meantime. If the
+// transmitter stays keyed, it is reported once and the watchdog tries again after Options.PTT.RetryMS.
+func (s *Service) pttWatchdog(since time.Time) {
+	const op errors.Op = "cat.Service.pttWatchdog"
+	s.ptt.mu.Lock()
+	current := s.ptt.state
+	s.ptt.mu.Unlock()
+	if !current.On || !current.Since.Equal(since) {
+		return
+	}
+
+	keyedFor := time.Since(since)
+	event := PTTWatchdogEvent{At: time.Now(), KeyedFor: keyedFor}
+	if err := s.unkeyNow(); err != nil {
+		err = errors.New(op).Err(err)
+		s.logger().ErrorWith().Err(err).Dur("keyed_for", keyedFor).Msg("PTT watchdog could not unkey the transmitter")
+		if s.rearmWatchdog(since) {
+			event.Err = err.Error()
+			s.notify(SeverityCritical, "Transmitter stuck keyed",
+				fmt.Sprintf("The transmitter has been keyed for %s and could not be unkeyed.", keyedFor.Round(time.Second)),
+				"Unkey the transmitter at the rig.")
+			s.emitEvent(event)
+		}
+		return
+	}
+	s.logger().WarnWith().Dur("keyed_for", keyedFor).Msg("PTT watchdog unkeyed the transmitter")
+	s.notify(SeverityWarning, "Transmitter unkeyed",
+		fmt.Sprintf("The transmitter was unkeyed after %s, over the maximum transmit time.", keyedFor.Round(time.Second)), "")
+	s.emitEvent(event)
+}
+
+// rearmWatchdog runs the watchdog again after Options.PTT.RetryMS, if the transmitter is still keyed at since. It
+// returns true the first time, when the stuck transmitter is to be reported.
+func (s *Service) rearmWatchdog(since time.Time) bool {
+	s.ptt.mu.Lock()
+	defer s.ptt.mu.Unlock()
+	if !s.ptt.state.On || !s.ptt.state.Since.Equal(since) {
+		return false
+	}
+	s.ptt.timer = time.AfterFunc(durationOrDefault(s.Options.PTT.RetryMS, defaultPTTRetryMS), func() { s.pttWatchdog(since) })
+	first := !s.ptt.stuck
+	s.ptt.stuck = true
+	return first
+}
+
+// unkeyNow unkeys the transmitter for the watchdog: by line right away, or by PTTOFF, which jumps the queue. It
+// waits up to Options.PTT.RetryMS for PTTOFF to be written and the transmitter to read back unkeyed.
+func (s *Service) unkeyNow() error {
+	const op errors.Op = "cat.Service.unkeyNow"
+	if release := s.pttRelease(); release != nil {
+		if err := release(); err != nil {
+			return err
+		}
+		s.notePTT(false)
+		return nil
+	}
+	if line := s.Options.ControlLines.PTT; line != "" {
+		if err := s.setControlLine(line, false); err != nil {
+			return err
+		}
+		s.notePTT(false)
+		return nil
+	}
+
+	handle, err := s.queueUnkey()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), durationOrDefault(s.Options.PTT.RetryMS, defaultPTTRetryMS))
+	defer cancel()
+	outcome, err := handle.Wait(ctx)
+	if err != nil {
+		return errors.New(op).Err(err).Msg("PTTOFF not written")
+	}
+	if outcome.State == OutcomeFailed || outcome.State == OutcomeTimedOut {
+		return errors.New(op).Msgf("PTTOFF %s: %s", outcome.State, outcome.Err)
+	}
+	// The keying state follows the command just after it was written.
+	ticker := time.NewTicker(pttReadBackPoll)
+	defer ticker.Stop()
+	for s.PTTState().On {
+		select {
+		case <-ctx.Done():
+			return errors.New(op).Msg("transmitter still keyed after PTTOFF")
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// queueUnkey queues PTTOFF for the watchdog, bypassing duplicate suppression.
+func (s *Service) queueUnkey() (*CommandHandle, error) {
+	return s.EnqueueTracked(CmdPTTOff, nil, WithOrigin(OriginInternal), WithPriority(PriorityHigh), Force())
+}
+
+// pttRelease returns the release of a transmitter keyed other than by PTT, or nil.
+func (s *Service) pttRelease() func() error {
+	s.ptt.mu.Lock()
+	defer s.ptt.mu.Unlock()
+	return s.ptt.release
+}
+
+// unkeyOnStop writes PTTOFF if the transmitter is keyed, so that stopping the service never leaves it
+// transmitting. It is called by Stop while the link is still open.
+func (s *Service) unkeyOnStop() {
+	s.ptt.mu.Lock()
+	keyed, release := s.ptt.state.On, s.ptt.release
+	if s.ptt.timer != nil {
+		s.ptt.timer.Stop()
+		s.ptt.timer = nil
+	}
+	s.ptt.mu.Unlock()
+	if !keyed {
+		return
+	}
+	if release != nil {
+		if err := release(); err != nil {
+			s.logger().ErrorWith().Err(err).Msg("transmitter not unkeyed on stop")
+			return
+		}
+		s.notePTT(false)
+		return
+	}
+	if s.Options.ControlLines.PTT != "" {
+		if err := s.setControlLine(s.Options.ControlLines.PTT, false); err != nil {
+			s.logger().ErrorWith().Err(err).Msg("transmitter not unkeyed on stop")
+			return
+		}
+		s.notePTT(false)
+		return
+	}
+	prepared, err := s.prepare(newCommandRequest(CmdPTTOff, nil, WithOrigin(OriginInternal), Force()))
+	if err != nil {
+		s.logger().ErrorWith().Err(err).Msg("transmitter not unkeyed on stop")
+		return
+	}
+	for _, cmd := range prepared {
+		if s.writeCommand(cmd) == nil {
+			s.trackPTT(cmd)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newPTTTestService(t *testing.T, maxTxMS time.Duration) (*Service, *fakeTransport) {
+	service := newStartedTestService(t, &types.RigConfig{CatCommands: []types.CatCommand{
+		{Name: CmdPTTOn.String(), Cmd: "TX;"},
+		{Name: CmdPTTOff.String(), Cmd: "RX;"},
+	}})
+	service.Options.PTT = PTTOptions{MaxTxMS: maxTxMS}
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	return service, fake
+}
+
+func TestPTTStateFollowsWrittenCommands(t *testing.T) {
+	service, fake := newPTTTestService(t, 0)
+	require.False(t, service.PTTState().On)
+
+	require.NoError(t, service.PTT(true))
+	require.Eventually(t, func() bool { return service.PTTState().On }, time.Second, 5*time.Millisecond)
+	keyedAt := service.PTTState().Since
+	require.False(t, keyedAt.IsZero())
+
+	require.NoError(t, service.PTT(false))
+	require.Eventually(t, func() bool { return !service.PTTState().On }, time.Second, 5*time.Millisecond)
+	require.True(t, service.PTTState().Since.After(keyedAt))
+	require.Equal(t, []string{"TX;", "RX;"}, fake.writes())
+}
+
+func TestPTTOffDropsTheKeyingItOvertakes(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{CatCommands: []types.CatCommand{
+		{Name: CmdPTTOn.String(), Cmd: "TX;"},
+		{Name: CmdPTTOff.String(), Cmd: "RX;"},
+	}})
+	require.NoError(t, service.PTT(true))
+	require.NoError(t, service.PTT(false))
+
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	require.Eventually(t, func() bool { return len(fake.writes()) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, []string{"RX;"}, fake.writes())
+	require.False(t, service.PTTState().On)
+
+	// Keying queued after the unkey is written.
+	require.NoError(t, service.PTT(true))
+	require.Eventually(t, func() bool { return service.PTTState().On }, time.Second, time.Millisecond)
+}
+
+func TestPTTWatchdogUnkeysStuckTransmitter(t *testing.T) {
+	service, fake := newPTTTestService(t, 30)
+
+	require.NoError(t, service.PTT(true))
+	select {
+	case e := <-service.eventChannel:
+		event, ok := e.(PTTWatchdogEvent)
+		require.True(t, ok)
+		require.Empty(t, event.Err)
+		require.GreaterOrEqual(t, event.KeyedFor, 30*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	require.Eventually(t, func() bool { return !service.PTTState().On }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"TX;", "RX;"}, fake.writes())
+	require.Equal(t, SeverityWarning, (<-service.notificationChannel).Severity)
+}
+
+func TestPTTWatchdogRetriesUntilUnkeyed(t *testing.T) {
+	service, fake := newPTTTestService(t, 30)
+	service.Options.PTT.RetryMS = 20
+
+	require.NoError(t, service.PTT(true))
+	require.Eventually(t, func() bool { return service.PTTState().On }, time.Second, time.Millisecond)
+	service.linkDown.Store(true)
+
+	watchdogEvent := func() PTTWatchdogEvent {
+		t.Helper()
+		for {
+			select {
+			case e := <-service.eventChannel:
+				if event, ok := e.(PTTWatchdogEvent); ok {
+					return event
+				}
+			case <-time.After(time.Second):
+				t.Fatal("watchdog did not fire")
+			}
+		}
+	}
+	require.NotEmpty(t, watchdogEvent().Err)
+	require.Equal(t, SeverityCritical, (<-service.notificationChannel).Severity)
+	require.True(t, service.PTTState().On)
+
+	service.linkDown.Store(false)
+	require.Empty(t, watchdogEvent().Err)
+	require.False(t, service.PTTState().On)
+	require.Equal(t, []string{"TX;", "RX;"}, fake.writes())
+}
+
+func TestPTTWatchdogDisarmedByUnkeying(t *testing.T) {
+	service, fake := newPTTTestService(t, 50)
+
+	require.NoError(t, service.PTT(true))
+	require.Eventually(t, func() bool { return service.PTTState().On }, time.Second, time.Millisecond)
+	require.NoError(t, service.PTT(false))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, []string{"TX;", "RX;"}, fake.writes())
+	require.Empty(t, service.eventChannel)
+}
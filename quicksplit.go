@@ -0,0 +1,77 @@
+package cat
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultQuickSplitOffsetHz is used when no SplitOffset matches and Options.QuickSplit.DefaultOffsetHz is zero.
+	defaultQuickSplitOffsetHz = 5000
+)
+
+// SplitOffset is the QuickSplit offset for a band and mode combination, e.g. +5 kHz for CW and +10 kHz for SSB.
+type SplitOffset struct {
+	// Band restricts the rule to one band. Empty matches every band.
+	Band bands.Band
+	// Modes restricts the rule to these display modes, e.g. "USB" and "LSB". Empty matches every mode.
+	Modes []string
+	// OffsetHz is added to the VFO A frequency; it may be negative.
+	OffsetHz int64
+}
+
+// matches reports whether the rule applies on band in mode.
+func (o SplitOffset) matches(band bands.Band, mode string) bool {
+	if o.Band != "" && o.Band != band {
+		return false
+	}
+	return len(o.Modes) == 0 || slices.ContainsFunc(o.Modes, func(m string) bool { return strings.EqualFold(m, mode) })
+}
+
+// QuickSplit tunes VFO B to the VFO A frequency plus the offset configured for the current band and mode, and
+// enables split. Both commands are prepared before either is queued, so that a rejected frequency (e.g. inside an
+// avoid range) leaves the rig untouched. It returns the VFO B frequency.
+func (s *Service) QuickSplit(ctx context.Context, opts ...CommandOption) (int64, error) {
+	const op errors.Op = "cat.Service.QuickSplit"
+
+	hz, err := s.GetFrequencyHz(ctx, VFOA)
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+	mode, err := s.GetMode(ctx)
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+
+	splitHz := hz + s.quickSplitOffset(hz, mode)
+	value, err := s.formatFrequency(tags.VfoBFreq, splitHz)
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+
+	req := newCommandRequest(CmdSetVfoBFreq, []string{value}, opts...)
+	req.then = append(req.then, newCommandRequest(CmdSplitOn, nil))
+	if err = s.submit(req); err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+	return splitHz, nil
+}
+
+// quickSplitOffset returns the offset of the first SplitOffset matching hz and mode, or the default offset.
+func (s *Service) quickSplitOffset(hz int64, mode string) int64 {
+	band, _ := s.bandForFrequency(hz)
+	for _, o := range s.Options.QuickSplit.Offsets {
+		if o.matches(band, mode) {
+			return o.OffsetHz
+		}
+	}
+	if s.Options.QuickSplit.DefaultOffsetHz != 0 {
+		return s.Options.QuickSplit.DefaultOffsetHz
+	}
+	return defaultQuickSplitOffsetHz
+}
@@ -0,0 +1,57 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuickSplitTestService(t *testing.T) *Service {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands, types.CatCommand{Name: CmdSplitOn.String(), Cmd: "FT1;"})
+	service := newStartedTestService(t, cfg)
+	service.Options.QuickSplit.Offsets = []SplitOffset{
+		{Band: bands.Band20, Modes: []string{"CW-U"}, OffsetHz: 1000},
+		{Modes: []string{"USB", "LSB"}, OffsetHz: 10000},
+	}
+	return service
+}
+
+func TestQuickSplitUsesBandAndModeOffset(t *testing.T) {
+	service := newQuickSplitTestService(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014025000", "MAINMODE": "CW-U"}, time.Now())
+	hz, err := service.QuickSplit(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(14026000), hz)
+	require.Equal(t, []string{"FB014026000;", "FT1;"}, drainCommands(service))
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "007150000", "MAINMODE": "LSB"}, time.Now())
+	hz, err = service.QuickSplit(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(7160000), hz)
+
+	// No rule for CW on 40m: the default offset.
+	service.cache.update(types.CatStatus{"VFOAFREQ": "007010000", "MAINMODE": "CW-U"}, time.Now())
+	hz, err = service.QuickSplit(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(7015000), hz)
+}
+
+func TestQuickSplitRejectedLeavesRigUntouched(t *testing.T) {
+	service := newQuickSplitTestService(t)
+	service.Options.AvoidRanges = []AvoidRange{{Label: "beacons", MinHz: 14099000, MaxHz: 14101000}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014090000", "MAINMODE": "USB"}, time.Now())
+	_, err := service.QuickSplit(ctx)
+	require.Error(t, err)
+	require.Empty(t, drainCommands(service))
+}
@@ -0,0 +1,156 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+// Quirk names a known deviation of a rig from the behaviour the pipeline assumes. Quirks are declared by the rig
+// driver or in Options.Quirks, and consulted by the subsystems they affect.
+type Quirk string
+
+const (
+	// QuirkNeedsWakePreamble rigs ignore the first command after an idle period, e.g. Icom rigs in CI-V power
+	// save; a wake preamble is written first (see QuirkOptions.WakePreamble).
+	QuirkNeedsWakePreamble Quirk = "NeedsWakePreamble"
+	// QuirkEchoesCommands rigs, or their interfaces, echo every command back; the echo is dropped instead of
+	// being counted as a rig response.
+	QuirkEchoesCommands Quirk = "EchoesCommands"
+	// QuirkSlowModeChange rigs need time after a mode change before they accept the next command.
+	QuirkSlowModeChange Quirk = "SlowModeChange"
+	// QuirkNoAIWhilePolling rigs misbehave when polled in auto-information mode, so polling is suspended while
+	// it is active.
+	QuirkNoAIWhilePolling Quirk = "NoAIWhilePolling"
+)
+
+// knownQuirks lists the quirks the pipeline understands.
+var knownQuirks = []Quirk{QuirkNeedsWakePreamble, QuirkEchoesCommands, QuirkSlowModeChange, QuirkNoAIWhilePolling}
+
+const (
+	// defaultWakeIdleMS is used when Options.QuirkOptions.WakeIdleMS is zero.
+	defaultWakeIdleMS = 1000
+	// defaultWakeDelayMS is used when Options.QuirkOptions.WakeDelayMS is zero.
+	defaultWakeDelayMS = 50
+	// defaultModeChangeDelayMS is used when Options.QuirkOptions.ModeChangeDelayMS is zero.
+	defaultModeChangeDelayMS = 300
+	// civWakePreambleBytes is the length of the default CI-V wake preamble, long enough at 19200 baud.
+	civWakePreambleBytes = 25
+)
+
+// DriverQuirks is implemented by drivers of rigs with quirks.
+type DriverQuirks interface {
+	Quirks() []Quirk
+}
+
+// Quirks returns the active quirks: those of the rig driver and those of Options.Quirks, sorted.
+func (s *Service) Quirks() []Quirk {
+	quirks := make([]Quirk, 0, len(s.quirks))
+	for q := range s.quirks {
+		quirks = append(quirks, q)
+	}
+	slices.Sort(quirks)
+	return quirks
+}
+
+// resolveQuirks collects the active quirks. Unknown quirk names in Options.Quirks are an error, so that a typo does
+// not silently leave a workaround off.
+func (s *Service) resolveQuirks() (map[Quirk]bool, error) {
+	const op errors.Op = "cat.Service.resolveQuirks"
+	quirks := make(map[Quirk]bool)
+	if declared, ok := s.driver.(DriverQuirks); ok {
+		for _, q := range declared.Quirks() {
+			quirks[q] = true
+		}
+	}
+	for _, q := range s.Options.Quirks {
+		known := slices.IndexFunc(knownQuirks, func(k Quirk) bool { return strings.EqualFold(string(k), string(q)) })
+		if known < 0 {
+			return nil, errors.New(op).Msgf("unknown quirk %q", q)
+		}
+		quirks[knownQuirks[known]] = true
+	}
+	return quirks, nil
+}
+
+// hasQuirk reports whether q is active.
+func (s *Service) hasQuirk(q Quirk) bool {
+	return s.quirks[q]
+}
+
+// wakeIfIdle writes the wake preamble before a command when the link has been quiet for
+// QuirkOptions.WakeIdleMS, for rigs with QuirkNeedsWakePreamble.
+func (s *Service) wakeIfIdle() {
+	if !s.hasQuirk(QuirkNeedsWakePreamble) {
+		return
+	}
+	if s.idleFor(time.Now()) < durationOrDefault(s.Options.QuirkOptions.WakeIdleMS, defaultWakeIdleMS) {
+		return
+	}
+	preamble := s.Options.QuirkOptions.WakePreamble
+	if preamble == "" {
+		preamble = ";"
+		if _, civ := s.codec().(civCodec); civ {
+			preamble = string(bytes.Repeat([]byte{civPreamble}, civWakePreambleBytes))
+		}
+	}
+	t, err := s.openLink()
+	if err == nil {
+		err = t.WriteCommand(context.Background(), preamble)
+	}
+	if err != nil {
+		s.logger().DebugWith().Err(err).Msg("wake preamble not written")
+		return
+	}
+	time.Sleep(durationOrDefault(s.Options.QuirkOptions.WakeDelayMS, defaultWakeDelayMS))
+}
+
+// echoFilter remembers the last command written, for rigs with QuirkEchoesCommands.
+type echoFilter struct {
+	last atomic.Pointer[string]
+}
+
+// noteWire remembers wire as the last command written.
+func (s *Service) noteWire(wire string) {
+	if s.hasQuirk(QuirkEchoesCommands) {
+		s.echo.last.Store(&wire)
+	}
+}
+
+// isEcho reports whether frame is the echo of the last command written.
+func (s *Service) isEcho(frame []byte) bool {
+	if !s.hasQuirk(QuirkEchoesCommands) {
+		return false
+	}
+	last := s.echo.last.Load()
+	if last == nil {
+		return false
+	}
+	const delimiters = ";\r\n"
+	return bytes.Equal(bytes.TrimRight(frame, delimiters), bytes.TrimRight([]byte(*last), delimiters))
+}
+
+// settleAfter holds the sender after cmd for rigs that need time to settle, e.g. after a mode change with
+// QuirkSlowModeChange, or for the settle time cmd was queued with. It returns false if shutdown was signaled.
+func (s *Service) settleAfter(shutdown <-chan struct{}, cmd queuedCommand) bool {
+	d := cmd.settle
+	if s.hasQuirk(QuirkSlowModeChange) && strings.EqualFold(cmd.Name, CmdSetMainMode.String()) {
+		d = max(d, durationOrDefault(s.Options.QuirkOptions.ModeChangeDelayMS, defaultModeChangeDelayMS))
+	}
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-shutdown:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
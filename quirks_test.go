@@ -0,0 +1,80 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+type quirkyDriver struct{ upperCaseDriver }
+
+func (quirkyDriver) Quirks() []Quirk { return []Quirk{QuirkSlowModeChange} }
+
+func TestQuirksFromDriverAndOptions(t *testing.T) {
+	service := newDriverTestService(types.RigConfig{}, "")
+	service.Driver = quirkyDriver{}
+	service.Options.Quirks = []Quirk{"echoescommands"}
+	require.NoError(t, service.Initialize())
+	require.Equal(t, []Quirk{QuirkEchoesCommands, QuirkSlowModeChange}, service.Quirks())
+
+	service = newDriverTestService(types.RigConfig{}, "")
+	service.Options.Quirks = []Quirk{"SlowModeChnage"}
+	require.Error(t, service.Initialize())
+}
+
+func newQuirkTestService(t *testing.T, quirks ...Quirk) (*Service, *fakeTransport) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.quirks = make(map[Quirk]bool)
+	for _, q := range quirks {
+		service.quirks[q] = true
+	}
+	return service, startTestWorkers(t, service, nil)
+}
+
+func TestWakePreambleAfterIdle(t *testing.T) {
+	service, fake := newQuirkTestService(t, QuirkNeedsWakePreamble)
+	service.Options.QuirkOptions = QuirkOptions{WakeIdleMS: 30, WakeDelayMS: 1}
+
+	write := func(cmd string) {
+		require.NoError(t, service.writeCommand(queuedCommand{CatCommand: types.CatCommand{Name: "READ", Cmd: cmd}}))
+	}
+	write("FA;")
+	write("FB;")
+	time.Sleep(40 * time.Millisecond)
+	write("MD0;")
+	require.Equal(t, []string{";", "FA;", "FB;", ";", "MD0;"}, fake.writes())
+}
+
+func TestEchoedCommandsAreDropped(t *testing.T) {
+	service, _ := newQuirkTestService(t, QuirkEchoesCommands)
+
+	service.noteWire("FA;")
+	require.True(t, service.isEcho([]byte("FA")))
+	require.False(t, service.isEcho([]byte("FA00014074000;")))
+
+	service.quirks = nil
+	require.False(t, service.isEcho([]byte("FA;")))
+}
+
+func TestSlowModeChangeHoldsSender(t *testing.T) {
+	service, _ := newQuirkTestService(t, QuirkSlowModeChange)
+	service.Options.QuirkOptions.ModeChangeDelayMS = 30
+
+	start := time.Now()
+	require.True(t, service.settleAfter(nil, queuedCommand{CatCommand: types.CatCommand{Name: CmdSetVfoAFreq.String()}}))
+	require.Less(t, time.Since(start), 30*time.Millisecond)
+	require.True(t, service.settleAfter(nil, queuedCommand{CatCommand: types.CatCommand{Name: CmdSetMainMode.String()}}))
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestNoAIWhilePollingSuspendsPolls(t *testing.T) {
+	service, _ := newQuirkTestService(t, QuirkNoAIWhilePolling)
+
+	_, ok := service.pollInterval(time.Second)
+	require.True(t, ok)
+	service.autoInfo.Store(true)
+	_, ok = service.pollInterval(time.Second)
+	require.False(t, ok)
+}
@@ -0,0 +1,95 @@
+package cat
+
+import (
+	"time"
+)
+
+// RateLimit is a token bucket: PerSecond commands per second on average, with bursts of up to Burst commands.
+type RateLimit struct {
+	PerSecond float64
+	// Burst is the bucket size. Zero means 1, i.e. no bursts.
+	Burst int
+}
+
+// tokenBucket implements RateLimit. Reservations may drive the balance negative; the debt is paid back by waiting.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	burst := float64(max(limit.Burst, 1))
+	return &tokenBucket{rate: limit.PerSecond, burst: burst, tokens: burst, last: now}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// sendThrottle paces the sender: a minimum gap between any two commands, and a token bucket per origin. It is
+// owned by the sender goroutine.
+type sendThrottle struct {
+	gap     time.Duration
+	limits  map[Origin]RateLimit
+	buckets map[Origin]*tokenBucket
+	last    time.Time
+}
+
+// newSendThrottle returns the throttle configured by Options.RateLimit, or nil if no limits are set.
+func (s *Service) newSendThrottle() *sendThrottle {
+	opts := s.Options.RateLimit
+	if opts.InterCommandDelayMS <= 0 && len(opts.Origins) == 0 {
+		return nil
+	}
+	return &sendThrottle{
+		gap:     opts.InterCommandDelayMS * time.Millisecond,
+		limits:  opts.Origins,
+		buckets: make(map[Origin]*tokenBucket),
+	}
+}
+
+// delay returns how long a command from origin must wait before it may be written, reserving its slot.
+func (t *sendThrottle) delay(origin Origin, now time.Time) time.Duration {
+	var wait time.Duration
+	if limit, ok := t.limits[origin]; ok && limit.PerSecond > 0 {
+		b, ok := t.buckets[origin]
+		if !ok {
+			b = newTokenBucket(limit, now)
+			t.buckets[origin] = b
+		}
+		wait = b.reserve(now)
+	}
+	if t.gap > 0 && !t.last.IsZero() {
+		wait = max(wait, t.last.Add(t.gap).Sub(now))
+	}
+	t.last = now.Add(wait)
+	return wait
+}
+
+// wait blocks until a command from origin may be written. It returns false on shutdown. A nil throttle never waits.
+func (t *sendThrottle) wait(shutdown <-chan struct{}, origin Origin) bool {
+	if t == nil {
+		return true
+	}
+	d := t.delay(origin, time.Now())
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-shutdown:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,61 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(RateLimit{PerSecond: 10, Burst: 2}, now)
+
+	require.Zero(t, b.reserve(now))
+	require.Zero(t, b.reserve(now))
+	require.Equal(t, 100*time.Millisecond, b.reserve(now))
+	require.Equal(t, 200*time.Millisecond, b.reserve(now))
+	// The debt is paid back over time.
+	require.Zero(t, b.reserve(now.Add(time.Second)))
+}
+
+func TestSendThrottleLimitsOnlyConfiguredOrigins(t *testing.T) {
+	service := &Service{Options: Options{RateLimit: RateLimitOptions{
+		Origins: map[Origin]RateLimit{OriginPoller: {PerSecond: 10}},
+	}}}
+	throttle := service.newSendThrottle()
+	now := time.Now()
+
+	require.Zero(t, throttle.delay(OriginPoller, now))
+	require.Equal(t, 100*time.Millisecond, throttle.delay(OriginPoller, now))
+	require.Zero(t, throttle.delay(OriginUI, now))
+	require.Zero(t, throttle.delay(OriginUI, now))
+
+	require.Nil(t, (&Service{}).newSendThrottle())
+}
+
+func TestSendThrottleInterCommandDelay(t *testing.T) {
+	service := &Service{Options: Options{RateLimit: RateLimitOptions{InterCommandDelayMS: 20}}}
+	throttle := service.newSendThrottle()
+	now := time.Now()
+
+	require.Zero(t, throttle.delay(OriginUI, now))
+	require.Equal(t, 20*time.Millisecond, throttle.delay(OriginUI, now))
+	require.Equal(t, 30*time.Millisecond, throttle.delay(OriginUI, now.Add(10*time.Millisecond)))
+}
+
+func TestSenderHonoursInterCommandDelay(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+	})
+	service.Options.RateLimit.InterCommandDelayMS = 30
+	for range 3 {
+		require.NoError(t, service.EnqueueCommand("READ"))
+	}
+	started := time.Now()
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+
+	require.Eventually(t, func() bool { return len(fake.writes()) == 3 }, time.Second, 2*time.Millisecond)
+	require.GreaterOrEqual(t, time.Since(started), 60*time.Millisecond)
+}
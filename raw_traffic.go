@@ -0,0 +1,36 @@
+package cat
+
+import (
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultRawTrafficChannelSize is used when Options.RawTraffic.ChannelSize is zero.
+	defaultRawTrafficChannelSize = 256
+)
+
+// RawTrafficChannel returns a channel of every raw frame written to or read from the rig, in wire format, for a
+// live hex console. It must be enabled with Options.RawTraffic.Enabled. The channel is bounded; when the consumer
+// falls behind the oldest frames are discarded so the rig link is never slowed down.
+func (s *Service) RawTrafficChannel() (<-chan TrafficFrame, error) {
+	const op errors.Op = "cat.Service.RawTrafficChannel"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if s.rawTrafficChannel == nil {
+		return nil, errors.New(op).Msg("Raw traffic streaming is not enabled.")
+	}
+	return s.rawTrafficChannel, nil
+}
+
+// newRawTrafficChannel creates the raw traffic channel if streaming is enabled.
+func newRawTrafficChannel(opts RawTrafficOptions) chan TrafficFrame {
+	if !opts.Enabled {
+		return nil
+	}
+	size := opts.ChannelSize
+	if size <= 0 {
+		size = defaultRawTrafficChannelSize
+	}
+	return make(chan TrafficFrame, size)
+}
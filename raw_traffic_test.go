@@ -0,0 +1,28 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawTrafficChannel(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	_, err := service.RawTrafficChannel()
+	require.Error(t, err)
+
+	service.rawTrafficChannel = newRawTrafficChannel(RawTrafficOptions{Enabled: true, ChannelSize: 2})
+	ch, err := service.RawTrafficChannel()
+	require.NoError(t, err)
+
+	service.recordTraffic(TrafficTX, []byte("FA;"))
+	service.recordTraffic(TrafficRX, []byte("FA00014074000"))
+	service.recordTraffic(TrafficTX, []byte("MD;"))
+
+	// The oldest frame was evicted to make room.
+	first := <-ch
+	require.Equal(t, TrafficRX, first.Direction)
+	require.Equal(t, "FA00014074000", string(first.Data))
+	require.Equal(t, "MD;", string((<-ch).Data))
+}
@@ -0,0 +1,190 @@
+package cat
+
+import (
+	stderr "errors"
+	"time"
+
+	"github.com/Station-Manager/serial"
+	bugst "go.bug.st/serial"
+)
+
+const (
+	// defaultReconnectRetryMS is used when Options.Reconnect.RetryMS is zero.
+	defaultReconnectRetryMS = 1000
+	// defaultReenumerationWaitMS is used when Options.Reconnect.ReenumerationWaitMS is zero.
+	defaultReenumerationWaitMS = 5000
+	// defaultErrorLogIntervalMS is used when Options.Reconnect.ErrorLogIntervalMS is zero.
+	defaultErrorLogIntervalMS = 10000
+)
+
+// linkFault classifies a transport error for the reconnect logic.
+type linkFault int
+
+const (
+	// linkFaultTransient is an error that does not affect the link, e.g. a malformed line.
+	linkFaultTransient linkFault = iota
+	// linkFaultLost means the port was closed underneath us for an unknown reason.
+	linkFaultLost
+	// linkFaultRemoved means the device disappeared, e.g. the USB cable was pulled. The OS needs time to
+	// re-enumerate it before it can be opened again.
+	linkFaultRemoved
+	// linkFaultInUse means the port exists but cannot be opened, typically because a stale handle has not been
+	// released yet after a surprise removal, or another program holds the port.
+	linkFaultInUse
+)
+
+// String implements fmt.Stringer.
+func (f linkFault) String() string {
+	switch f {
+	case linkFaultTransient:
+		return "transient"
+	case linkFaultLost:
+		return "lost"
+	case linkFaultRemoved:
+		return "removed"
+	case linkFaultInUse:
+		return "in use"
+	default:
+		return "unknown"
+	}
+}
+
+// advice returns the operator-facing explanation and suggested action for f.
+func (f linkFault) advice() (string, string) {
+	switch f {
+	case linkFaultRemoved:
+		return "The rig's serial port disappeared.", "Check the USB cable and that the rig is powered on."
+	case linkFaultInUse:
+		return "The rig's serial port is busy.", "Close any other program using the port; it is retried automatically."
+	default:
+		return "The connection to the rig was lost.", "Check the cable and the rig; it is retried automatically."
+	}
+}
+
+// errorSource is implemented by transports that report asynchronous errors, such as the serial port's reader.
+type errorSource interface {
+	Errors() <-chan error
+}
+
+// classifyLinkError decides how err affects the link. The transport's asynchronous error, if any, is consulted as
+// it carries the OS error behind a closed port.
+func (s *Service) classifyLinkError(err error) linkFault {
+	if src, ok := s.link().(errorSource); ok {
+		select {
+		case cause, ok := <-src.Errors():
+			if ok && cause != nil {
+				if f := classifyPortError(cause); f != linkFaultTransient {
+					return f
+				}
+			}
+		default:
+		}
+	}
+	if f := classifyPortError(err); f != linkFaultTransient {
+		return f
+	}
+	if stderr.Is(err, serial.ErrClosed) {
+		return linkFaultLost
+	}
+	return linkFaultTransient
+}
+
+// classifyPortError maps serial library and OS errors to a link fault.
+func classifyPortError(err error) linkFault {
+	var pe *bugst.PortError
+	if stderr.As(err, &pe) {
+		switch pe.Code() {
+		case bugst.PortNotFound:
+			return linkFaultRemoved
+		case bugst.PortBusy, bugst.PermissionDenied:
+			return linkFaultInUse
+		case bugst.PortClosed:
+			return linkFaultLost
+		}
+	}
+	return classifyOSError(err)
+}
+
+// reconnect closes the failed transport and reopens it until it succeeds or shutdown is closed, waiting longer
+// while the OS re-enumerates a removed device. Commands written while the link is down are dropped. It returns
+// false on shutdown.
+func (s *Service) reconnect(shutdown <-chan struct{}, fault linkFault) bool {
+	s.linkDown.Store(true)
+	defer s.linkDown.Store(false)
+
+	msg, action := fault.advice()
+	s.logger().WarnWith().Str("fault", fault.String()).Msg("rig link lost; reconnecting")
+	s.notify(SeverityWarning, "Rig disconnected", msg, action)
+	s.emitEvent(ReconnectEvent{At: time.Now(), Fault: fault.String()})
+	s.metricsSink().Gauge("link_up", 0)
+
+	if old := s.link(); old != nil {
+		_ = old.Close()
+	}
+
+	opts := s.Options.Reconnect
+	retry := durationOrDefault(opts.RetryMS, defaultReconnectRetryMS)
+	reenumerate := durationOrDefault(opts.ReenumerationWaitMS, defaultReenumerationWaitMS)
+	logs := newLogLimiter(durationOrDefault(opts.ErrorLogIntervalMS, defaultErrorLogIntervalMS))
+
+	lost := fault
+	wait := retry
+	for attempt := 1; ; attempt++ {
+		if fault == linkFaultRemoved || fault == linkFaultInUse {
+			wait = reenumerate
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-shutdown:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+
+		err := s.initializeTransport()
+		if err == nil {
+			s.logger().InfoWith().Int("attempts", attempt).Msg("rig link re-established")
+			s.count(&s.counters.reconnects, "reconnects", 1)
+			s.emitEvent(ReconnectEvent{At: time.Now(), Connected: true, Fault: lost.String(), Attempts: attempt})
+			s.notify(SeverityInfo, "Rig reconnected", "The connection to the rig was re-established.", "")
+			s.enableAutoInfo(shutdown)
+			return true
+		}
+		fault = classifyPortError(err)
+		wait = retry
+		if suppressed, ok := logs.allow(time.Now()); ok {
+			s.logger().WarnWith().Err(err).Int("attempt", attempt).Int("suppressed", suppressed).
+				Str("fault", fault.String()).Msg("reconnect attempt failed")
+		}
+	}
+}
+
+// durationOrDefault returns ms, or def when ms is not positive, as a duration.
+func durationOrDefault(ms time.Duration, def time.Duration) time.Duration {
+	if ms <= 0 {
+		ms = def
+	}
+	return ms * time.Millisecond
+}
+
+// logLimiter lets a repeated log line through at most once per interval, counting the ones it suppressed.
+type logLimiter struct {
+	interval   time.Duration
+	last       time.Time
+	suppressed int
+}
+
+func newLogLimiter(interval time.Duration) *logLimiter {
+	return &logLimiter{interval: interval}
+}
+
+// allow reports whether a line may be logged at now and, if so, how many were suppressed since the last one.
+func (l *logLimiter) allow(now time.Time) (int, bool) {
+	if !l.last.IsZero() && now.Sub(l.last) < l.interval {
+		l.suppressed++
+		return 0, false
+	}
+	suppressed := l.suppressed
+	l.last, l.suppressed = now, 0
+	return suppressed, true
+}
@@ -0,0 +1,90 @@
+package cat
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/serial"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// closedTransport behaves like a serial port whose reader loop has exited.
+type closedTransport struct{ fakeTransport }
+
+func (c *closedTransport) ReadResponseBytes(context.Context) ([]byte, error) {
+	return nil, fmt.Errorf("read: %w", serial.ErrClosed)
+}
+
+func TestClassifyPortError(t *testing.T) {
+	require.Equal(t, linkFaultRemoved, classifyPortError(fmt.Errorf("open: %w", syscall.ENOENT)))
+	require.Equal(t, linkFaultInUse, classifyPortError(fmt.Errorf("open: %w", syscall.EBUSY)))
+	require.Equal(t, linkFaultTransient, classifyPortError(stderr.New("garbled line")))
+
+	service := &Service{transport: newFakeTransport()}
+	require.Equal(t, linkFaultLost, service.classifyLinkError(fmt.Errorf("read: %w", serial.ErrClosed)))
+}
+
+func TestListenerReconnectsAfterRemoval(t *testing.T) {
+	cfg := &types.RigConfig{CatStates: []types.CatState{{Prefix: "FA"}}}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 5
+	service := newStartedTestService(t, cfg)
+	service.Options.Reconnect = ReconnectOptions{Enabled: true, RetryMS: 5, ReenumerationWaitMS: 20}
+
+	replacement := newFakeTransport()
+	replacement.push("FA014074000")
+	var dials atomic.Int32
+	service.dialer = func() (Transport, error) {
+		if dials.Add(1) == 1 {
+			return nil, fmt.Errorf("open: %w", syscall.ENOENT)
+		}
+		return replacement, nil
+	}
+
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.setLink(&closedTransport{})
+	service.launchWorkerThread(run, service.serialPortListener, "serialPortListener")
+	t.Cleanup(func() {
+		close(run.shutdownChannel)
+		run.wg.Wait()
+	})
+
+	require.Eventually(t, func() bool { return service.counters.framesReceived.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+	require.Equal(t, int32(2), dials.Load())
+
+	notes, err := service.Notifications()
+	require.NoError(t, err)
+	require.Equal(t, "Rig disconnected", (<-notes).Title)
+	require.Equal(t, "Rig reconnected", (<-notes).Title)
+
+	var reconnects []ReconnectEvent
+	for len(service.eventChannel) > 0 {
+		if e, ok := (<-service.eventChannel).(ReconnectEvent); ok {
+			reconnects = append(reconnects, e)
+		}
+	}
+	require.Len(t, reconnects, 2)
+	require.False(t, reconnects[0].Connected)
+	require.True(t, reconnects[1].Connected)
+	require.Equal(t, 2, reconnects[1].Attempts)
+	require.Equal(t, reconnects[0].Fault, reconnects[1].Fault)
+	require.Equal(t, uint64(1), service.Metrics().Reconnects)
+}
+
+func TestLogLimiter(t *testing.T) {
+	l := newLogLimiter(time.Second)
+	now := time.Now()
+
+	_, ok := l.allow(now)
+	require.True(t, ok)
+	_, ok = l.allow(now.Add(100 * time.Millisecond))
+	require.False(t, ok)
+	suppressed, ok := l.allow(now.Add(2 * time.Second))
+	require.True(t, ok)
+	require.Equal(t, 1, suppressed)
+}
@@ -0,0 +1,108 @@
+package cat
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// defaultRecoveryCommands is the recovery sequence used when Options.Recovery.Commands is empty: re-run the
+// initialization command (which re-enables auto-information on most rigs) and then resync the full state.
+var defaultRecoveryCommands = []cmds.CatCmdName{cmds.Init, cmds.Read}
+
+// frameMonitor tracks how well incoming frames match the configured states. It is only touched by the listener
+// goroutine, apart from the recovering flag.
+type frameMonitor struct {
+	consecutiveUnknown int
+	window             desyncWindow
+	recovering         atomic.Bool
+}
+
+// noteFrame records whether a received frame matched a configured state and triggers an automatic recovery once
+// the configured number of consecutive unknown frames is reached. shutdown is that of the listener's run.
+func (s *Service) noteFrame(shutdown <-chan struct{}, matched bool) {
+	s.checkDesync(shutdown, matched)
+
+	if matched {
+		s.frames.consecutiveUnknown = 0
+		return
+	}
+
+	s.frames.consecutiveUnknown++
+	threshold := s.Options.Recovery.UnknownFrameThreshold
+	if threshold <= 0 || s.frames.consecutiveUnknown < threshold {
+		return
+	}
+
+	s.frames.consecutiveUnknown = 0
+	s.logger().WarnWith().Int("threshold", threshold).Msg("CAT protocol desync suspected; starting rig recovery")
+	s.startRecovery(shutdown)
+}
+
+// startRecovery runs the recovery sequence in the background as a task of the run whose shutdown channel is
+// shutdown, so the listener is never blocked by it. It reports whether a recovery was started: none is while one
+// is already in progress.
+func (s *Service) startRecovery(shutdown <-chan struct{}) bool {
+	if !s.frames.recovering.CompareAndSwap(false, true) {
+		return false
+	}
+	started := s.launchTask(shutdown, func() {
+		defer s.frames.recovering.Store(false)
+		if err := s.recoverRig(shutdown); err != nil {
+			s.logger().ErrorWith().Err(err).Msg("CAT rig recovery failed")
+			s.reportError("recovery", err)
+			s.notify(SeverityWarning, "Rig recovery failed",
+				"The service could not resynchronize with the rig automatically.",
+				"Check the rig is powered on and connected, then restart CAT control.")
+			return
+		}
+		s.notify(SeverityInfo, "Rig resynchronized", "Communication with the rig was restored automatically.", "")
+	})
+	if !started {
+		s.frames.recovering.Store(false)
+	}
+	return started
+}
+
+// RecoverRig runs the configured recovery command sequence (by default INIT then READ), pausing between commands
+// as configured, to bring the rig and the service back in sync without operator intervention.
+func (s *Service) RecoverRig() error {
+	const op errors.Op = "cat.Service.RecoverRig"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	if err := s.recoverRig(nil); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// recoverRig implements RecoverRig, giving up between steps once shutdown is closed.
+func (s *Service) recoverRig(shutdown <-chan struct{}) error {
+	const op errors.Op = "cat.Service.recoverRig"
+	sequence := s.Options.Recovery.Commands
+	if len(sequence) == 0 {
+		sequence = defaultRecoveryCommands
+	}
+
+	for i, name := range sequence {
+		if i > 0 && s.Options.Recovery.StepDelayMS > 0 {
+			timer := time.NewTimer(s.Options.Recovery.StepDelayMS * time.Millisecond)
+			select {
+			case <-shutdown:
+				timer.Stop()
+				return errors.New(op).Msg(errMsgServiceNotStarted)
+			case <-timer.C:
+			}
+		}
+		if err := s.EnqueueCommandWith(name, nil, WithOrigin(OriginInternal)); err != nil {
+			return errors.New(op).Err(err).Msgf("Recovery step %s failed.", name)
+		}
+	}
+
+	s.logger().InfoWith().Int("steps", len(sequence)).Msg("CAT rig recovery sequence sent")
+	return nil
+}
@@ -0,0 +1,84 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutomaticRecoveryAfterUnknownFrames(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: cmds.Init.String(), Cmd: "AI1;"},
+			{Name: cmds.Read.String(), Cmd: "FA;"},
+		},
+	})
+	service.Options.Recovery.UnknownFrameThreshold = 3
+	startTestWorkers(t, service, nil)
+	shutdown := service.runShutdown()
+
+	service.noteFrame(shutdown, false)
+	service.noteFrame(shutdown, false)
+	service.noteFrame(shutdown, true) // a matched frame resets the count
+	service.noteFrame(shutdown, false)
+	service.noteFrame(shutdown, false)
+	require.Len(t, service.sendChannel, 0)
+
+	service.noteFrame(shutdown, false)
+	require.Eventually(t, func() bool { return len(service.sendChannel) == 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"AI1;", "FA;"}, drainCommands(service))
+}
+
+func TestDesyncEventOnUnknownRatio(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Desync = DesyncOptions{UnknownRatio: 0.5, WindowSize: 4}
+
+	// Two unknown frames out of four is exactly the threshold.
+	for _, matched := range []bool{true, false, true, false} {
+		service.noteFrame(nil, matched)
+	}
+
+	events, err := service.Events()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	ev, ok := (<-events).(ProtocolDesyncEvent)
+	require.True(t, ok)
+	require.Equal(t, 0.5, ev.UnknownRatio)
+	require.Equal(t, 4, ev.WindowSize)
+	require.False(t, ev.RecoveryTriggered)
+
+	notifications, err := service.Notifications()
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, SeverityWarning, (<-notifications).Severity)
+
+	// The window is reset after reporting, so the next frame does not report again.
+	service.noteFrame(nil, false)
+	require.Len(t, events, 0)
+}
+
+func TestDesyncDuringRecoveryDoesNotReportAnotherOne(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: cmds.Init.String(), Cmd: "AI1;"},
+			{Name: cmds.Read.String(), Cmd: "FA;"},
+		},
+	})
+	service.Options.Desync = DesyncOptions{UnknownRatio: 1, WindowSize: 2, Recover: true}
+	service.Options.Recovery.StepDelayMS = 5000 // Stop ends the recovery between its steps
+	startTestWorkers(t, service, nil)
+	shutdown := service.runShutdown()
+
+	var triggered []bool
+	for range 2 {
+		service.noteFrame(shutdown, false)
+		service.noteFrame(shutdown, false)
+		triggered = append(triggered, (<-service.eventChannel).(ProtocolDesyncEvent).RecoveryTriggered)
+	}
+	require.Equal(t, []bool{true, false}, triggered, "the first recovery is still running")
+	require.Eventually(t, func() bool { return len(service.sendChannel) == 1 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"AI1;"}, drainCommands(service))
+}
@@ -0,0 +1,241 @@
+package cat
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+)
+
+// Registry runs several rigs side by side, e.g. for SO2R, each in its own Service with its own serial port, worker
+// goroutines and channels. The zero value is ready to use once ConfigService is set.
+type Registry struct {
+	ConfigService *config.Service  `di.inject:"configservice"`
+	LoggerService *logging.Service `di.inject:"loggingservice"`
+	// EventBus, Store, PortManager, Translator and Definitions, when set, are shared by every rig added afterwards.
+	// Each rig publishes its EventBus payloads wrapped in a RigPayload, and keeps its Store keys below "rigs/<id>/",
+	// so the rigs neither overwrite nor mistake each other's state.
+	EventBus    EventBus
+	Store       Store
+	PortManager PortManager
+	Translator  Translator
+	Definitions DefinitionSource
+
+	mu   sync.Mutex
+	rigs map[int64]*Service
+}
+
+// Add creates and initializes the Service for rigID with the given options. Each rig may be added only once.
+func (r *Registry) Add(rigID int64, opts Options) (*Service, error) {
+	const op errors.Op = "cat.Registry.Add"
+	if rigID < 1 {
+		return nil, errors.New(op).Msg(errMsgInvalidRigID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rigs[rigID]; ok {
+		return nil, errors.New(op).Msgf("Rig %d is already registered.", rigID)
+	}
+
+	s := &Service{
+		ConfigService: r.ConfigService,
+		LoggerService: r.LoggerService,
+		PortManager:   r.PortManager,
+		Translator:    r.Translator,
+		Definitions:   r.Definitions,
+		RigID:         rigID,
+		Options:       opts,
+	}
+	if r.EventBus != nil {
+		s.EventBus = &rigEventBus{inner: r.EventBus, rigID: rigID}
+	}
+	if r.Store != nil {
+		s.Store = &rigStore{inner: r.Store, prefix: fmt.Sprintf("rigs/%d/", rigID)}
+	}
+	if err := s.Initialize(); err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	port := s.rigConfig().SerialConfig.PortName
+	for id, other := range r.rigs {
+		if port != "" && other.rigConfig().SerialConfig.PortName == port {
+			return nil, errors.New(op).Msgf("Rig %d uses serial port %s, which is already used by rig %d.",
+				rigID, port, id)
+		}
+	}
+
+	if r.rigs == nil {
+		r.rigs = make(map[int64]*Service)
+	}
+	r.rigs[rigID] = s
+	return s, nil
+}
+
+// Remove stops the rig if it is running and removes it from the registry.
+func (r *Registry) Remove(rigID int64) error {
+	const op errors.Op = "cat.Registry.Remove"
+	s, err := r.Rig(rigID)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.Stop(); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	r.mu.Lock()
+	delete(r.rigs, rigID)
+	r.mu.Unlock()
+	return nil
+}
+
+// Rig returns the Service for rigID.
+func (r *Registry) Rig(rigID int64) (*Service, error) {
+	const op errors.Op = "cat.Registry.Rig"
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.rigs[rigID]
+	if !ok {
+		return nil, errors.New(op).Msgf("Rig %d is not registered.", rigID)
+	}
+	return s, nil
+}
+
+// RigIDs returns the IDs of the registered rigs in ascending order.
+func (r *Registry) RigIDs() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]int64, 0, len(r.rigs))
+	for id := range r.rigs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Start starts the rig with the given ID.
+func (r *Registry) Start(rigID int64) error {
+	const op errors.Op = "cat.Registry.Start"
+	s, err := r.Rig(rigID)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.Start(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// Stop stops the rig with the given ID.
+func (r *Registry) Stop(rigID int64) error {
+	const op errors.Op = "cat.Registry.Stop"
+	s, err := r.Rig(rigID)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.Stop(); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// StartAll starts every registered rig, stopping at the first failure.
+func (r *Registry) StartAll() error {
+	const op errors.Op = "cat.Registry.StartAll"
+	for _, id := range r.RigIDs() {
+		if err := r.Start(id); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	return nil
+}
+
+// StopAll stops every registered rig, returning the first error after attempting all of them.
+func (r *Registry) StopAll() error {
+	const op errors.Op = "cat.Registry.StopAll"
+	var first error
+	for _, id := range r.RigIDs() {
+		if err := r.Stop(id); err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		return errors.New(op).Err(first)
+	}
+	return nil
+}
+
+// EnqueueCommand queues a command for the rig with the given ID.
+func (r *Registry) EnqueueCommand(rigID int64, cmdName cmds.CatCmdName, params ...string) error {
+	const op errors.Op = "cat.Registry.EnqueueCommand"
+	s, err := r.Rig(rigID)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.EnqueueCommand(cmdName, params...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// StatusChannel returns the status channel of the rig with the given ID.
+func (r *Registry) StatusChannel(rigID int64) (<-chan types.CatStatus, error) {
+	const op errors.Op = "cat.Registry.StatusChannel"
+	s, err := r.Rig(rigID)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	ch, err := s.StatusChannel()
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return ch, nil
+}
+
+// RigPayload is what the rigs of a Registry publish on its EventBus: the payload a single Service would publish,
+// with the ID of the rig it is from.
+type RigPayload struct {
+	RigID   int64
+	Payload any
+}
+
+// rigEventBus wraps the payloads published by one rig of a Registry in a RigPayload.
+type rigEventBus struct {
+	inner EventBus
+	rigID int64
+}
+
+// Publish implements EventBus.
+func (b *rigEventBus) Publish(topic string, payload any) error {
+	return b.inner.Publish(topic, RigPayload{RigID: b.rigID, Payload: payload})
+}
+
+// rigStore keeps the keys of one rig of a Registry below prefix in the shared Store.
+type rigStore struct {
+	inner  Store
+	prefix string
+}
+
+// Load implements Store.
+func (r *rigStore) Load(key string) ([]byte, error) {
+	return r.inner.Load(r.prefix + key)
+}
+
+// Save implements Store.
+func (r *rigStore) Save(key string, data []byte) error {
+	return r.inner.Save(r.prefix+key, data)
+}
+
+// Append implements Store.
+func (r *rigStore) Append(key string, record []byte) error {
+	return r.inner.Append(r.prefix+key, record)
+}
+
+// Delete implements Store.
+func (r *rigStore) Delete(key string) error {
+	return r.inner.Delete(r.prefix + key)
+}
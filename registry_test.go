@@ -0,0 +1,91 @@
+package cat
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegistryTestConfigService(t *testing.T) *config.Service {
+	t.Helper()
+	rig := func(id int64, port string) types.RigConfig {
+		return types.RigConfig{
+			ID:           id,
+			SerialConfig: types.SerialConfig{PortName: port},
+			CatConfig:    types.CatConfig{SendChannelSize: 1, ProcessingChannelSize: 1},
+		}
+	}
+	cfgService := &config.Service{}
+	require.NoError(t, cfgService.Initialize())
+	// Initialize loads the configuration file, so the rigs are seeded afterwards.
+	cfgService.AppConfig.RigConfigs = []types.RigConfig{rig(1, "/dev/rig-a"), rig(2, "/dev/rig-b"), rig(3, "/dev/rig-a")}
+	return cfgService
+}
+
+func TestRegistryScopesRigs(t *testing.T) {
+	registry := &Registry{ConfigService: newRegistryTestConfigService(t), LoggerService: &logging.Service{}}
+
+	a, err := registry.Add(1, Options{})
+	require.NoError(t, err)
+	b, err := registry.Add(2, Options{})
+	require.NoError(t, err)
+	require.NotSame(t, a, b)
+	require.Equal(t, "/dev/rig-b", b.config.SerialConfig.PortName)
+	require.Equal(t, []int64{1, 2}, registry.RigIDs())
+
+	_, err = registry.Add(2, Options{})
+	require.Error(t, err)
+	_, err = registry.Add(9, Options{})
+	require.Error(t, err)
+	_, err = registry.Add(3, Options{})
+	require.ErrorContains(t, err, "already used by rig 1")
+
+	chA, err := registry.StatusChannel(1)
+	require.NoError(t, err)
+	chB, err := registry.StatusChannel(2)
+	require.NoError(t, err)
+	require.NotEqual(t, chA, chB)
+
+	require.NoError(t, registry.Remove(2))
+	_, err = registry.Rig(2)
+	require.Error(t, err)
+}
+
+// busRecorder is an EventBus that keeps every published payload.
+type busRecorder struct {
+	mu       sync.Mutex
+	payloads []any
+}
+
+func (b *busRecorder) Publish(_ string, payload any) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.payloads = append(b.payloads, payload)
+	return nil
+}
+
+func TestRegistryScopesSharedStoreAndBus(t *testing.T) {
+	bus := &busRecorder{}
+	store := &FileStore{Dir: t.TempDir()}
+	registry := &Registry{ConfigService: newRegistryTestConfigService(t), LoggerService: &logging.Service{}, EventBus: bus, Store: store}
+	a, err := registry.Add(1, Options{})
+	require.NoError(t, err)
+	b, err := registry.Add(2, Options{})
+	require.NoError(t, err)
+
+	require.NoError(t, a.Store.Save(storeKeyLastState, []byte("a")))
+	require.NoError(t, b.Store.Save(storeKeyLastState, []byte("b")))
+	data, err := a.Store.Load(storeKeyLastState)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(data), "the rigs do not overwrite each other's state")
+	data, err = store.Load("rigs/2/" + storeKeyLastState)
+	require.NoError(t, err)
+	require.Equal(t, "b", string(data))
+
+	require.NoError(t, b.EventBus.Publish(TopicStatus, types.CatStatus{"VFOAFREQ": "00014074000"}))
+	require.Equal(t, []any{RigPayload{RigID: 2, Payload: types.CatStatus{"VFOAFREQ": "00014074000"}}}, bus.payloads)
+}
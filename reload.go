@@ -0,0 +1,113 @@
+package cat
+
+import (
+	"reflect"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// loadRigConfig fetches the rig configuration from the ConfigService, resolves its hex escapes, migrates and
+// validates it, and fills in the timing defaults.
+func (s *Service) loadRigConfig() (*types.RigConfig, MigrationReport, error) {
+	cfg, err := s.getRigConfig()
+	if err != nil {
+		return nil, MigrationReport{}, err
+	}
+
+	if err = unescapeDefinition(cfg); err != nil {
+		return nil, MigrationReport{}, err
+	}
+
+	// Upgrade older rig definitions before validating, so that a renamed or re-scaled field does not fail
+	// validation when it could be fixed automatically.
+	report := migrateConfig(cfg, s.Options.SchemaVersion)
+	for _, applied := range report.Applied {
+		s.logger().InfoWith().Str("migration", applied).Msg("CAT rig definition migrated")
+	}
+	if s.driver != nil {
+		applyDriverDefinition(cfg, s.driver)
+	}
+
+	if err = validateConfig(cfg); err != nil {
+		return nil, MigrationReport{}, err
+	}
+
+	// Ensure sensible defaults for CAT timing configuration to avoid panics
+	// when creating tickers or timeouts with non-positive durations.
+	if cfg.CatConfig.ListenerRateLimiterIntervalMS <= 0 {
+		cfg.CatConfig.ListenerRateLimiterIntervalMS = defaultListenerIntervalMS
+	}
+	if cfg.CatConfig.ListenerReadTimeoutMS <= 0 {
+		cfg.CatConfig.ListenerReadTimeoutMS = cfg.SerialConfig.ReadTimeoutMS
+	}
+	return cfg, report, nil
+}
+
+// Reload fetches the rig configuration again and swaps the CAT commands, states and timing parameters in one step
+// while the workers keep running. The serial port is reopened only if the serial configuration changed.
+// Channel sizes are fixed at Initialize and are not reloaded. On error the current configuration stays in effect.
+func (s *Service) Reload() error {
+	const op errors.Op = "cat.Service.Reload"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	cfg, report, err := s.loadRigConfig()
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	states, maxLen, err := buildStateSet(cfg, s.prefixKey)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	patterns, err := s.buildPatterns(states)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	s.definitionMu.Lock()
+	if s.configuredBaud != 0 && cfg.SerialConfig.PortName == s.config.SerialConfig.PortName &&
+		cfg.SerialConfig.BaudRate == s.configuredBaud {
+		// Keep the negotiated rate rather than reopening the port at the configured one, unless that changed.
+		cfg.SerialConfig.BaudRate = s.config.SerialConfig.BaudRate
+	} else {
+		s.configuredBaud = 0
+	}
+	serialChanged := !reflect.DeepEqual(s.config.SerialConfig, cfg.SerialConfig)
+	s.config = cfg
+	s.supportedCatStates = states
+	s.maxCatPrefixLen = maxLen
+	s.patterns = patterns
+	s.migrationReport = report
+	s.definitionMu.Unlock()
+
+	s.logger().InfoWith().Bool("serial_changed", serialChanged).Msg("CAT rig definition reloaded")
+	if serialChanged && s.started.Load() {
+		// The listener owns the transport while running; it reopens the port on its next tick.
+		s.reopenPort.Store(true)
+	}
+	return nil
+}
+
+// reopenRequestedPort closes the transport and opens it again with the current serial configuration, falling back
+// to the reconnect logic if that fails and reconnecting is enabled. It returns false on shutdown.
+func (s *Service) reopenRequestedPort(shutdown <-chan struct{}) bool {
+	s.linkDown.Store(true)
+	if old := s.link(); old != nil {
+		_ = old.Close()
+	}
+	err := s.initializeTransport()
+	s.linkDown.Store(false)
+	if err == nil {
+		s.logger().InfoWith().Msg("serial port reopened with the reloaded configuration")
+		return true
+	}
+
+	s.logger().ErrorWith().Err(err).Msg("reopening the serial port failed")
+	s.reportError("reload", err)
+	if s.Options.Reconnect.Enabled {
+		return s.reconnect(shutdown, classifyPortError(err))
+	}
+	return true
+}
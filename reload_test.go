@@ -0,0 +1,92 @@
+package cat
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newReloadTestService(t *testing.T) (*Service, *config.Service) {
+	t.Helper()
+	cfgService := &config.Service{}
+	require.NoError(t, cfgService.Initialize())
+	cfgService.AppConfig.RigConfigs = []types.RigConfig{{
+		ID:           1,
+		SerialConfig: types.SerialConfig{PortName: "/dev/rig-a"},
+		CatConfig: types.CatConfig{
+			Enabled: true, SendChannelSize: 4, ProcessingChannelSize: 4, ListenerRateLimiterIntervalMS: 5,
+		},
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	}}
+	service := &Service{ConfigService: cfgService, LoggerService: &logging.Service{}, RigID: 1}
+	require.NoError(t, service.Initialize())
+	return service, cfgService
+}
+
+func TestReloadSwapsDefinition(t *testing.T) {
+	service, cfgService := newReloadTestService(t)
+
+	rig := &cfgService.AppConfig.RigConfigs[0]
+	rig.CatCommands = []types.CatCommand{{Name: "READ", Cmd: "IF;"}}
+	rig.CatStates = []types.CatState{{Prefix: "IF", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}}
+	require.NoError(t, service.Reload())
+
+	cmd, err := service.commandLookup("READ")
+	require.NoError(t, err)
+	require.Equal(t, "IF;", cmd.Cmd)
+	_, ok := service.lookupCatState([]byte("IF00014074000"))
+	require.True(t, ok)
+	_, ok = service.lookupCatState([]byte("FA00014074000"))
+	require.False(t, ok)
+}
+
+func TestReloadKeepsDefinitionOnError(t *testing.T) {
+	service, cfgService := newReloadTestService(t)
+
+	cfgService.AppConfig.RigConfigs[0].CatStates = []types.CatState{{Prefix: " "}}
+	require.Error(t, service.Reload())
+	_, ok := service.lookupCatState([]byte("FA00014074000"))
+	require.True(t, ok)
+}
+
+func TestReloadReopensPortOnlyWhenSerialConfigChanged(t *testing.T) {
+	service, cfgService := newReloadTestService(t)
+	var dials atomic.Int32
+	service.dialer = func() (Transport, error) {
+		dials.Add(1)
+		return newFakeTransport(), nil
+	}
+	require.NoError(t, service.Start())
+	t.Cleanup(func() { _ = service.Stop() })
+
+	cfgService.AppConfig.RigConfigs[0].CatCommands = []types.CatCommand{{Name: "READ", Cmd: "IF;"}}
+	require.NoError(t, service.Reload())
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, int32(1), dials.Load())
+
+	cfgService.AppConfig.RigConfigs[0].SerialConfig.PortName = "/dev/rig-b"
+	require.NoError(t, service.Reload())
+	require.Eventually(t, func() bool { return dials.Load() == 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, "/dev/rig-b", service.RigConfig().SerialConfig.PortName)
+}
+
+func TestReloadKeepsNegotiatedBaudRateWhileConfiguredUnchanged(t *testing.T) {
+	service, cfgService := newReloadTestService(t)
+	cfgService.AppConfig.RigConfigs[0].SerialConfig.BaudRate = 9600
+	require.NoError(t, service.Reload())
+	service.setBaudRate(19200) // as negotiated on Start
+
+	require.NoError(t, service.Reload())
+	require.Equal(t, 19200, service.RigConfig().SerialConfig.BaudRate)
+
+	cfgService.AppConfig.RigConfigs[0].SerialConfig.BaudRate = 4800
+	require.NoError(t, service.Reload())
+	require.Equal(t, 4800, service.RigConfig().SerialConfig.BaudRate, "the new configured rate applies")
+	require.Equal(t, 4800, service.configuredBaudRate())
+}
@@ -0,0 +1,273 @@
+package cat
+
+import (
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// defaultRemoteFlushMS is used when Options.Remote.FlushIntervalMS is zero.
+	defaultRemoteFlushMS = 250
+	// defaultRemoteMeterMS is used when Options.Remote.MeterIntervalMS is zero.
+	defaultRemoteMeterMS = 1000
+	// defaultRemoteSize is used when SubscribeRemote is called with a non-positive size.
+	defaultRemoteSize = 4
+)
+
+// RemoteUpdate is an update sent by SubscribeRemote. Status holds only the tags that changed since the previous
+// update, with their display values, unless Full is set.
+type RemoteUpdate struct {
+	At time.Time
+	// Full is set for a keyframe carrying every known tag: the first update, and the one after an update could not
+	// be delivered because the consumer fell behind. The remote side replaces its state with a keyframe instead
+	// of merging it.
+	Full   bool
+	Status types.CatStatus
+	// Meters holds the latest reading of each meter tag since the previous update carrying meters.
+	Meters []MeterReading
+}
+
+// remoteSubscribers holds the channels handed out by SubscribeRemote.
+type remoteSubscribers struct {
+	mu   sync.Mutex
+	subs map[<-chan RemoteUpdate]*remoteSubscriber
+}
+
+// remoteSubscriber is one channel returned by SubscribeRemote with the state the remote side is known to have.
+type remoteSubscriber struct {
+	ch chan RemoteUpdate
+	// sent holds the values delivered so far and pending the changes not yet delivered.
+	sent    types.CatStatus
+	pending types.CatStatus
+	meters  map[string]MeterReading
+	// resync is set when the next update must be a keyframe.
+	resync     bool
+	lastMeters time.Time
+}
+
+// SubscribeRemote returns a new channel receiving the rig state as coalesced deltas: changes are collected for
+// Options.Remote.FlushIntervalMS and sent as one update, and values that change back before they are sent are
+// not sent at all. Updates are never dropped silently; if the channel is full, the update is held back and the
+// next one is a keyframe. Remote mode must be enabled with Options.Remote.Enabled. Call UnsubscribeRemote when
+// done.
+func (s *Service) SubscribeRemote(size int) (<-chan RemoteUpdate, error) {
+	const op errors.Op = "cat.Service.SubscribeRemote"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if !s.Options.Remote.Enabled {
+		return nil, errors.New(op).Msg("Remote mode is not enabled.")
+	}
+	if size <= 0 {
+		size = defaultRemoteSize
+	}
+
+	sub := &remoteSubscriber{
+		ch:      make(chan RemoteUpdate, size),
+		sent:    make(types.CatStatus),
+		pending: make(types.CatStatus),
+		meters:  make(map[string]MeterReading),
+		resync:  true,
+	}
+	s.remotes.mu.Lock()
+	defer s.remotes.mu.Unlock()
+	if s.remotes.subs == nil {
+		s.remotes.subs = make(map[<-chan RemoteUpdate]*remoteSubscriber)
+	}
+	s.remotes.subs[sub.ch] = sub
+	return sub.ch, nil
+}
+
+// UnsubscribeRemote stops delivery to a channel returned by SubscribeRemote and closes it. Unknown channels are
+// ignored.
+func (s *Service) UnsubscribeRemote(ch <-chan RemoteUpdate) {
+	s.remotes.mu.Lock()
+	defer s.remotes.mu.Unlock()
+	if sub, ok := s.remotes.subs[ch]; ok {
+		delete(s.remotes.subs, ch)
+		close(sub.ch)
+	}
+}
+
+// EnqueueRemoteBatch queues requests received from a remote frontend in one round trip, e.g. the steps of a VFO
+// knob turned while the link was busy, as one batch. A run of adjacent set commands that set the same tag, see
+// Options.DuplicateTags, is cut down to its last request, so only the final value of each step crosses the serial
+// link. Every other request is sent, in order.
+func (s *Service) EnqueueRemoteBatch(requests []CatCommandRequest, opts ...CommandOption) (*Batch, error) {
+	const op errors.Op = "cat.Service.EnqueueRemoteBatch"
+	batch, err := s.EnqueueBatch(s.coalesceRequests(requests), opts...)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return batch, nil
+}
+
+// coalesceRequests drops each set request directly followed by a request setting the same tag. Only set commands
+// with a single parameter are idempotent enough to drop; the order of the requests is kept.
+func (s *Service) coalesceRequests(requests []CatCommandRequest) []CatCommandRequest {
+	target := func(req CatCommandRequest) (tags.CatStateTag, bool) {
+		if len(req.Params) != 1 {
+			return "", false
+		}
+		return s.commandTag(req.Name)
+	}
+	out := make([]CatCommandRequest, 0, len(requests))
+	for i, req := range requests {
+		if tag, ok := target(req); ok && i+1 < len(requests) {
+			if next, ok := target(requests[i+1]); ok && next == tag && requests[i+1].Name == req.Name {
+				continue
+			}
+		}
+		out = append(out, req)
+	}
+	return out
+}
+
+// offerRemote adds the changes in status, as translated for display, to the pending deltas of the remote
+// subscribers.
+func (s *Service) offerRemote(status types.CatStatus) {
+	s.remotes.mu.Lock()
+	defer s.remotes.mu.Unlock()
+	for _, sub := range s.remotes.subs {
+		for tag, value := range status {
+			if sent, ok := sub.sent[tag]; ok && sent == value {
+				delete(sub.pending, tag)
+				continue
+			}
+			sub.pending[tag] = value
+		}
+	}
+}
+
+// offerRemoteMeter keeps reading as the latest of its tag for the remote subscribers.
+func (s *Service) offerRemoteMeter(reading MeterReading) {
+	s.remotes.mu.Lock()
+	defer s.remotes.mu.Unlock()
+	for _, sub := range s.remotes.subs {
+		sub.meters[reading.Tag] = reading
+	}
+}
+
+// remoteFlusher sends the pending deltas to the remote subscribers every Options.Remote.FlushIntervalMS.
+func (s *Service) remoteFlusher(shutdown <-chan struct{}) {
+	ticker := time.NewTicker(durationOrDefault(s.Options.Remote.FlushIntervalMS, defaultRemoteFlushMS))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case now := <-ticker.C:
+			s.flushRemote(now)
+		}
+	}
+}
+
+// flushRemote sends each remote subscriber its pending update, or a keyframe if it needs one.
+func (s *Service) flushRemote(now time.Time) {
+	meterInterval := durationOrDefault(s.Options.Remote.MeterIntervalMS, defaultRemoteMeterMS)
+	var keyframe types.CatStatus
+
+	s.remotes.mu.Lock()
+	defer s.remotes.mu.Unlock()
+	for _, sub := range s.remotes.subs {
+		update := RemoteUpdate{At: now, Status: sub.pending}
+		if sub.resync {
+			if keyframe == nil {
+				keyframe = s.remoteKeyframe()
+			}
+			update.Full = true
+			update.Status = maps.Clone(keyframe)
+			maps.Copy(update.Status, sub.pending)
+		}
+		withMeters := len(sub.meters) > 0 && now.Sub(sub.lastMeters) >= meterInterval
+		if withMeters {
+			update.Meters = make([]MeterReading, 0, len(sub.meters))
+			for _, reading := range sub.meters {
+				update.Meters = append(update.Meters, reading)
+			}
+			sort.Slice(update.Meters, func(i, j int) bool { return update.Meters[i].Tag < update.Meters[j].Tag })
+		}
+		if len(update.Status) == 0 && !update.Full && !withMeters {
+			continue
+		}
+
+		select {
+		case sub.ch <- update:
+		default:
+			s.logger().DebugWith().Msg("remote subscriber behind; holding back the update for a keyframe")
+			sub.resync = true
+			continue
+		}
+		if update.Full {
+			clear(sub.sent)
+		}
+		maps.Copy(sub.sent, update.Status)
+		sub.pending = make(types.CatStatus)
+		sub.resync = false
+		if withMeters {
+			clear(sub.meters)
+			sub.lastMeters = now
+		}
+	}
+}
+
+// remoteKeyframe returns the cached rig state translated for display, without the meter tags, which are sent
+// as readings.
+func (s *Service) remoteKeyframe() types.CatStatus {
+	status := s.cache.snapshot()
+	if s.meters != nil {
+		for _, tag := range s.meters.tags {
+			delete(status, tag)
+		}
+	}
+	return s.translateStatus(status)
+}
+
+// MeterPredictor predicts meter values on the remote side between the readings of RemoteUpdate.Meters, by
+// extrapolating the trend of the last two readings of each tag. It is not safe for concurrent use.
+type MeterPredictor struct {
+	// Horizon limits the extrapolation; beyond it the prediction holds. Zero means the interval between the last
+	// two readings.
+	Horizon  time.Duration
+	previous map[string]MeterReading
+	last     map[string]MeterReading
+}
+
+// Observe records a reading received from the rig.
+func (p *MeterPredictor) Observe(reading MeterReading) {
+	if p.last == nil {
+		p.previous = make(map[string]MeterReading)
+		p.last = make(map[string]MeterReading)
+	}
+	if last, ok := p.last[reading.Tag]; ok && reading.At.After(last.At) {
+		p.previous[reading.Tag] = last
+	}
+	p.last[reading.Tag] = reading
+}
+
+// Predict returns the predicted value of the meter tag at the given time. ok is false if no reading of tag was
+// observed.
+func (p *MeterPredictor) Predict(tag string, at time.Time) (value float64, ok bool) {
+	last, ok := p.last[tag]
+	if !ok {
+		return 0, false
+	}
+	previous, ok := p.previous[tag]
+	if !ok {
+		return last.Value, true
+	}
+	span := last.At.Sub(previous.At)
+	horizon := p.Horizon
+	if horizon <= 0 {
+		horizon = span
+	}
+	elapsed := min(max(at.Sub(last.At), 0), horizon)
+	slope := (last.Value - previous.Value) / span.Seconds()
+	return last.Value + slope*elapsed.Seconds(), true
+}
@@ -0,0 +1,102 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteUpdatesAreCoalescedDeltas(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	_, err := service.SubscribeRemote(0)
+	require.Error(t, err, "remote mode must be enabled")
+
+	service.Options.Remote = RemoteOptions{Enabled: true, MeterIntervalMS: 1000}
+	service.cache.update(types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "USB"}, time.Now())
+	ch, err := service.SubscribeRemote(1)
+	require.NoError(t, err)
+
+	now := time.Now()
+	service.flushRemote(now)
+	first := <-ch
+	require.True(t, first.Full)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "USB"}, first.Status)
+
+	service.offerRemote(types.CatStatus{"VFOAFREQ": "00014075000", "MAINMODE": "USB"})
+	service.offerRemote(types.CatStatus{"VFOAFREQ": "00014076000"})
+	service.flushRemote(now.Add(250 * time.Millisecond))
+	delta := <-ch
+	require.False(t, delta.Full)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014076000"}, delta.Status)
+
+	service.offerRemote(types.CatStatus{"MAINMODE": "CW"})
+	service.offerRemote(types.CatStatus{"MAINMODE": "USB"})
+	service.flushRemote(now.Add(500 * time.Millisecond))
+	require.Empty(t, ch, "a value that changed back is not sent")
+}
+
+func TestRemoteSubscriberBehindGetsKeyframe(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Remote = RemoteOptions{Enabled: true}
+	ch, err := service.SubscribeRemote(1)
+	require.NoError(t, err)
+	now := time.Now()
+	service.flushRemote(now) // empty keyframe fills the channel
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "00007074000"}, now)
+	service.offerRemote(types.CatStatus{"VFOAFREQ": "00007074000"})
+	service.flushRemote(now.Add(time.Second))
+	<-ch
+
+	service.offerRemoteMeter(MeterReading{Tag: TagSMeter, Value: -73, At: now})
+	service.flushRemote(now.Add(2 * time.Second))
+	update := <-ch
+	require.True(t, update.Full, "the held-back delta is replaced by a keyframe")
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00007074000"}, update.Status)
+	require.Equal(t, []MeterReading{{Tag: TagSMeter, Value: -73, At: now}}, update.Meters)
+}
+
+func TestCoalesceRequests(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	got := service.coalesceRequests([]CatCommandRequest{
+		{Name: CmdSetVfoAFreq, Params: []string{"00014074000"}},
+		{Name: CmdSetVfoAFreq, Params: []string{"00014075000"}},
+		{Name: CmdSetMainMode, Params: []string{"2"}},
+		{Name: CmdSetVfoAFreq, Params: []string{"00014076000"}},
+		{Name: "READ"},
+		{Name: CmdWriteMemory, Params: []string{"001", "00014074000"}},
+		{Name: CmdWriteMemory, Params: []string{"002", "00007074000"}},
+		{Name: CmdSendCW, Params: []string{"CQ"}},
+		{Name: CmdSendCW, Params: []string{"CQ"}},
+	})
+	require.Equal(t, []CatCommandRequest{
+		{Name: CmdSetVfoAFreq, Params: []string{"00014075000"}},
+		{Name: CmdSetMainMode, Params: []string{"2"}},
+		{Name: CmdSetVfoAFreq, Params: []string{"00014076000"}},
+		{Name: "READ"},
+		{Name: CmdWriteMemory, Params: []string{"001", "00014074000"}},
+		{Name: CmdWriteMemory, Params: []string{"002", "00007074000"}},
+		{Name: CmdSendCW, Params: []string{"CQ"}},
+		{Name: CmdSendCW, Params: []string{"CQ"}},
+	}, got, "only adjacent sets of the same tag are merged, and the mode stays after the first frequency")
+}
+
+func TestMeterPredictorExtrapolatesWithinHorizon(t *testing.T) {
+	var p MeterPredictor
+	_, ok := p.Predict(TagSMeter, time.Now())
+	require.False(t, ok)
+
+	start := time.Now()
+	p.Observe(MeterReading{Tag: TagSMeter, Value: 10, At: start})
+	v, ok := p.Predict(TagSMeter, start.Add(time.Second))
+	require.True(t, ok)
+	require.Equal(t, 10.0, v)
+
+	p.Observe(MeterReading{Tag: TagSMeter, Value: 20, At: start.Add(time.Second)})
+	v, _ = p.Predict(TagSMeter, start.Add(1500*time.Millisecond))
+	require.InDelta(t, 25, v, 1e-9)
+	v, _ = p.Predict(TagSMeter, start.Add(10*time.Second))
+	require.InDelta(t, 30, v, 1e-9, "held after the horizon")
+}
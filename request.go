@@ -0,0 +1,148 @@
+package cat
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// defaultResponseTimeoutMS is used by SendCommand when ctx has no deadline and Options.ResponseTimeoutMS is
+	// zero.
+	defaultResponseTimeoutMS = 1000
+)
+
+// SendCommand writes a command and waits for the rig's response to it, returning the parsed values directly
+// instead of via the status channel. The response is the first frame received once the command began to be
+// written whose state prefix is taken from Options.ResponsePrefixes or derived from the command template (e.g.
+// "FA;" is answered by state "FA"), so that a frame of the prefix already on its way, e.g. the answer to a poll,
+// is not taken for it. If ctx has no deadline, Options.ResponseTimeoutMS applies. A command that fails, or is
+// not written at all, e.g. because it was suppressed as a duplicate, returns the reason at once.
+func (s *Service) SendCommand(ctx context.Context, cmdName cmds.CatCmdName, params ...string) (types.CatStatus, error) {
+	const op errors.Op = "cat.Service.SendCommand"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	prefix, err := s.responsePrefixFor(cmdName)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, durationOrDefault(s.Options.ResponseTimeoutMS, defaultResponseTimeoutMS))
+		defer cancel()
+	}
+
+	// Register before sending so that a fast response is not missed.
+	responses, from, cancel := s.awaitResponse(prefix)
+	defer cancel()
+
+	written := make(chan struct{})
+	handle, err := s.EnqueueTracked(cmdName, params, respondedFrom(from, written))
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+
+	state, _, err := s.awaitWrittenResponse(ctx, handle, written, responses, 0)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.New(op).Err(err).Msgf("no %s response to %s", prefix, cmdName)
+		}
+		return nil, errors.New(op).Err(err)
+	}
+	status, err := s.parseState(state)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return status, nil
+}
+
+// awaitWrittenResponse waits for the first of responses received once written is closed, i.e. once the command
+// followed by handle began to be written. It fails as soon as the command fails or ends without having been
+// written, e.g. suppressed as a duplicate or dropped by a filter, rather than waiting for a response that cannot
+// come. If timeout is positive, it gives up timeout after the write and returns false.
+func (s *Service) awaitWrittenResponse(ctx context.Context, handle *CommandHandle, written <-chan struct{}, responses <-chan types.CatState, timeout time.Duration) (types.CatState, bool, error) {
+	const op errors.Op = "cat.Service.awaitWrittenResponse"
+
+	done := handle.Done()
+	var states <-chan types.CatState // read once the command is being written
+	var expired <-chan time.Time     // runs from the write
+	for {
+		select {
+		case <-ctx.Done():
+			return types.CatState{}, false, errors.New(op).Err(ctx.Err())
+		case <-done:
+			done = nil
+			outcome := handle.Outcome()
+			if outcome.State == OutcomeFailed {
+				return types.CatState{}, false, errors.New(op).Msgf("%s failed: %s", outcome.Command, outcome.Err)
+			}
+			if handle.lastWrite().IsZero() {
+				return types.CatState{}, false, errors.New(op).Msgf("%s was not written: %s", outcome.Command, outcome.Note)
+			}
+		case <-written:
+			written, states = nil, responses
+			if timeout > 0 {
+				timer := time.NewTimer(timeout)
+				defer timer.Stop()
+				expired = timer.C
+			}
+		case state := <-states:
+			return state, true, nil
+		case <-expired:
+			return types.CatState{}, false, nil
+		}
+	}
+}
+
+// respondedFrom calls from with the time the command begins to be written, to ignore the frames received
+// earlier, and then closes written.
+func respondedFrom(from func(time.Time), written chan struct{}) CommandOption {
+	return func(req *commandRequest) {
+		req.onFirstWrite = func(at time.Time) {
+			from(at)
+			close(written)
+		}
+	}
+}
+
+// responsePrefixFor returns the state prefix of the response to cmdName: the configured override, or the longest
+// configured state prefix that the command template starts with.
+func (s *Service) responsePrefixFor(cmdName cmds.CatCmdName) (string, error) {
+	const op errors.Op = "cat.Service.responsePrefixFor"
+	if prefix, ok := s.Options.ResponsePrefixes[cmdName]; ok {
+		prefix, err := unescapeHex(prefix, false)
+		if err != nil {
+			return "", errors.New(op).Err(err)
+		}
+		return s.prefixKey(prefix), nil
+	}
+
+	catCmd, err := s.commandLookup(cmdName)
+	if err != nil {
+		return "", errors.New(op).Err(err)
+	}
+	template := s.foldPrefix(catCmd.Cmd)
+	if i := strings.IndexByte(template, '%'); i >= 0 {
+		template = template[:i]
+	}
+
+	s.definitionMu.RLock()
+	defer s.definitionMu.RUnlock()
+	best := ""
+	for prefix := range s.supportedCatStates {
+		if len(prefix) > len(best) && strings.HasPrefix(template, prefix) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return "", errors.New(op).Msgf("no CAT state answers command %s; set Options.ResponsePrefixes", cmdName)
+	}
+	return best, nil
+}
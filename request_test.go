@@ -0,0 +1,97 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendCommandReturnsResponse(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READFREQ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if cmd == "FA;" {
+			state, _ := service.lookupCatState([]byte("FA00014074000"))
+			service.deliverToWaiters(state, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+
+	status, err := service.SendCommand(context.Background(), "READFREQ")
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014074000"}, status)
+	require.Equal(t, []string{"FA;"}, rig.writes())
+}
+
+func TestSendCommandIgnoresFramesFromBeforeTheWrite(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READFREQ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	type reply struct {
+		status types.CatStatus
+		err    error
+	}
+	replies := make(chan reply, 1)
+	go func() {
+		status, err := service.SendCommand(context.Background(), "READFREQ")
+		replies <- reply{status, err}
+	}()
+	require.Eventually(t, func() bool { return len(service.sendChannel) == 1 }, time.Second, time.Millisecond)
+
+	// A report received while the command is still queued, e.g. after a turn of the VFO knob.
+	unsolicited, _ := service.lookupCatState([]byte("FA00003573000"))
+	service.deliverToWaiters(unsolicited, time.Now())
+
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(string) {
+		state, _ := service.lookupCatState([]byte("FA00014074000"))
+		service.deliverToWaiters(state, time.Now())
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+
+	got := <-replies
+	require.NoError(t, got.err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014074000"}, got.status)
+}
+
+func TestSendCommandTimesOut(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "IDENTIFY", Cmd: "ID;"}},
+		CatStates:   []types.CatState{{Prefix: "RID", Markers: []types.Marker{{Tag: "IDENTITY", Index: 0, Length: 3}}}},
+	})
+	service.Options.ResponsePrefixes = map[cmds.CatCmdName]string{}
+	service.Options.ResponseTimeoutMS = 20
+
+	_, err := service.SendCommand(context.Background(), "IDENTIFY")
+	require.ErrorContains(t, errors.Root(err), "no CAT state answers")
+
+	service.Options.ResponsePrefixes = map[cmds.CatCmdName]string{"IDENTIFY": "rid"}
+	start := time.Now()
+	_, err = service.SendCommand(context.Background(), "IDENTIFY")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestSendCommandFailsAtOnceWhenNotWritten(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READFREQ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	service.Options.ResponseTimeoutMS = 5000
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.linkDown.Store(true)
+
+	start := time.Now()
+	_, err := service.SendCommand(context.Background(), "READFREQ")
+	require.ErrorContains(t, errors.Root(err), errMsgLinkDown)
+	require.Less(t, time.Since(start), time.Second)
+}
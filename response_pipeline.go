@@ -0,0 +1,231 @@
+package cat
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// ResponseStage names a stage of the response pipeline, which turns every state received from the rig into the
+// status emitted on the status channel. The stages run in the order of responseStages.
+type ResponseStage string
+
+const (
+	// StageDecode parses the state into a status with the raw values of its markers.
+	StageDecode ResponseStage = "decode"
+	// StageTransform corrects the values, applies them to the rig state cache and takes out the meter readings.
+	// Steps added to it run before the cache is updated, so that the cache holds the values they produce.
+	StageTransform ResponseStage = "transform"
+	// StageEnrich acts on the status: QSY history, band tracking, the band map and power limits.
+	StageEnrich ResponseStage = "enrich"
+	// StagePublish reduces the status to its changes when Options.StatusDiff is enabled; the status left after
+	// it is emitted.
+	StagePublish ResponseStage = "publish"
+)
+
+// responseStages are the stages in the order they run.
+var responseStages = []ResponseStage{StageDecode, StageTransform, StageEnrich, StagePublish}
+
+// String implements fmt.Stringer.
+func (r ResponseStage) String() string {
+	return string(r)
+}
+
+// ResponseStep is a step added to a stage of the response pipeline with AddResponseStep. It may modify status and
+// returns the status passed on; an empty status ends the processing of the state, so nothing is emitted.
+type ResponseStep func(status types.CatStatus) types.CatStatus
+
+// response is a received state on its way through the response pipeline.
+type response struct {
+	receivedState
+	status types.CatStatus
+	// at is when processing started; previousFreq is VFO A before the status was applied to the cache.
+	at           time.Time
+	previousFreq cachedValue
+	hadFreq      bool
+}
+
+// responseStep is a step of a stage. It returns false to end the processing of the state.
+type responseStep struct {
+	name string
+	run  func(r *response) bool
+}
+
+// responseSteps holds the steps added with AddResponseStep.
+type responseSteps struct {
+	mu    sync.RWMutex
+	steps map[ResponseStage][]responseStep
+	// built holds the built-in and added steps of every stage, in order; nil until first used and after a step is
+	// added.
+	built map[ResponseStage][]responseStep
+}
+
+// builtinResponseSteps returns the steps of stage provided by this package, in order.
+func (s *Service) builtinResponseSteps(stage ResponseStage) []responseStep {
+	switch stage {
+	case StageDecode:
+		return []responseStep{{"parse", s.parseStep}}
+	case StageTransform:
+		return []responseStep{{"calibrate", s.calibrateStep}, {"cache", s.cacheStep}, {"meters", s.meterStep}}
+	case StageEnrich:
+		return []responseStep{{"qsy", s.qsyStep}, {"band", s.bandStep}, {"bandmap", s.bandMapStep}, {"powerlimit", s.powerLimitStep}}
+	case StagePublish:
+		return []responseStep{{"diff", s.diffStep}}
+	}
+	return nil
+}
+
+// AddResponseStep appends step, under name, to stage, after the built-in steps and the steps added before; in
+// StageTransform it runs before the cache is updated. It may be called while the service runs; the states being
+// processed finish with the previous steps.
+func (s *Service) AddResponseStep(stage ResponseStage, name string, step ResponseStep) error {
+	const op errors.Op = "cat.Service.AddResponseStep"
+	if !slices.Contains(responseStages, stage) {
+		return errors.New(op).Msgf("unknown response stage %q", stage)
+	}
+	if name == "" || step == nil {
+		return errors.New(op).Msg("A response step needs a name and a function.")
+	}
+	s.responseSteps.mu.Lock()
+	defer s.responseSteps.mu.Unlock()
+	if s.responseSteps.steps == nil {
+		s.responseSteps.steps = make(map[ResponseStage][]responseStep)
+	}
+	s.responseSteps.steps[stage] = append(s.responseSteps.steps[stage], responseStep{name: name, run: func(r *response) bool {
+		r.status = step(r.status)
+		return len(r.status) > 0
+	}})
+	s.responseSteps.built = nil
+	return nil
+}
+
+// ResponseSteps returns the names of the steps of every stage, in the order they run.
+func (s *Service) ResponseSteps() map[ResponseStage][]string {
+	out := make(map[ResponseStage][]string, len(responseStages))
+	for _, stage := range responseStages {
+		for _, step := range s.stageSteps(stage) {
+			out[stage] = append(out[stage], step.name)
+		}
+	}
+	return out
+}
+
+// stageSteps returns the built-in and added steps of stage, building those of every stage once after a change.
+// The returned slice must not be modified.
+func (s *Service) stageSteps(stage ResponseStage) []responseStep {
+	s.responseSteps.mu.RLock()
+	built := s.responseSteps.built
+	s.responseSteps.mu.RUnlock()
+	if built != nil {
+		return built[stage]
+	}
+
+	s.responseSteps.mu.Lock()
+	defer s.responseSteps.mu.Unlock()
+	if s.responseSteps.built == nil {
+		built = make(map[ResponseStage][]responseStep, len(responseStages))
+		for _, st := range responseStages {
+			built[st] = s.buildStageSteps(st)
+		}
+		s.responseSteps.built = built
+	}
+	return s.responseSteps.built[stage]
+}
+
+// buildStageSteps returns the built-in steps of stage with the added ones appended, or, in StageTransform, put
+// before the cache step. The caller holds responseSteps.mu.
+func (s *Service) buildStageSteps(stage ResponseStage) []responseStep {
+	steps := s.builtinResponseSteps(stage)
+	added := s.responseSteps.steps[stage]
+	at := len(steps)
+	if stage == StageTransform {
+		at = slices.IndexFunc(steps, func(step responseStep) bool { return step.name == "cache" })
+	}
+	return slices.Insert(steps, at, added...)
+}
+
+// runResponsePipeline runs r through the stages and reports whether a status is left to emit. The time spent in
+// each stage is observed as response_<stage>_seconds, and the states a stage ends are counted as
+// response_<stage>_ended.
+func (s *Service) runResponsePipeline(r *response) bool {
+	sink := s.metricsSink()
+	for _, stage := range responseStages {
+		start := time.Now()
+		ok := true
+		for _, step := range s.stageSteps(stage) {
+			if ok = step.run(r); !ok {
+				break
+			}
+		}
+		sink.Observe("response_"+stage.String()+"_seconds", time.Since(start).Seconds())
+		if !ok {
+			sink.Count("response_"+stage.String()+"_ended", 1)
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) parseStep(r *response) bool {
+	if !s.hasMarkers(r.CatState) {
+		s.logger().ErrorWith().Str("line", r.Data).Msg("Bad catState configuration; no markers defined. Skipping line.")
+		return false
+	}
+	status, err := s.parseState(r.CatState)
+	if err != nil {
+		s.logger().WarnWith().Err(err).Msg("frame rejected by strict parsing")
+		s.count(&s.counters.framesRejected, "frames_rejected", 1)
+		s.reportError("processor", err)
+		return false
+	}
+	s.count(&s.counters.framesParsed, "frames_parsed", 1)
+	r.status = status
+	return true
+}
+
+func (s *Service) calibrateStep(r *response) bool {
+	s.calibrateStatus(r.status)
+	return true
+}
+
+func (s *Service) cacheStep(r *response) bool {
+	r.previousFreq, r.hadFreq = s.cache.get(tags.VfoAFreq.String())
+	s.cache.update(r.status, r.at)
+	return true
+}
+
+func (s *Service) meterStep(r *response) bool {
+	r.status = s.feedMeters(r.status, r.at)
+	return len(r.status) > 0
+}
+
+func (s *Service) qsyStep(r *response) bool {
+	s.recordQSY(r.previousFreq, r.hadFreq, r.status)
+	return true
+}
+
+func (s *Service) bandStep(r *response) bool {
+	s.trackBand(r.status)
+	return true
+}
+
+func (s *Service) bandMapStep(r *response) bool {
+	s.trackBandMap(r.status, r.at)
+	return true
+}
+
+func (s *Service) powerLimitStep(r *response) bool {
+	s.enforcePowerLimit(r.status)
+	return true
+}
+
+func (s *Service) diffStep(r *response) bool {
+	if s.Options.StatusDiff.Enabled {
+		r.status = s.changedFields(r.status)
+	}
+	return len(r.status) > 0
+}
@@ -0,0 +1,73 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newPipelineTestService(t *testing.T) *Service {
+	t.Helper()
+	service := newStartedTestService(t, &types.RigConfig{CatStates: []types.CatState{
+		{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+	}})
+	service.statusChannel = make(chan types.CatStatus, 1)
+	return service
+}
+
+func TestResponseStepsComposeInStageOrder(t *testing.T) {
+	service := newPipelineTestService(t)
+	require.NoError(t, service.AddResponseStep(StageEnrich, "band-label", func(status types.CatStatus) types.CatStatus {
+		if status["VFOAFREQ"] != "" {
+			status["LABEL"] = "20m FT8"
+		}
+		return status
+	}))
+	require.NoError(t, service.AddResponseStep(StageDecode, "trim", func(status types.CatStatus) types.CatStatus {
+		status["VFOAFREQ"] = status["VFOAFREQ"][1:]
+		return status
+	}))
+	require.Equal(t, []string{"parse", "trim"}, service.ResponseSteps()[StageDecode])
+	require.Equal(t, []string{"qsy", "band", "bandmap", "powerlimit", "band-label"}, service.ResponseSteps()[StageEnrich])
+
+	state, ok := service.lookupCatState([]byte("FA00014074000"))
+	require.True(t, ok)
+	require.True(t, service.processState(receivedState{CatState: state}, make(chan struct{})))
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "0014074000", "LABEL": "20m FT8"}, <-service.statusChannel)
+	cached, _ := service.cache.get("LABEL")
+	require.Empty(t, cached.Value, "enrich runs after the cache is updated")
+
+	m := service.MemoryMetrics()
+	for _, stage := range responseStages {
+		require.Equal(t, uint64(1), m.Histograms["response_"+stage.String()+"_seconds"].Count, stage)
+	}
+}
+
+func TestTransformStepsRunBeforeTheCache(t *testing.T) {
+	service := newPipelineTestService(t)
+	require.NoError(t, service.AddResponseStep(StageTransform, "offset", func(status types.CatStatus) types.CatStatus {
+		status["VFOAFREQ"] = "00014074100"
+		return status
+	}))
+	require.Equal(t, []string{"calibrate", "offset", "cache", "meters"}, service.ResponseSteps()[StageTransform])
+
+	state, _ := service.lookupCatState([]byte("FA00014074000"))
+	require.True(t, service.processState(receivedState{CatState: state}, make(chan struct{})))
+	cached, _ := service.cache.get("VFOAFREQ")
+	require.Equal(t, "00014074100", cached.Value, "the cache holds the transformed value")
+}
+
+func TestResponseStepEndsProcessing(t *testing.T) {
+	service := newPipelineTestService(t)
+	require.NoError(t, service.AddResponseStep(StageTransform, "drop", func(types.CatStatus) types.CatStatus { return nil }))
+
+	state, _ := service.lookupCatState([]byte("FA00014074000"))
+	require.True(t, service.processState(receivedState{CatState: state}, make(chan struct{})))
+	require.Empty(t, service.statusChannel)
+	require.Equal(t, uint64(1), service.MemoryMetrics().Counters["response_transform_ended"])
+	require.Zero(t, service.MemoryMetrics().Histograms["response_enrich_seconds"].Count)
+
+	require.Error(t, service.AddResponseStep("normalize", "x", func(s types.CatStatus) types.CatStatus { return s }))
+	require.Error(t, service.AddResponseStep(StageEnrich, "", nil))
+}
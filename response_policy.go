@@ -0,0 +1,82 @@
+package cat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// defaultResponseTimeoutPolicyMS is used when ResponsePolicy.TimeoutMS is zero.
+const defaultResponseTimeoutPolicyMS = 500
+
+// responsePolicyFor returns the response policy of cmd and the state prefix of its response. ok is false if no
+// policy applies, or if the response cannot be recognised, which ValidateOnly reports.
+func (s *Service) responsePolicyFor(cmd queuedCommand) (ResponsePolicy, string, bool) {
+	policy, ok := s.Options.ResponsePolicies[cmds.CatCmdName(cmd.Name)]
+	if !ok {
+		return ResponsePolicy{}, "", false
+	}
+	prefix, err := s.responsePrefixFor(cmds.CatCmdName(cmd.Name))
+	if err != nil {
+		s.logger().DebugWith().Err(err).Str("cmd", cmd.Name).Msg("response policy ignored: response not recognisable")
+		return ResponsePolicy{}, "", false
+	}
+	return policy, prefix, true
+}
+
+// writeAwaitingResponse writes cmd and waits up to policy.TimeoutMS for a frame with the response prefix, resending
+// cmd up to policy.RetryCount times. Only frames received once the attempt began to be written count, so that the
+// answer to an earlier command is not taken for the response. If no response arrives a CommandFailedEvent describes
+// the failure. The sender is held while it waits, so that the response cannot be confused with that of a later
+// command; Stop ends the wait.
+func (s *Service) writeAwaitingResponse(cmd queuedCommand, policy ResponsePolicy, prefix string) error {
+	const op errors.Op = "cat.Service.writeAwaitingResponse"
+	timeout := durationOrDefault(policy.TimeoutMS, defaultResponseTimeoutPolicyMS)
+	attempts := max(policy.RetryCount, 0) + 1
+	shutdown := s.runShutdown()
+
+	responses, from, cancel := s.awaitResponse(prefix)
+	defer cancel()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			s.count(&s.counters.responseRetries, "response_retries", 1)
+			s.logger().DebugWith().Str("cmd", cmd.Name).Int("attempt", attempt).Msg("no response; resending command")
+		}
+		sent := time.Now()
+		if err := s.transmit(cmd); err != nil {
+			cmd.outcome.fail(err)
+			return err
+		}
+		if at := cmd.outcome.lastWrite(); !at.IsZero() {
+			sent = at
+		}
+		from(sent)
+		timer := time.NewTimer(timeout)
+		select {
+		case <-shutdown:
+			timer.Stop()
+			err := errors.New(op).Msg(errMsgServiceNotStarted)
+			cmd.outcome.fail(err)
+			return err
+		case <-responses:
+			timer.Stop()
+			cmd.outcome.written(true)
+			if cmd.verify == nil {
+				cmd.outcome.confirmed()
+			}
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	s.count(&s.counters.responseTimeouts, "response_timeouts", 1)
+	msg := fmt.Sprintf("no %s response to %s after %d attempts of %s", prefix, cmd.Name, attempts, timeout)
+	err := errors.New(op).Msg(msg)
+	s.logger().WarnWith().Str("cmd", cmd.Name).Int("attempts", attempts).Msg("CAT command got no response")
+	s.recordError("sender", err)
+	s.emitEvent(CommandFailedEvent{At: time.Now(), Command: cmd.Name, Origin: cmd.origin, Attempts: attempts, Err: msg})
+	cmd.outcome.timedOut(err)
+	return err
+}
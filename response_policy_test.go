@@ -0,0 +1,107 @@
+package cat
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponsePolicyResendsUntilAnswered(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}, {Name: "IDENTIFY", Cmd: "ID;"}},
+		CatStates: []types.CatState{
+			{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+			{Prefix: "ID", Markers: []types.Marker{{Tag: "IDENTITY", Index: 0, Length: 3}}},
+		},
+	})
+	service.Options.ResponsePolicies = map[cmds.CatCmdName]ResponsePolicy{
+		"READ":     {RetryCount: 2, TimeoutMS: 20},
+		"IDENTIFY": {RetryCount: 1, TimeoutMS: 5},
+	}
+	var reads atomic.Int32
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if cmd == "FA;" && reads.Add(1) == 2 {
+			state, _ := service.lookupCatState([]byte("FA00014074000"))
+			service.deliverToWaiters(state, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	handle, err := service.EnqueueTracked("READ", nil)
+	require.NoError(t, err)
+	outcome, err := handle.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, OutcomeConfirmed, outcome.State)
+	require.Equal(t, []string{"FA;", "FA;"}, rig.writes(), "the first attempt was not answered")
+
+	handle, err = service.EnqueueTracked("IDENTIFY", nil)
+	require.NoError(t, err)
+	outcome, err = handle.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, OutcomeTimedOut, outcome.State)
+	require.Equal(t, []string{"FA;", "FA;", "ID;", "ID;"}, rig.writes())
+
+	event := (<-service.eventChannel).(CommandFailedEvent)
+	require.Equal(t, "IDENTIFY", event.Command)
+	require.Equal(t, 2, event.Attempts)
+	require.Contains(t, event.Err, "no ID response to IDENTIFY after 2 attempts")
+	require.Equal(t, uint64(2), service.counters.responseRetries.Load())
+	require.Equal(t, uint64(1), service.counters.responseTimeouts.Load())
+}
+
+func TestResponsePolicyIgnoresFramesFromBeforeTheWrite(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	service.Options.ResponsePolicies = map[cmds.CatCmdName]ResponsePolicy{"READ": {TimeoutMS: 20}}
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(string) {
+		// The answer to a poll written earlier, read before this write and handed over late.
+		state, _ := service.lookupCatState([]byte("FA00014074000"))
+		service.deliverToWaiters(state, time.Now().Add(-time.Second))
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	handle, err := service.EnqueueTracked("READ", nil)
+	require.NoError(t, err)
+	outcome, err := handle.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, OutcomeTimedOut, outcome.State)
+}
+
+func TestResponsePolicyWaitEndsOnStop(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{{Name: "READ", Cmd: "FA;"}},
+		CatStates:   []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	service.Options.ResponsePolicies = map[cmds.CatCmdName]ResponsePolicy{"READ": {RetryCount: 3, TimeoutMS: 5000}}
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.mu.Lock()
+	service.setRun(run)
+	service.mu.Unlock()
+	service.setLink(newFakeTransport())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- service.writeCommand(queuedCommand{CatCommand: types.CatCommand{Name: "READ", Cmd: "FA;"}})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(run.shutdownChannel)
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the sender kept waiting for a response after Stop")
+	}
+}
@@ -0,0 +1,259 @@
+package cat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// CmdReadMenu reads one menu setting; it takes the menu number, e.g. {Name: "READMENU", Cmd: "EX%s0000;"}. The
+	// reply reports TagMenuValue, and TagMenuNumber if the rig echoes the number.
+	CmdReadMenu cmds.CatCmdName = "READMENU"
+	// CmdWriteMenu writes one menu setting; it takes the menu number and the value.
+	CmdWriteMenu cmds.CatCmdName = "WRITEMENU"
+
+	// defaultBackupReadIntervalMS is used when Options.Backup.ReadIntervalMS is zero.
+	defaultBackupReadIntervalMS = 50
+
+	// rigBackupVersion is the version of the RigBackup format written by ExportRigState.
+	rigBackupVersion = 1
+)
+
+// Tags reported by the reply to READMENU.
+const (
+	TagMenuNumber = "MENUNUMBER"
+	TagMenuValue  = "MENUVALUE"
+)
+
+// Backup steps reported to BackupProgress.
+const (
+	BackupStepMemories = "memories"
+	BackupStepMenus    = "menus"
+	BackupStepLevels   = "levels"
+)
+
+// BackupProgress is called by ExportRigState and ImportRigState after every item of step, one of the BackupStep
+// constants, with the number of items done and the total of the step.
+type BackupProgress func(step string, done, total int)
+
+// RigBackup is the rig state saved by ExportRigState: the memory channels, the menu settings by menu number and
+// the level settings by BackupLevel.Name. Values are raw rig values, so a backup only restores to the same model.
+type RigBackup struct {
+	Version   int               `json:"version"`
+	Model     string            `json:"model,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Memories  []MemoryChannel   `json:"memories,omitempty"`
+	Menus     map[string]string `json:"menus,omitempty"`
+	Levels    map[string]string `json:"levels,omitempty"`
+}
+
+// ExportRigState reads the memory channels of Options.Memory and the menus and levels of Options.Backup from the
+// rig and writes them to w as a JSON RigBackup, e.g. before a firmware update. Reads are paced so that polling and
+// user commands carry on. progress may be nil.
+func (s *Service) ExportRigState(ctx context.Context, w io.Writer, progress BackupProgress) error {
+	const op errors.Op = "cat.Service.ExportRigState"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	backup := RigBackup{Version: rigBackupVersion, Model: s.rigConfig().Model, CreatedAt: time.Now()}
+	if s.Options.Memory.Channels > 0 {
+		channels, err := s.dumpMemories(ctx, stepProgress(progress, BackupStepMemories))
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		backup.Memories = channels
+	}
+
+	opts := s.Options.Backup
+	interval := durationOrDefault(opts.ReadIntervalMS, defaultBackupReadIntervalMS)
+	report := stepProgress(progress, BackupStepMenus)
+	for i, menu := range opts.Menus {
+		if i > 0 {
+			if err := pause(ctx, interval); err != nil {
+				return errors.New(op).Err(err)
+			}
+		}
+		value, err := s.readMenu(ctx, menu)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		if backup.Menus == nil {
+			backup.Menus = make(map[string]string, len(opts.Menus))
+		}
+		backup.Menus[menu] = value
+		report(i+1, len(opts.Menus))
+	}
+
+	report = stepProgress(progress, BackupStepLevels)
+	for i, level := range opts.Levels {
+		if err := pause(ctx, interval); err != nil {
+			return errors.New(op).Err(err)
+		}
+		status, err := s.SendCommand(ctx, level.Read)
+		if err != nil {
+			return errors.New(op).Err(err).Msgf("level %s not read", level.Name)
+		}
+		value, ok := status[level.Tag]
+		if !ok {
+			return errors.New(op).Msgf("reply to %s does not report %s", level.Read, level.Tag)
+		}
+		if backup.Levels == nil {
+			backup.Levels = make(map[string]string, len(opts.Levels))
+		}
+		backup.Levels[level.Name] = value
+		report(i+1, len(opts.Levels))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(backup); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// ImportRigState reads a RigBackup written by ExportRigState from r and writes it to the rig: the memory channels,
+// then the menus and levels that Options.Backup lists. The whole backup is validated before anything is written.
+// Items the rig definition has no write command for are rejected. progress may be nil.
+func (s *Service) ImportRigState(ctx context.Context, r io.Reader, progress BackupProgress) error {
+	const op errors.Op = "cat.Service.ImportRigState"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	var backup RigBackup
+	if err := json.NewDecoder(r).Decode(&backup); err != nil {
+		return errors.New(op).Err(err).Msg("invalid rig backup")
+	}
+	levels, err := s.validateBackup(backup)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	report := stepProgress(progress, BackupStepMemories)
+	for i, ch := range backup.Memories {
+		if err = s.WriteMemoryChannel(ctx, ch.Number, ch); err != nil {
+			return errors.New(op).Err(err)
+		}
+		report(i+1, len(backup.Memories))
+	}
+
+	report = stepProgress(progress, BackupStepMenus)
+	done := 0
+	for _, menu := range s.Options.Backup.Menus {
+		value, ok := backup.Menus[menu]
+		if !ok {
+			continue
+		}
+		if err = s.runBatch(ctx, []CatCommandRequest{{Name: CmdWriteMenu, Params: []string{menu, value}}}); err != nil {
+			return errors.New(op).Err(err).Msgf("menu %s not written", menu)
+		}
+		done++
+		report(done, len(backup.Menus))
+	}
+
+	report = stepProgress(progress, BackupStepLevels)
+	for i, level := range levels {
+		request := CatCommandRequest{Name: level.Write, Params: []string{backup.Levels[level.Name]}}
+		if err = s.runBatch(ctx, []CatCommandRequest{request}); err != nil {
+			return errors.New(op).Err(err).Msgf("level %s not written", level.Name)
+		}
+		report(i+1, len(levels))
+	}
+	return nil
+}
+
+// validateBackup checks that backup can be restored to this rig, and returns the levels of Options.Backup it
+// holds.
+func (s *Service) validateBackup(backup RigBackup) ([]BackupLevel, error) {
+	const op errors.Op = "cat.Service.validateBackup"
+	if backup.Version != rigBackupVersion {
+		return nil, errors.New(op).Msgf("unsupported rig backup version %d", backup.Version)
+	}
+	if model := s.rigConfig().Model; backup.Model != "" && model != "" && !strings.EqualFold(backup.Model, model) {
+		return nil, errors.New(op).Msgf("the backup is of a %s, not a %s", backup.Model, model)
+	}
+	if len(backup.Memories) > 0 {
+		if err := s.validateMemories(backup.Memories); err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+		if _, err := s.commandLookup(CmdWriteMemory); err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+	}
+
+	known := make(map[string]bool, len(s.Options.Backup.Menus))
+	for _, menu := range s.Options.Backup.Menus {
+		known[menu] = true
+	}
+	for menu := range backup.Menus {
+		if !known[menu] {
+			return nil, errors.New(op).Msgf("menu %s is not listed in Options.Backup.Menus", menu)
+		}
+	}
+	if len(backup.Menus) > 0 {
+		if _, err := s.commandLookup(CmdWriteMenu); err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+	}
+
+	var levels []BackupLevel
+	for name := range backup.Levels {
+		level, ok := s.backupLevel(name)
+		if !ok {
+			return nil, errors.New(op).Msgf("level %s is not listed in Options.Backup.Levels", name)
+		}
+		if _, err := s.commandLookup(level.Write); err != nil {
+			return nil, errors.New(op).Err(err).Msgf("level %s cannot be written", name)
+		}
+	}
+	for _, level := range s.Options.Backup.Levels {
+		if _, ok := backup.Levels[level.Name]; ok {
+			levels = append(levels, level)
+		}
+	}
+	return levels, nil
+}
+
+// backupLevel returns the level of Options.Backup called name.
+func (s *Service) backupLevel(name string) (BackupLevel, bool) {
+	for _, level := range s.Options.Backup.Levels {
+		if level.Name == name {
+			return level, true
+		}
+	}
+	return BackupLevel{}, false
+}
+
+// readMenu reads the value of menu.
+func (s *Service) readMenu(ctx context.Context, menu string) (string, error) {
+	const op errors.Op = "cat.Service.readMenu"
+	status, err := s.SendCommand(ctx, CmdReadMenu, menu)
+	if err != nil {
+		return "", errors.New(op).Err(err).Msgf("menu %s not read", menu)
+	}
+	if number, ok := status[TagMenuNumber]; ok && strings.TrimSpace(number) != menu {
+		return "", errors.New(op).Msgf("reply is for menu %q, not %s", number, menu)
+	}
+	value, ok := status[TagMenuValue]
+	if !ok {
+		return "", errors.New(op).Msgf("reply to menu %s does not report %s", menu, TagMenuValue)
+	}
+	return value, nil
+}
+
+// stepProgress binds progress to step; the result is safe to call if progress is nil.
+func stepProgress(progress BackupProgress, step string) func(done, total int) {
+	return func(done, total int) {
+		if progress != nil {
+			progress(step, done, total)
+		}
+	}
+}
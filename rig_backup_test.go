@@ -0,0 +1,101 @@
+package cat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newBackupTestService(t *testing.T, replies map[string]string) (*Service, *answeringTransport) {
+	t.Helper()
+	service := newStartedTestService(t, &types.RigConfig{
+		Model: "TS-590SG",
+		CatCommands: []types.CatCommand{
+			{Name: "READMEMORY", Cmd: "MR0%s;"},
+			{Name: "WRITEMEMORY", Cmd: "MW%s%s%s;"},
+			{Name: "READMENU", Cmd: "EX%s0000;"},
+			{Name: "WRITEMENU", Cmd: "EX%s0000%s;"},
+			{Name: "READAFGAIN", Cmd: "AG0;"},
+			{Name: "SETAFGAIN", Cmd: "AG0%s;"},
+		},
+		CatStates: []types.CatState{
+			{Prefix: "MR0", Markers: []types.Marker{
+				{Tag: TagMemoryChannel, Index: 0, Length: 3},
+				{Tag: TagMemoryFreq, Index: 3, Length: 11},
+				{Tag: TagMemoryMode, Index: 14, Length: 1},
+			}},
+			{Prefix: "EX", Markers: []types.Marker{
+				{Tag: TagMenuNumber, Index: 0, Length: 3},
+				{Tag: TagMenuValue, Index: 7, Length: 1},
+			}},
+			{Prefix: "AG0", Markers: []types.Marker{{Tag: "AFGAIN", Index: 0, Length: 3}}},
+		},
+	})
+	service.Options.Memory = MemoryOptions{FirstChannel: 1, Channels: 2, ReadIntervalMS: 1}
+	service.Options.Backup = BackupOptions{
+		Menus:          []string{"006", "017"},
+		Levels:         []BackupLevel{{Name: "AFGAIN", Read: "READAFGAIN", Tag: "AFGAIN", Write: "SETAFGAIN"}},
+		ReadIntervalMS: 1,
+	}
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if frame, ok := replies[cmd]; ok {
+			state, _ := service.lookupCatState([]byte(frame))
+			service.deliverToWaiters(state, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	return service, rig
+}
+
+func TestExportAndImportRigState(t *testing.T) {
+	source, _ := newBackupTestService(t, map[string]string{
+		"MR0001;":    "MR0001000140740002;",
+		"MR0002;":    "MR0002000000000000;",
+		"EX0060000;": "EX00600003;",
+		"EX0170000;": "EX01700001;",
+		"AG0;":       "AG0128;",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var steps []string
+	var buf bytes.Buffer
+	require.NoError(t, source.ExportRigState(ctx, &buf, func(step string, done, total int) {
+		if done == total {
+			steps = append(steps, step)
+		}
+	}))
+	require.Equal(t, []string{BackupStepMemories, BackupStepMenus, BackupStepLevels}, steps)
+
+	var backup RigBackup
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &backup))
+	require.Equal(t, "TS-590SG", backup.Model)
+	require.Equal(t, []MemoryChannel{{Number: 1, FrequencyHz: 14074000, Mode: "2"}}, backup.Memories)
+	require.Equal(t, map[string]string{"006": "3", "017": "1"}, backup.Menus)
+	require.Equal(t, map[string]string{"AFGAIN": "128"}, backup.Levels)
+
+	target, rig := newBackupTestService(t, nil)
+	require.NoError(t, target.ImportRigState(ctx, bytes.NewReader(buf.Bytes()), nil))
+	require.Equal(t, []string{"MW001140740002;", "EX00600003;", "EX01700001;", "AG0128;"}, rig.writes())
+}
+
+func TestImportRigStateValidatesFirst(t *testing.T) {
+	service, rig := newBackupTestService(t, nil)
+	ctx := context.Background()
+
+	for _, blob := range []string{
+		`{"version": 2}`,
+		`{"version": 1, "model": "FT-991A"}`,
+		`{"version": 1, "memories": [{"Number": 1, "FrequencyHz": 7074000, "Mode": "2"}], "menus": {"099": "1"}}`,
+		`{"version": 1, "levels": {"RFGAIN": "100"}}`,
+	} {
+		require.Error(t, service.ImportRigState(ctx, bytes.NewReader([]byte(blob)), nil), blob)
+	}
+	require.Empty(t, rig.writes(), "nothing is written from an invalid backup")
+}
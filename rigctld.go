@@ -0,0 +1,219 @@
+package cat
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/serial"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// rigctldScheme selects the rigctld transport when used as the serial port name, e.g.
+	// "rigctld://localhost:4532".
+	rigctldScheme = "rigctld://"
+	// defaultRigctldDialTimeoutMS is used when Options.Rigctld.DialTimeoutMS is zero.
+	defaultRigctldDialTimeoutMS = 3000
+	// defaultRigctldReplyTimeoutMS is used when Options.Rigctld.ReplyTimeoutMS is zero.
+	defaultRigctldReplyTimeoutMS = 1000
+)
+
+// RigctldOptions configures the Hamlib rigctld network transport.
+type RigctldOptions struct {
+	// Address is the host:port of rigctld. Empty uses the serial port name if it has the form
+	// "rigctld://host:port", and the serial port otherwise.
+	Address string
+	// DialTimeoutMS bounds connecting to rigctld. The unit is milliseconds.
+	//
+	// Default is 3000ms.
+	DialTimeoutMS time.Duration
+	// ReplyTimeoutMS bounds how long a command waits for the "RPRT" line that ends rigctld's reply to it. The unit
+	// is milliseconds.
+	//
+	// Default is 1000ms.
+	ReplyTimeoutMS time.Duration
+}
+
+// rigctldAddress returns the rigctld endpoint to use, or false to use the serial port.
+func (s *Service) rigctldAddress() (string, bool) {
+	if s.Options.Rigctld.Address != "" {
+		return s.Options.Rigctld.Address, true
+	}
+	if cfg := s.rigConfig(); cfg != nil {
+		if addr, ok := strings.CutPrefix(cfg.SerialConfig.PortName, rigctldScheme); ok {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
+// rigctldTransport talks to rigctld using its extended response protocol. Commands are sent with the '+' prefix,
+// so that every reply value arrives as a "Key: value" line, delivered as one frame. The "RPRT n" line that ends
+// each reply is its command's status: WriteCommand waits for it and fails if it reports an error. States are
+// configured with the key as prefix, e.g. "Frequency:", see RigctldDefinition.
+type rigctldTransport struct {
+	conn         net.Conn
+	frames       chan []byte
+	replyTimeout time.Duration
+
+	// writeMu serialises the commands, so that their replies arrive in the order of pending.
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	// pending holds a channel for every command written whose RPRT line has not arrived, oldest first. A command
+	// that gave up waiting keeps its place, so that its late RPRT is not taken for that of the next one.
+	pending []chan error
+	// ended is closed by readLoop once the connection is closed.
+	ended chan struct{}
+
+	closeOnce sync.Once
+}
+
+// dialRigctld connects to rigctld at addr.
+func dialRigctld(addr string, timeout, replyTimeout time.Duration) (*rigctldTransport, error) {
+	const op errors.Op = "cat.dialRigctld"
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, errors.New(op).Err(err).Msgf("cannot connect to rigctld at %s", addr)
+	}
+	t := &rigctldTransport{conn: conn, frames: make(chan []byte, 64), replyTimeout: replyTimeout, ended: make(chan struct{})}
+	go t.readLoop()
+	return t, nil
+}
+
+// WriteCommand sends one rigctl command, e.g. "F 14074000", and waits for rigctld to report its status.
+func (t *rigctldTransport) WriteCommand(ctx context.Context, cmd string) error {
+	const op errors.Op = "cat.rigctldTransport.WriteCommand"
+	cmd = strings.TrimRight(strings.TrimSpace(cmd), ";")
+	if !strings.HasPrefix(cmd, "+") {
+		cmd = "+" + cmd
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = t.conn.SetWriteDeadline(deadline)
+	} else {
+		_ = t.conn.SetWriteDeadline(time.Time{})
+	}
+
+	reply := make(chan error, 1)
+	t.mu.Lock()
+	t.pending = append(t.pending, reply)
+	t.mu.Unlock()
+	if _, err := t.conn.Write([]byte(cmd + "\n")); err != nil {
+		t.mu.Lock()
+		t.pending = t.pending[:len(t.pending)-1] // still the last one: writes are serialised
+		t.mu.Unlock()
+		return errors.New(op).Err(err)
+	}
+
+	timer := time.NewTimer(t.replyTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-reply:
+		if err != nil {
+			return errors.New(op).Err(err).Msgf("rigctld rejected %q", cmd)
+		}
+		return nil
+	case <-ctx.Done():
+		return errors.New(op).Err(ctx.Err())
+	case <-timer.C:
+		return errors.New(op).Msgf("no reply from rigctld to %q", cmd)
+	case <-t.ended:
+		return errors.New(op).Err(serial.ErrClosed)
+	}
+}
+
+// ReadResponseBytes returns the next reply line.
+func (t *rigctldTransport) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	const op errors.Op = "cat.rigctldTransport.ReadResponseBytes"
+	select {
+	case <-ctx.Done():
+		return nil, errors.New(op).Err(ctx.Err())
+	case frame, ok := <-t.frames:
+		if !ok {
+			// Reported like a closed serial port, so that the reconnect logic applies to both.
+			return nil, errors.New(op).Err(serial.ErrClosed)
+		}
+		return frame, nil
+	}
+}
+
+// Close closes the connection. It is safe to call multiple times.
+func (t *rigctldTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() { err = t.conn.Close() })
+	return err
+}
+
+// readLoop turns reply lines into frames, and RPRT lines into the status of the oldest pending command, until the
+// connection is closed.
+func (t *rigctldTransport) readLoop() {
+	defer close(t.frames)
+	defer close(t.ended)
+	scanner := bufio.NewScanner(t.conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || isRigctldEcho(line) {
+			continue
+		}
+		if code, ok := strings.CutPrefix(line, "RPRT"); ok {
+			t.reported(strings.TrimSpace(code))
+			continue
+		}
+		t.frames <- []byte(line)
+	}
+}
+
+// isRigctldEcho reports whether line is the echo that starts an extended reply, e.g. "get_freq:" or
+// "set_freq: 7074000". It carries no value: command names are lower case, while value keys, e.g. "Frequency:", are
+// capitalised.
+func isRigctldEcho(line string) bool {
+	name, _, ok := strings.Cut(line, ":")
+	return ok && name != "" && strings.Trim(name, "abcdefghijklmnopqrstuvwxyz_") == ""
+}
+
+// reported hands the RPRT status code to the oldest pending command. Hamlib reports success as 0 and errors as
+// negative codes.
+func (t *rigctldTransport) reported(code string) {
+	const op errors.Op = "cat.rigctldTransport.readLoop"
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.pending) == 0 {
+		return
+	}
+	reply := t.pending[0]
+	t.pending = t.pending[1:]
+	if n, err := strconv.Atoi(code); err != nil || n != 0 {
+		reply <- errors.New(op).Msgf("RPRT %s", code)
+		return
+	}
+	reply <- nil
+}
+
+// RigctldDefinition returns commands, states and state options for controlling a rig through rigctld with the
+// package's higher-level operations (Tune, GetFrequencyHz, GetMode, SetPower ...). They are meant to be used as
+// the RigConfig and Options.StateOptions of a rigctld rig, and extended as needed.
+func RigctldDefinition() ([]types.CatCommand, []types.CatState, map[string]StateOptions) {
+	commands := []types.CatCommand{
+		{Name: cmds.Read.String(), Cmd: "f"},
+		{Name: CmdSetVfoAFreq.String(), Cmd: "F %s"},
+		{Name: CmdSetMainMode.String(), Cmd: "M %s 0"},
+		{Name: "READMODE", Cmd: "m"},
+	}
+	states := []types.CatState{
+		{Prefix: "Frequency:"},
+		{Prefix: "Mode:"},
+	}
+	options := map[string]StateOptions{
+		"FREQUENCY:": {FieldMarkers: []FieldMarker{{Tag: "VFOAFREQ", Field: 0, Delimiter: " "}}},
+		"MODE:":      {FieldMarkers: []FieldMarker{{Tag: "MAINMODE", Field: 0, Delimiter: " "}}},
+	}
+	return commands, states, options
+}
@@ -0,0 +1,74 @@
+package cat
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRigctld answers "+f" and "+F <hz>" the way rigctld does in extended response mode, rejecting a frequency of
+// zero.
+func fakeRigctld(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			switch cmd := scanner.Text(); {
+			case cmd == "+f":
+				_, _ = conn.Write([]byte("get_freq:\nFrequency: 14074000\nRPRT 0\n"))
+			case cmd == "+F 0":
+				_, _ = conn.Write([]byte("set_freq: 0\nRPRT -1\n"))
+			case strings.HasPrefix(cmd, "+F "):
+				_, _ = conn.Write([]byte("set_freq: " + cmd[3:] + "\nRPRT 0\n"))
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRigctldTransportFramesReplies(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.config.SerialConfig.PortName = rigctldScheme + fakeRigctld(t)
+	commands, states, options := RigctldDefinition()
+	service.config.CatCommands, service.config.CatStates, service.Options.StateOptions = commands, states, options
+	require.NoError(t, service.initializeStateSet())
+
+	transport, err := service.openPort()
+	require.NoError(t, err)
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, transport.WriteCommand(ctx, "f"))
+
+	frame, err := transport.ReadResponseBytes(ctx)
+	require.NoError(t, err)
+	state, ok := service.lookupCatState(frame)
+	require.True(t, ok)
+	status, err := service.parseState(state)
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "14074000"}, status)
+
+	require.NoError(t, transport.WriteCommand(ctx, "F 7074000"), "RPRT 0 acknowledges the set")
+	require.Error(t, transport.WriteCommand(ctx, "F 0"), "RPRT -1 rejects it")
+
+	quiet, stop := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer stop()
+	_, err = transport.ReadResponseBytes(quiet)
+	require.Error(t, err, "status lines are consumed by their commands, not delivered as frames")
+}
@@ -0,0 +1,82 @@
+package cat
+
+import "sync"
+
+// ring is a fixed-capacity, concurrency-safe buffer that keeps the most recent items. If a byte budget is set, the
+// oldest items are also dropped while the approximate size of the stored items exceeds it.
+type ring[T any] struct {
+	mu    sync.Mutex
+	items []T
+	head  int // index of the oldest item
+	count int
+
+	size     func(T) int
+	maxBytes int
+	bytes    int
+	evicted  uint64
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &ring[T]{items: make([]T, capacity)}
+}
+
+// newBudgetRing returns a ring that additionally keeps the total of size(item) within maxBytes. The most recent
+// item is always kept, even if it alone exceeds the budget. A maxBytes of zero or less means no byte budget.
+func newBudgetRing[T any](capacity, maxBytes int, size func(T) int) *ring[T] {
+	r := newRing[T](capacity)
+	r.size = size
+	if maxBytes > 0 {
+		r.maxBytes = maxBytes
+	}
+	return r
+}
+
+// add stores v, overwriting the oldest item when the ring is full.
+func (r *ring[T]) add(v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == len(r.items) {
+		r.dropOldest()
+	}
+	r.items[(r.head+r.count)%len(r.items)] = v
+	r.count++
+	if r.size != nil {
+		r.bytes += r.size(v)
+	}
+	for r.maxBytes > 0 && r.bytes > r.maxBytes && r.count > 1 {
+		r.dropOldest()
+	}
+}
+
+// dropOldest removes the oldest item. The caller holds mu and ensures the ring is not empty.
+func (r *ring[T]) dropOldest() {
+	var zero T
+	if r.size != nil {
+		r.bytes -= r.size(r.items[r.head])
+	}
+	r.items[r.head] = zero
+	r.head = (r.head + 1) % len(r.items)
+	r.count--
+	r.evicted++
+}
+
+// list returns the stored items, oldest first.
+func (r *ring[T]) list() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]T, 0, r.count)
+	for i := 0; i < r.count; i++ {
+		out = append(out, r.items[(r.head+i)%len(r.items)])
+	}
+	return out
+}
+
+// usage returns the approximate size of the stored items and how many items have been dropped so far.
+func (r *ring[T]) usage() (bytes int, evicted uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bytes, r.evicted
+}
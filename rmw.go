@@ -0,0 +1,113 @@
+package cat
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultReadModifyWriteRetries is used when Options.ReadModifyWrite.Retries is zero.
+	defaultReadModifyWriteRetries = 3
+)
+
+// MaskedSetting describes a rig setting that packs several flags into a single value, such as a function flag
+// bitfield, so that individual flags can only be changed by rewriting the whole value.
+type MaskedSetting struct {
+	// Tag is the state tag reporting the current value.
+	Tag tags.CatStateTag
+	// WriteCommand names the template that sets the value; it takes one %s.
+	WriteCommand cmds.CatCmdName
+	// Base is the radix the rig uses for the value: 2 for one character per flag, 16 for hex. Zero means 2.
+	Base int
+}
+
+// ModifyMasked changes the bits of setting selected by mask to the corresponding bits of value, leaving the other
+// bits as the rig reports them. The current value is read from the rig, the merged value is written back and read
+// again; if the readback does not match, because the setting was changed concurrently or the write was lost, the
+// whole cycle is retried up to Options.ReadModifyWrite.Retries times.
+func (s *Service) ModifyMasked(ctx context.Context, setting MaskedSetting, mask, value uint64) error {
+	const op errors.Op = "cat.Service.ModifyMasked"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	base := setting.Base
+	if base == 0 {
+		base = 2
+	}
+	if base < 2 || base > 36 {
+		return errors.New(op).Msgf("invalid base %d for %s", base, setting.Tag)
+	}
+	retries := s.Options.ReadModifyWrite.Retries
+	if retries <= 0 {
+		retries = defaultReadModifyWriteRetries
+	}
+
+	current, err := s.readMasked(ctx, setting.Tag, base)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	for attempt := 0; ; attempt++ {
+		merged := current&^mask | value&mask
+		if merged == current {
+			return nil
+		}
+
+		encoded, err := s.formatMasked(setting.Tag, merged, base)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		if err = s.EnqueueCommand(setting.WriteCommand, encoded); err != nil {
+			return errors.New(op).Err(err)
+		}
+
+		readback, err := s.readMasked(ctx, setting.Tag, base)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		if readback == merged {
+			return nil
+		}
+		if attempt >= retries {
+			return errors.New(op).Msgf("%s changed concurrently; gave up after %d attempts", setting.Tag, attempt+1)
+		}
+		s.logger().WarnWith().Str("tag", setting.Tag.String()).Int("attempt", attempt+1).
+			Msg("readback mismatch on masked write; retrying")
+		// The readback is the freshest view of the rig, so the next attempt merges into it.
+		current = readback
+	}
+}
+
+// readMasked queries the rig for tag and parses the value in base.
+func (s *Service) readMasked(ctx context.Context, tag tags.CatStateTag, base int) (uint64, error) {
+	const op errors.Op = "cat.Service.readMasked"
+
+	raw, err := s.queryTag(ctx, tag)
+	if err != nil {
+		return 0, errors.New(op).Err(err)
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(raw), base, 64)
+	if err != nil {
+		return 0, errors.New(op).Msgf("invalid value %q for %s", raw, tag)
+	}
+	return v, nil
+}
+
+// formatMasked renders v in base, zero-padded to the width of the marker that reports tag.
+func (s *Service) formatMasked(tag tags.CatStateTag, v uint64, base int) (string, error) {
+	const op errors.Op = "cat.Service.formatMasked"
+
+	value := strings.ToUpper(strconv.FormatUint(v, base))
+	if marker, ok := s.markerFor(tag); ok && marker.Length > 0 {
+		if len(value) > marker.Length {
+			return "", errors.New(op).Msgf("value %s exceeds the %d digits supported by the rig", value, marker.Length)
+		}
+		value = strings.Repeat("0", marker.Length-len(value)) + value
+	}
+	return value, nil
+}
@@ -0,0 +1,73 @@
+package cat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newMaskedTestService(t *testing.T) *Service {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "READFUNC", Cmd: "FN;"},
+			{Name: "SETFUNC", Cmd: "FN%s;"},
+		},
+		CatStates: []types.CatState{
+			{Prefix: "FN", Markers: []types.Marker{{Tag: "FUNCTIONS", Index: 0, Length: 4}}},
+		},
+	})
+	service.Options.ReadCommands = map[string]cmds.CatCmdName{"FUNCTIONS": "READFUNC"}
+	return service
+}
+
+// fakeMaskedRig answers reads of the FUNCTIONS tag from value, applying writes through apply.
+func fakeMaskedRig(t *testing.T, service *Service, value string, apply func(current, written string) string) {
+	runFakeRig(t, service, func(cmd string) {
+		if written, ok := strings.CutPrefix(strings.TrimSuffix(cmd, ";"), "FN"); ok && written != "" {
+			value = apply(value, written)
+			return
+		}
+		service.cache.update(types.CatStatus{"FUNCTIONS": value}, time.Now())
+	})
+}
+
+func TestModifyMaskedPreservesOtherBits(t *testing.T) {
+	service := newMaskedTestService(t)
+	var writes []string
+	fakeMaskedRig(t, service, "1001", func(_, written string) string {
+		writes = append(writes, written)
+		return written
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, service.ModifyMasked(ctx, MaskedSetting{Tag: "FUNCTIONS", WriteCommand: "SETFUNC"}, 0b0110, 0b0100))
+	require.Equal(t, []string{"1101"}, writes)
+}
+
+func TestModifyMaskedRetriesOnConcurrentChange(t *testing.T) {
+	service := newMaskedTestService(t)
+	var writes []string
+	fakeMaskedRig(t, service, "0000", func(_, written string) string {
+		writes = append(writes, written)
+		if len(writes) == 1 {
+			return "1010" // the front panel changed another flag in the meantime
+		}
+		return written
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, service.ModifyMasked(ctx, MaskedSetting{Tag: "FUNCTIONS", WriteCommand: "SETFUNC"}, 0b0001, 0b0001))
+	require.Equal(t, []string{"0001", "1011"}, writes)
+}
+
+func TestModifyMaskedBeforeInitialize(t *testing.T) {
+	err := (&Service{}).ModifyMasked(context.Background(), MaskedSetting{Tag: "FUNCTIONS", WriteCommand: "SETFUNC"}, 1, 1)
+	require.ErrorContains(t, err, errMsgServiceNotInit)
+}
@@ -0,0 +1,129 @@
+package cat
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/errors"
+)
+
+// EventRotatorSuggestion is the kind of RotatorSuggestionEvent.
+const EventRotatorSuggestion EventKind = "ROTATOR_SUGGESTION"
+
+// RotatorAction is the action a RotatorSuggestionEvent suggests to the rotator service.
+type RotatorAction string
+
+const (
+	// RotatorTurn suggests turning the beam to the preset heading of the new band.
+	RotatorTurn RotatorAction = "TURN"
+	// RotatorNone means the new band has no heading preset; the beam is left where it is.
+	RotatorNone RotatorAction = "NONE"
+)
+
+// HeadingPreset is the beam heading used on a band, e.g. the long path to a DX window.
+type HeadingPreset struct {
+	// AzimuthDeg is the heading in degrees from true north, 0-359.
+	AzimuthDeg int
+	// Label describes the preset for the operator, e.g. "JA short path".
+	Label string
+}
+
+// RotatorSuggestionEvent is emitted when VFO A moves to another band while Options.Rotator is enabled, so that a
+// rotator service subscribed to the events or the EventBus can follow the rig.
+type RotatorSuggestionEvent struct {
+	At          time.Time
+	Band        bands.Band
+	FrequencyHz int64
+	Action      RotatorAction
+	// Preset is the heading preset of Band; it is only set with RotatorTurn.
+	Preset HeadingPreset
+}
+
+func (e RotatorSuggestionEvent) Kind() EventKind { return EventRotatorSuggestion }
+func (e RotatorSuggestionEvent) Time() time.Time { return e.At }
+
+// rotatorFollow holds the heading presets.
+type rotatorFollow struct {
+	mu      sync.Mutex
+	presets map[bands.Band]HeadingPreset
+}
+
+// newRotatorFollow validates the heading presets of opts.
+func newRotatorFollow(opts RotatorOptions) (*rotatorFollow, error) {
+	const op errors.Op = "cat.newRotatorFollow"
+	for band, preset := range opts.Presets {
+		if err := validHeading(band, preset); err != nil {
+			return nil, errors.New(op).Err(err)
+		}
+	}
+	presets := maps.Clone(opts.Presets)
+	if presets == nil {
+		presets = make(map[bands.Band]HeadingPreset)
+	}
+	return &rotatorFollow{presets: presets}, nil
+}
+
+// validHeading checks that preset is a heading within 0-359 degrees.
+func validHeading(band bands.Band, preset HeadingPreset) error {
+	const op errors.Op = "cat.validHeading"
+	if preset.AzimuthDeg < 0 || preset.AzimuthDeg > 359 {
+		return errors.New(op).Msgf("heading preset for %s: azimuth %d is outside 0-359", band, preset.AzimuthDeg)
+	}
+	return nil
+}
+
+// SetHeadingPreset registers the beam heading suggested when VFO A moves to band, replacing any previous preset.
+func (s *Service) SetHeadingPreset(band bands.Band, preset HeadingPreset) error {
+	const op errors.Op = "cat.Service.SetHeadingPreset"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if err := validHeading(band, preset); err != nil {
+		return errors.New(op).Err(err)
+	}
+	s.rotator.mu.Lock()
+	s.rotator.presets[band] = preset
+	s.rotator.mu.Unlock()
+	return nil
+}
+
+// ClearHeadingPreset removes the heading preset of band.
+func (s *Service) ClearHeadingPreset(band bands.Band) error {
+	const op errors.Op = "cat.Service.ClearHeadingPreset"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	s.rotator.mu.Lock()
+	delete(s.rotator.presets, band)
+	s.rotator.mu.Unlock()
+	return nil
+}
+
+// HeadingPresets returns the registered heading presets by band.
+func (s *Service) HeadingPresets() map[bands.Band]HeadingPreset {
+	if s.rotator == nil {
+		return map[bands.Band]HeadingPreset{}
+	}
+	s.rotator.mu.Lock()
+	defer s.rotator.mu.Unlock()
+	return maps.Clone(s.rotator.presets)
+}
+
+// followRotator emits a RotatorSuggestionEvent for a band change. Leaving the band plan emits nothing, but
+// returning to the previous band then suggests its heading again.
+func (s *Service) followRotator(change BandChangedEvent) {
+	if !s.Options.Rotator.Enabled || s.rotator == nil || change.Band == "" {
+		return
+	}
+	s.rotator.mu.Lock()
+	preset, hasPreset := s.rotator.presets[change.Band]
+	s.rotator.mu.Unlock()
+
+	event := RotatorSuggestionEvent{At: change.At, Band: change.Band, FrequencyHz: change.FrequencyHz, Action: RotatorNone}
+	if hasPreset {
+		event.Action, event.Preset = RotatorTurn, preset
+	}
+	s.emitEvent(event)
+}
@@ -0,0 +1,46 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/bands"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatorFollowSuggestsHeadingOnBandChange(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Rotator = RotatorOptions{Enabled: true, Presets: map[bands.Band]HeadingPreset{bands.Band20: {AzimuthDeg: 45, Label: "EU"}}}
+	var err error
+	service.rotator, err = newRotatorFollow(service.Options.Rotator)
+	require.NoError(t, err)
+
+	service.trackBand(types.CatStatus{"VFOAFREQ": "00014074000"})
+	service.trackBand(types.CatStatus{"VFOAFREQ": "00014076000"}) // same band
+	service.trackBand(types.CatStatus{"VFOAFREQ": "00007074000"})
+
+	first := (<-service.eventChannel).(RotatorSuggestionEvent)
+	require.Equal(t, bands.Band20, first.Band)
+	require.Equal(t, RotatorTurn, first.Action)
+	require.Equal(t, 45, first.Preset.AzimuthDeg)
+
+	second := (<-service.eventChannel).(RotatorSuggestionEvent)
+	require.Equal(t, bands.Band40, second.Band)
+	require.Equal(t, RotatorNone, second.Action)
+	require.Empty(t, service.eventChannel)
+}
+
+func TestHeadingPresets(t *testing.T) {
+	_, err := newRotatorFollow(RotatorOptions{Presets: map[bands.Band]HeadingPreset{bands.Band10: {AzimuthDeg: 360}}})
+	require.Error(t, err)
+
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.rotator, err = newRotatorFollow(RotatorOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, service.SetHeadingPreset(bands.Band15, HeadingPreset{AzimuthDeg: 300}))
+	require.Error(t, service.SetHeadingPreset(bands.Band15, HeadingPreset{AzimuthDeg: -1}))
+	require.Equal(t, map[bands.Band]HeadingPreset{bands.Band15: {AzimuthDeg: 300}}, service.HeadingPresets())
+	require.NoError(t, service.ClearHeadingPreset(bands.Band15))
+	require.Empty(t, service.HeadingPresets())
+}
@@ -1,19 +1,185 @@
 package cat
 
-import "context"
+import (
+	"time"
 
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultBulkSliceSize is used when Options.Bulk.SliceSize is zero.
+	defaultBulkSliceSize = 4
+	// defaultBulkYieldMS is used when Options.Bulk.YieldMS is zero.
+	defaultBulkYieldMS = 50
+)
+
+// serialPortSender writes queued commands to the transport. Regular commands always take precedence, highest
+// priority first; commands of bulk transfers are written in slices, with a pause after each slice so that the rig
+// can answer the regular polls in between and the frequency display does not freeze during long transfers.
 func (s *Service) serialPortSender(shutdown <-chan struct{}) {
+	sliceSize := s.Options.Bulk.SliceSize
+	if sliceSize <= 0 {
+		sliceSize = defaultBulkSliceSize
+	}
+	yield := s.Options.Bulk.YieldMS
+	if yield <= 0 {
+		yield = defaultBulkYieldMS
+	}
+	yield *= time.Millisecond
+	throttle := s.newSendThrottle()
+
+	sliced := 0
 	for {
+		// Regular commands first, without waiting.
 		select {
 		case <-shutdown:
 			return
+		default:
+		}
+		if cmd, ok := s.nextRegular(); ok {
+			if !s.writeRegular(shutdown, throttle, cmd) {
+				return
+			}
+			continue
+		}
+
+		if sliced >= sliceSize {
+			sliced = 0
+			if !s.yieldToRegular(shutdown, throttle, yield) {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-shutdown:
+			return
+		case cmd := <-s.highChannel:
+			if !s.writeRegular(shutdown, throttle, cmd) {
+				return
+			}
 		case cmd, ok := <-s.sendChannel:
-			if !ok {
+			if !ok || !s.writeRegular(shutdown, throttle, cmd) {
 				return
 			}
-			if err := s.serialPort.WriteCommand(context.Background(), cmd.Cmd); err != nil {
-				s.LoggerService.ErrorWith().Err(err).Msg("serial write failed")
+		case cmd := <-s.lowChannel:
+			if !s.writeRegular(shutdown, throttle, cmd) {
+				return
+			}
+		case <-s.fairReady():
+			// Picked up by nextRegular.
+		case item := <-s.bulkChannel:
+			if item.transfer.finished() {
+				continue // cancelled or failed; skip its remaining commands
+			}
+			if !throttle.wait(shutdown, item.cmd.origin) {
+				return
+			}
+			if err := s.writeCommand(item.cmd); err != nil {
+				item.transfer.finish(err)
+				continue
+			}
+			item.transfer.advance()
+			sliced++
+		}
+	}
+}
+
+// yieldToRegular pauses bulk transfers for d while still writing regular commands. It returns false on shutdown.
+func (s *Service) yieldToRegular(shutdown <-chan struct{}, throttle *sendThrottle, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return false
+		case <-timer.C:
+			return true
+		case cmd := <-s.highChannel:
+			if !s.writeRegular(shutdown, throttle, cmd) {
+				return false
+			}
+		case cmd, ok := <-s.sendChannel:
+			if !ok || !s.writeRegular(shutdown, throttle, cmd) {
+				return false
+			}
+		case cmd := <-s.lowChannel:
+			if !s.writeRegular(shutdown, throttle, cmd) {
+				return false
+			}
+		case <-s.fairReady():
+			if cmd, ok := s.fair.pop(); ok && !s.writeRegular(shutdown, throttle, cmd) {
+				return false
 			}
 		}
 	}
 }
+
+// afterWrite runs the follow-up work of a command that was written successfully.
+func (s *Service) afterWrite(shutdown <-chan struct{}, cmd queuedCommand) {
+	s.trackPTT(cmd)
+	s.activateTransverter(cmd)
+	s.verifyWritten(shutdown, cmd)
+	s.inferWritten(shutdown, cmd)
+	s.settleAfter(shutdown, cmd)
+}
+
+// writeCommand writes a single command to the transport, recording the outcome. Commands with a ResponsePolicy
+// are resent until the rig responds.
+func (s *Service) writeCommand(cmd queuedCommand) error {
+	if policy, prefix, ok := s.responsePolicyFor(cmd); ok {
+		return s.writeAwaitingResponse(cmd, policy, prefix)
+	}
+	if err := s.transmit(cmd); err != nil {
+		cmd.outcome.fail(err)
+		return err
+	}
+	cmd.outcome.written(cmd.verify != nil)
+	return nil
+}
+
+// transmit encodes cmd and writes it to the transport, retrying transient errors.
+func (s *Service) transmit(cmd queuedCommand) error {
+	const op errors.Op = "cat.Service.writeCommand"
+	if cmd.origin == OriginPoller {
+		// The poll has left the queue, whatever the outcome, so the next one may be queued.
+		s.polls.release(cmds.CatCmdName(cmd.Name))
+	}
+	if s.linkDown.Load() {
+		s.logger().DebugWith().Str("cmd", cmd.Name).Msg("rig link down; command dropped")
+		return errors.New(op).Msg(errMsgLinkDown)
+	}
+	if _, err := s.openLink(); err != nil {
+		s.logger().DebugWith().Str("cmd", cmd.Name).Msg("rig link closed; command dropped")
+		return errors.New(op).Err(err)
+	}
+	wire, err := s.codec().encodeCommand(cmd.Cmd)
+	if err != nil {
+		s.logger().ErrorWith().Err(err).Msg("command encoding failed")
+		s.reportError("sender", err)
+		return errors.New(op).Err(err)
+	}
+	s.wakeIfIdle()
+	s.noteWire(wire)
+	cmd.outcome.writing(time.Now())
+	attempts, err := s.writeWithRetry(wire)
+	if err != nil {
+		s.logger().ErrorWith().Err(err).Int("attempts", attempts).Msg("serial write failed")
+		s.count(&s.counters.writeErrors, "write_errors", 1)
+		s.recordError("sender", err)
+		s.emitEvent(CommandFailedEvent{At: time.Now(), Command: cmd.Name, Origin: cmd.origin, Attempts: attempts, Err: err.Error()})
+		return err
+	}
+	if attempts > 1 {
+		s.count(&s.counters.writeRetries, "write_retries", uint64(attempts-1))
+	}
+	s.count(&s.counters.commandsSent, "commands_sent", 1)
+	s.noteRoundTripSent(cmd, time.Now())
+	s.origins.noteWritten(cmd, time.Now())
+	s.noteCommandWritten(cmd)
+	s.markActivity()
+	s.recordTraffic(TrafficTX, []byte(wire))
+	s.auditCommand(cmd)
+	return nil
+}
@@ -1,15 +1,18 @@
 package cat
 
 import (
-	"fmt"
+	"context"
+	"regexp"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Station-Manager/config"
+	"github.com/Station-Manager/enums/bands"
 	"github.com/Station-Manager/enums/cmds"
 	"github.com/Station-Manager/errors"
 	"github.com/Station-Manager/logging"
-	"github.com/Station-Manager/serial"
 	"github.com/Station-Manager/types"
 )
 
@@ -18,22 +21,190 @@ const (
 	// defaultListenerIntervalMS is used when the configured ListenerRateLimiterInterval
 	// is zero or negative, to avoid creating a ticker with a non-positive duration.
 	defaultListenerIntervalMS = 50
+	// stopGracePeriod is how long StopContext waits for the workers after force-closing the port.
+	stopGracePeriod = 200 * time.Millisecond
 )
 
 type runState struct {
 	shutdownChannel chan struct{}
 	wg              sync.WaitGroup
+	// running holds the names of the workers that have not exited yet, for StopContext to report.
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// workerStarted and workerExited track the running workers of the run.
+func (r *runState) workerStarted(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = make(map[string]int)
+	}
+	r.running[name]++
+}
+
+func (r *runState) workerExited(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[name]--; r.running[name] <= 0 {
+		delete(r.running, name)
+	}
+}
+
+// runningWorkers returns the names of the workers that have not exited, sorted.
+func (r *runState) runningWorkers() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedKeys(r.running)
 }
 
 type Service struct {
-	ConfigService *config.Service  `di.inject:"configservice"`
+	ConfigService *config.Service `di.inject:"configservice"`
+	// LoggerService is optional; without it, and without a logger set by SetLogger, nothing is logged.
 	LoggerService *logging.Service `di.inject:"loggingservice"`
-	config        *types.RigConfig
-
-	serialPort *serial.Port
+	// EventBus is optional; when set, statuses, events and notifications are also published on it.
+	EventBus EventBus
+	// Store is optional; it backs the persistence features selected in Options.Persistence.
+	Store Store
+	// PortManager is optional; when set, the serial port is claimed through it before it is opened and released
+	// when it is closed.
+	PortManager PortManager
+	// Definitions is optional; it supplies the rig definitions instead of the ConfigService.
+	Definitions DefinitionSource
+	// Translator is optional; when set, mapped display values on the status channels are translated with it.
+	Translator Translator
+	// KeyLine is optional; when set, CW break-in keys the transmitter through it instead of CAT commands.
+	KeyLine KeyLine
+	// Driver is optional; when set, it is used instead of the built-in driver selected by Options.Driver.
+	Driver RigDriver
+	// Dial is optional; when set, it opens the link instead of the serial port or rigctld, e.g. to replay a
+	// capture with a ReplayTransport.
+	Dial func() (Transport, error)
+	// MetricsSink is optional; when set, counters, gauges and latency samples are reported to it instead of the
+	// built-in MemorySink, see MemoryMetrics.
+	MetricsSink MetricsSink
+	// RigID selects the rig configuration to use; zero means the configured default rig.
+	RigID int64
+	// Options holds optional cat-specific settings; it must be set before Initialize is called.
+	Options Options
+
+	// definitionMu guards the rig definition: config, supportedCatStates, maxCatPrefixLen, patterns and the
+	// migration report. Reload replaces them together; the config is never modified in place.
+	definitionMu sync.RWMutex
+	config       *types.RigConfig
+	// configuredBaud is the baud rate of the rig configuration while config holds a negotiated one, and zero
+	// otherwise; see Options.BaudProbe.
+	configuredBaud int
+
+	transport   Transport
+	transportMu sync.RWMutex
+	// dialer replaces serial.Open when set; used by the tests.
+	dialer func() (Transport, error)
+	// linkDown is set while the reconnect logic is reopening the transport.
+	linkDown atomic.Bool
+	// reopenPort asks the listener to reopen the transport, after Reload changed the serial configuration or the
+	// health monitor found the rig disconnected.
+	reopenPort atomic.Bool
+	// openAttempts counts transport open attempts, used by fault injection to fail the first N opens.
+	openAttempts int
+	// portWatch runs while watchPortRelease waits for another process to release the port.
+	portWatch portWatch
+	// stationBusy is set while a station power-up or power-down sequence runs.
+	stationBusy atomic.Bool
 
 	supportedCatStates map[string]types.CatState
 	maxCatPrefixLen    int
+	// protocol translates commands and frames to and from the wire format.
+	protocol protocolCodec
+	// driver is the rig driver in use; nil for the generic config-driven driver.
+	driver RigDriver
+	// quirks are the active quirks of the rig; see Quirks.
+	quirks map[Quirk]bool
+	// echo remembers the last command written, for QuirkEchoesCommands.
+	echo echoFilter
+	// patterns are the compiled StateOptions patterns, keyed by state prefix.
+	patterns map[string]*regexp.Regexp
+
+	// diag and counters hold the history and totals exported for diagnostics.
+	diag     *diagnostics
+	counters counters
+	origins  originTracker
+	polls    pollTracker
+	// roundTrips measures the time from a write to the matching response, for Metrics.
+	roundTrips roundTripTracker
+	// memoryMetrics is the MetricsSink used when Service.MetricsSink is not set.
+	memoryMetrics MemorySink
+	// lastActivity is when the link last carried traffic, in Unix nanoseconds; used by the keepalive.
+	lastActivity atomic.Int64
+	// rigOff is set while the rig is considered powered off.
+	rigOff atomic.Bool
+	// lastValidFrame is when a frame matching a configured state was last received, in Unix nanoseconds, and
+	// health the state of the health monitor.
+	lastValidFrame atomic.Int64
+	health         healthTracker
+	// awaitedSince is when the oldest command still awaiting a response was written, in Unix nanoseconds; zero
+	// if none is.
+	awaitedSince atomic.Int64
+	// autoInfo is set while the rig is in auto-information mode.
+	autoInfo atomic.Bool
+
+	// subscribers receive status updates through Subscribe.
+	subscribers subscribers
+	// topics routes status values and events to the channels handed out by SubscribeTopic.
+	topics topicRouter
+	// remotes receive coalesced status deltas through SubscribeRemote.
+	remotes remoteSubscribers
+	// drops rate-limits the DropEvents.
+	drops dropTracker
+
+	// waiters receive matched states for callers waiting on a specific response.
+	waiters stateWaiters
+	// batches holds the queued batches, which Stop fails.
+	batches pendingBatches
+
+	// frames monitors how well incoming frames match the configured states.
+	frames frameMonitor
+
+	// cache holds the latest value reported for each tag, used by the typed getters.
+	cache *stateCache
+	// lastPowerClamp is when the processor last corrected the power for a band limit; processor goroutine only.
+	lastPowerClamp time.Time
+	// emitted holds the last emitted value of each tag, for Options.StatusDiff; processor goroutine only.
+	emitted types.CatStatus
+	// lastBand is the band of the last reported VFO A frequency, if bandKnown; processor goroutine only.
+	lastBand  bands.Band
+	bandKnown bool
+	// automation holds the scheduled actions of Options.Automation.
+	automation *automation
+	// rotator holds the heading presets of Options.Rotator.
+	rotator *rotatorFollow
+	// responseSteps are the steps added to the response pipeline with AddResponseStep.
+	responseSteps responseSteps
+	// bandMap holds the recently visited frequencies, see BandMap.
+	bandMap bandMap
+	// outcomeSeq numbers the command outcomes.
+	outcomeSeq atomic.Uint64
+	// transverter is the active entry of Options.Transverters.
+	transverter transverterState
+	// meters computes the readings of Options.Meters; nil if meter streaming is disabled.
+	meters *meterFeed
+	// latency holds the monitors of the stages with a budget in Options.Latency.
+	latency map[LatencyStage]*latencyMonitor
+
+	// controlLines is the secondary port of Options.ControlLines, if any.
+	controlLines controlLines
+	// cw holds the transmission of SendCW in progress.
+	cw struct {
+		mu      sync.Mutex
+		current *CWTransmission
+	}
+	// breakIn serializes CW break-in transitions and records their timing.
+	breakIn breakIn
+	// ptt tracks the keying state of the transmitter for PTTState and the watchdog.
+	ptt pttTracker
+
+	// customLogger is the logger set by SetLogger; see logger.
+	customLogger atomic.Pointer[loggerRef]
 
 	initialized atomic.Bool
 	started     atomic.Bool // guarded via atomic operations; Start/Stop also hold mu for a broader state
@@ -42,10 +213,28 @@ type Service struct {
 	mu       sync.Mutex
 
 	currentRun *runState
+	// liveRun mirrors currentRun for the workers, which cannot take mu while Stop holds it; see launchTask.
+	liveRun atomic.Pointer[runState]
+
+	migrationReport MigrationReport
 
 	statusChannel     chan types.CatStatus
-	sendChannel       chan types.CatCommand
-	processingChannel chan types.CatState
+	broadcastChannel  chan types.CatStatus
+	sendChannel       chan queuedCommand // normal priority; see highChannel and lowChannel
+	fair              *fairQueue         // normal priority by origin, replacing sendChannel; see Options.Fairness
+	highChannel       chan queuedCommand
+	lowChannel        chan queuedCommand
+	bulkChannel       chan bulkItem
+	processingChannel chan receivedState
+	eventChannel      chan CatEvent
+
+	notificationChannel chan Notification
+	busChannel          chan busMessage
+	rawTrafficChannel   chan TrafficFrame
+	captureChannel      chan TrafficFrame
+	storeChannel        chan storeRecord
+	meterChannel        chan MeterReading
+	bandChannel         chan BandChangedEvent
 }
 
 // Initialize ensures the service is properly set up by initializing required components and loading configurations.
@@ -55,46 +244,78 @@ func (s *Service) Initialize() error {
 
 	var initErr error
 	s.initOnce.Do(func() {
-		if s.ConfigService == nil {
+		if s.ConfigService == nil && s.Definitions == nil {
 			initErr = errors.New(op).Msg(errMsgNilConfigService)
 			return
 		}
 
-		if s.LoggerService == nil {
-			initErr = errors.New(op).Msg(errMsgNilLoggerService)
+		if s.driver, initErr = s.resolveDriver(); initErr != nil {
 			return
 		}
-
-		cfg, err := s.getRigConfig()
+		if s.quirks, initErr = s.resolveQuirks(); initErr != nil {
+			return
+		}
+		cfg, report, err := s.loadRigConfig()
 		if err != nil {
 			initErr = err
 			return
 		}
+		s.migrationReport = report
+		s.config = cfg
 
-		if initErr = validateConfig(cfg); initErr != nil {
+		if s.driver != nil {
+			s.protocol = driverCodec{driver: s.driver}
+		} else if s.protocol, initErr = newProtocolCodec(s.Options); initErr != nil {
 			return
 		}
-
-		// Ensure sensible defaults for CAT timing configuration to avoid panics
-		// when creating tickers or timeouts with non-positive durations.
-		if cfg.CatConfig.ListenerRateLimiterIntervalMS <= 0 {
-			cfg.CatConfig.ListenerRateLimiterIntervalMS = defaultListenerIntervalMS
-		}
-		if cfg.CatConfig.ListenerReadTimeoutMS <= 0 {
-			cfg.CatConfig.ListenerReadTimeoutMS = cfg.SerialConfig.ReadTimeoutMS
-		}
-
-		s.config = cfg
-
 		if initErr = s.initializeStateSet(); initErr != nil {
 			return
 		}
+		if initErr = s.compilePatterns(); initErr != nil {
+			return
+		}
 
 		// This channel is non-blocking and buffered to avoid deadlocks. Leaving it a 1 ensures that
 		// the status stream is “latest-wins” so that the caller (the frontend) should not lag behind.
+		s.cache = newStateCache()
+		s.cache.setBudget(s.Options.CacheBudget.MaxEntries, s.Options.CacheBudget.MaxBytes)
+		s.diag = newDiagnostics(s.Options.Diagnostics)
+		s.latency = newLatencyMonitors(s.Options.Latency)
+		if s.automation, initErr = newAutomation(s.Options.Automation); initErr != nil {
+			return
+		}
+		if s.rotator, initErr = newRotatorFollow(s.Options.Rotator); initErr != nil {
+			return
+		}
+		if initErr = validateTransverters(s.Options.Transverters); initErr != nil {
+			return
+		}
 		s.statusChannel = make(chan types.CatStatus, 1)
-		s.sendChannel = make(chan types.CatCommand, s.config.CatConfig.SendChannelSize)
-		s.processingChannel = make(chan types.CatState, s.config.CatConfig.ProcessingChannelSize)
+		s.broadcastChannel = make(chan types.CatStatus, broadcastQueueSize)
+		s.sendChannel = make(chan queuedCommand, s.config.CatConfig.SendChannelSize)
+		s.fair = newFairQueue(s.Options.Fairness, s.config.CatConfig.SendChannelSize)
+		s.highChannel = make(chan queuedCommand, s.config.CatConfig.SendChannelSize)
+		s.lowChannel = make(chan queuedCommand, s.config.CatConfig.SendChannelSize)
+		s.bulkChannel = make(chan bulkItem, bulkChannelSize)
+		s.processingChannel = make(chan receivedState, s.config.CatConfig.ProcessingChannelSize)
+
+		eventSize := s.Options.EventChannelSize
+		if eventSize <= 0 {
+			eventSize = defaultEventChannelSize
+		}
+		s.eventChannel = make(chan CatEvent, eventSize)
+
+		notificationSize := s.Options.NotificationChannelSize
+		if notificationSize <= 0 {
+			notificationSize = defaultNotificationChannelSize
+		}
+		s.notificationChannel = make(chan Notification, notificationSize)
+		s.busChannel = make(chan busMessage, busQueueSize)
+		s.rawTrafficChannel = newRawTrafficChannel(s.Options.RawTraffic)
+		s.captureChannel = newCaptureChannel(s.Options.TrafficCapture)
+		s.storeChannel = newStoreChannel(s.Options.Persistence)
+		s.meters, s.meterChannel = newMeterFeed(s.Options.Meters)
+		s.bandChannel = make(chan BandChangedEvent, bandChannelSize)
 
 		s.initialized.Store(true)
 	})
@@ -109,8 +330,8 @@ func (s *Service) Start() error {
 		return errors.New(op).Msg(errMsgServiceNotInit)
 	}
 
-	if !s.config.CatConfig.Enabled {
-		s.LoggerService.InfoWith().Msg("CAT service is disabled in configuration; not starting.")
+	if !s.rigConfig().CatConfig.Enabled {
+		s.logger().InfoWith().Msg("CAT service is disabled in configuration; not starting.")
 		return nil
 	}
 
@@ -121,39 +342,98 @@ func (s *Service) Start() error {
 	if s.started.Load() {
 		return nil
 	}
+	// Opening the port supersedes waiting for its release.
+	s.stopPortWatch()
 
-	if err := s.initializeSerialPort(); err != nil {
+	openLink := s.initializeTransport
+	if s.Options.BaudProbe.Enabled {
+		openLink = s.negotiateBaudRate
+	}
+	if err := openLink(); err != nil {
 		return errors.New(op).Err(err).Msg("Failed to initialize serial port.")
 	}
 
 	run := &runState{
 		shutdownChannel: make(chan struct{}),
 	}
-	s.currentRun = run
+	s.setRun(run)
+	// A new run starts with a full status and reports the band it starts on.
+	s.emitted = nil
+	s.bandKnown = false
 
 	s.launchWorkerThread(run, s.serialPortListener, "serialPortListener")
 	s.launchWorkerThread(run, s.serialPortSender, "serialPortSender")
 	s.launchWorkerThread(run, s.lineProcessor, "lineProcessor")
+	s.launchWorkerThread(run, s.statusBroadcaster, "statusBroadcaster")
+	if s.EventBus != nil {
+		s.launchWorkerThread(run, s.eventBusPublisher, "eventBusPublisher")
+	}
+	if len(s.Options.Polls) > 0 || len(s.meterPolls()) > 0 {
+		s.launchWorkerThread(run, s.poller, "poller")
+	}
+	if s.Options.Presence.Enabled {
+		s.launchWorkerThread(run, s.presenceMonitor, "presenceMonitor")
+	}
+	if s.keepaliveEnabled() {
+		s.launchWorkerThread(run, s.keepalive, "keepalive")
+	}
+	if s.Options.Health.Enabled {
+		s.launchWorkerThread(run, s.healthMonitor, "healthMonitor")
+	}
+	if s.Store != nil && s.Options.Persistence.LastState {
+		s.launchWorkerThread(run, s.stateSaver, "stateSaver")
+	}
+	if s.Store != nil && s.storeChannel != nil {
+		s.launchWorkerThread(run, s.storeWriter, "storeWriter")
+	}
+	if s.Options.Remote.Enabled {
+		s.launchWorkerThread(run, s.remoteFlusher, "remoteFlusher")
+	}
+	if s.captureChannel != nil {
+		s.launchWorkerThread(run, s.trafficCapture, "trafficCapture")
+	}
+	if len(s.Options.Automation.Actions) > 0 {
+		s.launchWorkerThread(run, s.scheduler, "scheduler")
+	}
 
 	s.started.Store(true)
+	s.enableAutoInfo(run.shutdownChannel)
+	if s.Options.Banner.Enabled {
+		s.launchWorkerThread(run, s.announceConnection, "announceConnection")
+	}
 
 	return nil
 }
 
 // Stop safely stops the service by shutting down active processes, releasing resources, and closing the serial port.
+// It waits for every worker to exit; see StopContext to bound the wait.
 func (s *Service) Stop() error {
 	const op errors.Op = "cat.Service.Stop"
+	if err := s.StopContext(context.Background()); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// StopContext behaves like Stop, but waits for the workers only until ctx is done. The serial port is then closed
+// to unblock workers stuck in I/O, and if some still do not exit within a short grace period the service is
+// stopped without them and the error names them. A worker left behind exits once its I/O returns.
+func (s *Service) StopContext(ctx context.Context) error {
+	const op errors.Op = "cat.Service.StopContext"
 	if !s.initialized.Load() {
 		return errors.New(op).Msg(errMsgServiceNotInit)
 	}
 
-	if !s.config.CatConfig.Enabled {
+	if !s.rigConfig().CatConfig.Enabled {
 		return nil
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// A watch outlives a Start that failed to open the port.
+	s.stopPortWatch()
+
 	// If not started, treat Stop as idempotent and return nil.
 	if !s.started.Load() {
 		return nil
@@ -175,24 +455,79 @@ func (s *Service) Stop() error {
 	// runtime's garbage collector to reclaim channel resources once the Service is stopped and no
 	// references remain.
 
+	var stuck []string
+	quiesced := false
 	if run != nil {
-		run.wg.Wait()
+		stuck, quiesced = s.awaitWorkers(ctx, run)
+	}
+	if !quiesced {
+		s.quiesceRig()
 	}
 
-	if s.serialPort != nil {
-		if err := s.serialPort.Close(); err != nil {
+	if t := s.link(); t != nil {
+		if err := t.Close(); err != nil {
 			return errors.New(op).Msgf("Failed to close serial port: %v", err)
 		}
-		s.serialPort = nil
+		s.setLink(nil)
 	}
 
-	s.currentRun = nil
+	s.failPendingBatches()
+	s.saveLastState()
+	s.flushDrops()
+
+	s.setRun(nil)
 	s.started.Store(false)
 
+	if len(stuck) > 0 {
+		return errors.New(op).Msgf("Workers did not stop: %s.", strings.Join(stuck, ", "))
+	}
 	return nil
 }
 
+// awaitWorkers waits for the workers of run to exit, until ctx is done. It then quiesces the rig while the link is
+// still open, force-closes the transport and waits for stopGracePeriod, returning the workers still running.
+// quiesced reports whether quiesceRig was run.
+func (s *Service) awaitWorkers(ctx context.Context, run *runState) (stuck []string, quiesced bool) {
+	done := make(chan struct{})
+	go func() {
+		run.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil, false
+	case <-ctx.Done():
+	}
+
+	s.logger().WarnWith().Strs("workers", run.runningWorkers()).Msg("CAT workers did not stop in time; closing the port")
+	s.quiesceRig()
+	if t := s.link(); t != nil {
+		_ = t.Close()
+		s.setLink(nil)
+	}
+	timer := time.NewTimer(stopGracePeriod)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil, true
+	case <-timer.C:
+	}
+	stuck = run.runningWorkers()
+	s.logger().ErrorWith().Strs("workers", stuck).Msg("CAT workers did not stop")
+	return stuck, true
+}
+
+// quiesceRig leaves auto-information mode, stops the CW keyer, unkeys the transmitter and releases the control
+// lines. Stop runs it while the link is still open, so before a force-close.
+func (s *Service) quiesceRig() {
+	s.disableAutoInfo()
+	s.abortCWOnStop()
+	s.unkeyOnStop()
+	s.releaseControlLines()
+}
+
 // StatusChannel returns a channel for monitoring cat status changes or an error if the service is uninitialized or closed.
+// The channel is shared by all its readers; use Subscribe for independent consumers.
 func (s *Service) StatusChannel() (<-chan types.CatStatus, error) {
 	const op errors.Op = "cat.Service.StatusChannel"
 	if !s.initialized.Load() {
@@ -209,13 +544,18 @@ func (s *Service) StatusChannel() (<-chan types.CatStatus, error) {
 // EnqueueCommand queues a command with the given name and parameters for execution, ensuring the service is initialized
 // and started. Returns an error if the service is not ready, the command lookup fails, or the sendChannel is full or closed.
 func (s *Service) EnqueueCommand(cmdName cmds.CatCmdName, params ...string) error {
-	const op errors.Op = "cat.Service.EnqueueCommand"
+	return s.EnqueueCommandWith(cmdName, params)
+}
+
+// EnqueueCommandWith behaves like EnqueueCommand, applying the given per-call options (e.g. ConfirmAvoidRange).
+func (s *Service) EnqueueCommandWith(cmdName cmds.CatCmdName, params []string, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.EnqueueCommandWith"
 	if !s.initialized.Load() {
 		return errors.New(op).Msg(errMsgServiceNotInit)
 	}
 
-	if !s.config.CatConfig.Enabled {
-		s.LoggerService.InfoWith().Msg("CAT service is disabled in configuration")
+	if !s.rigConfig().CatConfig.Enabled {
+		s.logger().InfoWith().Msg("CAT service is disabled in configuration")
 		return nil
 	}
 
@@ -223,44 +563,31 @@ func (s *Service) EnqueueCommand(cmdName cmds.CatCmdName, params ...string) erro
 		return errors.New(op).Msg(errMsgServiceNotStarted)
 	}
 
-	catCmd, err := s.commandLookup(cmdName)
-	if err != nil {
-		return errors.New(op).Msgf("Command lookup failed: %v", err)
-	}
-
-	paramsInterface := make([]interface{}, len(params))
-	for i, v := range params {
-		paramsInterface[i] = v
-	}
-
-	// Validate the format string against provided parameters to avoid runtime panics from fmt.Sprintf.
-	if err := s.validateCommandFormat(catCmd.Cmd, paramsInterface...); err != nil {
-		return errors.New(op).Err(err).Msg("Command parameter validation failed")
-	}
-
-	pCmd := &catCmd
-	pCmd.Cmd = fmt.Sprintf(catCmd.Cmd, paramsInterface...)
-	catCmd = *pCmd
-
-	// Command is fully defined in configuration and already validated for format/arity,
-	// so no additional sanitization is required here.
-
-	if s.sendChannel != nil {
-		select {
-		case s.sendChannel <- catCmd:
-			return nil
-		default:
-			return errors.New(op).Msg("Send channel is full.")
-		}
-	}
-	return errors.New(op).Msg("Send channel is closed.")
+	return s.submit(newCommandRequest(cmdName, params, opts...))
 }
 
 // RigConfig returns the rig configuration for the service, or an empty configuration if the service is not initialized.
 // This provides a copy of the current rig configuration, for other consumers, e.g., frontend facades.
 func (s *Service) RigConfig() types.RigConfig {
-	if s.config == nil {
+	cfg := s.rigConfig()
+	if cfg == nil {
 		return types.RigConfig{}
 	}
-	return *s.config
+	return *cfg
+}
+
+// rigConfig returns the current rig configuration. Callers must not modify it.
+func (s *Service) rigConfig() *types.RigConfig {
+	s.definitionMu.RLock()
+	defer s.definitionMu.RUnlock()
+	return s.config
+}
+
+// MigrationReport returns the report of the rig definition migrations applied during Initialize or the last Reload.
+func (s *Service) MigrationReport() MigrationReport {
+	s.definitionMu.RLock()
+	defer s.definitionMu.RUnlock()
+	report := s.migrationReport
+	report.Applied = append([]string(nil), s.migrationReport.Applied...)
+	return report
 }
@@ -1,13 +1,16 @@
 package cat
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/Station-Manager/config"
 	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
 	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/serial"
 	"github.com/Station-Manager/types"
 	"github.com/stretchr/testify/require"
 )
@@ -19,16 +22,19 @@ func TestInitFailureNilConfigService(t *testing.T) {
 	require.Contains(t, err.Error(), errMsgNilConfigService)
 }
 
-func TestInitFailureNilLoggerService(t *testing.T) {
+func TestInitWithoutLoggerService(t *testing.T) {
 	service := &Service{
-		ConfigService: &config.Service{
-			WorkingDir: "some/path",
-			AppConfig:  types.AppConfig{},
-		},
+		Definitions: StaticDefinitions{{CatConfig: types.CatConfig{SendChannelSize: 1, ProcessingChannelSize: 1}}},
 	}
-	err := service.Initialize()
-	require.Error(t, err)
-	require.Contains(t, err.Error(), errMsgNilLoggerService)
+	require.NoError(t, service.Initialize())
+	service.logger().WarnWith().Str("state", "FA").Msg("discarded")
+	service.dropStale(queuedCommand{CatCommand: types.CatCommand{Name: "READ"}})
+
+	logger := &logging.Service{}
+	service.SetLogger(logger)
+	require.Same(t, logger, service.logger())
+	service.SetLogger(nil)
+	require.Equal(t, noopLogger, service.logger())
 }
 
 func TestInitFailureInvalidRigID(t *testing.T) {
@@ -178,7 +184,7 @@ func TestEnqueueCommandFormatValidation(t *testing.T) {
 		ConfigService: &config.Service{},
 		LoggerService: &logging.Service{},
 		config:        cfg,
-		sendChannel:   make(chan types.CatCommand, 1),
+		sendChannel:   make(chan queuedCommand, 1),
 	}
 	service.initialized.Store(true)
 	service.started.Store(true)
@@ -226,3 +232,75 @@ func TestInitializeFailsOnEmptyCatStatePrefix(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "CAT state entry has an empty prefix")
 }
+
+// hangingTransport blocks reads, ignoring their context, until it is closed.
+type hangingTransport struct {
+	fakeTransport
+	released chan struct{}
+	once     sync.Once
+}
+
+func (h *hangingTransport) ReadResponseBytes(context.Context) ([]byte, error) {
+	<-h.released
+	return nil, serial.ErrClosed
+}
+
+func (h *hangingTransport) Close() error {
+	h.once.Do(func() { close(h.released) })
+	return nil
+}
+
+func TestStopContextForceClosesPort(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	port := &hangingTransport{released: make(chan struct{})}
+	service.setLink(port)
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.currentRun = run
+	service.launchWorkerThread(run, func(<-chan struct{}) { _, _ = port.ReadResponseBytes(context.Background()) }, "reader")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.NoError(t, service.StopContext(ctx))
+	require.False(t, service.started.Load())
+	require.Nil(t, service.link())
+}
+
+func TestStopContextUnkeysBeforeForceClose(t *testing.T) {
+	cfg := &types.RigConfig{CatCommands: []types.CatCommand{{Name: CmdPTTOff.String(), Cmd: "RX;"}}}
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 1
+	service := newStartedTestService(t, cfg)
+	port := &hangingTransport{fakeTransport: *newFakeTransport(), released: make(chan struct{})}
+	service.setLink(port)
+	service.notePTT(true)
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.currentRun = run
+	service.launchWorkerThread(run, service.serialPortListener, "serialPortListener")
+	time.Sleep(10 * time.Millisecond) // let the listener block in its read
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.NoError(t, service.StopContext(ctx))
+	require.Equal(t, []string{"RX;"}, port.writes(), "unkeyed while the link was open")
+	require.False(t, service.PTTState().On)
+	require.Nil(t, service.link())
+}
+
+func TestStopContextReportsStuckWorkers(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	release := make(chan struct{})
+	run := &runState{shutdownChannel: make(chan struct{})}
+	service.currentRun = run
+	service.launchWorkerThread(run, func(<-chan struct{}) { <-release }, "stuckWorker")
+	service.launchWorkerThread(run, func(shutdown <-chan struct{}) { <-shutdown }, "politeWorker")
+	t.Cleanup(func() {
+		close(release)
+		run.wg.Wait()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := service.StopContext(ctx)
+	require.Error(t, err)
+	require.Equal(t, "Workers did not stop: stuckWorker.", errors.Root(err).Error())
+	require.False(t, service.started.Load(), "the service is stopped without the stuck worker")
+}
@@ -0,0 +1,272 @@
+package cat
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/serial"
+	"github.com/Station-Manager/types"
+)
+
+// TransportSimulator is the Options.Transport of the simulated rig.
+const TransportSimulator = "simulator"
+
+// defaultSimulatorDelayMS is used when Options.Simulator.DelayMS is zero.
+const defaultSimulatorDelayMS = 20
+
+// simulatorDelimiters end the commands of ASCII command sets.
+const simulatorDelimiters = ";\r\n"
+
+// Simulator is a Transport simulating a rig that speaks an ASCII command set, such as those of Kenwood and Yaesu,
+// for tests and demos without hardware. It accepts the commands of the rig definition and keeps the value of
+// every tag of its states: a command made of a state prefix alone reads the state, and one followed by data sets
+// its markers, after which the rig reports the state as in auto-information mode. Commands the definition does
+// not configure are answered with "?".
+type Simulator struct {
+	states    []types.CatState
+	templates []string
+	delay     time.Duration
+	noiseRate float64
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	values  map[string]string
+	pending []simulatorFrame
+	// due is when the last pending frame is delivered; answers never overtake each other.
+	due  time.Time
+	wake chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// simulatorFrame is an answer waiting for its delay.
+type simulatorFrame struct {
+	data []byte
+	due  time.Time
+}
+
+// NewSimulator returns a simulated rig for the commands and states of cfg.
+func NewSimulator(cfg types.RigConfig, opts SimulatorOptions) *Simulator {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	sim := &Simulator{
+		states:    slices.Clone(cfg.CatStates),
+		delay:     durationOrDefault(opts.DelayMS, defaultSimulatorDelayMS),
+		noiseRate: opts.NoiseRate,
+		rng:       rand.New(rand.NewPCG(seed, seed)),
+		values:    make(map[string]string),
+		wake:      make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	for _, c := range cfg.CatCommands {
+		sim.templates = append(sim.templates, strings.TrimRight(c.Cmd, simulatorDelimiters))
+	}
+	// Longer prefixes first, so that "MD0" wins over "MD".
+	slices.SortStableFunc(sim.states, func(a, b types.CatState) int { return len(b.Prefix) - len(a.Prefix) })
+	for _, st := range sim.states {
+		for _, m := range st.Markers {
+			if _, ok := sim.values[m.Tag]; !ok {
+				sim.values[m.Tag] = simulatorDefault(m, opts.State)
+			}
+		}
+	}
+	return sim
+}
+
+// simulatorDefault returns the initial raw value of the marker's tag.
+func simulatorDefault(m types.Marker, state map[string]string) string {
+	if v, ok := state[m.Tag]; ok {
+		return v
+	}
+	switch m.Tag {
+	case tags.VfoAFreq.String():
+		return "14074000"
+	case tags.VfoBFreq.String():
+		return "14076000"
+	case tags.TxPwr.String():
+		return "100"
+	case tags.MainMode.String():
+		for _, vm := range m.ValueMappings {
+			if vm.Value == "USB" {
+				return vm.Key
+			}
+		}
+	}
+	return "0"
+}
+
+// Value returns the raw value of tag in the simulated rig.
+func (sim *Simulator) Value(tag string) string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	return sim.values[tag]
+}
+
+// WriteCommand implements Transport.
+func (sim *Simulator) WriteCommand(_ context.Context, cmd string) error {
+	select {
+	case <-sim.closed:
+		return serial.ErrClosed
+	default:
+	}
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	answer, ok := sim.execute(strings.TrimRight(cmd, simulatorDelimiters))
+	if !ok {
+		return nil
+	}
+	if sim.noiseRate > 0 && sim.rng.Float64() < sim.noiseRate {
+		noise := make([]byte, 2+sim.rng.IntN(6))
+		for i := range noise {
+			noise[i] = byte(0x80 + sim.rng.IntN(0x80))
+		}
+		sim.queue(noise)
+	}
+	sim.queue(answer)
+	return nil
+}
+
+// execute applies cmd to the simulated rig and returns its answer, if any. The caller holds mu.
+func (sim *Simulator) execute(cmd string) ([]byte, bool) {
+	if !slices.ContainsFunc(sim.templates, func(tmpl string) bool { return matchesTemplate(cmd, tmpl) }) {
+		return []byte("?"), true
+	}
+	i := slices.IndexFunc(sim.states, func(st types.CatState) bool {
+		return len(cmd) >= len(st.Prefix) && strings.EqualFold(cmd[:len(st.Prefix)], st.Prefix)
+	})
+	if i < 0 {
+		return nil, false // a command without state, e.g. TX
+	}
+	st := sim.states[i]
+	if data := cmd[len(st.Prefix):]; data != "" {
+		for _, m := range st.Markers {
+			switch {
+			case m.Index+m.Length <= len(data):
+				sim.values[m.Tag] = data[m.Index : m.Index+m.Length]
+			case len(st.Markers) == 1:
+				sim.values[m.Tag] = data
+			}
+		}
+	}
+	return sim.frame(st), true
+}
+
+// frame formats st from the current values, padding every marker with zeros. The caller holds mu.
+func (sim *Simulator) frame(st types.CatState) []byte {
+	size := 0
+	for _, m := range st.Markers {
+		size = max(size, m.Index+m.Length)
+	}
+	data := []byte(strings.Repeat("0", size))
+	for _, m := range st.Markers {
+		v := sim.values[m.Tag]
+		if len(v) > m.Length {
+			v = v[len(v)-m.Length:]
+		}
+		copy(data[m.Index+m.Length-len(v):], v)
+	}
+	return append([]byte(st.Prefix), data...)
+}
+
+// matchesTemplate reports whether cmd is an instance of the command template tmpl, whose parameters are fmt
+// verbs.
+func matchesTemplate(cmd, tmpl string) bool {
+	verb := strings.IndexByte(tmpl, '%')
+	if verb < 0 {
+		return strings.EqualFold(cmd, tmpl)
+	}
+	end := verb + 1
+	for end < len(tmpl) && !isVerbLetter(tmpl[end]) {
+		end++
+	}
+	prefix, suffix := tmpl[:verb], ""
+	if end < len(tmpl) {
+		suffix = tmpl[end+1:]
+	}
+	if strings.Contains(suffix, "%") {
+		suffix = "" // several parameters; the prefix must do
+	}
+	return len(cmd) > len(prefix)+len(suffix) && strings.EqualFold(cmd[:len(prefix)], prefix) &&
+		strings.HasSuffix(cmd, suffix)
+}
+
+func isVerbLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// queue schedules data after the rig's delay. The caller holds mu.
+func (sim *Simulator) queue(data []byte) {
+	delay := sim.delay/2 + time.Duration(sim.rng.Int64N(int64(sim.delay)+1))
+	due := time.Now().Add(delay)
+	if due.Before(sim.due) {
+		due = sim.due
+	}
+	sim.due = due
+	sim.pending = append(sim.pending, simulatorFrame{data: data, due: due})
+	close(sim.wake)
+	sim.wake = make(chan struct{})
+}
+
+// ReadResponseBytes implements Transport.
+func (sim *Simulator) ReadResponseBytes(ctx context.Context) ([]byte, error) {
+	for {
+		sim.mu.Lock()
+		var timer *time.Timer
+		var ready <-chan time.Time
+		if len(sim.pending) > 0 {
+			next := sim.pending[0]
+			wait := time.Until(next.due)
+			if wait <= 0 {
+				sim.pending = sim.pending[1:]
+				sim.mu.Unlock()
+				return next.data, nil
+			}
+			timer = time.NewTimer(wait)
+			ready = timer.C
+		}
+		wake := sim.wake
+		sim.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil, ctx.Err()
+		case <-sim.closed:
+			stopTimer(timer)
+			return nil, serial.ErrClosed
+		case <-wake:
+			stopTimer(timer)
+		case <-ready:
+		}
+	}
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// Close implements Transport.
+func (sim *Simulator) Close() error {
+	sim.closeOnce.Do(func() { close(sim.closed) })
+	return nil
+}
+
+// openSimulator returns the simulated rig of Options.Transport for the current rig definition.
+func (s *Service) openSimulator() (Transport, error) {
+	const op errors.Op = "cat.Service.openSimulator"
+	if s.codec().lineDelimiter() != 0 {
+		return nil, errors.New(op).Msg("The simulator speaks ASCII command sets only.")
+	}
+	return NewSimulator(*s.rigConfig(), s.Options.Simulator), nil
+}
@@ -0,0 +1,123 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func simulatorTestConfig() *types.RigConfig {
+	return &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "READVFOA", Cmd: "FA;"},
+			{Name: "SETVFOAFREQ", Cmd: "FA%s;"},
+			{Name: "READMODE", Cmd: "MD;"},
+		},
+		CatStates: []types.CatState{
+			{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+			{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: []types.ValueMapping{
+				{Key: "1", Value: "LSB"}, {Key: "2", Value: "USB"},
+			}}}},
+		},
+	}
+}
+
+func readSimulator(t *testing.T, sim *Simulator) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	frame, err := sim.ReadResponseBytes(ctx)
+	require.NoError(t, err)
+	return string(frame)
+}
+
+func TestSimulatorAnswersReadsAndSets(t *testing.T) {
+	sim := NewSimulator(*simulatorTestConfig(), SimulatorOptions{DelayMS: 1, Seed: 1})
+	ctx := context.Background()
+
+	require.NoError(t, sim.WriteCommand(ctx, "FA;"))
+	require.Equal(t, "FA00014074000", readSimulator(t, sim))
+	require.NoError(t, sim.WriteCommand(ctx, "MD;"))
+	require.Equal(t, "MD2", readSimulator(t, sim), "USB by default")
+
+	require.NoError(t, sim.WriteCommand(ctx, "FA00007074000;"))
+	require.Equal(t, "FA00007074000", readSimulator(t, sim), "a set is reported back")
+	require.Equal(t, "00007074000", sim.Value("VFOAFREQ"))
+
+	require.NoError(t, sim.WriteCommand(ctx, "PS;"))
+	require.Equal(t, "?", readSimulator(t, sim), "unknown commands are rejected")
+
+	require.NoError(t, sim.Close())
+	require.Error(t, sim.WriteCommand(ctx, "FA;"))
+}
+
+func TestSimulatorKeepsOrderAndSeededNoise(t *testing.T) {
+	frames := func() []string {
+		sim := NewSimulator(*simulatorTestConfig(), SimulatorOptions{DelayMS: 2, NoiseRate: 0.5, Seed: 42})
+		for range 10 {
+			require.NoError(t, sim.WriteCommand(context.Background(), "FA;"))
+			require.NoError(t, sim.WriteCommand(context.Background(), "MD;"))
+		}
+		var got []string
+		for answers := 0; answers < 20; {
+			frame := readSimulator(t, sim)
+			if frame[0] < 0x80 {
+				answers++
+			}
+			got = append(got, frame)
+		}
+		return got
+	}
+	first := frames()
+	require.Greater(t, len(first), 20, "some noise at a rate of 0.5")
+	require.Equal(t, first, frames(), "the same seed gives the same traffic")
+
+	var answers []string
+	for _, frame := range first {
+		if frame[0] < 0x80 {
+			answers = append(answers, frame)
+		}
+	}
+	for i := 0; i < len(answers); i += 2 {
+		require.Equal(t, []string{"FA00014074000", "MD2"}, answers[i:i+2], "answers never overtake each other")
+	}
+}
+
+func TestSimulatorTransportFeedsPipeline(t *testing.T) {
+	cfg := simulatorTestConfig()
+	cfg.CatConfig.ListenerRateLimiterIntervalMS = 1
+	service := newStartedTestService(t, cfg)
+	service.processingChannel = make(chan receivedState, 4)
+	service.statusChannel = make(chan types.CatStatus, 4)
+	service.Options.Transport = "Simulator"
+	service.Options.Simulator = SimulatorOptions{DelayMS: 1, State: map[string]string{"VFOAFREQ": "00021074000"}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortListener": service.serialPortListener,
+		"lineProcessor":      service.lineProcessor,
+		"serialPortSender":   service.serialPortSender,
+	})
+	require.NoError(t, service.initializeTransport())
+	require.IsType(t, &Simulator{}, service.link())
+
+	status, err := service.SendCommand(context.Background(), "READVFOA")
+	require.NoError(t, err)
+	require.Equal(t, "00021074000", status["VFOAFREQ"])
+
+	status, err = service.SendCommand(context.Background(), "SETVFOAFREQ", "00003573000")
+	require.NoError(t, err)
+	require.Equal(t, "00003573000", status["VFOAFREQ"])
+	require.Eventually(t, func() bool {
+		freq, ok := service.cache.get("VFOAFREQ")
+		return ok && freq.Value == "00003573000"
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestUnknownTransportIsRejected(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.Transport = "carrier-pigeon"
+	_, err := service.openPort()
+	require.Error(t, err)
+}
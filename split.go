@@ -0,0 +1,132 @@
+package cat
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+// Command names of the split operations. SPLITON and SPLITOFF are required; SELECTTXVFOB is sent after SPLITON
+// only if the rig definition provides it, for rigs where enabling split does not select VFO B for transmit.
+const (
+	CmdSplitOn      cmds.CatCmdName = "SPLITON"
+	CmdSplitOff     cmds.CatCmdName = "SPLITOFF"
+	CmdSelectTxVFOB cmds.CatCmdName = "SELECTTXVFOB"
+)
+
+// splitOnValues are the (mapped) SPLIT tag values that mean split is on.
+var splitOnValues = []string{"1", "ON", "TRUE"}
+
+// SetSplit sets up split operation as one unit: VFO A receives on rxHz, VFO B transmits on txHz, split is enabled
+// and VFO B is selected for transmit. All commands are validated before any is queued, and the result is then read
+// back from the rig. If the rig does not confirm it, the previous frequencies and split state are restored and the
+// error says whether that succeeded.
+func (s *Service) SetSplit(ctx context.Context, rxHz, txHz int64, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.SetSplit"
+
+	prevA, prevB, err := s.readVFOs(ctx)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	prevSplit := s.splitEnabled(ctx)
+
+	rx, err := s.formatFrequency(tags.VfoAFreq, rxHz)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	tx, err := s.formatFrequency(tags.VfoBFreq, txHz)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	req := newCommandRequest(CmdSetVfoAFreq, []string{rx}, opts...)
+	req.then = append(req.then,
+		newCommandRequest(CmdSetVfoBFreq, []string{tx}, opts...),
+		newCommandRequest(CmdSplitOn, nil),
+	)
+	if _, err = s.commandLookup(CmdSelectTxVFOB); err == nil {
+		req.then = append(req.then, newCommandRequest(CmdSelectTxVFOB, nil))
+	}
+	if err = s.submit(req); err != nil {
+		return errors.New(op).Err(err)
+	}
+
+	verifyErr := s.verifySplit(ctx, rxHz, txHz)
+	if verifyErr == nil {
+		return nil
+	}
+	if err = s.restoreSplit(prevA, prevB, prevSplit, opts); err != nil {
+		return errors.New(op).Msgf("Split not confirmed by the rig (%v) and the previous state could not be restored: %v", errors.Root(verifyErr), errors.Root(err))
+	}
+	return errors.New(op).Msgf("Split not confirmed by the rig (%v); the previous state was restored.", errors.Root(verifyErr))
+}
+
+// verifySplit reads both VFOs, and the split state if the rig reports it, back from the rig.
+func (s *Service) verifySplit(ctx context.Context, rxHz, txHz int64) error {
+	const op errors.Op = "cat.Service.verifySplit"
+
+	a, b, err := s.queryVFOs(ctx)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if !sameValue(a, strconv.FormatInt(rxHz, 10)) || !sameValue(b, strconv.FormatInt(txHz, 10)) {
+		return errors.New(op).Msgf("VFO A %s and VFO B %s instead of %d and %d", a, b, rxHz, txHz)
+	}
+	if _, ok := s.markerFor(tags.Split); ok {
+		value, err := s.queryTag(ctx, tags.Split)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		if !isSplitOn(value) {
+			return errors.New(op).Msgf("split reported as %s", value)
+		}
+	}
+	return nil
+}
+
+// restoreSplit puts back the frequencies and, if split was off, the split state recorded before SetSplit.
+func (s *Service) restoreSplit(a, b string, split bool, opts []CommandOption) error {
+	const op errors.Op = "cat.Service.restoreSplit"
+
+	var params [2]string
+	for i, v := range []struct {
+		tag   tags.CatStateTag
+		value string
+	}{{tags.VfoAFreq, a}, {tags.VfoBFreq, b}} {
+		hz, err := strconv.ParseInt(strings.TrimSpace(v.value), 10, 64)
+		if err != nil {
+			return errors.New(op).Msgf("invalid previous frequency %q for %s", v.value, v.tag)
+		}
+		if params[i], err = s.formatFrequency(v.tag, hz); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+
+	opts = append(opts, Force(), ConfirmAvoidRange())
+	req := newCommandRequest(CmdSetVfoAFreq, []string{params[0]}, opts...)
+	req.then = append(req.then, newCommandRequest(CmdSetVfoBFreq, []string{params[1]}, opts...))
+	if !split {
+		req.then = append(req.then, newCommandRequest(CmdSplitOff, nil))
+	}
+	return s.submit(req)
+}
+
+// splitEnabled reports whether split is on according to the rig. Rigs that do not report it count as off.
+func (s *Service) splitEnabled(ctx context.Context) bool {
+	if _, ok := s.markerFor(tags.Split); !ok {
+		return false
+	}
+	value, err := s.readTag(ctx, tags.Split)
+	return err == nil && isSplitOn(value)
+}
+
+// isSplitOn reports whether a (mapped) split value means on.
+func isSplitOn(value string) bool {
+	value = strings.TrimSpace(value)
+	return slices.ContainsFunc(splitOnValues, func(on string) bool { return strings.EqualFold(value, on) })
+}
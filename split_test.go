@@ -0,0 +1,96 @@
+package cat
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSplitRig applies frequency and split commands and answers READ with both VFOs and the split state. A broken
+// rig ignores VFO B and split commands; every command it receives is recorded.
+func fakeSplitRig(t *testing.T, broken bool) (*Service, func() []string) {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands,
+		types.CatCommand{Name: "READ", Cmd: "IF;"},
+		types.CatCommand{Name: CmdSplitOn.String(), Cmd: "FT1;"},
+		types.CatCommand{Name: CmdSplitOff.String(), Cmd: "FT0;"},
+	)
+	cfg.CatStates = append(cfg.CatStates, types.CatState{Prefix: "FT", Markers: []types.Marker{{Tag: "SPLIT", Index: 0, Length: 1}}})
+	service := newStartedTestService(t, cfg)
+
+	var mu sync.Mutex
+	var sent []string
+	a, b, split := "014074000", "014074000", "0"
+	service.cache.update(types.CatStatus{"VFOAFREQ": a, "VFOBFREQ": b, "SPLIT": split}, time.Now())
+	runFakeRig(t, service, func(cmd string) {
+		mu.Lock()
+		sent = append(sent, cmd)
+		mu.Unlock()
+		switch {
+		case strings.HasPrefix(cmd, "FA"):
+			a = strings.TrimSuffix(cmd[2:], ";")
+		case broken:
+		case strings.HasPrefix(cmd, "FB"):
+			b = strings.TrimSuffix(cmd[2:], ";")
+		case cmd == "FT1;":
+			split = "1"
+		case cmd == "FT0;":
+			split = "0"
+		}
+		if cmd == "IF;" {
+			service.cache.update(types.CatStatus{"VFOAFREQ": a, "VFOBFREQ": b, "SPLIT": split}, time.Now())
+		}
+	})
+	return service, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), sent...)
+	}
+}
+
+func TestSetSplit(t *testing.T) {
+	service, sent := fakeSplitRig(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, service.SetSplit(ctx, 14025000, 14026000))
+	require.Equal(t, []string{"FA014025000;", "FB014026000;", "FT1;"}, sent()[:3])
+
+	hz, err := service.GetFrequencyHz(ctx, VFOB)
+	require.NoError(t, err)
+	require.Equal(t, int64(14026000), hz)
+}
+
+func TestSetSplitRestoresPreviousStateWhenUnconfirmed(t *testing.T) {
+	service, sent := fakeSplitRig(t, true)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := service.SetSplit(ctx, 14025000, 14026000)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "previous state was restored")
+
+	require.Eventually(t, func() bool {
+		got := sent()
+		return len(got) > 0 && got[len(got)-1] == "FT0;"
+	}, time.Second, 5*time.Millisecond)
+	require.Contains(t, sent(), "FA014074000;")
+}
+
+func TestSetSplitRejectedLeavesRigUntouched(t *testing.T) {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands, types.CatCommand{Name: CmdSplitOn.String(), Cmd: "FT1;"})
+	service := newStartedTestService(t, cfg)
+	service.Options.AvoidRanges = []AvoidRange{{Label: "beacons", MinHz: 14099000, MaxHz: 14101000}}
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000", "VFOBFREQ": "014074000"}, time.Now())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.Error(t, service.SetSplit(ctx, 14090000, 14100000))
+	require.Empty(t, drainCommands(service))
+}
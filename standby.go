@@ -0,0 +1,175 @@
+package cat
+
+import (
+	"context"
+	stderr "errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	defaultStandbyProbeIntervalMS  = 2000
+	defaultStandbyFailureThreshold = 3
+	defaultStandbyLeaseTTLMS       = 10000
+)
+
+// PrimaryProbe checks whether the primary controller is alive, typically through its network API.
+type PrimaryProbe interface {
+	Probe(ctx context.Context) error
+}
+
+// ErrFenceLost is wrapped by a Fence's Renew error when the lease is held by another owner or token, as opposed to
+// a fence that could not be reached or was briefly busy.
+var ErrFenceLost = stderr.New("fence lost")
+
+// Fence grants exclusive control of the rig to a single owner at a time. A lease that is not renewed within its
+// TTL expires, so a crashed holder cannot block a takeover forever. Tokens increase monotonically with every
+// successful Acquire, so a stale holder can be recognised. Renew reports a lease that has gone to someone else with
+// an error wrapping ErrFenceLost.
+type Fence interface {
+	Acquire(ctx context.Context, owner string, ttl time.Duration) (uint64, error)
+	Renew(ctx context.Context, owner string, token uint64, ttl time.Duration) error
+	Release(ctx context.Context, owner string, token uint64) error
+}
+
+// Standby runs a Service as part of a redundant controller pair. The primary runs a Standby without a Probe and
+// takes the fence immediately; the secondary runs one with a Probe and only competes for the fence once the
+// primary has failed FailureThreshold consecutive probes. Whoever holds the fence starts the Service, and stops it
+// as soon as the lease is lost, or could expire before the next renewal, so the two hosts never drive the rig at
+// the same time. A renewal that fails for another reason, such as a busy fence, is retried on the next interval.
+type Standby struct {
+	Service *Service
+	Fence   Fence
+	// Probe monitors the primary. Nil means this instance is the primary.
+	Probe PrimaryProbe
+	// Owner identifies this instance in the fence, e.g. the host name.
+	Owner string
+
+	// ProbeIntervalMS is the interval between probes and lease renewals. The unit is milliseconds.
+	//
+	// Default is 2000ms.
+	ProbeIntervalMS time.Duration
+	// FailureThreshold is the number of consecutive failed probes before taking over.
+	//
+	// Default is 3.
+	FailureThreshold int
+	// LeaseTTLMS is the lifetime of the fence lease. It must be well above ProbeIntervalMS.
+	// The unit is milliseconds.
+	//
+	// Default is 10000ms.
+	LeaseTTLMS time.Duration
+
+	active atomic.Bool
+}
+
+// Active reports whether this instance currently holds the fence and controls the rig.
+func (sb *Standby) Active() bool {
+	return sb.active.Load()
+}
+
+// Run monitors the primary and manages the takeover until ctx is done. The Service must already be initialized.
+// On return the Service is stopped and the lease released if this instance was active.
+func (sb *Standby) Run(ctx context.Context) error {
+	const op errors.Op = "cat.Standby.Run"
+	if sb.Service == nil || sb.Fence == nil {
+		return errors.New(op).Msg("standby requires a Service and a Fence")
+	}
+
+	interval := sb.ProbeIntervalMS * time.Millisecond
+	if interval <= 0 {
+		interval = defaultStandbyProbeIntervalMS * time.Millisecond
+	}
+	threshold := sb.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultStandbyFailureThreshold
+	}
+	ttl := sb.LeaseTTLMS * time.Millisecond
+	if ttl <= 0 {
+		ttl = defaultStandbyLeaseTTLMS * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var token uint64
+	var renewed time.Time
+	failures := 0
+	for {
+		if sb.active.Load() {
+			switch err := sb.Fence.Renew(ctx, sb.Owner, token, ttl); {
+			case err == nil:
+				renewed = time.Now()
+			case stderr.Is(err, ErrFenceLost):
+				sb.Service.logger().ErrorWith().Err(err).Msg("standby: lost the fence; releasing rig control")
+				sb.demote()
+			case time.Since(renewed)+interval >= ttl:
+				sb.Service.logger().ErrorWith().Err(err).Msg("standby: lease is about to expire; releasing rig control")
+				sb.demote()
+			default:
+				sb.Service.logger().WarnWith().Err(err).Msg("standby: failed to renew the fence; retrying")
+			}
+		} else if sb.shouldCompete(ctx, &failures, threshold) {
+			t, err := sb.Fence.Acquire(ctx, sb.Owner, ttl)
+			if err == nil {
+				token, renewed = t, time.Now()
+				if err = sb.promote(); err != nil {
+					// Could not take the rig; give the lease back so another instance can try.
+					_ = sb.Fence.Release(ctx, sb.Owner, token)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if sb.active.Load() {
+				sb.demote()
+				// Use a fresh context: ctx is already done but the release should still reach the fence.
+				_ = sb.Fence.Release(context.Background(), sb.Owner, token)
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// shouldCompete reports whether this instance should try to take the fence, probing the primary when configured.
+func (sb *Standby) shouldCompete(ctx context.Context, failures *int, threshold int) bool {
+	if sb.Probe == nil {
+		return true
+	}
+	if err := sb.Probe.Probe(ctx); err != nil {
+		*failures++
+		sb.Service.logger().WarnWith().Err(err).Int("failures", *failures).Msg("standby: primary probe failed")
+		return *failures >= threshold
+	}
+	*failures = 0
+	return false
+}
+
+// promote starts the Service after the fence has been acquired.
+func (sb *Standby) promote() error {
+	if err := sb.Service.Start(); err != nil {
+		sb.Service.logger().ErrorWith().Err(err).Msg("standby: failed to take over rig control")
+		return err
+	}
+	sb.active.Store(true)
+	if sb.Probe == nil {
+		sb.Service.logger().InfoWith().Str("owner", sb.Owner).Msg("standby: primary holds rig control")
+		return nil
+	}
+	sb.Service.logger().WarnWith().Str("owner", sb.Owner).Msg("standby: took over rig control")
+	sb.Service.notify(SeverityWarning, "Rig control taken over",
+		"This controller is now driving the rig because the primary stopped responding.",
+		"Check the primary controller.")
+	return nil
+}
+
+// demote stops the Service after the fence has been lost or released.
+func (sb *Standby) demote() {
+	sb.active.Store(false)
+	if err := sb.Service.Stop(); err != nil {
+		sb.Service.logger().ErrorWith().Err(err).Msg("standby: failed to stop rig control")
+	}
+}
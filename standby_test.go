@@ -0,0 +1,68 @@
+package cat
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyFence fails Acquire and Renew with the error set by the test.
+type flakyFence struct {
+	mu       sync.Mutex
+	renewErr error
+	renews   int
+}
+
+func (f *flakyFence) Acquire(context.Context, string, time.Duration) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return 1, f.renewErr
+}
+
+func (f *flakyFence) Renew(context.Context, string, uint64, time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.renews++
+	return f.renewErr
+}
+
+func (f *flakyFence) Release(context.Context, string, uint64) error { return nil }
+
+func (f *flakyFence) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.renewErr, f.renews = err, 0
+}
+
+func (f *flakyFence) renewCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.renews
+}
+
+func TestStandbyKeepsControlWhileFenceBusy(t *testing.T) {
+	service, _ := newReloadTestService(t)
+	service.dialer = func() (Transport, error) { return newFakeTransport(), nil }
+	fence := &flakyFence{}
+	sb := &Standby{Service: service, Fence: fence, Owner: "host-a", ProbeIntervalMS: 5, LeaseTTLMS: 60000}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sb.Run(ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+	require.Eventually(t, sb.Active, time.Second, 5*time.Millisecond)
+
+	fence.fail(errors.New("test").Msg("fence is locked by another host"))
+	require.Eventually(t, func() bool { return fence.renewCount() >= 3 }, time.Second, 5*time.Millisecond)
+	require.True(t, sb.Active(), "a busy fence does not give up a lease that is still valid")
+
+	fence.fail(errors.New("test").Err(ErrFenceLost))
+	require.Eventually(t, func() bool { return !sb.Active() }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,200 @@
+package cat
+
+import (
+	"container/list"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+// cachedValue is a single tag value together with the time it was last reported by the rig.
+type cachedValue struct {
+	Value   string
+	Updated time.Time
+}
+
+// cacheEntryOverhead approximates the memory used by one cache entry besides its tag and value: the map slot, the
+// LRU list element and the timestamp.
+const cacheEntryOverhead = 96
+
+// cacheEntry is an element of the cache's LRU list.
+type cacheEntry struct {
+	tag string
+	cachedValue
+	// version is the state version at which the value last changed.
+	version uint64
+}
+
+// stateCache holds the latest value seen for every tag. Waiters can block until the cache changes by selecting on
+// the channel returned from changed(), which is closed and replaced on every update.
+//
+// If a budget is set, the least recently updated or read tags are evicted once the cache holds more entries or more
+// (approximate) bytes than allowed. An evicted tag is simply read from the rig again when next needed.
+type stateCache struct {
+	mu      sync.RWMutex
+	values  map[string]*list.Element
+	lru     *list.List // front is the most recently used
+	changes chan struct{}
+
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	evicted    uint64
+
+	// version counts the updates that changed a value in its low 32 bits, and holds the cache's epoch in its high
+	// 32 bits, so that a version issued by another cache is never mistaken for one of this cache's. floor is the
+	// version of the last eviction: a reader behind it may hold tags that are no longer cached and must resync in
+	// full.
+	version uint64
+	floor   uint64
+}
+
+// epochShift is the position of the epoch in a state version.
+const epochShift = 32
+
+func newStateCache() *stateCache {
+	epoch := uint64(rand.Uint32()|1) << epochShift // never zero, so version 0 is never current
+	return &stateCache{
+		values:  make(map[string]*list.Element),
+		lru:     list.New(),
+		changes: make(chan struct{}),
+		version: epoch,
+		floor:   epoch,
+	}
+}
+
+// setBudget limits the cache to maxEntries tags and maxBytes approximate bytes. Zero means no limit.
+func (c *stateCache) setBudget(maxEntries, maxBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxEntries, c.maxBytes = max(maxEntries, 0), max(maxBytes, 0)
+	c.evict()
+}
+
+// update merges status into the cache, stamping every tag with the given time, and wakes any waiters.
+func (c *stateCache) update(status types.CatStatus, at time.Time) {
+	if len(status) == 0 {
+		return
+	}
+	c.mu.Lock()
+	next := c.version + 1
+	changed := false
+	for tag, value := range status {
+		if el, ok := c.values[tag]; ok {
+			entry := el.Value.(*cacheEntry)
+			c.bytes += len(value) - len(entry.Value)
+			if entry.Value != value {
+				entry.version, changed = next, true
+			}
+			entry.cachedValue = cachedValue{Value: value, Updated: at}
+			c.lru.MoveToFront(el)
+			continue
+		}
+		c.values[tag] = c.lru.PushFront(&cacheEntry{tag: tag, cachedValue: cachedValue{Value: value, Updated: at}, version: next})
+		c.bytes += entrySize(tag, value)
+		changed = true
+	}
+	if changed {
+		c.version = next
+	}
+	c.evict()
+	close(c.changes)
+	c.changes = make(chan struct{})
+	c.mu.Unlock()
+}
+
+// evict drops least recently used entries until the cache is within its budget. The caller holds mu.
+func (c *stateCache) evict() {
+	for c.lru.Len() > 0 && ((c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		entry := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.values, entry.tag)
+		c.bytes -= entrySize(entry.tag, entry.Value)
+		c.evicted++
+		c.floor = c.version
+	}
+}
+
+// entrySize approximates the memory used by a cache entry.
+func entrySize(tag, value string) int {
+	return len(tag) + len(value) + cacheEntryOverhead
+}
+
+// get returns the cached value for tag, if any, and marks it as recently used.
+func (c *stateCache) get(tag string) (cachedValue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.values[tag]
+	if !ok {
+		return cachedValue{}, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry).cachedValue, true
+}
+
+// changed returns a channel that is closed on the next update.
+func (c *stateCache) changed() <-chan struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.changes
+}
+
+// snapshot returns a copy of all cached values.
+func (c *stateCache) snapshot() types.CatStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(types.CatStatus, len(c.values))
+	for tag, el := range c.values {
+		out[tag] = el.Value.(*cacheEntry).Value
+	}
+	return out
+}
+
+// currentVersion returns the version of the last update that changed a value.
+func (c *stateCache) currentVersion() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// initialVersion returns the version of the cache before its first update.
+func (c *stateCache) initialVersion() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version >> epochShift << epochShift
+}
+
+// since returns the tags whose values changed after version, and the current version. full is set, and every
+// cached tag returned, if tags were evicted after version, or version is from the future or from another cache
+// (e.g. a previous service instance).
+func (c *stateCache) since(version uint64) (changed types.CatStatus, current uint64, full bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	full = version>>epochShift != c.version>>epochShift || version < c.floor || version > c.version
+	changed = make(types.CatStatus)
+	for tag, el := range c.values {
+		if entry := el.Value.(*cacheEntry); full || entry.version > version {
+			changed[tag] = entry.Value
+		}
+	}
+	return changed, c.version, full
+}
+
+// updatedTimes returns when each cached tag was last reported.
+func (c *stateCache) updatedTimes() map[string]time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]time.Time, len(c.values))
+	for tag, el := range c.values {
+		out[tag] = el.Value.(*cacheEntry).Updated
+	}
+	return out
+}
+
+// usage returns the number of cached tags, their approximate size and how many tags have been evicted so far.
+func (c *stateCache) usage() (entries, bytes int, evicted uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lru.Len(), c.bytes, c.evicted
+}
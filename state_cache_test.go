@@ -0,0 +1,106 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newStateCache()
+	c.setBudget(2, 0)
+	now := time.Now()
+
+	c.update(types.CatStatus{"VFOAFREQ": "014074000"}, now)
+	c.update(types.CatStatus{"VFOBFREQ": "007074000"}, now)
+	_, ok := c.get("VFOAFREQ") // VFOBFREQ is now the least recently used
+	require.True(t, ok)
+	c.update(types.CatStatus{"MAINMODE": "USB"}, now)
+
+	_, ok = c.get("VFOBFREQ")
+	require.False(t, ok)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "014074000", "MAINMODE": "USB"}, c.snapshot())
+	entries, _, evicted := c.usage()
+	require.Equal(t, 2, entries)
+	require.Equal(t, uint64(1), evicted)
+}
+
+func TestStateCacheByteBudget(t *testing.T) {
+	c := newStateCache()
+	c.setBudget(0, 2*entrySize("TAG1", "12345"))
+	now := time.Now()
+
+	c.update(types.CatStatus{"TAG1": "12345"}, now)
+	c.update(types.CatStatus{"TAG2": "12345"}, now)
+	_, bytes, evicted := c.usage()
+	require.Equal(t, 2*entrySize("TAG1", "12345"), bytes)
+	require.Zero(t, evicted)
+
+	// Growing a value pushes the cache over budget and evicts the other tag.
+	c.update(types.CatStatus{"TAG2": "1234567"}, now)
+	require.Equal(t, types.CatStatus{"TAG2": "1234567"}, c.snapshot())
+	_, bytes, _ = c.usage()
+	require.Equal(t, entrySize("TAG2", "1234567"), bytes)
+}
+
+func TestStateCacheUnboundedByDefault(t *testing.T) {
+	c := newStateCache()
+	for i := 0; i < 100; i++ {
+		c.update(types.CatStatus{string(rune('A'+i%26)) + string(rune('a'+i/26)): "x"}, time.Now())
+	}
+	entries, _, evicted := c.usage()
+	require.Equal(t, 100, entries)
+	require.Zero(t, evicted)
+}
+
+func TestStateSinceReturnsChangedTags(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	now := time.Now()
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000", "MAINMODE": "USB"}, now)
+
+	delta := service.StateSince(0)
+	require.True(t, delta.Full)
+	require.Len(t, delta.Changed, 2)
+	version := delta.Version
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000", "MAINMODE": "USB"}, now) // no change
+	require.Equal(t, StateDelta{Version: version, Changed: types.CatStatus{}}, service.StateSince(version))
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014076000", "MAINMODE": "USB"}, now)
+	delta = service.StateSince(version)
+	require.False(t, delta.Full)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "014076000"}, delta.Changed)
+	require.Greater(t, delta.Version, version)
+}
+
+func TestStateSinceResyncsFullyAfterEviction(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.cache.setBudget(2, 0)
+	service.cache.update(types.CatStatus{"A": "1", "B": "1"}, time.Now())
+	version := service.StateSince(0).Version
+
+	service.cache.update(types.CatStatus{"C": "1"}, time.Now()) // evicts A or B
+	delta := service.StateSince(version)
+	require.True(t, delta.Full)
+	require.Len(t, delta.Changed, 2)
+
+	require.True(t, service.StateSince(delta.Version+5).Full, "a version from the future")
+}
+
+func TestStateSinceResyncsFullyFromAnotherInstance(t *testing.T) {
+	previous := newStateCache()
+	for _, freq := range []string{"014074000", "014076000", "014078000"} {
+		previous.update(types.CatStatus{"VFOAFREQ": freq}, time.Now())
+	}
+	_, stale, _ := previous.since(0)
+
+	service := newStartedTestService(t, &types.RigConfig{})
+	for _, freq := range []string{"014074000", "014076000", "014078000", "014080000", "014082000"} {
+		service.cache.update(types.CatStatus{"VFOAFREQ": freq, "MAINMODE": "USB"}, time.Now())
+	}
+	delta := service.StateSince(stale)
+	require.True(t, delta.Full, "a version of an earlier cache is never current, even if it is behind this one")
+	require.Len(t, delta.Changed, 2)
+}
@@ -0,0 +1,224 @@
+package cat
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+// EventStationStep is the kind of StationStepEvent.
+const EventStationStep EventKind = "STATION_STEP"
+
+const (
+	// defaultStationCheckTimeoutMS is used when StationCheck.TimeoutMS is zero.
+	defaultStationCheckTimeoutMS = 5000
+	// stationCheckInterval is the pause between attempts of a station check.
+	stationCheckInterval = 250 * time.Millisecond
+	// stationRollbackTimeout bounds the rollback of one step, which is not cut short by the sequence's context.
+	stationRollbackTimeout = 5 * time.Second
+)
+
+// StationSequence names a station sequence.
+type StationSequence string
+
+const (
+	StationPowerUp   StationSequence = "POWER_UP"
+	StationPowerDown StationSequence = "POWER_DOWN"
+)
+
+// String implements fmt.Stringer.
+func (q StationSequence) String() string {
+	return string(q)
+}
+
+// StationStepStatus is what happened to a station step.
+type StationStepStatus string
+
+const (
+	// StationStepDone means the step passed its checkpoint.
+	StationStepDone StationStepStatus = "DONE"
+	// StationStepFailed means the step failed and the sequence is rolled back.
+	StationStepFailed StationStepStatus = "FAILED"
+	// StationStepRolledBack means the rollback commands of the step were sent.
+	StationStepRolledBack StationStepStatus = "ROLLED_BACK"
+)
+
+// StationStepEvent is emitted as a station sequence progresses. Err is set for failed steps and failed rollbacks.
+type StationStepEvent struct {
+	At       time.Time
+	Sequence StationSequence
+	Step     string
+	Status   StationStepStatus
+	Err      string
+}
+
+func (e StationStepEvent) Kind() EventKind { return EventStationStep }
+func (e StationStepEvent) Time() time.Time { return e.At }
+
+// profileSetters are the tags of a profile applied by a station step, with the commands that set them.
+var profileSetters = []struct {
+	tag  tags.CatStateTag
+	name cmds.CatCmdName
+}{
+	{tags.VfoAFreq, CmdSetVfoAFreq},
+	{tags.VfoBFreq, CmdSetVfoBFreq},
+	{tags.MainMode, CmdSetMainMode},
+	{tags.TxPwr, CmdSetTxPower},
+}
+
+// PowerUpStation runs the steps of Options.Station.PowerUp in order. If a step fails, the rollback commands of it
+// and of the steps before it are sent in reverse order and the error names the failed step.
+func (s *Service) PowerUpStation(ctx context.Context) error {
+	const op errors.Op = "cat.Service.PowerUpStation"
+	if err := s.runStationSequence(ctx, StationPowerUp, s.Options.Station.PowerUp); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// PowerDownStation runs the steps of Options.Station.PowerDown in order, rolling back as PowerUpStation does.
+func (s *Service) PowerDownStation(ctx context.Context) error {
+	const op errors.Op = "cat.Service.PowerDownStation"
+	if err := s.runStationSequence(ctx, StationPowerDown, s.Options.Station.PowerDown); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// runStationSequence runs steps, rolling back the steps run so far if one fails. Only one sequence runs at a time.
+func (s *Service) runStationSequence(ctx context.Context, seq StationSequence, steps []StationStep) error {
+	const op errors.Op = "cat.Service.runStationSequence"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	if len(steps) == 0 {
+		return errors.New(op).Msgf("no %s sequence is configured", seq)
+	}
+	if !s.stationBusy.CompareAndSwap(false, true) {
+		return errors.New(op).Msg("A station sequence is already running.")
+	}
+	defer s.stationBusy.Store(false)
+
+	s.logger().InfoWith().Str("sequence", seq.String()).Int("steps", len(steps)).Msg("station sequence started")
+	for i, step := range steps {
+		if err := s.runStationStep(ctx, step); err != nil {
+			s.emitEvent(StationStepEvent{At: time.Now(), Sequence: seq, Step: step.Name, Status: StationStepFailed, Err: err.Error()})
+			s.logger().ErrorWith().Err(err).Str("sequence", seq.String()).Str("step", step.Name).Msg("station step failed, rolling back")
+			s.rollbackStation(ctx, seq, steps[:i+1])
+			return errors.New(op).Err(err).Msgf("%s step %s failed", seq, step.Name)
+		}
+		s.emitEvent(StationStepEvent{At: time.Now(), Sequence: seq, Step: step.Name, Status: StationStepDone})
+	}
+	s.logger().InfoWith().Str("sequence", seq.String()).Msg("station sequence completed")
+	return nil
+}
+
+// runStationStep sends the commands of step, applies its profile, waits and runs its checkpoint.
+func (s *Service) runStationStep(ctx context.Context, step StationStep) error {
+	const op errors.Op = "cat.Service.runStationStep"
+	if len(step.Commands) > 0 {
+		if err := s.runBatch(ctx, step.Commands); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	if step.Profile != "" {
+		if err := s.applyProfile(ctx, step.Profile); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	if err := pause(ctx, step.WaitMS*time.Millisecond); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if step.Check != nil {
+		if err := s.stationCheck(ctx, *step.Check); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+	return nil
+}
+
+// applyProfile sends the frequency, mode and power saved in the profile called name, for the commands the rig
+// definition provides. The profile holds display values, which are mapped back to the rig's values.
+func (s *Service) applyProfile(ctx context.Context, name string) error {
+	const op errors.Op = "cat.Service.applyProfile"
+	profile, err := s.Profile(name)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	var requests []CatCommandRequest
+	for _, set := range profileSetters {
+		value, ok := profile[set.tag.String()]
+		if !ok {
+			continue
+		}
+		if _, err = s.commandLookup(set.name); err != nil {
+			continue
+		}
+		if value, err = s.encodeMappedValue(set.tag, value); err != nil {
+			return errors.New(op).Err(err).Msgf("profile %s cannot be applied", name)
+		}
+		requests = append(requests, CatCommandRequest{Name: set.name, Params: []string{value}})
+	}
+	if len(requests) == 0 {
+		return errors.New(op).Msgf("profile %s has nothing to apply", name)
+	}
+	if err = s.runBatch(ctx, requests); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// stationCheck sends the command of check until its reply reports the expected value, or until the check times
+// out.
+func (s *Service) stationCheck(ctx context.Context, check StationCheck) error {
+	const op errors.Op = "cat.Service.stationCheck"
+	ctx, cancel := context.WithTimeout(ctx, durationOrDefault(check.TimeoutMS, defaultStationCheckTimeoutMS))
+	defer cancel()
+
+	attempt := durationOrDefault(s.Options.ResponseTimeoutMS, defaultResponseTimeoutMS)
+	last := "no reply"
+	for {
+		attemptCtx, cancelAttempt := context.WithTimeout(ctx, attempt)
+		status, err := s.SendCommand(attemptCtx, check.Command)
+		cancelAttempt()
+		if err == nil {
+			value, ok := status[check.Tag]
+			if ok && strings.TrimSpace(value) == check.Value {
+				return nil
+			}
+			last = "reported " + strings.TrimSpace(value)
+			if !ok {
+				last = "did not report " + check.Tag
+			}
+		}
+		if err = pause(ctx, stationCheckInterval); err != nil {
+			return errors.New(op).Msgf("%s: %s %s, expected %s", check.Command, check.Tag, last, check.Value)
+		}
+	}
+}
+
+// rollbackStation sends the rollback commands of steps in reverse order. It goes on if ctx is done, so that a
+// cancelled sequence does not leave the station half powered, but gives each step stationRollbackTimeout so that a
+// stopped Service cannot hold it up; failures are logged and reported as events.
+func (s *Service) rollbackStation(ctx context.Context, seq StationSequence, steps []StationStep) {
+	ctx = context.WithoutCancel(ctx)
+	for _, step := range slices.Backward(steps) {
+		if len(step.Rollback) == 0 {
+			continue
+		}
+		event := StationStepEvent{At: time.Now(), Sequence: seq, Step: step.Name, Status: StationStepRolledBack}
+		stepCtx, cancel := context.WithTimeout(ctx, stationRollbackTimeout)
+		err := s.runBatch(stepCtx, step.Rollback)
+		cancel()
+		if err != nil {
+			s.logger().ErrorWith().Err(err).Str("sequence", seq.String()).Str("step", step.Name).Msg("station rollback failed")
+			event.Err = err.Error()
+		}
+		s.emitEvent(event)
+	}
+}
@@ -0,0 +1,111 @@
+package cat
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newStationTestService(t *testing.T, replies map[string]string) (*Service, *answeringTransport) {
+	t.Helper()
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "POWERON", Cmd: "PS1;"},
+			{Name: "POWEROFF", Cmd: "PS0;"},
+			{Name: "READPOWER", Cmd: "PS;"},
+			{Name: "AMPON", Cmd: "EX0850000 1;"},
+			{Name: "AMPOFF", Cmd: "EX0850000 0;"},
+			{Name: "TUNE", Cmd: "AC111;"},
+			{Name: "READTUNER", Cmd: "AC;"},
+			{Name: "SETVFOAFREQ", Cmd: "FA%s;"},
+			{Name: "SETMAINMODE", Cmd: "MD%s;"},
+		},
+		CatStates: []types.CatState{
+			{Prefix: "PS", Markers: []types.Marker{{Tag: "POWERSTATE", Index: 0, Length: 1}}},
+			{Prefix: "AC", Markers: []types.Marker{{Tag: "TUNER", Index: 0, Length: 3}}},
+			{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: []types.ValueMapping{
+				{Key: "1", Value: "LSB"}, {Key: "2", Value: "USB"},
+			}}}},
+		},
+	})
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		if frame, ok := replies[cmd]; ok {
+			state, _ := service.lookupCatState([]byte(frame))
+			service.deliverToWaiters(state, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	return service, rig
+}
+
+func stationEvents(service *Service) []StationStepEvent {
+	var events []StationStepEvent
+	for len(service.eventChannel) > 0 {
+		if e, ok := (<-service.eventChannel).(StationStepEvent); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestPowerUpStationRunsStepsAndAppliesProfile(t *testing.T) {
+	service, rig := newStationTestService(t, map[string]string{"PS;": "PS1;"})
+	service.Store = &FileStore{Dir: t.TempDir()}
+	data, err := json.Marshal(types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "USB"})
+	require.NoError(t, err)
+	require.NoError(t, service.Store.Save(storeProfilePrefix+"ft8", data))
+
+	service.Options.Station.PowerUp = []StationStep{
+		{Name: "rig", Commands: []CatCommandRequest{{Name: "POWERON"}}, Check: &StationCheck{Command: "READPOWER", Tag: "POWERSTATE", Value: "1"}, Rollback: []CatCommandRequest{{Name: "POWEROFF"}}},
+		{Name: "amplifier", Commands: []CatCommandRequest{{Name: "AMPON"}}, WaitMS: 1},
+		{Name: "profile", Profile: "ft8"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.PowerUpStation(ctx))
+	require.Equal(t, []string{"PS1;", "PS;", "EX0850000 1;", "FA00014074000;", "MD2;"}, rig.writes(), "the profile's mode is mapped back to the rig's value")
+
+	events := stationEvents(service)
+	require.Len(t, events, 3)
+	for _, e := range events {
+		require.Equal(t, StationPowerUp, e.Sequence)
+		require.Equal(t, StationStepDone, e.Status)
+	}
+}
+
+func TestPowerUpStationRollsBackOnFailedCheck(t *testing.T) {
+	service, rig := newStationTestService(t, map[string]string{"PS;": "PS1;", "AC;": "AC110;"})
+	service.Options.Station.PowerUp = []StationStep{
+		{Name: "rig", Commands: []CatCommandRequest{{Name: "POWERON"}}, Rollback: []CatCommandRequest{{Name: "POWEROFF"}}},
+		{Name: "amplifier", Commands: []CatCommandRequest{{Name: "AMPON"}}, Rollback: []CatCommandRequest{{Name: "AMPOFF"}}},
+		{Name: "tuner", Commands: []CatCommandRequest{{Name: "TUNE"}}, Check: &StationCheck{Command: "READTUNER", Tag: "TUNER", Value: "111", TimeoutMS: 100}},
+	}
+	err := service.PowerUpStation(context.Background())
+	require.ErrorContains(t, errors.Root(err), "TUNER reported 110, expected 111")
+
+	writes := rig.writes()
+	require.Equal(t, []string{"PS1;", "EX0850000 1;", "AC111;", "AC;"}, writes[:4])
+	require.Equal(t, []string{"EX0850000 0;", "PS0;"}, writes[len(writes)-2:], "rollback runs in reverse order")
+
+	events := stationEvents(service)
+	require.Equal(t, StationStepFailed, events[2].Status)
+	require.Equal(t, "tuner", events[2].Step)
+	require.Equal(t, StationStepRolledBack, events[3].Status)
+	require.Equal(t, "amplifier", events[3].Step)
+	require.Equal(t, "rig", events[4].Step)
+}
+
+func TestStationSequenceRequiresSteps(t *testing.T) {
+	service, _ := newStationTestService(t, nil)
+	require.Error(t, service.PowerDownStation(context.Background()))
+
+	service.stationBusy.Store(true)
+	service.Options.Station.PowerDown = []StationStep{{Name: "rig", Commands: []CatCommandRequest{{Name: "POWEROFF"}}}}
+	require.Error(t, service.PowerDownStation(context.Background()), "only one sequence runs at a time")
+}
@@ -0,0 +1,68 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatusDiffTestService(t *testing.T, heartbeatMS time.Duration) *Service {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatStates: []types.CatState{{Prefix: "IF", Markers: []types.Marker{
+			{Tag: "VFOAFREQ", Index: 0, Length: 11},
+			{Tag: "MAINMODE", Index: 11, Length: 1},
+		}}},
+	})
+	service.Options.StatusDiff = StatusDiffOptions{Enabled: true, HeartbeatMS: heartbeatMS}
+	service.statusChannel = make(chan types.CatStatus, 8)
+	service.processingChannel = make(chan receivedState, 4)
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"lineProcessor": service.lineProcessor})
+	return service
+}
+
+func receiveStatus(t *testing.T, service *Service) types.CatStatus {
+	t.Helper()
+	select {
+	case status := <-service.statusChannel:
+		return status
+	case <-time.After(time.Second):
+		t.Fatal("no status emitted")
+		return nil
+	}
+}
+
+func TestStatusDiffEmitsChangedFieldsOnly(t *testing.T) {
+	service := newStatusDiffTestService(t, 0)
+	markers := service.supportedCatStates["IF"].Markers
+
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "IF", Data: "000140740002", Markers: markers}}
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "2"}, receiveStatus(t, service))
+
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "IF", Data: "000140740002", Markers: markers}}
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "IF", Data: "000140760002", Markers: markers}}
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014076000"}, receiveStatus(t, service))
+	require.Equal(t, uint64(2), service.counters.statusesEmitted.Load())
+}
+
+func TestStatusDiffHeartbeat(t *testing.T) {
+	service := newStatusDiffTestService(t, 20)
+	markers := service.supportedCatStates["IF"].Markers
+
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "IF", Data: "000140740002", Markers: markers}}
+	receiveStatus(t, service)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014074000", "MAINMODE": "2"}, receiveStatus(t, service))
+}
+
+func TestStatusDiffMergesEvictedDiffs(t *testing.T) {
+	service := newStatusDiffTestService(t, 0)
+	service.statusChannel = make(chan types.CatStatus, 1)
+	markers := service.supportedCatStates["IF"].Markers
+
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "IF", Data: "000140740002", Markers: markers}}
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "IF", Data: "000140760002", Markers: markers}}
+	require.Eventually(t, func() bool { return service.counters.statusesEmitted.Load() == 2 }, time.Second, 5*time.Millisecond)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "00014076000", "MAINMODE": "2"}, receiveStatus(t, service),
+		"the mode of the evicted diff is not lost")
+}
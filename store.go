@@ -0,0 +1,121 @@
+package cat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Station-Manager/errors"
+)
+
+// Store keys used by the persistence features.
+const (
+	storeKeyLastState  = "state/last"
+	storeKeyAuditLog   = "audit/commands"
+	storeKeyQSYHistory = "history/qsy"
+	storeProfilePrefix = "profiles/"
+)
+
+// Store is the persistence backend for the last known rig state, profiles, the command audit log and the QSY
+// history. Embedders can back it with their own database; FileStore is the default implementation.
+type Store interface {
+	// Load returns the value saved under key, or an error wrapping errors.ErrNotFound if there is none.
+	Load(key string) ([]byte, error)
+	// Save replaces the value under key.
+	Save(key string, data []byte) error
+	// Append adds a record to the log under key.
+	Append(key string, record []byte) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// FileStore is a Store that keeps one file per key below Dir. Keys may contain '/' to create sub-directories.
+type FileStore struct {
+	Dir string
+}
+
+// path maps a key to a file below Dir, refusing keys that would escape it.
+func (f *FileStore) path(key string) (string, error) {
+	const op errors.Op = "cat.FileStore.path"
+	if f.Dir == "" {
+		return "", errors.New(op).Msg("file store directory is not set")
+	}
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errors.New(op).Msgf("invalid store key %q", key)
+	}
+	return filepath.Join(f.Dir, clean), nil
+}
+
+// Load implements Store.
+func (f *FileStore) Load(key string) ([]byte, error) {
+	const op errors.Op = "cat.FileStore.Load"
+	p, err := f.path(key)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, errors.New(op).Err(errors.ErrNotFound).Msgf("store key %q not found", key)
+	}
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	return data, nil
+}
+
+// Save implements Store. The value is written to a temporary file first so a crash never leaves it half written.
+func (f *FileStore) Save(key string, data []byte) error {
+	const op errors.Op = "cat.FileStore.Save"
+	p, err := f.path(key)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return errors.New(op).Err(err)
+	}
+	tmp := p + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = os.Rename(tmp, p); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// Append implements Store, writing each record on its own line.
+func (f *FileStore) Append(key string, record []byte) error {
+	const op errors.Op = "cat.FileStore.Append"
+	p, err := f.path(key)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return errors.New(op).Err(err)
+	}
+	file, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	line := append(append([]byte(nil), record...), '\n')
+	if _, err = file.Write(line); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (f *FileStore) Delete(key string) error {
+	const op errors.Op = "cat.FileStore.Delete"
+	p, err := f.path(key)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
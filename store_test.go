@@ -0,0 +1,103 @@
+package cat
+
+import (
+	stderr "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := &FileStore{Dir: t.TempDir()}
+
+	_, err := store.Load("state/last")
+	require.True(t, stderr.Is(err, errors.ErrNotFound))
+
+	require.NoError(t, store.Save("state/last", []byte(`{"a":"b"}`)))
+	data, err := store.Load("state/last")
+	require.NoError(t, err)
+	require.Equal(t, `{"a":"b"}`, string(data))
+
+	require.NoError(t, store.Append("audit/commands", []byte("one")))
+	require.NoError(t, store.Append("audit/commands", []byte("two")))
+	data, err = os.ReadFile(filepath.Join(store.Dir, "audit", "commands"))
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\n", string(data))
+
+	require.NoError(t, store.Delete("state/last"))
+	require.NoError(t, store.Delete("state/last"))
+	require.Error(t, store.Save("../escape", nil))
+}
+
+func TestProfilesUseStore(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Store = &FileStore{Dir: t.TempDir()}
+	service.cache.update(types.CatStatus{"VFOAFREQ": "007074000", "MAINMODE": "DATA-U"}, time.Now())
+
+	require.NoError(t, service.SaveProfile("ft8-40m"))
+	profile, err := service.Profile("ft8-40m")
+	require.NoError(t, err)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "007074000", "MAINMODE": "DATA-U"}, profile)
+}
+
+func TestLastStateSavedWhileRunning(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Store = &FileStore{Dir: t.TempDir()}
+	service.Options.Persistence = PersistenceOptions{LastState: true, LastStateIntervalMS: 20}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"stateSaver": service.stateSaver})
+
+	service.cache.update(types.CatStatus{"VFOAFREQ": "007074000"}, time.Now())
+	service.cache.update(types.CatStatus{"VFOAFREQ": "014074000"}, time.Now())
+	require.Eventually(t, func() bool {
+		saved, err := service.LastSavedState()
+		return err == nil && saved["VFOAFREQ"] == "014074000"
+	}, time.Second, 5*time.Millisecond, "the burst is saved once it settles")
+}
+
+// blockingStore is a Store whose appends wait until release is closed.
+type blockingStore struct {
+	FileStore
+	release chan struct{}
+}
+
+func (b *blockingStore) Append(key string, record []byte) error {
+	<-b.release
+	return b.FileStore.Append(key, record)
+}
+
+func TestAuditLogDoesNotBlockTheSender(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{CatCommands: []types.CatCommand{{Name: "READFREQ", Cmd: "FA;"}}})
+	store := &blockingStore{FileStore: FileStore{Dir: t.TempDir()}, release: make(chan struct{})}
+	service.Store = store
+	service.Options.Persistence = PersistenceOptions{AuditLog: true, QueueSize: 2}
+	service.storeChannel = newStoreChannel(service.Options.Persistence)
+	fake := startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortSender": service.serialPortSender,
+		"storeWriter":      service.storeWriter,
+	})
+
+	var once sync.Once
+	release := func() { once.Do(func() { close(store.release) }) }
+	t.Cleanup(release)
+
+	for range 6 {
+		require.NoError(t, service.EnqueueCommand("READFREQ"))
+	}
+	require.Eventually(t, func() bool { return len(fake.writes()) == 6 }, time.Second, time.Millisecond, "the sender does not wait on the Store")
+	// Two records are queued, and one may be held by the blocked writer; the rest are dropped.
+	dropped := service.counters.storeDropped.Load()
+	require.GreaterOrEqual(t, dropped, uint64(3))
+
+	release()
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(filepath.Join(store.Dir, storeKeyAuditLog))
+		return err == nil && uint64(strings.Count(string(data), "\n")) == 6-dropped
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,190 @@
+package cat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+const (
+	// defaultSubscriptionSize is used when Subscribe is called with a non-positive size.
+	defaultSubscriptionSize = 8
+	// broadcastQueueSize bounds the statuses waiting to be fanned out to the subscribers.
+	broadcastQueueSize = 16
+)
+
+// subscribers holds the channels handed out by Subscribe.
+type subscribers struct {
+	mu    sync.Mutex
+	chans map[<-chan types.CatStatus]*subscriber
+}
+
+// subscriber is one channel returned by Subscribe, with its per-tag throttling state. The throttling state is only
+// used by the broadcaster, under subscribers.mu.
+type subscriber struct {
+	ch           chan types.CatStatus
+	minIntervals map[string]time.Duration
+	lastSent     map[string]time.Time
+	// pending holds the latest throttled value of each tag, delivered once its interval has elapsed.
+	pending types.CatStatus
+}
+
+// SubscriptionOptions configures a subscription.
+type SubscriptionOptions struct {
+	// Size is the buffer size of the channel.
+	//
+	// Default is 8.
+	Size int
+	// MinIntervalMS is the minimum interval between two updates of a tag, e.g. 100ms for the S-meter, so that
+	// frontends do not need to throttle themselves. Changes within the interval are coalesced and the latest value
+	// is delivered when it elapses. Tags without an entry are delivered immediately. The unit is milliseconds.
+	MinIntervalMS map[string]time.Duration
+}
+
+// Subscribe returns a new channel receiving every status update, independently of StatusChannel and of other
+// subscribers. Each subscriber gets its own buffer of size updates (8 if size is not positive); when a subscriber
+// falls behind, its oldest update is dropped so that it never stalls the others. Call Unsubscribe when done.
+func (s *Service) Subscribe(size int) (<-chan types.CatStatus, error) {
+	return s.SubscribeWith(SubscriptionOptions{Size: size})
+}
+
+// SubscribeWith behaves like Subscribe, additionally throttling individual tags as set in opts.
+func (s *Service) SubscribeWith(opts SubscriptionOptions) (<-chan types.CatStatus, error) {
+	const op errors.Op = "cat.Service.SubscribeWith"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = defaultSubscriptionSize
+	}
+
+	sub := &subscriber{ch: make(chan types.CatStatus, size)}
+	for tag, interval := range opts.MinIntervalMS {
+		if interval <= 0 {
+			continue
+		}
+		if sub.minIntervals == nil {
+			sub.minIntervals = make(map[string]time.Duration)
+			sub.lastSent = make(map[string]time.Time)
+			sub.pending = make(types.CatStatus)
+		}
+		sub.minIntervals[tag] = interval * time.Millisecond
+	}
+
+	s.subscribers.mu.Lock()
+	defer s.subscribers.mu.Unlock()
+	if s.subscribers.chans == nil {
+		s.subscribers.chans = make(map[<-chan types.CatStatus]*subscriber)
+	}
+	s.subscribers.chans[sub.ch] = sub
+	return sub.ch, nil
+}
+
+// Unsubscribe stops delivery to a channel returned by Subscribe and closes it. Unknown channels are ignored.
+func (s *Service) Unsubscribe(ch <-chan types.CatStatus) {
+	s.subscribers.mu.Lock()
+	defer s.subscribers.mu.Unlock()
+	if sub, ok := s.subscribers.chans[ch]; ok {
+		delete(s.subscribers.chans, ch)
+		close(sub.ch)
+	}
+}
+
+// hasSubscribers reports whether any channel returned by Subscribe is still open.
+func (s *Service) hasSubscribers() bool {
+	s.subscribers.mu.Lock()
+	defer s.subscribers.mu.Unlock()
+	return len(s.subscribers.chans) > 0
+}
+
+// offerToSubscribers queues status for the broadcaster without blocking the processor.
+func (s *Service) offerToSubscribers(status types.CatStatus) {
+	if s.hasSubscribers() {
+		offerEvicting(s.broadcastChannel, status)
+	}
+}
+
+// statusBroadcaster fans queued statuses out to the subscribers, and delivers throttled values when their
+// interval has elapsed.
+func (s *Service) statusBroadcaster(shutdown <-chan struct{}) {
+	flush := time.NewTimer(time.Hour)
+	defer flush.Stop()
+	for {
+		var status types.CatStatus
+		select {
+		case <-shutdown:
+			return
+		case status = <-s.broadcastChannel:
+		case <-flush.C:
+		}
+
+		now := time.Now()
+		var next time.Time
+		s.subscribers.mu.Lock()
+		for _, sub := range s.subscribers.chans {
+			if out := sub.throttle(status, now); len(out) > 0 {
+				offerEvicting(sub.ch, out)
+			}
+			if due := sub.nextDue(); !due.IsZero() && (next.IsZero() || due.Before(next)) {
+				next = due
+			}
+		}
+		s.subscribers.mu.Unlock()
+
+		if !flush.Stop() {
+			select {
+			case <-flush.C:
+			default:
+			}
+		}
+		if next.IsZero() {
+			flush.Reset(time.Hour)
+		} else {
+			flush.Reset(max(time.Until(next), 0))
+		}
+	}
+}
+
+// throttle returns the fields of status, plus any pending values that are due, that may be delivered at now.
+// Throttled fields that arrive too early are kept as pending.
+func (sub *subscriber) throttle(status types.CatStatus, now time.Time) types.CatStatus {
+	if sub.minIntervals == nil {
+		return status
+	}
+	out := make(types.CatStatus, len(status))
+	for tag, value := range sub.pending {
+		if !now.Before(sub.lastSent[tag].Add(sub.minIntervals[tag])) {
+			out[tag] = value
+			sub.lastSent[tag] = now
+			delete(sub.pending, tag)
+		}
+	}
+	for tag, value := range status {
+		interval, ok := sub.minIntervals[tag]
+		switch {
+		case !ok:
+			out[tag] = value
+		case now.Before(sub.lastSent[tag].Add(interval)):
+			sub.pending[tag] = value
+		default:
+			out[tag] = value
+			sub.lastSent[tag] = now
+			delete(sub.pending, tag)
+		}
+	}
+	return out
+}
+
+// nextDue returns when the earliest pending value may be delivered, or the zero time if nothing is pending.
+func (sub *subscriber) nextDue() time.Time {
+	var next time.Time
+	for tag := range sub.pending {
+		if due := sub.lastSent[tag].Add(sub.minIntervals[tag]); next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	return next
+}
@@ -0,0 +1,101 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newSubscriptionTestService(t *testing.T) *Service {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatStates: []types.CatState{{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}}},
+	})
+	service.statusChannel = make(chan types.CatStatus, 1)
+	service.broadcastChannel = make(chan types.CatStatus, broadcastQueueSize)
+	service.processingChannel = make(chan receivedState, 4)
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"lineProcessor":     service.lineProcessor,
+		"statusBroadcaster": service.statusBroadcaster,
+	})
+	return service
+}
+
+func TestSubscribersEachReceiveEveryStatus(t *testing.T) {
+	service := newSubscriptionTestService(t)
+	ui, err := service.Subscribe(4)
+	require.NoError(t, err)
+	logger, err := service.Subscribe(0)
+	require.NoError(t, err)
+
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "FA", Data: "00014074000", Markers: service.supportedCatStates["FA"].Markers}}
+
+	for _, ch := range []<-chan types.CatStatus{ui, logger} {
+		select {
+		case status := <-ch:
+			require.Equal(t, "00014074000", status["VFOAFREQ"])
+		case <-time.After(time.Second):
+			t.Fatal("subscriber did not receive the status")
+		}
+	}
+}
+
+func TestSlowSubscriberKeepsLatest(t *testing.T) {
+	service := newSubscriptionTestService(t)
+	slow, err := service.Subscribe(1)
+	require.NoError(t, err)
+
+	markers := service.supportedCatStates["FA"].Markers
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "FA", Data: "00014074000", Markers: markers}}
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "FA", Data: "00007074000", Markers: markers}}
+
+	require.Eventually(t, func() bool { return service.counters.statusesEmitted.Load() == 2 }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		select {
+		case status := <-slow:
+			return status["VFOAFREQ"] == "00007074000"
+		default:
+			return false
+		}
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestUnsubscribeClosesChannel(t *testing.T) {
+	service := newSubscriptionTestService(t)
+	ch, err := service.Subscribe(1)
+	require.NoError(t, err)
+
+	service.Unsubscribe(ch)
+	_, ok := <-ch
+	require.False(t, ok)
+	require.False(t, service.hasSubscribers())
+	service.Unsubscribe(ch) // unknown channels are ignored
+
+	_, err = (&Service{}).Subscribe(1)
+	require.Error(t, err)
+}
+
+func TestSubscriptionMinIntervalCoalescesTag(t *testing.T) {
+	service := newSubscriptionTestService(t)
+	ch, err := service.SubscribeWith(SubscriptionOptions{Size: 8, MinIntervalMS: map[string]time.Duration{"SMETER": 100}})
+	require.NoError(t, err)
+
+	service.offerToSubscribers(types.CatStatus{"SMETER": "5", "VFOAFREQ": "014074000"})
+	require.Equal(t, types.CatStatus{"SMETER": "5", "VFOAFREQ": "014074000"}, <-ch)
+
+	sent := time.Now()
+	service.offerToSubscribers(types.CatStatus{"SMETER": "6", "VFOAFREQ": "014074010"})
+	service.offerToSubscribers(types.CatStatus{"SMETER": "7"})
+
+	// The unthrottled tag is delivered at once; the S-meter only once its interval has elapsed, with the latest value.
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "014074010"}, <-ch)
+	select {
+	case status := <-ch:
+		require.Equal(t, types.CatStatus{"SMETER": "7"}, status)
+		require.GreaterOrEqual(t, time.Since(sent), 90*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("throttled value was not delivered")
+	}
+	require.Empty(t, ch)
+}
@@ -0,0 +1,80 @@
+package cat
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+// EventInferred is the kind of InferredEvent.
+const EventInferred EventKind = "INFERRED"
+
+// InferredEvent precedes a status whose values were inferred from a command sent rather than reported by the rig;
+// see Options.SyntheticStates. The status itself carries only the tags, as one from the rig would.
+type InferredEvent struct {
+	At time.Time
+	// Tags are the tags of the status that were inferred, sorted.
+	Tags []string
+}
+
+func (e InferredEvent) Kind() EventKind { return EventInferred }
+func (e InferredEvent) Time() time.Time { return e.At }
+
+// syntheticFor returns the status inferred from req once it is written, or nil if no synthetic state is configured
+// for its command.
+func (s *Service) syntheticFor(req *commandRequest) types.CatStatus {
+	if len(req.params) == 0 {
+		return nil
+	}
+	for _, synth := range s.Options.SyntheticStates {
+		if !strings.EqualFold(synth.Command.String(), req.name.String()) {
+			continue
+		}
+		tag := synth.Tag
+		if tag == "" {
+			var ok bool
+			if tag, ok = s.commandTag(req.name); !ok {
+				continue
+			}
+		}
+		// Report the display value, as a frame from the rig would.
+		value := req.reportedParams()[0]
+		if marker, ok := s.markerFor(tag); ok {
+			if mapped, err := mapMarkerValue(marker, value, false); err == nil && mapped != "" {
+				value = mapped
+			}
+		}
+		return types.CatStatus{tag.String(): value}
+	}
+	return nil
+}
+
+// inferWritten hands the status inferred from cmd to the processor, which emits it like a frame from the rig.
+func (s *Service) inferWritten(shutdown <-chan struct{}, cmd queuedCommand) {
+	if cmd.synthetic == nil {
+		return
+	}
+	select {
+	case <-shutdown:
+	case s.processingChannel <- receivedState{synthetic: cmd.synthetic, received: time.Now()}:
+	default:
+		s.logger().DebugWith().Str("cmd", cmd.Name).Msg("dropping inferred state: processing channel full")
+	}
+}
+
+// processSynthetic caches and emits an inferred status, after an InferredEvent listing its tags. It returns false
+// if shutdown was signaled.
+func (s *Service) processSynthetic(status types.CatStatus, shutdown <-chan struct{}) bool {
+	status = maps.Clone(status)
+	s.cache.update(status, time.Now())
+	if s.Options.StatusDiff.Enabled {
+		if status = s.changedFields(status); len(status) == 0 {
+			return true
+		}
+	}
+	s.emitEvent(InferredEvent{At: time.Now(), Tags: slices.Sorted(maps.Keys(status))})
+	return s.emitStatus(status, shutdown)
+}
@@ -0,0 +1,38 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntheticStateFromWrittenCommand(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: CmdSetMainMode.String(), Cmd: "MD%s;"},
+			{Name: CmdSetTxPower.String(), Cmd: "PC%s;"},
+		},
+		CatStates: []types.CatState{{Prefix: "MD", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1,
+			ValueMappings: []types.ValueMapping{{Key: "2", Value: "USB"}}}}}},
+	})
+	service.Options.SyntheticStates = []SyntheticState{{Command: CmdSetMainMode}, {Command: "SETTXPWR", Tag: "POWER"}}
+	service.statusChannel = make(chan types.CatStatus, 8)
+	service.processingChannel = make(chan receivedState, 4)
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){
+		"serialPortSender": service.serialPortSender,
+		"lineProcessor":    service.lineProcessor,
+	})
+
+	require.NoError(t, service.EnqueueCommand(CmdSetMainMode, "2"))
+	require.Equal(t, types.CatStatus{"MAINMODE": "USB"}, receiveStatus(t, service))
+	require.Equal(t, []string{"MAINMODE"}, (<-service.eventChannel).(InferredEvent).Tags)
+	mode, ok := service.cache.get(tags.MainMode.String())
+	require.True(t, ok)
+	require.Equal(t, "USB", mode.Value)
+
+	require.NoError(t, service.EnqueueCommand(CmdSetTxPower, "050"))
+	require.Equal(t, types.CatStatus{"POWER": "050"}, receiveStatus(t, service))
+	require.Equal(t, []string{"POWER"}, (<-service.eventChannel).(InferredEvent).Tags)
+}
@@ -0,0 +1,217 @@
+package cat
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// defaultTopicSize is used when SubscribeTopic is called with a non-positive size.
+const defaultTopicSize = 16
+
+// Last segments of the topics that do not carry a single tag.
+const (
+	TopicSegmentStatus       = "status"
+	TopicSegmentEvent        = "event"
+	TopicSegmentNotification = "notification"
+	TopicSegmentBand         = "band"
+)
+
+// Wildcards of SubscribeTopic patterns: TopicAnySegment matches one segment, TopicAnySuffix the remaining
+// segments and must come last, e.g. "cat.*.freq" or "cat.rig1.event.#".
+const (
+	TopicAnySegment = "*"
+	TopicAnySuffix  = "#"
+)
+
+// defaultTopicNames are the names of the tag topics unless Options.Topics.Names overrides them.
+var defaultTopicNames = map[string]string{
+	tags.VfoAFreq.String(): "freq",
+	tags.VfoBFreq.String(): "freqb",
+	tags.MainMode.String(): "mode",
+	tags.SubMode.String():  "submode",
+	tags.TxPwr.String():    "power",
+}
+
+// TopicMessage is a message delivered by SubscribeTopic. The payload depends on the topic:
+//
+//   - cat.<rig>.<name>: the display value of one tag, as a string, e.g. "cat.rig1.freq"
+//   - cat.<rig>.status: the status carrying the values, as types.CatStatus
+//   - cat.<rig>.event.<kind>: a CatEvent, with its kind in lower case, e.g. "cat.rig1.event.health_changed"
+//   - cat.<rig>.notification: a Notification
+//   - cat.<rig>.band: a BandChangedEvent
+type TopicMessage struct {
+	Topic   string
+	Payload any
+	At      time.Time
+}
+
+// topicRouter holds the channels handed out by SubscribeTopic.
+type topicRouter struct {
+	mu   sync.RWMutex
+	subs map[<-chan TopicMessage]*topicSubscriber
+}
+
+// topicSubscriber is one channel returned by SubscribeTopic with its parsed pattern.
+type topicSubscriber struct {
+	ch      chan TopicMessage
+	pattern []string
+}
+
+// SubscribeTopic returns a new channel receiving the messages of the topics matching pattern, so that a
+// frontend can wire subscriptions by name instead of switching over tags. The channel buffers size messages (16
+// if size is not positive); when it falls behind, its oldest message is dropped. Call UnsubscribeTopic when done.
+func (s *Service) SubscribeTopic(pattern string, size int) (<-chan TopicMessage, error) {
+	const op errors.Op = "cat.Service.SubscribeTopic"
+	if !s.initialized.Load() {
+		return nil, errors.New(op).Msg(errMsgServiceNotInit)
+	}
+	segments, err := parseTopicPattern(pattern)
+	if err != nil {
+		return nil, errors.New(op).Err(err)
+	}
+	if size <= 0 {
+		size = defaultTopicSize
+	}
+
+	sub := &topicSubscriber{ch: make(chan TopicMessage, size), pattern: segments}
+	s.topics.mu.Lock()
+	defer s.topics.mu.Unlock()
+	if s.topics.subs == nil {
+		s.topics.subs = make(map[<-chan TopicMessage]*topicSubscriber)
+	}
+	s.topics.subs[sub.ch] = sub
+	return sub.ch, nil
+}
+
+// UnsubscribeTopic stops delivery to a channel returned by SubscribeTopic and closes it. Unknown channels are
+// ignored.
+func (s *Service) UnsubscribeTopic(ch <-chan TopicMessage) {
+	s.topics.mu.Lock()
+	defer s.topics.mu.Unlock()
+	if sub, ok := s.topics.subs[ch]; ok {
+		delete(s.topics.subs, ch)
+		close(sub.ch)
+	}
+}
+
+// Topics returns the names of the topics this rig publishes on, sorted: the topics of the tags its definition
+// reports plus the status, event, notification and band topics. Event topics are listed without their kind.
+func (s *Service) Topics() []string {
+	s.definitionMu.RLock()
+	names := []string{TopicSegmentStatus, TopicSegmentEvent, TopicSegmentNotification, TopicSegmentBand}
+	for _, state := range s.supportedCatStates {
+		for _, marker := range state.Markers {
+			names = append(names, s.topicName(marker.Tag))
+		}
+	}
+	s.definitionMu.RUnlock()
+
+	topics := make([]string, 0, len(names))
+	for _, name := range names {
+		topics = append(topics, s.topic(name))
+	}
+	slices.Sort(topics)
+	return slices.Compact(topics)
+}
+
+// parseTopicPattern splits pattern into its segments, checking the wildcards.
+func parseTopicPattern(pattern string) ([]string, error) {
+	const op errors.Op = "cat.parseTopicPattern"
+	segments := strings.Split(pattern, ".")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+			return nil, errors.New(op).Msgf("invalid topic pattern %q: empty segment", pattern)
+		case seg == TopicAnySuffix && i != len(segments)-1:
+			return nil, errors.New(op).Msgf("invalid topic pattern %q: %s must be the last segment", pattern, TopicAnySuffix)
+		}
+	}
+	return segments, nil
+}
+
+// matches reports whether topic, split into segments, matches the pattern of sub.
+func (sub *topicSubscriber) matches(topic []string) bool {
+	for i, seg := range sub.pattern {
+		if seg == TopicAnySuffix {
+			return true
+		}
+		if i >= len(topic) || (seg != TopicAnySegment && seg != topic[i]) {
+			return false
+		}
+	}
+	return len(sub.pattern) == len(topic)
+}
+
+// topic returns the full name of the topic whose last segment is name.
+func (s *Service) topic(name string) string {
+	rig := s.Options.Topics.Rig
+	if rig == "" {
+		var id int64
+		if s.config != nil {
+			id = s.config.ID
+		}
+		rig = "rig" + strconv.FormatInt(id, 10)
+	}
+	return "cat." + rig + "." + name
+}
+
+// topicName returns the last segment of the topic of tag.
+func (s *Service) topicName(tag string) string {
+	if name, ok := s.Options.Topics.Names[tag]; ok {
+		return name
+	}
+	if name, ok := defaultTopicNames[tag]; ok {
+		return name
+	}
+	return strings.ToLower(tag)
+}
+
+// hasTopicSubscribers reports whether any channel returned by SubscribeTopic is still open.
+func (s *Service) hasTopicSubscribers() bool {
+	s.topics.mu.RLock()
+	defer s.topics.mu.RUnlock()
+	return len(s.topics.subs) > 0
+}
+
+// route delivers payload to the subscribers of the topic whose last segment is name, without blocking.
+func (s *Service) route(name string, payload any, at time.Time) {
+	if !s.hasTopicSubscribers() {
+		return
+	}
+	topic := s.topic(name)
+	segments := strings.Split(topic, ".")
+	msg := TopicMessage{Topic: topic, Payload: payload, At: at}
+
+	s.topics.mu.RLock()
+	defer s.topics.mu.RUnlock()
+	for _, sub := range s.topics.subs {
+		if sub.matches(segments) && !offerEvicting(sub.ch, msg) {
+			s.logger().DebugWith().Str("topic", topic).Msg("dropping topic message: subscriber full")
+		}
+	}
+}
+
+// routeStatus delivers status, as translated for display, on the status topic and each of its values on the
+// topic of its tag.
+func (s *Service) routeStatus(status types.CatStatus) {
+	if !s.hasTopicSubscribers() {
+		return
+	}
+	now := time.Now()
+	s.route(TopicSegmentStatus, status, now)
+	for _, tag := range sortedKeys(status) {
+		s.route(s.topicName(tag), status[tag], now)
+	}
+}
+
+// routeEvent delivers e on the event topic of its kind.
+func (s *Service) routeEvent(e CatEvent) {
+	s.route(TopicSegmentEvent+"."+strings.ToLower(e.Kind().String()), e, e.Time())
+}
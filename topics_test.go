@@ -0,0 +1,75 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTopicTestService(t *testing.T) *Service {
+	t.Helper()
+	service := newStartedTestService(t, &types.RigConfig{
+		ID: 1,
+		CatStates: []types.CatState{
+			{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 11}}},
+			{Prefix: "TX", Markers: []types.Marker{{Tag: "PTT", Index: 0, Length: 1}}},
+		},
+	})
+	service.statusChannel = make(chan types.CatStatus, 4)
+	return service
+}
+
+func TestSubscribeTopicRoutesTagValues(t *testing.T) {
+	service := newTopicTestService(t)
+	freq, err := service.SubscribeTopic("cat.rig1.freq", 0)
+	require.NoError(t, err)
+	all, err := service.SubscribeTopic("cat.*.#", 8)
+	require.NoError(t, err)
+
+	require.True(t, service.emitStatus(types.CatStatus{"VFOAFREQ": "00014074000", "PTT": "1"}, make(chan struct{})))
+
+	msg := <-freq
+	require.Equal(t, "cat.rig1.freq", msg.Topic)
+	require.Equal(t, "00014074000", msg.Payload)
+	require.Empty(t, freq)
+
+	var topics []string
+	for len(all) > 0 {
+		topics = append(topics, (<-all).Topic)
+	}
+	require.Equal(t, []string{"cat.rig1.status", "cat.rig1.ptt", "cat.rig1.freq"}, topics)
+
+	service.UnsubscribeTopic(freq)
+	_, open := <-freq
+	require.False(t, open)
+}
+
+func TestSubscribeTopicRoutesEvents(t *testing.T) {
+	service := newTopicTestService(t)
+	service.Options.Topics = TopicOptions{Rig: "ic7300"}
+	events, err := service.SubscribeTopic("cat.ic7300.event.#", 0)
+	require.NoError(t, err)
+
+	service.emitEvent(HealthChangedEvent{At: time.Now(), Previous: HealthHealthy, State: HealthDegraded})
+	service.notify(SeverityWarning, "title", "message", "")
+
+	msg := <-events
+	require.Equal(t, "cat.ic7300.event.health_changed", msg.Topic)
+	require.IsType(t, HealthChangedEvent{}, msg.Payload)
+	require.Empty(t, events, "notifications are not events")
+}
+
+func TestTopicPatternsAndNames(t *testing.T) {
+	service := newTopicTestService(t)
+	for _, pattern := range []string{"", "cat..freq", "cat.#.freq"} {
+		_, err := service.SubscribeTopic(pattern, 0)
+		require.Error(t, err, pattern)
+	}
+
+	service.Options.Topics.Names = map[string]string{"PTT": "tx"}
+	require.Equal(t, []string{
+		"cat.rig1.band", "cat.rig1.event", "cat.rig1.freq", "cat.rig1.notification", "cat.rig1.status", "cat.rig1.tx",
+	}, service.Topics())
+}
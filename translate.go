@@ -0,0 +1,67 @@
+package cat
+
+import (
+	"github.com/Station-Manager/types"
+)
+
+// Translator translates mapped display values, e.g. "SPLIT ON" or "USB", for non-English user interfaces. It is
+// called with the state tag and its canonical value and returns the text to show.
+type Translator interface {
+	Translate(tag, value string) string
+}
+
+// TranslatorFunc adapts a function to the Translator interface.
+type TranslatorFunc func(tag, value string) string
+
+// Translate implements Translator.
+func (f TranslatorFunc) Translate(tag, value string) string {
+	return f(tag, value)
+}
+
+// translateStatus returns a copy of status with the values of mapped tags translated for the user interface.
+// Unmapped values such as frequencies are passed through, and status itself keeps the canonical values used by the
+// cache, the typed getters and the EventBus.
+func (s *Service) translateStatus(status types.CatStatus) types.CatStatus {
+	if s.Translator == nil {
+		return status
+	}
+	out := make(types.CatStatus, len(status))
+	for tag, value := range status {
+		if s.isMappedTag(tag) {
+			value = s.Translator.Translate(tag, value)
+		}
+		out[tag] = value
+	}
+	return out
+}
+
+// isMappedTag reports whether any configured marker maps the raw values of tag to display values.
+func (s *Service) isMappedTag(tag string) bool {
+	hasMappings := func(markers []types.Marker) bool {
+		for _, m := range markers {
+			if m.Tag == tag && len(m.ValueMappings) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, state := range s.rigConfig().CatStates {
+		if hasMappings(state.Markers) {
+			return true
+		}
+	}
+	for _, opts := range s.Options.StateOptions {
+		for _, layout := range opts.Layouts {
+			if hasMappings(layout.Markers) {
+				return true
+			}
+		}
+		for _, fm := range opts.FieldMarkers {
+			if fm.Tag == tag && len(fm.ValueMappings) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
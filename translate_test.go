@@ -0,0 +1,44 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslatorAppliesToMappedValuesOnly(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Translator = TranslatorFunc(func(tag, value string) string {
+		if tag == "MAINMODE" && value == "USB" {
+			return "BLU"
+		}
+		return value
+	})
+
+	status := types.CatStatus{"MAINMODE": "USB", "VFOAFREQ": "014074000"}
+	display := service.translateStatus(status)
+	require.Equal(t, types.CatStatus{"MAINMODE": "BLU", "VFOAFREQ": "014074000"}, display)
+	require.Equal(t, "USB", status["MAINMODE"], "the canonical status is not modified")
+}
+
+func TestTranslatedStatusKeepsCanonicalCache(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Translator = TranslatorFunc(func(_, value string) string { return "«" + value + "»" })
+	service.statusChannel = make(chan types.CatStatus, 1)
+	service.processingChannel = make(chan receivedState, 1)
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"lineProcessor": service.lineProcessor})
+
+	service.processingChannel <- receivedState{CatState: types.CatState{Prefix: "MD0", Data: "2", Markers: service.supportedCatStates["MD0"].Markers}}
+
+	select {
+	case status := <-service.statusChannel:
+		require.Equal(t, "«USB»", status["MAINMODE"])
+	case <-time.After(time.Second):
+		t.Fatal("no status emitted")
+	}
+	cached, ok := service.cache.get("MAINMODE")
+	require.True(t, ok)
+	require.Equal(t, "USB", cached.Value)
+}
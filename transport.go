@@ -0,0 +1,43 @@
+package cat
+
+import (
+	"context"
+
+	"github.com/Station-Manager/errors"
+)
+
+// Transport is the subset of the serial client used by the CAT workers. It allows the physical serial port to be
+// wrapped (e.g. for fault injection) or replaced without the listener and sender needing to know about it.
+type Transport interface {
+	// WriteCommand writes a single CAT command to the rig.
+	WriteCommand(ctx context.Context, cmd string) error
+	// ReadResponseBytes reads a single framed response from the rig.
+	ReadResponseBytes(ctx context.Context) ([]byte, error)
+	// Close releases the underlying resources. It must be safe to call multiple times.
+	Close() error
+}
+
+// link returns the current transport. The reconnect logic replaces it while the sender is running, so workers
+// must not read the field directly.
+func (s *Service) link() Transport {
+	s.transportMu.RLock()
+	defer s.transportMu.RUnlock()
+	return s.transport
+}
+
+// openLink returns the current transport, or an error once Stop has closed it, so that a worker still running
+// does not write to or read from a nil link.
+func (s *Service) openLink() (Transport, error) {
+	const op errors.Op = "cat.Service.openLink"
+	if t := s.link(); t != nil {
+		return t, nil
+	}
+	return nil, errors.New(op).Msg(errMsgLinkClosed)
+}
+
+// setLink replaces the current transport.
+func (s *Service) setLink(t Transport) {
+	s.transportMu.Lock()
+	defer s.transportMu.Unlock()
+	s.transport = t
+}
@@ -0,0 +1,279 @@
+package cat
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Transverter converts the rig's IF band to a band the rig does not cover, e.g. 144 MHz from 28 MHz. Frequencies in
+// the commands, status and getters of this package are in the transverter band while it is active; the rig is
+// sent the IF frequency.
+type Transverter struct {
+	// Name identifies the transverter for SelectTransverter.
+	Name string
+	// MinHz and MaxHz are the transverter band. Tuning into it activates the transverter; tuning outside every
+	// transverter band deactivates it.
+	MinHz int64
+	MaxHz int64
+	// LOHz is the local oscillator offset: IF = frequency - LOHz, e.g. 116 MHz for 144 MHz on a 28 MHz IF.
+	LOHz int64
+	// IFMinHz and IFMaxHz limit the IF range of the rig. Zero means the transverter band shifted by LOHz.
+	IFMinHz int64
+	IFMaxHz int64
+	// DriveLimitW is the highest rig power the transverter accepts; power requests above it are clamped while the
+	// transverter is active. Zero means no limit.
+	DriveLimitW int
+}
+
+// ifRange returns the IF range of t.
+func (t Transverter) ifRange() (lo, hi int64) {
+	lo, hi = t.IFMinHz, t.IFMaxHz
+	if lo == 0 && hi == 0 {
+		lo, hi = t.MinHz-t.LOHz, t.MaxHz-t.LOHz
+	}
+	return lo, hi
+}
+
+// validateTransverters checks the transverter definitions of Options.Transverters.
+func validateTransverters(list []Transverter) error {
+	const op errors.Op = "cat.validateTransverters"
+	names := make(map[string]bool, len(list))
+	for _, t := range list {
+		if t.Name == "" {
+			return errors.New(op).Msg("transverter without a name")
+		}
+		if names[t.Name] {
+			return errors.New(op).Msgf("duplicate transverter %q", t.Name)
+		}
+		names[t.Name] = true
+		lo, hi := t.ifRange()
+		if t.MinHz <= 0 || t.MaxHz <= t.MinHz || lo <= 0 || hi <= lo {
+			return errors.New(op).Msgf("transverter %q: invalid band or IF range", t.Name)
+		}
+		if t.MinHz-t.LOHz < lo || t.MaxHz-t.LOHz > hi {
+			return errors.New(op).Msgf("transverter %q: band does not fit the IF range", t.Name)
+		}
+	}
+	return nil
+}
+
+// transverterState is the active transverter, as an index into Options.Transverters plus one; zero when none is
+// active. A VFO A frequency command activates its transverter once it is written; requested is the transverter of
+// the last one queued, whose drive limit already applies.
+type transverterState struct {
+	active    atomic.Int32
+	requested atomic.Int32
+}
+
+// SelectTransverter activates the transverter called name, or deactivates transverters if name is empty. Tuning
+// into a transverter band activates it as well.
+func (s *Service) SelectTransverter(name string) error {
+	const op errors.Op = "cat.Service.SelectTransverter"
+	if name == "" {
+		s.transverter.active.Store(0)
+		s.transverter.requested.Store(0)
+		return nil
+	}
+	for i, t := range s.Options.Transverters {
+		if t.Name == name {
+			s.transverter.active.Store(int32(i + 1))
+			s.transverter.requested.Store(int32(i + 1))
+			return nil
+		}
+	}
+	return errors.New(op).Msgf("no transverter %q", name)
+}
+
+// ActiveTransverter returns the active transverter, if any.
+func (s *Service) ActiveTransverter() (Transverter, bool) {
+	return s.transverterAt(s.transverter.active.Load())
+}
+
+// transverterAt returns the transverter of a transverterState value.
+func (s *Service) transverterAt(state int32) (Transverter, bool) {
+	i := int(state) - 1
+	if i < 0 || i >= len(s.Options.Transverters) {
+		return Transverter{}, false
+	}
+	return s.Options.Transverters[i], true
+}
+
+// transverterFor returns the transverterState value for VFO A tuned to hz: the transverter whose band holds hz,
+// or none.
+func (s *Service) transverterFor(hz int64) int32 {
+	for i, t := range s.Options.Transverters {
+		if hz >= t.MinHz && hz <= t.MaxHz {
+			return int32(i + 1)
+		}
+	}
+	return 0
+}
+
+// driveLimited returns the transverter whose drive limit applies: the lower limit of the active transverter and
+// the one requested by a VFO A command not yet written.
+func (s *Service) driveLimited() (Transverter, bool) {
+	active, activeOK := s.ActiveTransverter()
+	requested, requestedOK := s.transverterAt(s.transverter.requested.Load())
+	activeOK = activeOK && active.DriveLimitW > 0
+	requestedOK = requestedOK && requested.DriveLimitW > 0
+	if requestedOK && (!activeOK || requested.DriveLimitW < active.DriveLimitW) {
+		return requested, true
+	}
+	return active, activeOK
+}
+
+// activateTransverter activates the transverter selected by cmd, a VFO A frequency command that was written.
+func (s *Service) activateTransverter(cmd queuedCommand) {
+	if cmd.transverter != nil {
+		s.transverter.active.Store(*cmd.transverter)
+	}
+}
+
+// frequencyTags are the tags whose values are translated by transverters and the calibration.
+var frequencyTags = []tags.CatStateTag{tags.VfoAFreq, tags.VfoBFreq}
+
+// rigFrequency converts a frequency as seen by the operator into the frequency sent to the rig: the IF of the
+// transverter state, see transverterState, corrected by Options.CalibrationPPM.
+func (s *Service) rigFrequency(hz int64, state int32) (int64, error) {
+	const op errors.Op = "cat.Service.rigFrequency"
+	if t, ok := s.transverterAt(state); ok {
+		hz -= t.LOHz
+		if lo, hi := t.ifRange(); hz < lo || hz > hi {
+			return 0, errors.New(op).Msgf("%d Hz is outside the IF range of transverter %q", hz+t.LOHz, t.Name)
+		}
+	}
+	if ppm := s.Options.CalibrationPPM; ppm != 0 {
+		hz = int64(math.Round(float64(hz) / (1 + ppm/1e6)))
+	}
+	return hz, nil
+}
+
+// operatorFrequency reverses rigFrequency for a frequency reported by the rig. An IF outside the active
+// transverter's IF range is reported as is.
+func (s *Service) operatorFrequency(hz int64) int64 {
+	if ppm := s.Options.CalibrationPPM; ppm != 0 {
+		hz = int64(math.Round(float64(hz) * (1 + ppm/1e6)))
+	}
+	if t, ok := s.ActiveTransverter(); ok {
+		if lo, hi := t.ifRange(); hz >= lo && hz <= hi {
+			hz += t.LOHz
+		}
+	}
+	return hz
+}
+
+// translatesFrequencies reports whether transverters or a calibration are configured.
+func (s *Service) translatesFrequencies() bool {
+	return len(s.Options.Transverters) > 0 || s.Options.CalibrationPPM != 0
+}
+
+// calibrateStatus converts the frequencies reported by the rig in status into operator frequencies, keeping
+// their width. It is applied before status is cached or emitted.
+func (s *Service) calibrateStatus(status types.CatStatus) {
+	if !s.translatesFrequencies() {
+		return
+	}
+	for _, tag := range frequencyTags {
+		raw, ok := status[tag.String()]
+		if !ok {
+			continue
+		}
+		hz, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			continue
+		}
+		value := strconv.FormatInt(s.operatorFrequency(hz), 10)
+		if width := len(raw); len(value) < width {
+			value = strings.Repeat("0", width-len(value)) + value
+		}
+		status[tag.String()] = value
+	}
+}
+
+// transverterFilter rewrites frequency commands to rig frequencies and applies the drive limit of the active
+// transverter, or of the one a queued VFO A command tunes to. It runs after every other filter, which all see
+// operator frequencies.
+func (s *Service) transverterFilter(req *commandRequest) error {
+	const op errors.Op = "cat.Service.transverterFilter"
+	if !s.translatesFrequencies() || len(req.params) != 1 || req.skip {
+		return nil
+	}
+
+	switch req.name {
+	case CmdSetVfoAFreq, CmdSetVfoBFreq:
+		tag := tags.VfoAFreq
+		if req.name == CmdSetVfoBFreq {
+			tag = tags.VfoBFreq
+		}
+		hz, err := s.parseFrequencyParam(tag, req.params[0])
+		if err != nil {
+			return nil
+		}
+		// VFO A selects the transverter for its frequency, which becomes active once the command is written.
+		state := s.transverter.active.Load()
+		if tag == tags.VfoAFreq {
+			state = s.transverterFor(hz)
+			req.transverter = &state
+		}
+		rigHz, err := s.rigFrequency(hz, state)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		value, err := s.formatFrequency(tag, rigHz)
+		if err != nil {
+			return errors.New(op).Err(err)
+		}
+		req.stateParams = []string{strconv.FormatInt(hz, 10)}
+		req.params = []string{value}
+		if req.transverter != nil {
+			s.transverter.requested.Store(state)
+		}
+
+		if t, ok := s.transverterAt(state); ok && t.DriveLimitW > 0 {
+			if watts, ok := s.cachedPower(); ok && watts > t.DriveLimitW {
+				req.then = append(req.then, &commandRequest{name: CmdSetTxPower, params: []string{s.formatPower(t.DriveLimitW)}})
+				s.noteDriveLimited(req, t, watts)
+			}
+		}
+
+	case CmdSetTxPower:
+		t, ok := s.driveLimited()
+		if !ok {
+			return nil
+		}
+		watts, err := strconv.Atoi(strings.TrimSpace(req.params[0]))
+		if err != nil || watts <= t.DriveLimitW {
+			return nil
+		}
+		req.params = []string{s.formatPower(t.DriveLimitW)}
+		s.noteDriveLimited(req, t, watts)
+	}
+	return nil
+}
+
+// parseFrequencyParam reads a formatted frequency parameter of tag, decoding BCD if the tag is encoded.
+func (s *Service) parseFrequencyParam(tag tags.CatStateTag, param string) (int64, error) {
+	if enc, ok := s.tagEncoding(tag.String()); ok {
+		decoded, err := decodeBCD(param, enc)
+		if err != nil {
+			return 0, err
+		}
+		param = decoded
+	}
+	return strconv.ParseInt(strings.TrimSpace(param), 10, 64)
+}
+
+// noteDriveLimited records and announces that req was clamped to the drive limit of t.
+func (s *Service) noteDriveLimited(req *commandRequest, t Transverter, watts int) {
+	note := fmt.Sprintf("power reduced from %d W to the %d W drive limit of transverter %s", watts, t.DriveLimitW, t.Name)
+	req.outcome.clamp(note)
+	s.logger().InfoWith().Str("transverter", t.Name).Int("requested", watts).Int("limit", t.DriveLimitW).Msg("CAT power clamped to transverter drive limit")
+	s.notify(SeverityWarning, "Power limited", fmt.Sprintf("Transmit power %d W exceeds the %d W drive limit of transverter %s; reduced to the limit.", watts, t.DriveLimitW, t.Name), "")
+}
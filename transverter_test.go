@@ -0,0 +1,61 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+var twoMetreTransverter = Transverter{Name: "2m", MinHz: 144_000_000, MaxHz: 146_000_000, LOHz: 116_000_000, DriveLimitW: 10}
+
+func TestTransverterTranslatesFrequencies(t *testing.T) {
+	service := newPowerTestService(t)
+	service.Options.Transverters = []Transverter{twoMetreTransverter}
+	service.cache.update(types.CatStatus{"TXPWR": "050"}, time.Now())
+
+	require.NoError(t, service.setFrequencyHz(VFOA, 144_300_000))
+	require.Equal(t, []string{"FA028300000;", "PC010;"}, drainCommands(service), "the rig gets the IF and the drive limit")
+	_, ok := service.ActiveTransverter()
+	require.False(t, ok, "the transverter is activated once the command is written")
+	require.NoError(t, service.SetPower(50))
+	require.Equal(t, []string{"PC010;"}, drainCommands(service), "the drive limit applies while the command is queued")
+
+	rig := startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	require.NoError(t, service.setFrequencyHz(VFOA, 144_300_000))
+	require.Eventually(t, func() bool { _, ok := service.ActiveTransverter(); return ok }, time.Second, time.Millisecond)
+	active, _ := service.ActiveTransverter()
+	require.Equal(t, "2m", active.Name)
+	require.Equal(t, []string{"FA028300000;", "PC010;"}, rig.writes())
+
+	status := types.CatStatus{"VFOAFREQ": "028300000"}
+	service.calibrateStatus(status)
+	require.Equal(t, types.CatStatus{"VFOAFREQ": "144300000"}, status)
+
+	require.NoError(t, service.setFrequencyHz(VFOA, 28_300_000))
+	require.Eventually(t, func() bool { _, ok := service.ActiveTransverter(); return !ok }, time.Second, time.Millisecond,
+		"tuning outside the transverter band deactivates it")
+	require.Equal(t, "FA028300000;", rig.writes()[2])
+}
+
+func TestCalibrationPPMOnBothPaths(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.CalibrationPPM = 1
+
+	require.NoError(t, service.setFrequencyHz(VFOA, 14_074_000))
+	require.Equal(t, []string{"FA014073986;"}, drainCommands(service))
+
+	status := types.CatStatus{"VFOAFREQ": "014073986"}
+	service.calibrateStatus(status)
+	require.Equal(t, "014074000", status["VFOAFREQ"])
+}
+
+func TestValidateTransverters(t *testing.T) {
+	require.NoError(t, validateTransverters([]Transverter{twoMetreTransverter}))
+
+	narrow := twoMetreTransverter
+	narrow.IFMinHz, narrow.IFMaxHz = 28_000_000, 29_000_000
+	require.Error(t, validateTransverters([]Transverter{narrow}))
+	require.Error(t, validateTransverters([]Transverter{twoMetreTransverter, twoMetreTransverter}))
+}
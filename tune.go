@@ -0,0 +1,53 @@
+package cat
+
+import (
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+// TuneOrder tells Tune which setting a rig needs to receive first.
+type TuneOrder string
+
+const (
+	// TuneFrequencyFirst sets the frequency and then the mode. This is the default.
+	TuneFrequencyFirst TuneOrder = "frequency-first"
+	// TuneModeFirst sets the mode and then the frequency, for rigs that reset the passband or snap the frequency
+	// when the mode changes.
+	TuneModeFirst TuneOrder = "mode-first"
+)
+
+// Tune sets the frequency of VFO A and the main mode as one operation, honouring the rig's ordering hint and the
+// delay between the two commands configured in Options.Tune. The delay is kept by the sender after writing the first
+// command, so Tune does not block. Options such as ConfirmAvoidRange apply to the
+// frequency change; Force applies to both commands.
+func (s *Service) Tune(freqHz int64, mode string, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.Tune"
+	if !s.initialized.Load() {
+		return errors.New(op).Msg(errMsgServiceNotInit)
+	}
+
+	opts = append(opts, withoutAutoMode())
+	// The sender holds after the first command for the delay, rather than delaying the caller or the queueing.
+	optsFor := func(first bool) []CommandOption {
+		if first && s.Options.Tune.DelayMS > 0 {
+			return append(opts[:len(opts):len(opts)], settleFor(s.Options.Tune.DelayMS*time.Millisecond))
+		}
+		return opts
+	}
+	setFreq := func(first bool) error { return s.setFrequencyHz(VFOA, freqHz, optsFor(first)...) }
+	setMode := func(first bool) error { return s.setMode(mode, optsFor(first)...) }
+
+	steps := []func(first bool) error{setFreq, setMode}
+	if s.Options.Tune.Order == TuneModeFirst {
+		steps = []func(first bool) error{setMode, setFreq}
+	}
+
+	for i, step := range steps {
+		if err := step(i == 0); err != nil {
+			return errors.New(op).Err(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package cat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newTuneTestConfig returns a rig configuration with Yaesu-style frequency and mode commands.
+func newTuneTestConfig() *types.RigConfig {
+	return &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: CmdSetVfoAFreq.String(), Cmd: "FA%s;"},
+			{Name: CmdSetVfoBFreq.String(), Cmd: "FB%s;"},
+			{Name: CmdSetMainMode.String(), Cmd: "MD0%s;"},
+		},
+		CatStates: []types.CatState{
+			{Prefix: "FA", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 9}}},
+			{Prefix: "FB", Markers: []types.Marker{{Tag: "VFOBFREQ", Index: 0, Length: 9}}},
+			{Prefix: "MD0", Markers: []types.Marker{{Tag: "MAINMODE", Index: 0, Length: 1, ValueMappings: []types.ValueMapping{
+				{Key: "1", Value: "LSB"}, {Key: "2", Value: "USB"}, {Key: "3", Value: "CW-U"},
+			}}}},
+		},
+	}
+}
+
+// drainCommands empties the send channels in the order the sender would write them.
+func drainCommands(service *Service) []string {
+	var out []string
+	for {
+		cmd, ok := service.nextRegular()
+		if !ok {
+			return out
+		}
+		if cmd.batch != nil {
+			for _, c := range cmd.batch.cmds {
+				out = append(out, c.Cmd)
+			}
+			continue
+		}
+		out = append(out, cmd.Cmd)
+	}
+}
+
+func TestTuneFrequencyFirst(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+
+	require.NoError(t, service.Tune(14074000, "usb"))
+	require.Equal(t, []string{"FA014074000;", "MD02;"}, drainCommands(service))
+}
+
+func TestTuneModeFirst(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.Tune.Order = TuneModeFirst
+
+	require.NoError(t, service.Tune(7030000, "CW-U"))
+	require.Equal(t, []string{"MD03;", "FA007030000;"}, drainCommands(service))
+}
+
+func TestTuneLeavesTheDelayToTheSender(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.Tune.DelayMS = 500
+
+	start := time.Now()
+	require.NoError(t, service.Tune(14074000, "usb"))
+	require.Less(t, time.Since(start), 250*time.Millisecond)
+
+	first, ok := service.nextRegular()
+	require.True(t, ok)
+	require.Equal(t, "FA014074000;", first.Cmd)
+	require.Equal(t, 500*time.Millisecond, first.settle)
+	second, ok := service.nextRegular()
+	require.True(t, ok)
+	require.Equal(t, "MD02;", second.Cmd)
+	require.Zero(t, second.settle)
+
+	start = time.Now()
+	require.True(t, service.settleAfter(nil, first))
+	require.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestTuneRejectsUnmappedMode(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+
+	require.Error(t, service.Tune(14074000, "PKT"))
+}
+
+func TestAutoModeOnFrequencyChange(t *testing.T) {
+	service := newStartedTestService(t, newTuneTestConfig())
+	service.Options.AutoModeSegments = []ModeSegment{{Label: "20m CW", MinHz: 14000000, MaxHz: 14070000, Mode: "CW-U"}}
+
+	require.NoError(t, service.EnqueueCommand(CmdSetVfoAFreq, "014025000"))
+	require.Equal(t, []string{"FA014025000;", "MD03;"}, drainCommands(service))
+
+	// Tune sets the mode explicitly, so auto-mode stays out of the way.
+	require.NoError(t, service.Tune(14025000, "USB"))
+	require.Equal(t, []string{"FA014025000;", "MD02;"}, drainCommands(service))
+}
@@ -0,0 +1,198 @@
+package cat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+)
+
+// Names of the checks in a ValidationReport.
+const (
+	ValidationInitialize = "initialize"
+	ValidationDefinition = "definition"
+	ValidationPort       = "port"
+)
+
+// ValidationCheck is the outcome of one check run by ValidateOnly.
+type ValidationCheck struct {
+	Name string
+	OK   bool
+	// Problems lists everything the check found wrong; empty when OK.
+	Problems []string
+	// Skipped is set, with the reason in Problems, when the check could not run because an earlier one failed.
+	Skipped bool
+}
+
+// ValidationReport is the result of ValidateOnly.
+type ValidationReport struct {
+	RigID  int64
+	Checks []ValidationCheck
+	// Migrations describes the rig definition migrations applied while loading the definition.
+	Migrations MigrationReport
+}
+
+// Passed reports whether every check passed.
+func (r ValidationReport) Passed() bool {
+	for _, c := range r.Checks {
+		if !c.OK {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
+}
+
+// String renders the report one check per line, for provisioning scripts and logs.
+func (r ValidationReport) String() string {
+	var b strings.Builder
+	for _, c := range r.Checks {
+		status := "ok"
+		switch {
+		case c.Skipped:
+			status = "skipped"
+		case !c.OK:
+			status = "FAILED"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", c.Name, status)
+		for _, p := range c.Problems {
+			fmt.Fprintf(&b, "  - %s\n", p)
+		}
+	}
+	return b.String()
+}
+
+// ValidateOnly verifies a station without going live: it runs Initialize, checks that the commands referenced by
+// the Options exist in the rig definition, and opens and immediately closes the serial port (or rigctld
+// connection). No workers are started. Failed checks are reported in the ValidationReport; the error is only set
+// when validation cannot run at all, e.g. because the service is already started and holds the port.
+func (s *Service) ValidateOnly() (ValidationReport, error) {
+	const op errors.Op = "cat.Service.ValidateOnly"
+	report := ValidationReport{RigID: s.RigID}
+	if s.started.Load() {
+		return report, errors.New(op).Msg("The service is already started; stop it before validating.")
+	}
+
+	if err := s.Initialize(); err != nil {
+		report.Checks = append(report.Checks,
+			ValidationCheck{Name: ValidationInitialize, Problems: []string{validationMessage(err)}},
+			ValidationCheck{Name: ValidationDefinition, Skipped: true, Problems: []string{"initialize failed"}},
+			ValidationCheck{Name: ValidationPort, Skipped: true, Problems: []string{"initialize failed"}},
+		)
+		return report, nil
+	}
+	report.Migrations = s.MigrationReport()
+	report.Checks = append(report.Checks,
+		ValidationCheck{Name: ValidationInitialize, OK: true},
+		newValidationCheck(ValidationDefinition, s.definitionProblems()),
+		newValidationCheck(ValidationPort, s.portProblems()),
+	)
+	return report, nil
+}
+
+// newValidationCheck builds a check that passed if there are no problems.
+func newValidationCheck(name string, problems []string) ValidationCheck {
+	return ValidationCheck{Name: name, OK: len(problems) == 0, Problems: problems}
+}
+
+// validationMessage returns the most specific message of err.
+func validationMessage(err error) string {
+	if root := errors.Root(err); root != nil {
+		return root.Error()
+	}
+	return err.Error()
+}
+
+// definitionProblems checks the rig definition for duplicate commands and for commands that the Options refer to
+// but the definition does not provide.
+func (s *Service) definitionProblems() []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, c := range s.rigConfig().CatCommands {
+		name := strings.ToUpper(strings.TrimSpace(c.Name))
+		if seen[name] {
+			problems = append(problems, fmt.Sprintf("command %s is defined more than once", name))
+		}
+		seen[name] = true
+	}
+
+	missing := func(use string, name cmds.CatCmdName) {
+		if _, err := s.commandLookup(name); err != nil {
+			problems = append(problems, fmt.Sprintf("%s refers to undefined command %s", use, name))
+		}
+	}
+	for _, p := range s.Options.Polls {
+		missing("Options.Polls", p.Command)
+	}
+	for _, tag := range sortedKeys(s.Options.ReadCommands) {
+		missing("Options.ReadCommands["+tag+"]", s.Options.ReadCommands[tag])
+	}
+	for _, name := range s.Options.Recovery.Commands {
+		missing("Options.Recovery.Commands", name)
+	}
+	for _, name := range s.Options.TxCommands {
+		missing("Options.TxCommands", name)
+	}
+	for _, name := range sortedKeys(s.Options.CommandGuards) {
+		missing("Options.CommandGuards", name)
+	}
+	if s.Options.Presence.Enabled && s.Options.Presence.ProbeCommand != "" {
+		missing("Options.Presence.ProbeCommand", s.Options.Presence.ProbeCommand)
+	}
+	for _, step := range slices.Concat(s.Options.Station.PowerUp, s.Options.Station.PowerDown) {
+		for _, req := range slices.Concat(step.Commands, step.Rollback) {
+			missing("Options.Station "+step.Name, req.Name)
+		}
+		if step.Check != nil {
+			missing("Options.Station "+step.Name, step.Check.Command)
+		}
+	}
+	for _, name := range sortedKeys(s.Options.ResponsePolicies) {
+		if _, err := s.commandLookup(name); err != nil {
+			missing("Options.ResponsePolicies", name)
+		} else if _, err = s.responsePrefixFor(name); err != nil {
+			problems = append(problems, fmt.Sprintf("Options.ResponsePolicies[%s]: the response cannot be recognised", name))
+		}
+	}
+	s.definitionMu.RLock()
+	states := s.supportedCatStates
+	s.definitionMu.RUnlock()
+	for _, name := range sortedKeys(s.Options.ResponsePrefixes) {
+		prefix, err := unescapeHex(s.Options.ResponsePrefixes[name], false)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Options.ResponsePrefixes[%s]: %v", name, err))
+			continue
+		}
+		prefix = s.prefixKey(prefix)
+		if _, ok := states[prefix]; !ok {
+			problems = append(problems, fmt.Sprintf("Options.ResponsePrefixes[%s] refers to undefined state %s", name, prefix))
+		}
+	}
+	return problems
+}
+
+// portProblems opens the configured port and closes it again.
+func (s *Service) portProblems() []string {
+	if !s.rigConfig().CatConfig.Enabled {
+		return nil // nothing will be opened
+	}
+	t, err := s.openPort()
+	if err != nil {
+		return []string{validationMessage(err)}
+	}
+	if err = t.Close(); err != nil {
+		return []string{fmt.Sprintf("port opened but failed to close: %s", validationMessage(err))}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order, so that reports are stable.
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
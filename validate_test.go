@@ -0,0 +1,72 @@
+package cat
+
+import (
+	"testing"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/logging"
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidateTestService(definitions DefinitionSource) *Service {
+	return &Service{LoggerService: &logging.Service{}, Definitions: definitions}
+}
+
+func validateTestDefinition() DefinitionSourceFunc {
+	return func(rigID int64) (types.RigConfig, error) {
+		return types.RigConfig{
+			ID:          1,
+			CatConfig:   types.CatConfig{Enabled: true, SendChannelSize: 1, ProcessingChannelSize: 1},
+			CatCommands: []types.CatCommand{{Name: "READ", Cmd: "IF;"}},
+			CatStates:   []types.CatState{{Prefix: "IF", Markers: []types.Marker{{Tag: "VFOAFREQ", Index: 0, Length: 9}}}},
+		}, nil
+	}
+}
+
+func TestValidateOnlyPasses(t *testing.T) {
+	service := newValidateTestService(validateTestDefinition())
+	fake := newFakeTransport()
+	service.dialer = func() (Transport, error) { return fake, nil }
+
+	report, err := service.ValidateOnly()
+	require.NoError(t, err)
+	require.True(t, report.Passed(), report.String())
+	require.True(t, fake.closed)
+	require.False(t, service.started.Load())
+}
+
+func TestValidateOnlyReportsProblems(t *testing.T) {
+	service := newValidateTestService(validateTestDefinition())
+	service.Options.Polls = []PollEntry{{Command: "FREQ", IntervalMS: 250}}
+	service.Options.ResponsePrefixes = map[cmds.CatCmdName]string{"READ": "ZZ"}
+	service.dialer = func() (Transport, error) {
+		return nil, errors.New("test").Msg("no such device")
+	}
+
+	report, err := service.ValidateOnly()
+	require.NoError(t, err)
+	require.False(t, report.Passed())
+	require.Len(t, report.Checks, 3)
+	require.True(t, report.Checks[0].OK)
+	require.Equal(t, []string{
+		"Options.Polls refers to undefined command FREQ",
+		"Options.ResponsePrefixes[READ] refers to undefined state ZZ",
+	}, report.Checks[1].Problems)
+	require.Equal(t, ValidationPort, report.Checks[2].Name)
+	require.Equal(t, []string{"no such device"}, report.Checks[2].Problems)
+}
+
+func TestValidateOnlySkipsChecksWhenInitializeFails(t *testing.T) {
+	service := newValidateTestService(DefinitionSourceFunc(func(rigID int64) (types.RigConfig, error) {
+		return types.RigConfig{}, errors.New("test").Msg("rig 1 not found")
+	}))
+
+	report, err := service.ValidateOnly()
+	require.NoError(t, err)
+	require.False(t, report.Passed())
+	require.Equal(t, []string{"rig 1 not found"}, report.Checks[0].Problems)
+	require.True(t, report.Checks[1].Skipped)
+	require.True(t, report.Checks[2].Skipped)
+}
@@ -0,0 +1,143 @@
+package cat
+
+import (
+	"context"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultVerifyTimeoutMS is used when Options.Verify.TimeoutMS is zero.
+	defaultVerifyTimeoutMS = 500
+)
+
+// readBack is the value a set command is expected to leave in its tag, checked after the command was written.
+type readBack struct {
+	tag  tags.CatStateTag
+	want string // raw rig value
+}
+
+// commandTag returns the tag reporting the value set by the set command name, from Options.DuplicateTags or the
+// set commands of this package.
+func (s *Service) commandTag(name cmds.CatCmdName) (tags.CatStateTag, bool) {
+	if tag, ok := s.Options.DuplicateTags[name]; ok {
+		return tag, true
+	}
+	tag, ok := setCommandTags[name]
+	return tag, ok
+}
+
+// readBackFor returns the verification for req, or nil if read-after-write verification is disabled or does not
+// apply. BCD-encoded tags are not verified: rigs using them acknowledge every command.
+func (s *Service) readBackFor(req *commandRequest) *readBack {
+	if !s.Options.Verify.Enabled || len(req.params) != 1 {
+		return nil
+	}
+	tag, ok := s.commandTag(req.name)
+	if !ok {
+		return nil
+	}
+	if _, encoded := s.tagEncoding(tag.String()); encoded {
+		return nil
+	}
+	return &readBack{tag: tag, want: req.reportedParams()[0]}
+}
+
+// verifyWritten reads the tag set by cmd back from the rig in the background and emits a CommandFailedEvent if
+// the rig does not report the value that was set, as rigs that silently ignore invalid commands do. The read runs
+// as a task of the sender's run, so Stop waits for it.
+func (s *Service) verifyWritten(shutdown <-chan struct{}, cmd queuedCommand) {
+	const op errors.Op = "cat.Service.verifyWritten"
+	if cmd.verify == nil {
+		return
+	}
+	if !s.launchTask(shutdown, func() { s.readBackWritten(shutdown, cmd) }) {
+		cmd.outcome.fail(errors.New(op).Msg(errMsgServiceNotStarted))
+	}
+}
+
+// readBackWritten runs the read-back of cmd for verifyWritten.
+func (s *Service) readBackWritten(shutdown <-chan struct{}, cmd queuedCommand) {
+	const op errors.Op = "cat.Service.verifyWritten"
+	ctx, cancel := context.WithTimeout(context.Background(), durationOrDefault(s.Options.Verify.TimeoutMS, defaultVerifyTimeoutMS))
+	defer cancel()
+
+	var err error
+	got, qerr := s.readBack(ctx, shutdown, cmd.verify.tag)
+	switch {
+	case qerr != nil:
+		select {
+		case <-shutdown:
+			cmd.outcome.fail(errors.New(op).Msg(errMsgServiceNotStarted))
+			return
+		default:
+		}
+		err = errors.New(op).Msgf("%s not confirmed: no %s reported after the write", cmd.Name, cmd.verify.tag)
+		cmd.outcome.timedOut(err)
+	default:
+		// The cache holds mapped (display) values while the parameter is the raw rig value.
+		raw, encErr := s.encodeMappedValue(cmd.verify.tag, got)
+		if encErr != nil || !sameValue(raw, cmd.verify.want) {
+			err = errors.New(op).Msgf("%s not confirmed: rig reports %s %s instead of %s", cmd.Name, cmd.verify.tag, got, cmd.verify.want)
+		}
+	}
+	if err == nil {
+		cmd.outcome.confirmed()
+		return
+	}
+	cmd.outcome.fail(err) // no-op after a time-out
+	s.count(&s.counters.verifyFailures, "verify_failures", 1)
+	s.logger().WarnWith().Err(err).Str("cmd", cmd.Name).Msg("command not confirmed by the rig")
+	s.recordError("verify", err)
+	s.emitEvent(CommandFailedEvent{At: time.Now(), Command: cmd.Name, Origin: cmd.origin, Attempts: 1, Err: err.Error()})
+}
+
+// readBack sends the read command for tag and returns the value reported in answer to it: the first one reported
+// once the read was being written, so that an update already on its way, e.g. the answer to a poll written before
+// the set, is not taken for the read-back.
+func (s *Service) readBack(ctx context.Context, shutdown <-chan struct{}, tag tags.CatStateTag) (string, error) {
+	return s.readBackWith(ctx, shutdown, s.readCommandFor(tag), tag)
+}
+
+// readBackWith is readBack with the read command name instead of the one configured for tag.
+func (s *Service) readBackWith(ctx context.Context, shutdown <-chan struct{}, name cmds.CatCmdName, tag tags.CatStateTag) (string, error) {
+	const op errors.Op = "cat.Service.readBack"
+	// Grab the change channel before sending so an answer arriving immediately is not missed.
+	changed := s.cache.changed()
+	handle, err := s.EnqueueTracked(name, nil, WithOrigin(OriginInternal))
+	if err != nil {
+		return "", errors.New(op).Err(err)
+	}
+
+	sent := handle.Done()
+	var written time.Time
+	answer := func() (string, bool) {
+		cached, ok := s.cache.get(tag.String())
+		if !ok || written.IsZero() || cached.Updated.Before(written) {
+			return "", false
+		}
+		return cached.Value, true
+	}
+	for {
+		select {
+		case <-shutdown:
+			return "", errors.New(op).Msg(errMsgServiceNotStarted)
+		case <-ctx.Done():
+			return "", errors.New(op).Err(ctx.Err()).Msgf("timed out waiting for %s", tag)
+		case <-sent:
+			sent = nil
+			if outcome := handle.Outcome(); outcome.State == OutcomeFailed {
+				return "", errors.New(op).Msgf("read of %s failed: %s", tag, outcome.Err)
+			}
+			written = handle.lastWrite()
+		case <-changed:
+			changed = s.cache.changed()
+		}
+		if value, ok := answer(); ok {
+			return value, nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cat
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// answeringTransport is a fakeTransport that lets a test react to every write, e.g. to answer a read command.
+type answeringTransport struct {
+	*fakeTransport
+	onWrite func(cmd string)
+}
+
+func (a *answeringTransport) WriteCommand(ctx context.Context, cmd string) error {
+	if err := a.fakeTransport.WriteCommand(ctx, cmd); err != nil {
+		return err
+	}
+	a.onWrite(cmd)
+	return nil
+}
+
+// newVerifyTestService starts a sender talking to a fake rig that reports VFO A on READ, and 14.250 MHz on LATE
+// as a report sent before the rig refused a set would be. A rig that ignores commands never changes its frequency.
+func newVerifyTestService(t *testing.T, ignoresCommands bool) *Service {
+	cfg := newTuneTestConfig()
+	cfg.CatCommands = append(cfg.CatCommands, types.CatCommand{Name: "READ", Cmd: "IF;"}, types.CatCommand{Name: "LATE", Cmd: "PS;"})
+	service := newStartedTestService(t, cfg)
+	service.Options.Verify = VerifyOptions{Enabled: true, TimeoutMS: 200}
+
+	var mu sync.Mutex
+	freq := "014074000"
+	rig := &answeringTransport{fakeTransport: newFakeTransport(), onWrite: func(cmd string) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasPrefix(cmd, "FA") && !ignoresCommands:
+			freq = strings.TrimSuffix(cmd[2:], ";")
+		case cmd == "IF;":
+			service.cache.update(types.CatStatus{"VFOAFREQ": freq}, time.Now())
+		case cmd == "PS;":
+			time.Sleep(20 * time.Millisecond) // the read-back is queued meanwhile
+			service.cache.update(types.CatStatus{"VFOAFREQ": "014250000"}, time.Now())
+		}
+	}}
+	startTestWorkers(t, service, map[string]func(<-chan struct{}){"serialPortSender": service.serialPortSender})
+	service.setLink(rig)
+	return service
+}
+
+func TestVerifyConfirmsSetCommand(t *testing.T) {
+	service := newVerifyTestService(t, false)
+
+	require.NoError(t, service.EnqueueCommand(CmdSetVfoAFreq, "014250000"))
+	require.Eventually(t, func() bool {
+		cached, ok := service.cache.get("VFOAFREQ")
+		return ok && cached.Value == "014250000"
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	require.Zero(t, service.counters.verifyFailures.Load())
+	require.Empty(t, service.eventChannel)
+}
+
+func TestVerifyReportsIgnoredCommand(t *testing.T) {
+	service := newVerifyTestService(t, true)
+
+	require.NoError(t, service.EnqueueCommand(CmdSetVfoAFreq, "014250000"))
+	select {
+	case e := <-service.eventChannel:
+		failed, ok := e.(CommandFailedEvent)
+		require.True(t, ok)
+		require.Equal(t, CmdSetVfoAFreq.String(), failed.Command)
+		require.Contains(t, failed.Err, "instead of 014250000")
+	case <-time.After(time.Second):
+		t.Fatal("no CommandFailedEvent for the ignored command")
+	}
+	require.Equal(t, uint64(1), service.counters.verifyFailures.Load())
+}
+
+func TestVerifyWaitsForItsOwnRead(t *testing.T) {
+	service := newVerifyTestService(t, true)
+
+	// The batch writes LATE before the read-back is queued, so its report arrives between the set and the read.
+	_, err := service.EnqueueBatch([]CatCommandRequest{{Name: CmdSetVfoAFreq, Params: []string{"014250000"}}, {Name: "LATE"}})
+	require.NoError(t, err)
+	select {
+	case e := <-service.eventChannel:
+		failed, ok := e.(CommandFailedEvent)
+		require.True(t, ok)
+		require.Contains(t, failed.Err, "rig reports VFOAFREQ 014074000", "the report ahead of the read is not its answer")
+	case <-time.After(time.Second):
+		t.Fatal("no CommandFailedEvent for the ignored command")
+	}
+}
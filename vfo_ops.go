@@ -0,0 +1,94 @@
+package cat
+
+import (
+	"context"
+	"time"
+
+	"github.com/Station-Manager/enums/cmds"
+	"github.com/Station-Manager/enums/tags"
+	"github.com/Station-Manager/errors"
+	"github.com/Station-Manager/types"
+)
+
+// Command names of the VFO operations. A rig definition provides their templates, e.g. {Name: "SWAPVFO", Cmd:
+// "SV;"}.
+const (
+	// CmdSwapVFO exchanges VFO A and VFO B.
+	CmdSwapVFO cmds.CatCmdName = "SWAPVFO"
+	// CmdEqualizeVFO copies VFO A to VFO B.
+	CmdEqualizeVFO cmds.CatCmdName = "EQUALIZEVFO"
+)
+
+// SwapVFO exchanges the frequencies of VFO A and VFO B. The cached state is updated straight away and the result
+// is then verified by reading both VFOs back, waiting until the rig answers or ctx is done.
+func (s *Service) SwapVFO(ctx context.Context, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.SwapVFO"
+
+	a, b, err := s.readVFOs(ctx)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.runVFOOperation(ctx, CmdSwapVFO, b, a, opts); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// EqualizeVFO copies the frequency of VFO A to VFO B (A→B). The cached state is updated straight away and the
+// result is then verified by reading both VFOs back, waiting until the rig answers or ctx is done.
+func (s *Service) EqualizeVFO(ctx context.Context, opts ...CommandOption) error {
+	const op errors.Op = "cat.Service.EqualizeVFO"
+
+	a, _, err := s.readVFOs(ctx)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if err = s.runVFOOperation(ctx, CmdEqualizeVFO, a, a, opts); err != nil {
+		return errors.New(op).Err(err)
+	}
+	return nil
+}
+
+// readVFOs returns the current values of both VFO frequency tags.
+func (s *Service) readVFOs(ctx context.Context) (a, b string, err error) {
+	const op errors.Op = "cat.Service.readVFOs"
+	if a, err = s.readTag(ctx, tags.VfoAFreq); err != nil {
+		return "", "", errors.New(op).Err(err)
+	}
+	if b, err = s.readTag(ctx, tags.VfoBFreq); err != nil {
+		return "", "", errors.New(op).Err(err)
+	}
+	return a, b, nil
+}
+
+// runVFOOperation sends name, records the expected VFO values in the cache and verifies them against the rig.
+func (s *Service) runVFOOperation(ctx context.Context, name cmds.CatCmdName, wantA, wantB string, opts []CommandOption) error {
+	const op errors.Op = "cat.Service.runVFOOperation"
+
+	if err := s.EnqueueCommandWith(name, nil, opts...); err != nil {
+		return errors.New(op).Err(err)
+	}
+	s.cache.update(types.CatStatus{tags.VfoAFreq.String(): wantA, tags.VfoBFreq.String(): wantB}, time.Now())
+
+	gotA, gotB, err := s.queryVFOs(ctx)
+	if err != nil {
+		return errors.New(op).Err(err)
+	}
+	if !sameValue(gotA, wantA) || !sameValue(gotB, wantB) {
+		return errors.New(op).Msgf("%s not confirmed by the rig: VFO A %s, VFO B %s; expected %s and %s",
+			name, gotA, gotB, wantA, wantB)
+	}
+	return nil
+}
+
+// queryVFOs reads both VFO frequency tags from the rig, ignoring the cache.
+func (s *Service) queryVFOs(ctx context.Context) (a, b string, err error) {
+	const op errors.Op = "cat.Service.queryVFOs"
+	if a, err = s.queryTag(ctx, tags.VfoAFreq); err != nil {
+		return "", "", errors.New(op).Err(err)
+	}
+	if b, err = s.queryTag(ctx, tags.VfoBFreq); err != nil {
+		return "", "", errors.New(op).Err(err)
+	}
+	return a, b, nil
+}
@@ -0,0 +1,65 @@
+package cat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVFORig answers READ with both VFO frequencies and applies the swap and equalize commands, unless broken.
+func fakeVFORig(t *testing.T, broken bool) *Service {
+	service := newStartedTestService(t, &types.RigConfig{
+		CatCommands: []types.CatCommand{
+			{Name: "READ", Cmd: "IF;"},
+			{Name: CmdSwapVFO.String(), Cmd: "SV;"},
+			{Name: CmdEqualizeVFO.String(), Cmd: "AB;"},
+		},
+	})
+	a, b := "014074000", "007074000"
+	runFakeRig(t, service, func(cmd string) {
+		switch {
+		case broken:
+		case cmd == "SV;":
+			a, b = b, a
+		case cmd == "AB;":
+			b = a
+		}
+		if cmd == "IF;" {
+			service.cache.update(types.CatStatus{"VFOAFREQ": a, "VFOBFREQ": b}, time.Now())
+		}
+	})
+	return service
+}
+
+func TestSwapVFO(t *testing.T) {
+	service := fakeVFORig(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, service.SwapVFO(ctx))
+	hz, err := service.GetFrequencyHz(ctx, VFOA)
+	require.NoError(t, err)
+	require.Equal(t, int64(7074000), hz)
+}
+
+func TestEqualizeVFO(t *testing.T) {
+	service := fakeVFORig(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, service.EqualizeVFO(ctx))
+	hz, err := service.GetFrequencyHz(ctx, VFOB)
+	require.NoError(t, err)
+	require.Equal(t, int64(14074000), hz)
+}
+
+func TestSwapVFOReportsUnconfirmedResult(t *testing.T) {
+	service := fakeVFORig(t, true)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.Error(t, service.SwapVFO(ctx))
+}
@@ -0,0 +1,93 @@
+package cat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Station-Manager/types"
+)
+
+// stateWaiters lets callers wait for the next frame matching a state prefix. The listener delivers every matched
+// state to the waiters registered for its prefix.
+type stateWaiters struct {
+	mu      sync.Mutex
+	next    uint64
+	waiters map[string]map[uint64]*stateWaiter
+}
+
+// stateWaiter is one registration of stateWaiters.
+type stateWaiter struct {
+	ch chan types.CatState
+	// after is when the awaited command began to be written; frames received earlier are not delivered.
+	after time.Time
+	// held is when the frame waiting in ch was received.
+	held time.Time
+}
+
+// awaitState registers interest in the next frame whose state prefix is prefix. The returned cancel function must
+// be called once the caller is no longer interested.
+func (s *Service) awaitState(prefix string) (<-chan types.CatState, func()) {
+	states, _, cancel := s.awaitResponse(prefix)
+	return states, cancel
+}
+
+// awaitResponse is awaitState for the response to a write: from sets when the write began, after which frames
+// received earlier are neither delivered nor kept, so that a frame already on its way is not taken for the
+// response. The channel is read by the caller only.
+func (s *Service) awaitResponse(prefix string) (states <-chan types.CatState, from func(time.Time), cancel func()) {
+	key := s.prefixKey(prefix)
+	waiter := &stateWaiter{ch: make(chan types.CatState, 1)}
+
+	w := &s.waiters
+	w.mu.Lock()
+	if w.waiters == nil {
+		w.waiters = make(map[string]map[uint64]*stateWaiter)
+	}
+	if w.waiters[key] == nil {
+		w.waiters[key] = make(map[uint64]*stateWaiter)
+	}
+	id := w.next
+	w.next++
+	w.waiters[key][id] = waiter
+	w.mu.Unlock()
+
+	from = func(at time.Time) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		waiter.after = at
+		if waiter.held.Before(at) {
+			select {
+			case <-waiter.ch:
+			default:
+			}
+		}
+	}
+	cancel = func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.waiters[key], id)
+		if len(w.waiters[key]) == 0 {
+			delete(w.waiters, key)
+		}
+	}
+	return waiter.ch, from, cancel
+}
+
+// deliverToWaiters hands state, received at received, to every waiter registered for its prefix without blocking
+// the listener.
+func (s *Service) deliverToWaiters(state types.CatState, received time.Time) {
+	w := &s.waiters
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, waiter := range w.waiters[s.foldPrefix(state.Prefix)] {
+		if received.Before(waiter.after) {
+			continue
+		}
+		select {
+		case waiter.ch <- state:
+			waiter.held = received
+		default:
+			// The waiter already has a frame it has not consumed yet.
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cat
+
+import (
+	"context"
+	stderr "errors"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/Station-Manager/errors"
+)
+
+const (
+	// defaultWriteRetryAttempts is used when Options.WriteRetry.Attempts is zero.
+	defaultWriteRetryAttempts = 3
+	// defaultWriteRetryDelayMS is used when Options.WriteRetry.DelayMS is zero.
+	defaultWriteRetryDelayMS = 10
+)
+
+// CommandFailedEvent is emitted when a command could not be written to the rig, after any retries.
+type CommandFailedEvent struct {
+	At       time.Time
+	Command  string
+	Origin   Origin
+	Attempts int
+	Err      string
+}
+
+func (e CommandFailedEvent) Kind() EventKind { return EventCommandFailed }
+func (e CommandFailedEvent) Time() time.Time { return e.At }
+
+// isTransientWriteError reports whether a failed write may succeed if the same frame is written again shortly,
+// e.g. a full driver buffer or a write timeout, as opposed to a closed or removed port.
+func isTransientWriteError(err error) bool {
+	if stderr.Is(err, context.DeadlineExceeded) || stderr.Is(err, syscall.EAGAIN) || stderr.Is(err, syscall.EINTR) {
+		return true
+	}
+	var netErr net.Error
+	return stderr.As(err, &netErr) && netErr.Timeout()
+}
+
+// writeWithRetry writes wire, retrying transient failures up to Options.WriteRetry.Attempts times in total. The
+// delay doubles after every attempt so that a briefly congested adapter is given progressively more time. It
+// returns the number of attempts made.
+func (s *Service) writeWithRetry(wire string) (int, error) {
+	const op errors.Op = "cat.Service.writeWithRetry"
+
+	attempts := s.Options.WriteRetry.Attempts
+	if attempts <= 0 {
+		attempts = defaultWriteRetryAttempts
+	}
+	delay := durationOrDefault(s.Options.WriteRetry.DelayMS, defaultWriteRetryDelayMS)
+
+	for attempt := 1; ; attempt++ {
+		t, err := s.openLink()
+		if err != nil {
+			return attempt, errors.New(op).Err(err)
+		}
+		if err = t.WriteCommand(context.Background(), wire); err == nil {
+			return attempt, nil
+		}
+		if attempt >= attempts || !isTransientWriteError(err) {
+			return attempt, errors.New(op).Err(err)
+		}
+		s.logger().DebugWith().Err(err).Int("attempt", attempt).Msg("transient write error; retrying")
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
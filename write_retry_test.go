@@ -0,0 +1,55 @@
+package cat
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/Station-Manager/types"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTransport fails the first failures writes with err before delegating to the fake transport.
+type failingTransport struct {
+	*fakeTransport
+	failures int
+	err      error
+}
+
+func (f *failingTransport) WriteCommand(ctx context.Context, cmd string) error {
+	if f.failures > 0 {
+		f.failures--
+		return f.err
+	}
+	return f.fakeTransport.WriteCommand(ctx, cmd)
+}
+
+func TestWriteRetriesTransientErrors(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	service.Options.WriteRetry = WriteRetryOptions{Attempts: 3, DelayMS: 1}
+	transport := &failingTransport{fakeTransport: newFakeTransport(), failures: 2, err: fmt.Errorf("write: %w", syscall.EAGAIN)}
+	service.transport = transport
+
+	require.NoError(t, service.writeCommand(queuedCommand{CatCommand: types.CatCommand{Name: "READ", Cmd: "FA;"}}))
+	require.Equal(t, []string{"FA;"}, transport.writes())
+	require.Equal(t, uint64(2), service.counters.writeRetries.Load())
+}
+
+func TestWriteSurfacesFatalErrorsImmediately(t *testing.T) {
+	service := newStartedTestService(t, &types.RigConfig{})
+	transport := &failingTransport{fakeTransport: newFakeTransport(), failures: 5, err: stderr.New("port removed")}
+	service.transport = transport
+
+	require.Error(t, service.writeCommand(queuedCommand{CatCommand: types.CatCommand{Name: "READ", Cmd: "FA;"}, origin: OriginUI}))
+	require.Equal(t, 4, transport.failures)
+
+	events, err := service.Events()
+	require.NoError(t, err)
+	failed, ok := (<-events).(CommandFailedEvent)
+	require.True(t, ok)
+	require.Equal(t, "READ", failed.Command)
+	require.Equal(t, OriginUI, failed.Origin)
+	require.Equal(t, 1, failed.Attempts)
+}